@@ -1,32 +1,41 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	kvauth "github.com/Azure/azure-sdk-for-go/services/keyvault/auth"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/dolittle/platform-api/pkg/git"
 	"github.com/dolittle/platform-api/pkg/k8s"
 	"github.com/dolittle/platform-api/pkg/middleware"
+	"github.com/dolittle/platform-api/pkg/platform"
 	"github.com/dolittle/platform-api/pkg/platform/application"
 	"github.com/dolittle/platform-api/pkg/platform/backup"
 	"github.com/dolittle/platform-api/pkg/platform/businessmoment"
 	"github.com/dolittle/platform-api/pkg/platform/cicd"
 	"github.com/dolittle/platform-api/pkg/platform/containerregistry"
 	"github.com/dolittle/platform-api/pkg/platform/customer"
+	"github.com/dolittle/platform-api/pkg/platform/customertenant"
 	"github.com/dolittle/platform-api/pkg/platform/insights"
 	"github.com/dolittle/platform-api/pkg/platform/job"
 	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	m3ConnectorListeners "github.com/dolittle/platform-api/pkg/platform/listeners/m3connector"
 	"github.com/dolittle/platform-api/pkg/platform/microservice"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configBundle"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/configFiles"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configHistory"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/environmentVariables"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/purchaseorderapi"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/rawdatalog"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
 	"github.com/dolittle/platform-api/pkg/platform/studio"
 	"github.com/dolittle/platform-api/pkg/platform/user"
 
@@ -75,11 +84,20 @@ var serverCMD = &cobra.Command{
 
 		userThirdPartyEnabled := viper.GetBool("tools.server.user.thirdPartyEnabled")
 		kratosURL := viper.GetString("tools.server.kratos.url")
-		// Hide secret
-		serverSettings := viper.Get("tools.server").(map[string]interface{})
+		configHistoryEncryptionKey := viper.GetString("tools.server.configHistory.encryptionKey")
+		// Hide secret, on a copy as viper hands out the maps it reads the settings from
+		serverSettings := copySettings(viper.Get("tools.server").(map[string]interface{}))
 		serverSettings["secret"] = fmt.Sprintf("%s***", sharedSecret[:3])
+		if configHistorySettings, ok := serverSettings["confighistory"].(map[string]interface{}); ok && configHistoryEncryptionKey != "" {
+			configHistorySettings["encryptionkey"] = "***"
+		}
+		if secretStoreSettings, ok := serverSettings["secretstores"].(map[string]interface{}); ok {
+			if vaultSettings, ok := secretStoreSettings["vault"].(map[string]interface{}); ok && vaultSettings["token"] != "" {
+				vaultSettings["token"] = "***"
+			}
+		}
 		logContext.WithFields(logrus.Fields{
-			"settings": viper.Get("tools.server"),
+			"settings": serverSettings,
 		}).Info("start up")
 
 		router := mux.NewRouter()
@@ -122,10 +140,40 @@ var serverCMD = &cobra.Command{
 		// TODO I wonder how this works when both are in the same cluster,
 		// today via the resources, it is not clear which is which "platform-environment".
 		go job.NewCustomerJobListener(k8sClient, gitRepo, logContext.WithField("context", "listener-job-customer"))
-		go job.NewApplicationJobListener(k8sClient, gitRepo, logContext.WithField("context", "listener-job-application"))
+		go job.NewApplicationJobListener(k8sClient, gitRepo, gitRepo, logContext.WithField("context", "listener-job-application"))
 
 		go m3ConnectorListeners.NewKafkaFilesConfigmapListener(k8sClient, gitRepo, logContext.WithField("context", "listener-m3connector-kafka-files"))
 
+		secretStores := map[string]secretstore.Store{}
+		if directory := viper.GetString("tools.server.secretStores.file.directory"); directory != "" {
+			secretStores[platform.SecretStoreFile] = secretstore.NewFileStore(directory)
+		}
+		if address := viper.GetString("tools.server.secretStores.vault.address"); address != "" {
+			secretStores[platform.SecretStoreVault] = secretstore.NewVaultStore(
+				&http.Client{Timeout: 10 * time.Second},
+				address,
+				viper.GetString("tools.server.secretStores.vault.token"),
+			)
+		}
+		if viper.GetBool("tools.server.secretStores.azureKeyVault.enabled") {
+			authorizer, err := kvauth.NewAuthorizerFromEnvironment()
+			if err != nil {
+				logContext.WithField("error", err).Fatal("Missing AZURE_XXX settings for Azure Key Vault")
+			}
+			secretStores[platform.SecretStoreAzureKeyVault] = secretstore.NewAzureKeyVaultStore(
+				authorizer,
+				strings.Split(viper.GetString("tools.server.secretStores.azureKeyVault.vaultNames"), ","),
+			)
+		}
+
+		secretSyncer := secretstore.NewSyncer(
+			k8sClient,
+			k8sRepo,
+			secretstore.NewResolver(secretStores),
+			logContext.WithField("context", "secret-store-syncer"),
+		)
+		go secretSyncer.Run(context.Background(), viper.GetDuration("tools.server.secretStores.syncInterval"))
+
 		microserviceService := microservice.NewService(
 			isProduction,
 			gitRepo,
@@ -135,6 +183,21 @@ var serverCMD = &cobra.Command{
 			logrus.WithField("context", "microservice-service"),
 		)
 
+		configHistoryRepo := configHistory.NewConfigHistoryK8sRepo(
+			k8sClient,
+			viper.GetInt("tools.server.configHistory.maxRevisions"),
+			logrus.WithField("context", "microservice-config-history-repo"),
+		)
+		microserviceConfigHistory := configHistory.NewConfigHistory(
+			configHistoryRepo,
+			k8sRepo,
+			configHistory.NewSecretCipher(configHistoryEncryptionKey),
+			logrus.WithField("context", "microservice-config-history"),
+		)
+		if configHistoryEncryptionKey == "" {
+			logContext.Warn("CONFIG_HISTORY_ENCRYPTION_KEY not set, secret environment variables can't be rolled back")
+		}
+
 		microserviceEnvironmentVariablesService := environmentVariables.NewService(
 			environmentVariables.NewEnvironmentVariablesK8sRepo(
 				k8sRepo,
@@ -142,6 +205,8 @@ var serverCMD = &cobra.Command{
 				logrus.WithField("context", "microservice-environment-variables-repo"),
 			),
 			k8sRepo,
+			microserviceConfigHistory,
+			secretSyncer,
 			logrus.WithField("context", "microservice-environment-variables-service"),
 		)
 
@@ -152,9 +217,28 @@ var serverCMD = &cobra.Command{
 				logrus.WithField("context", "microservice-config-files-repo"),
 			),
 			k8sRepo,
+			microserviceConfigHistory,
+			gitRepo,
 			logrus.WithField("context", "microservice-config-files-service"),
 		)
 
+		microserviceConfigBundleService := configBundle.NewService(
+			configBundle.NewConfigBundleK8sRepo(
+				k8sRepo,
+				logrus.WithField("context", "microservice-config-bundle-repo"),
+			),
+			k8sRepo,
+			microserviceConfigHistory,
+			logrus.WithField("context", "microservice-config-bundle-service"),
+		)
+
+		microserviceConfigHistoryService := configHistory.NewService(
+			microserviceConfigHistory,
+			configHistoryRepo,
+			k8sRepo,
+			logrus.WithField("context", "microservice-config-history-service"),
+		)
+
 		applicationService := application.NewService(
 			subscriptionID,
 			externalClusterHost,
@@ -168,6 +252,14 @@ var serverCMD = &cobra.Command{
 			logrus.WithField("context", "application-service"),
 		)
 
+		customerTenantService := customertenant.NewService(
+			k8sClient,
+			gitRepo,
+			k8sRepo,
+			isProduction,
+			logrus.WithField("context", "customer-tenant-service"),
+		)
+
 		customerService := customer.NewService(
 			k8sClient,
 			gitRepo,
@@ -191,6 +283,7 @@ var serverCMD = &cobra.Command{
 			gitRepo,
 			k8sRepo,
 			k8sClient,
+			backup.NewStoreResolver(k8sClient, gitRepo),
 		)
 		purchaseorderapiService := purchaseorderapi.NewService(
 			isProduction,
@@ -200,11 +293,23 @@ var serverCMD = &cobra.Command{
 			logrus.WithField("context", "purchase-order-api-service"),
 		)
 
+		rawDataLogService := rawdatalog.NewService(
+			gitRepo,
+			k8sRepo,
+			logrus.WithField("context", "raw-data-log-service"),
+		)
+
 		cicdService := cicd.NewService(
 			logrus.WithField("context", "cicd-service"),
 			k8sRepo,
 		)
 
+		jobService := job.NewService(
+			logrus.WithField("context", "job-service"),
+			k8sClient,
+			jobResourceConfig.Namespace,
+		)
+
 		studioService := studio.NewService(
 			gitRepo,
 			logrus.WithField("context", "studio-service"),
@@ -238,8 +343,20 @@ var serverCMD = &cobra.Command{
 		})
 
 		// x-shared-secret not happy with this
-		stdChainBase := alice.New(c.Handler, middleware.LogTenantUser, middleware.RestrictHandlerWithSharedSecretAndIDS(sharedSecret, "x-shared-secret"))
+		stdChainBase := alice.New(
+			c.Handler,
+			middleware.LogTenantUser,
+			middleware.RestrictHandlerWithSharedSecretAndIDS(sharedSecret, "x-shared-secret"),
+			middleware.RejectWritesForSuspendedCustomers(gitRepo),
+		)
 		stdChainWithJSON := stdChainBase.Append(middleware.EnforceJSONHandler)
+		// The lifecycle of customers is only for platform admins, it is not blocked by the suspension so a customer can be resumed
+		adminChainWithJSON := alice.New(
+			c.Handler,
+			middleware.LogTenantUser,
+			middleware.RestrictHandlerWithSharedSecretAndIDS(sharedSecret, "x-shared-secret"),
+			middleware.EnforceJSONHandler,
+		)
 
 		//router.NotFoundHandler = http.HandlerFunc(MyNotFound)
 
@@ -271,6 +388,26 @@ var serverCMD = &cobra.Command{
 			stdChainWithJSON.ThenFunc(customerService.GetOne),
 		).Methods(http.MethodGet, http.MethodOptions)
 
+		router.Handle(
+			"/customer/{customerID}",
+			adminChainWithJSON.ThenFunc(customerService.Update),
+		).Methods(http.MethodPut, http.MethodOptions)
+
+		router.Handle(
+			"/customer/{customerID}",
+			adminChainWithJSON.ThenFunc(customerService.Delete),
+		).Methods(http.MethodDelete, http.MethodOptions)
+
+		router.Handle(
+			"/customer/{customerID}/suspend",
+			adminChainWithJSON.ThenFunc(customerService.Suspend),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/customer/{customerID}/resume",
+			adminChainWithJSON.ThenFunc(customerService.Resume),
+		).Methods(http.MethodPost, http.MethodOptions)
+
 		router.Handle(
 			"/application/{applicationID}/microservices",
 			stdChainWithJSON.ThenFunc(microserviceService.GetByApplicationID),
@@ -285,6 +422,41 @@ var serverCMD = &cobra.Command{
 			stdChainWithJSON.ThenFunc(applicationService.GetByID),
 		).Methods(http.MethodGet, http.MethodOptions)
 
+		router.Handle(
+			"/application/{applicationID}",
+			stdChainWithJSON.ThenFunc(applicationService.Delete),
+		).Methods(http.MethodDelete, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment",
+			stdChainWithJSON.ThenFunc(applicationService.AddEnvironment),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}",
+			stdChainWithJSON.ThenFunc(applicationService.DeleteEnvironment),
+		).Methods(http.MethodDelete, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/customertenants",
+			stdChainWithJSON.ThenFunc(customerTenantService.GetAll),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/customertenant",
+			stdChainWithJSON.ThenFunc(customerTenantService.Create),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/customertenant/{customerTenantID}",
+			stdChainWithJSON.ThenFunc(customerTenantService.Delete),
+		).Methods(http.MethodDelete, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/build-status",
+			stdChainWithJSON.ThenFunc(applicationService.GetBuildStatus),
+		).Methods(http.MethodGet, http.MethodOptions)
+
 		router.Handle(
 			"/applications",
 			stdChainWithJSON.ThenFunc(applicationService.GetApplications),
@@ -348,6 +520,31 @@ var serverCMD = &cobra.Command{
 			stdChainWithJSON.ThenFunc(microserviceConfigFilesService.GetConfigFilesNamesList),
 		).Methods(http.MethodGet, http.MethodOptions)
 
+		router.Handle(
+			"/live/application/{applicationID}/environment/{environment}/microservice/{microserviceID}/config-bundle",
+			stdChainBase.ThenFunc(microserviceConfigBundleService.Export),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/live/application/{applicationID}/environment/{environment}/microservice/{microserviceID}/config-bundle",
+			stdChainBase.ThenFunc(microserviceConfigBundleService.Import),
+		).Methods(http.MethodPut, http.MethodOptions)
+
+		router.Handle(
+			"/live/application/{applicationID}/environment/{environment}/microservice/{microserviceID}/config-history",
+			stdChainWithJSON.ThenFunc(microserviceConfigHistoryService.GetRevisions),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/live/application/{applicationID}/environment/{environment}/microservice/{microserviceID}/config-history/diff",
+			stdChainWithJSON.ThenFunc(microserviceConfigHistoryService.DiffRevisions),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/live/application/{applicationID}/environment/{environment}/microservice/{microserviceID}/config-history/{revision}/rollback",
+			stdChainWithJSON.ThenFunc(microserviceConfigHistoryService.Rollback),
+		).Methods(http.MethodPost, http.MethodOptions)
+
 		router.Handle(
 			"/live/application/{applicationID}/pod/{podName}/logs",
 			stdChainBase.ThenFunc(microserviceService.GetPodLogs),
@@ -397,6 +594,10 @@ var serverCMD = &cobra.Command{
 			"/businessmoment",
 			stdChainWithJSON.ThenFunc(businessMomentsService.SaveMoment),
 		).Methods(http.MethodPost, http.MethodOptions)
+		router.Handle(
+			"/businessmoment/test-run",
+			stdChainWithJSON.ThenFunc(businessMomentsService.TestRun),
+		).Methods(http.MethodPost, http.MethodOptions)
 		router.Handle(
 			"/application/{applicationID}/environment/{environment}/businessmoments",
 			stdChainWithJSON.ThenFunc(businessMomentsService.GetMoments),
@@ -412,6 +613,31 @@ var serverCMD = &cobra.Command{
 			stdChainWithJSON.ThenFunc(businessMomentsService.DeleteMoment),
 		).Methods(http.MethodDelete, http.MethodOptions)
 
+		router.Handle(
+			"/application/{applicationID}/businessmoments/search",
+			stdChainWithJSON.ThenFunc(businessMomentsService.Search),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/businessmoments/compare",
+			stdChainWithJSON.ThenFunc(businessMomentsService.Compare),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/businessmoments/microservice/{microserviceID}/versions",
+			stdChainWithJSON.ThenFunc(businessMomentsService.GetVersions),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/businessmoments/microservice/{microserviceID}/publish",
+			stdChainWithJSON.ThenFunc(businessMomentsService.Publish),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/businessmoments/microservice/{microserviceID}/versions/{version}/rollback",
+			stdChainWithJSON.ThenFunc(businessMomentsService.RollbackVersion),
+		).Methods(http.MethodPost, http.MethodOptions)
+
 		router.Handle(
 			"/backups/logs/latest/by/app/{applicationID}/{environment}",
 			stdChainWithJSON.ThenFunc(backupService.GetLatestByApplication),
@@ -422,11 +648,76 @@ var serverCMD = &cobra.Command{
 			stdChainWithJSON.ThenFunc(backupService.CreateLink),
 		).Methods(http.MethodPost, http.MethodOptions)
 
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backups",
+			stdChainWithJSON.ThenFunc(backupService.GetAll),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backup",
+			stdChainWithJSON.ThenFunc(backupService.Create),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backup/restore",
+			stdChainWithJSON.ThenFunc(backupService.Restore),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backup/restores",
+			stdChainWithJSON.ThenFunc(backupService.GetRestores),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backup/retention",
+			stdChainWithJSON.ThenFunc(backupService.GetRetention),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backup/retention",
+			stdChainWithJSON.ThenFunc(backupService.UpdateRetention),
+		).Methods(http.MethodPut, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backup/prune",
+			stdChainWithJSON.ThenFunc(backupService.Prune),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/backup/verify",
+			stdChainWithJSON.ThenFunc(backupService.Verify),
+		).Methods(http.MethodPost, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/backups/verifications",
+			stdChainWithJSON.ThenFunc(backupService.GetVerifications),
+		).Methods(http.MethodGet, http.MethodOptions)
+
 		router.Handle(
 			"/application/{applicationID}/environment/{environment}/purchaseorderapi/{microserviceID}/datastatus",
 			stdChainBase.ThenFunc(purchaseorderapiService.GetDataStatus),
 		).Methods(http.MethodGet, http.MethodOptions)
 
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/purchaseorderapi/{microserviceID}/status",
+			stdChainBase.ThenFunc(purchaseorderapiService.GetStatus),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/application/{applicationID}/environment/{environment}/rawdatalog/{microserviceID}/webhookstats",
+			stdChainBase.ThenFunc(rawDataLogService.GetWebhookStats),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/jobs/{jobID}",
+			stdChainWithJSON.ThenFunc(jobService.GetStatus),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/jobs/{jobID}/logs",
+			stdChainBase.ThenFunc(jobService.GetLogs),
+		).Methods(http.MethodGet, http.MethodOptions)
+
 		router.Handle(
 			"/application/{applicationID}/cicd/credentials/service-account/devops",
 			stdChainBase.ThenFunc(cicdService.GetDevops),
@@ -457,6 +748,16 @@ var serverCMD = &cobra.Command{
 			stdChainBase.ThenFunc(containerRegistryService.GetTags),
 		).Methods(http.MethodGet, http.MethodOptions)
 
+		router.Handle(
+			"/admin/customer/{customerID}/application/{applicationID}/environment/{environment}/mongo",
+			stdChainWithJSON.ThenFunc(applicationService.GetMongoSettings),
+		).Methods(http.MethodGet, http.MethodOptions)
+
+		router.Handle(
+			"/admin/customer/{customerID}/application/{applicationID}/environment/{environment}/mongo",
+			stdChainWithJSON.ThenFunc(applicationService.UpdateMongoSettings),
+		).Methods(http.MethodPut, http.MethodOptions)
+
 		router.Handle(
 			"/admin/customer/{customerID}/application/{applicationID}/access/users",
 			stdChainBase.ThenFunc(applicationService.UserList),
@@ -492,6 +793,13 @@ func init() {
 	viper.SetDefault("tools.server.azure.subscriptionId", "")
 	viper.SetDefault("tools.server.kubernetes.externalClusterHost", defaultExternalClusterHost)
 	viper.SetDefault("tools.server.user.thirdPartyEnabled", false)
+	viper.SetDefault("tools.server.configHistory.encryptionKey", "")
+	viper.SetDefault("tools.server.configHistory.maxRevisions", 50)
+	viper.SetDefault("tools.server.secretStores.syncInterval", "5m")
+	viper.SetDefault("tools.server.secretStores.file.directory", "")
+	viper.SetDefault("tools.server.secretStores.vault.address", "")
+	viper.SetDefault("tools.server.secretStores.vault.token", "")
+	viper.SetDefault("tools.server.secretStores.azureKeyVault.enabled", false)
 
 	viper.BindEnv("tools.server.secret", "HEADER_SECRET")
 	viper.BindEnv("tools.server.listenOn", "LISTEN_ON")
@@ -500,6 +808,14 @@ func init() {
 	viper.BindEnv("tools.server.kubernetes.externalClusterHost", "AZURE_EXTERNAL_CLUSTER_HOST")
 	viper.BindEnv("tools.server.kratos.url", "KRATOS_URL")
 	viper.BindEnv("tools.server.user.thirdPartyEnabled", "USER_THIRD_PARTY_ENABLED")
+	viper.BindEnv("tools.server.configHistory.encryptionKey", "CONFIG_HISTORY_ENCRYPTION_KEY")
+	viper.BindEnv("tools.server.configHistory.maxRevisions", "CONFIG_HISTORY_MAX_REVISIONS")
+	viper.BindEnv("tools.server.secretStores.syncInterval", "SECRET_STORE_SYNC_INTERVAL")
+	viper.BindEnv("tools.server.secretStores.file.directory", "SECRET_STORE_FILE_DIRECTORY")
+	viper.BindEnv("tools.server.secretStores.vault.address", "VAULT_ADDR")
+	viper.BindEnv("tools.server.secretStores.vault.token", "VAULT_TOKEN")
+	viper.BindEnv("tools.server.secretStores.azureKeyVault.enabled", "SECRET_STORE_AZURE_KEY_VAULT_ENABLED")
+	viper.BindEnv("tools.server.secretStores.azureKeyVault.vaultNames", "SECRET_STORE_AZURE_KEY_VAULT_NAMES")
 }
 
 // getExternalClusterHost Return externalHost if set, otherwise fall back to the internalHost
@@ -509,3 +825,16 @@ func getExternalClusterHost(externalHost string, internalHost string) string {
 	}
 	return internalHost
 }
+
+// copySettings deep copies the nested maps of settings
+func copySettings(settings map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		if nested, ok := value.(map[string]interface{}); ok {
+			copied[key] = copySettings(nested)
+			continue
+		}
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,75 @@
+package rawdatalog
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/dolittle/platform-api/pkg/rawdatalog"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var exportCMD = &cobra.Command{
+	Use:   "export",
+	Short: "Export moments from the log as NDJSON",
+	Long: `
+	Reads a topic from a sequence number or time and writes the matching moments,
+	one per line, to a file or stdout
+
+	STAN_CLIENT_ID=raw-data-log-export \
+	STAN_CLUSTER_ID=stan \
+	NATS_SERVER=127.0.0.1 \
+	go run main.go raw-data-log export \
+		--topic=topic.todo \
+		--from=2021-10-01T00:00:00Z \
+		--kind=purchaseorder \
+		--label=uriSuffix=m3/purchaseorder \
+		--output=./export.ndjson
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.SetOutput(os.Stderr)
+		logContext := logrus.WithField("context", "raw-data-log-export")
+
+		filter, err := getFilterFromFlags(cmd)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("invalid filter")
+		}
+
+		topic := getTopicFromFlags(cmd)
+		output, _ := cmd.Flags().GetString("output")
+
+		var w io.Writer = os.Stdout
+		if output != "-" {
+			file, err := os.Create(output)
+			if err != nil {
+				logContext.WithField("error", err).Fatal("failed to create output file")
+			}
+			defer file.Close()
+			w = file
+		}
+
+		reader, closeReader := getReader(cmd, logContext)
+		defer closeReader()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		written, err := rawdatalog.Copy(ctx, reader, topic, filter, rawdatalog.NewNDJSONSink(w))
+		logContext = logContext.WithFields(logrus.Fields{
+			"topic":   topic,
+			"written": written,
+		})
+		if err != nil {
+			logContext.WithField("error", err).Fatal("export failed")
+		}
+		logContext.Info("export finished")
+	},
+}
+
+func init() {
+	addReadFlags(exportCMD)
+	exportCMD.Flags().String("output", "-", "File to write to, - for stdout")
+}
@@ -2,13 +2,17 @@ package rawdatalog
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
+	"github.com/dolittle/platform-api/pkg/rawdatalog"
+
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/stan.go"
 	"github.com/sirupsen/logrus"
@@ -26,6 +30,13 @@ var readLogsCMD = &cobra.Command{
 	STAN_CLUSTER_ID=stan \
 	NATS_SERVER=127.0.0.1 \
 	go run main.go raw-data-log read-logs
+
+	Reading from the file log, written by the server with WEBHOOK_REPO=file
+
+	TOPIC=topic.todo \
+	WEBHOOK_REPO=file \
+	LOG_FILE_DIRECTORY=/tmp/raw-data-log \
+	go run main.go raw-data-log read-logs
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 		viper.SetDefault("topic", "topic.todo")
@@ -34,6 +45,7 @@ var readLogsCMD = &cobra.Command{
 		viper.BindEnv("rawdatalog.log.stan.clusterID", "STAN_CLUSTER_ID")
 		viper.BindEnv("rawdatalog.log.stan.clientID", "STAN_CLIENT_ID")
 		viper.BindEnv("rawdatalog.log.nats.server", "NATS_SERVER")
+		viper.BindEnv("rawdatalog.log.file.directory", "LOG_FILE_DIRECTORY")
 
 		natsServer := viper.GetString("rawdatalog.log.nats.server")
 		clusterID := viper.GetString("rawdatalog.log.stan.clusterID")
@@ -43,6 +55,12 @@ var readLogsCMD = &cobra.Command{
 
 		logrus.SetFormatter(&logrus.JSONFormatter{})
 
+		if strings.ToLower(viper.GetString("rawdatalog.server.webhookRepo")) == "file" {
+			directory := viper.GetString("rawdatalog.log.file.directory")
+			followFileLog(rawdatalog.NewFileLogReader(directory), topic)
+			return
+		}
+
 		opts := []nats.Option{nats.Name("raw-data-log-reader")}
 		logContext := logrus.WithFields(logrus.Fields{
 			"context":    "raw-data-log-reader",
@@ -143,6 +161,35 @@ func readLatest(ctx context.Context, sc stan.Conn, topic string, onRead func(msg
 	return subscription
 }
 
+// followFileLog prints the topic and keeps polling for new entries until interrupted
+func followFileLog(reader rawdatalog.Reader, topic string) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	position := rawdatalog.ReadPosition{}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		err := reader.Read(ctx, topic, position, func(entry rawdatalog.LogEntry) error {
+			data, _ := json.Marshal(entry.Moment)
+			fmt.Println(string(data))
+			position.Sequence = entry.Sequence + 1
+			return nil
+		})
+
+		if err != nil && err != context.Canceled {
+			logrus.WithField("error", err).Fatal("reading file log")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func logCloser(c io.Closer) {
 	if err := c.Close(); err != nil {
 		log.Printf("close error: %s", err)
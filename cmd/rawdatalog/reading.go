@@ -0,0 +1,102 @@
+package rawdatalog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/rawdatalog"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addReadFlags adds the flags shared by the commands reading a topic
+func addReadFlags(cmd *cobra.Command) {
+	cmd.Flags().String("topic", "", "Topic to read, defaults to TOPIC")
+	cmd.Flags().Uint64("from-sequence", 0, "Start reading at this sequence number")
+	cmd.Flags().Uint64("to-sequence", 0, "Stop reading after this sequence number")
+	cmd.Flags().String("from", "", "Only include moments received at or after this time (RFC3339)")
+	cmd.Flags().String("to", "", "Only include moments received at or before this time (RFC3339)")
+	cmd.Flags().StringSlice("kind", []string{}, "Only include moments of this kind, can be repeated")
+	cmd.Flags().StringSlice("label", []string{}, "Only include moments with this metadata label (key=value), can be repeated")
+	cmd.Flags().Duration("idle-timeout", 2*time.Second, "Consider the topic read when no message arrives within this time")
+	cmd.Flags().String("client-id", "", "NATS Streaming client id, defaults to STAN_CLIENT_ID or a random raw-data-log-reader id")
+}
+
+// getReaderClientID returns the client id to connect to NATS Streaming with
+// It never falls back to the server's client id, as NATS Streaming refuses a second connection with the same id
+func getReaderClientID(cmd *cobra.Command) string {
+	clientID, _ := cmd.Flags().GetString("client-id")
+	if clientID != "" {
+		return clientID
+	}
+
+	clientID = os.Getenv("STAN_CLIENT_ID")
+	if clientID != "" {
+		return clientID
+	}
+	return fmt.Sprintf("raw-data-log-reader-%s", strings.Split(uuid.New().String(), "-")[0])
+}
+
+func getFilterFromFlags(cmd *cobra.Command) (rawdatalog.Filter, error) {
+	var filter rawdatalog.Filter
+
+	filter.FromSequence, _ = cmd.Flags().GetUint64("from-sequence")
+	filter.ToSequence, _ = cmd.Flags().GetUint64("to-sequence")
+	filter.Kinds, _ = cmd.Flags().GetStringSlice("kind")
+
+	from, _ := cmd.Flags().GetString("from")
+	if from != "" {
+		when, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, fmt.Errorf("from is not a valid RFC3339 time: %w", err)
+		}
+		filter.From = when
+	}
+
+	to, _ := cmd.Flags().GetString("to")
+	if to != "" {
+		when, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, fmt.Errorf("to is not a valid RFC3339 time: %w", err)
+		}
+		filter.To = when
+	}
+
+	labels, _ := cmd.Flags().GetStringSlice("label")
+	parsed, err := rawdatalog.ParseLabels(labels)
+	if err != nil {
+		return filter, err
+	}
+	filter.Labels = parsed
+	return filter, nil
+}
+
+func getTopicFromFlags(cmd *cobra.Command) string {
+	topic, _ := cmd.Flags().GetString("topic")
+	if topic != "" {
+		return topic
+	}
+	return viper.GetString("rawdatalog.log.topic")
+}
+
+// getReader returns a reader for the file log when WEBHOOK_REPO is file, otherwise for nats, as read-logs does
+// For nats it connects to NATS Streaming with the connection settings from the environment
+// and a client id of its own, so it can run next to the server
+// The returned func releases the connection, it is safe to defer
+func getReader(cmd *cobra.Command, logContext logrus.FieldLogger) (rawdatalog.Reader, func()) {
+	if strings.ToLower(viper.GetString("rawdatalog.server.webhookRepo")) == "file" {
+		return rawdatalog.NewFileLogReader(viper.GetString("rawdatalog.log.file.directory")), func() {}
+	}
+
+	natsServer := viper.GetString("rawdatalog.log.nats.server")
+	clusterID := viper.GetString("rawdatalog.log.stan.clusterID")
+	clientID := getReaderClientID(cmd)
+	idleTimeout, _ := cmd.Flags().GetDuration("idle-timeout")
+
+	sc := rawdatalog.SetupStan(logContext, natsServer, clusterID, clientID)
+	return rawdatalog.NewStanLogReader(sc, idleTimeout), func() { logCloser(sc) }
+}
@@ -0,0 +1,90 @@
+package rawdatalog
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/rawdatalog"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var replayCMD = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay moments from the log to a http endpoint",
+	Long: `
+	Reads a topic from a sequence number or time and POSTs the matching moments
+	to the target, one request per moment.
+	If the target fails, the sequence number is logged so you can continue with --from-sequence
+
+	STAN_CLIENT_ID=raw-data-log-replay \
+	STAN_CLUSTER_ID=stan \
+	NATS_SERVER=127.0.0.1 \
+	go run main.go raw-data-log replay \
+		--topic=topic.todo \
+		--from-sequence=1200 \
+		--kind=purchaseorder \
+		--target=http://localhost:8080/api/webhooks/purchaseorder \
+		--header="Authorization=Bearer fake" \
+		--rate=10
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logContext := logrus.WithField("context", "raw-data-log-replay")
+
+		filter, err := getFilterFromFlags(cmd)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("invalid filter")
+		}
+
+		target, _ := cmd.Flags().GetString("target")
+		if target == "" {
+			logContext.Fatal("target is required")
+		}
+
+		rate, _ := cmd.Flags().GetInt("rate")
+		dataOnly, _ := cmd.Flags().GetBool("data-only")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		headerPairs, _ := cmd.Flags().GetStringSlice("header")
+		headers, err := rawdatalog.ParseLabels(headerPairs)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("invalid header")
+		}
+
+		sink, err := rawdatalog.NewHTTPSink(&http.Client{Timeout: timeout}, target, headers, rate, dataOnly)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("invalid rate")
+		}
+		defer sink.Close()
+
+		topic := getTopicFromFlags(cmd)
+		reader, closeReader := getReader(cmd, logContext)
+		defer closeReader()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		written, err := rawdatalog.Copy(ctx, reader, topic, filter, sink)
+		logContext = logContext.WithFields(logrus.Fields{
+			"topic":   topic,
+			"target":  target,
+			"written": written,
+		})
+		if err != nil {
+			logContext.WithField("error", err).Fatal("replay failed")
+		}
+		logContext.Info("replay finished")
+	},
+}
+
+func init() {
+	addReadFlags(replayCMD)
+	replayCMD.Flags().String("target", "", "Url to POST each moment to")
+	replayCMD.Flags().Int("rate", 0, "Maximum requests per second (up to 1000), 0 for no limit")
+	replayCMD.Flags().StringSlice("header", []string{}, "Header to add to each request (key=value), can be repeated")
+	replayCMD.Flags().Bool("data-only", false, "POST the original payload instead of the whole moment")
+	replayCMD.Flags().Duration("timeout", 10*time.Second, "Timeout for each request")
+}
@@ -13,4 +13,6 @@ var RootCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(serverCMD)
 	RootCmd.AddCommand(readLogsCMD)
+	RootCmd.AddCommand(replayCMD)
+	RootCmd.AddCommand(exportCMD)
 }
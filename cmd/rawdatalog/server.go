@@ -1,12 +1,18 @@
 package rawdatalog
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/dolittle/platform-api/pkg/rawdatalog"
 	"github.com/rs/cors"
 
@@ -41,6 +47,7 @@ var serverCMD = &cobra.Command{
 			AllowedOrigins:     []string{"*", "localhost:5000"},
 			AllowedMethods: []string{
 				http.MethodOptions,
+				http.MethodGet,
 				http.MethodPost,
 				http.MethodPut,
 			},
@@ -61,20 +68,56 @@ var serverCMD = &cobra.Command{
 
 			stanConnection := rawdatalog.SetupStan(logrus.WithField("service", "raw-data-log-writer"), natsServer, clusterID, clientID)
 			repo = rawdatalog.NewStanLogRepo(stanConnection)
+		case "file":
+			fileRepo, err := rawdatalog.NewFileLogRepo(getFileLogOptionsFromViper())
+			if err != nil {
+				logrus.WithField("error", err).Fatal("failed to open the file log")
+			}
+			defer fileRepo.Close()
+			repo = fileRepo
 		default:
-			panic(fmt.Sprintf("WEBHOOK_REPO %s not supported, pick stdout or nats", webhookRepoType))
+			panic(fmt.Sprintf("WEBHOOK_REPO %s not supported, pick stdout, nats or file", webhookRepoType))
 		}
 
+		var configSource rawdatalog.ConfigSource
+		configSourceType := strings.ToLower(viper.GetString("rawdatalog.server.microserviceConfigSource"))
+		switch configSourceType {
+		case "file":
+			configSource = rawdatalog.NewFileConfigSource(pathToMicroserviceConfig, logrus.WithField("service", "raw-data-log-config"))
+		case "configmap":
+			k8sClient, _, err := platformK8s.NewKubernetesClient()
+			if err != nil {
+				logrus.WithField("error", err).Fatal("failed to connect to kubernetes, MICROSERVICE_CONFIG_SOURCE configmap needs the cluster")
+			}
+			configSource = rawdatalog.NewConfigMapConfigSource(
+				k8sClient,
+				viper.GetString("rawdatalog.server.microserviceConfigNamespace"),
+				viper.GetString("rawdatalog.server.microserviceConfigConfigMap"),
+				filepath.Base(pathToMicroserviceConfig),
+				logrus.WithField("service", "raw-data-log-config"),
+			)
+		default:
+			panic(fmt.Sprintf("MICROSERVICE_CONFIG_SOURCE %s not supported, pick file or configmap", configSourceType))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
 		service := rawdatalog.NewService(
+			ctx,
 			logrus.WithField("service", "raw-data-log"),
 			webhookUriPrefix,
-			pathToMicroserviceConfig,
+			configSource,
 			topic,
 			repo,
 			tenantID,
 			applicationID,
 			environment,
 		)
+		// Registered before the webhooks so it is not caught by the prefix
+		router.Handle(rawdatalog.WebhookStatsPath, stdChain.ThenFunc(service.WebhookStats)).Methods("GET", "OPTIONS")
+		go reloadOnSignal(ctx, service)
+
 		router.PathPrefix(webhookUriPrefix).Handler(stdChain.ThenFunc(service.Webhook)).Methods("POST", "PUT", "OPTIONS")
 
 		srv := &http.Server{
@@ -95,6 +138,7 @@ func init() {
 	viper.SetDefault("rawdatalog.server.webhookRepo", "stdout")
 	viper.SetDefault("rawdatalog.server.webhookUriPrefix", "/webhook/")
 	viper.SetDefault("rawdatalog.server.microserviceConfig", "/tmp/ms.json")
+	viper.SetDefault("rawdatalog.server.microserviceConfigSource", "file")
 	viper.SetDefault("rawdatalog.server.tenantID", "tenant-fake-123")
 	viper.SetDefault("rawdatalog.server.applicationID", "application-fake-123")
 	viper.SetDefault("rawdatalog.server.environment", "environment-fake-123")
@@ -103,10 +147,17 @@ func init() {
 	viper.SetDefault("rawdatalog.log.stan.clusterID", "stan")
 	viper.SetDefault("rawdatalog.log.stan.clientID", "webhook-inserter")
 	viper.SetDefault("rawdatalog.log.nats.server", "127.0.0.1")
+	viper.SetDefault("rawdatalog.log.file.directory", "/tmp/raw-data-log")
+	viper.SetDefault("rawdatalog.log.file.segmentMaxBytes", 64*1024*1024)
+	viper.SetDefault("rawdatalog.log.file.sync", string(rawdatalog.SyncInterval))
+	viper.SetDefault("rawdatalog.log.file.syncInterval", "1s")
 
 	viper.BindEnv("rawdatalog.server.listenOn", "LISTEN_ON")
 	viper.BindEnv("rawdatalog.server.webhookRepo", "WEBHOOK_REPO")
 	viper.BindEnv("rawdatalog.server.microserviceConfig", "MICROSERVICE_CONFIG")
+	viper.BindEnv("rawdatalog.server.microserviceConfigSource", "MICROSERVICE_CONFIG_SOURCE")
+	viper.BindEnv("rawdatalog.server.microserviceConfigNamespace", "MICROSERVICE_CONFIG_NAMESPACE")
+	viper.BindEnv("rawdatalog.server.microserviceConfigConfigMap", "MICROSERVICE_CONFIG_CONFIGMAP")
 	viper.BindEnv("rawdatalog.server.webhookUriPrefix", "WEBHOOK_PREFIX")
 	viper.BindEnv("rawdatalog.server.tenantID", "DOLITTLE_TENANT_ID")
 	viper.BindEnv("rawdatalog.server.applicationID", "DOLITTLE_APPLICATION_ID")
@@ -116,5 +167,36 @@ func init() {
 	viper.BindEnv("rawdatalog.log.stan.clientID", "STAN_CLIENT_ID")
 	viper.BindEnv("rawdatalog.log.nats.server", "NATS_SERVER")
 	viper.BindEnv("rawdatalog.log.topic", "TOPIC")
+	viper.BindEnv("rawdatalog.log.file.directory", "LOG_FILE_DIRECTORY")
+	viper.BindEnv("rawdatalog.log.file.segmentMaxBytes", "LOG_FILE_SEGMENT_MAX_BYTES")
+	viper.BindEnv("rawdatalog.log.file.sync", "LOG_FILE_SYNC")
+	viper.BindEnv("rawdatalog.log.file.syncInterval", "LOG_FILE_SYNC_INTERVAL")
+
+}
+
+// reloadOnSignal reloads the microservice config on SIGHUP
+func reloadOnSignal(ctx context.Context, service interface{ Reload() error }) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			logrus.Info("SIGHUP received, reloading microservice config")
+			// Errors are logged by the service
+			service.Reload()
+		}
+	}
+}
 
+func getFileLogOptionsFromViper() rawdatalog.FileLogOptions {
+	return rawdatalog.FileLogOptions{
+		Directory:       viper.GetString("rawdatalog.log.file.directory"),
+		SegmentMaxBytes: viper.GetInt64("rawdatalog.log.file.segmentMaxBytes"),
+		Sync:            rawdatalog.SyncPolicy(strings.ToLower(viper.GetString("rawdatalog.log.file.sync"))),
+		SyncInterval:    viper.GetDuration("rawdatalog.log.file.syncInterval"),
+	}
 }
@@ -14,6 +14,9 @@ var applicationCMD = &cobra.Command{
 	Use:   "application",
 	Short: "Shows commands to aid in deleting an application from the cluster",
 	Long: `
+	Prefer DELETE /application/{applicationID}, which runs the deletion as a job, or
+	"tools automate delete-application" for the cluster and git parts, these commands are for cleaning up by hand.
+
 	go run main.go tools studio delete-application --directory="/Users/freshteapot/dolittle/git/Operations" 6677c2f0-9e2f-4d2b-beb5-50014fc8ad0c
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -0,0 +1,107 @@
+package automate
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dolittle/platform-api/pkg/git"
+	platformApplication "github.com/dolittle/platform-api/pkg/platform/application"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	gitStorage "github.com/dolittle/platform-api/pkg/platform/storage/git"
+)
+
+var deleteApplicationCMD = &cobra.Command{
+	Use:   "delete-application",
+	Short: "Delete Application from kubernetes and git",
+	Long: `
+Backs up mongo in every environment of the application, then removes its namespace and what is stored about it in git.
+The terraform module is not touched, the job deleting an application destroys it after this has run.
+
+	--customer-id=XXX
+		Customer ID of where the application lives
+
+	--application-id=XXX
+		Application to delete
+
+	--force
+		Delete the application even if it has a Prod environment
+
+	--backup-timeout=30m
+		How long to wait for the mongo backups before giving up, nothing is deleted if they do not finish
+
+	go run main.go tools automate delete-application \
+	--customer-id=XXX \
+	--application-id=XXX
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Make sure we use git variables
+		git.SetupViper()
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.SetOutput(os.Stdout)
+
+		logContext := logrus.StandardLogger()
+		platformEnvironment := viper.GetString("tools.server.platformEnvironment")
+
+		gitRepoConfig := git.InitGit(logContext, platformEnvironment)
+
+		gitRepo := gitStorage.NewGitStorage(
+			logrus.WithField("context", "git-repo"),
+			gitRepoConfig,
+		)
+
+		customerID, _ := cmd.Flags().GetString("customer-id")
+		applicationID, _ := cmd.Flags().GetString("application-id")
+		force, _ := cmd.Flags().GetBool("force")
+		backupTimeout, _ := cmd.Flags().GetDuration("backup-timeout")
+
+		if customerID == "" {
+			fmt.Println("An --customer-id  is required")
+			os.Exit(1)
+		}
+
+		if applicationID == "" {
+			fmt.Println("An --application-id  is required")
+			os.Exit(1)
+		}
+
+		applicationContext := logContext.WithFields(logrus.Fields{
+			"customer_id":    customerID,
+			"application_id": applicationID,
+		})
+
+		application, err := gitRepo.GetApplication(customerID, applicationID)
+		if err != nil {
+			applicationContext.WithField("error", err).Fatal("Failed to get the application")
+		}
+
+		err = platformApplication.CanDelete(application, force)
+		if err != nil {
+			applicationContext.WithField("error", err).Fatal("Not deleting the application")
+		}
+
+		k8sClient, _ := platformK8s.InitKubernetesClient()
+		err = platformApplication.DeleteFromCluster(k8sClient, applicationID, backupTimeout, applicationContext)
+		if err != nil {
+			applicationContext.WithField("error", err).Fatal("Failed to delete the application from the cluster")
+		}
+
+		err = gitRepo.DeleteApplication(customerID, applicationID)
+		if err != nil {
+			applicationContext.WithField("error", err).Fatal("Failed to delete the application from git")
+		}
+
+		applicationContext.Info("Application deleted")
+	},
+}
+
+func init() {
+	deleteApplicationCMD.Flags().String("customer-id", "", "Customer ID of where the application lives")
+	deleteApplicationCMD.Flags().String("application-id", "", "Application ID to delete")
+	deleteApplicationCMD.Flags().Bool("force", false, "Delete the application even if it has a Prod environment")
+	deleteApplicationCMD.Flags().Duration("backup-timeout", 30*time.Minute, "How long to wait for the mongo backups")
+}
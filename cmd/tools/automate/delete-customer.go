@@ -0,0 +1,122 @@
+package automate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dolittle/platform-api/pkg/git"
+	platformApplication "github.com/dolittle/platform-api/pkg/platform/application"
+	platformCustomer "github.com/dolittle/platform-api/pkg/platform/customer"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	gitStorage "github.com/dolittle/platform-api/pkg/platform/storage/git"
+	"github.com/dolittle/platform-api/pkg/platform/user"
+)
+
+var deleteCustomerCMD = &cobra.Command{
+	Use:   "delete-customer",
+	Short: "Delete Customer from kubernetes, kratos and git",
+	Long: `
+Backs up mongo in every environment of every application of the customer, then removes their namespaces,
+takes the customer out of its users in kratos and removes what is stored about it in git.
+The terraform modules are not touched, the job deleting a customer destroys them after this has run.
+
+	--customer-id=XXX
+		Customer to delete
+
+	--force
+		Delete the customer even if it has an application with a Prod environment
+
+	--kratos-url=XXX
+		Kratos admin to remove the customer from its users with, without it the users are left as they are
+
+	--backup-timeout=30m
+		How long to wait for the mongo backups of each application before giving up
+
+	go run main.go tools automate delete-customer \
+	--customer-id=XXX \
+	--kratos-url="localhost:4434"
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Make sure we use git variables
+		git.SetupViper()
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.SetOutput(os.Stdout)
+
+		logContext := logrus.StandardLogger()
+		platformEnvironment := viper.GetString("tools.server.platformEnvironment")
+
+		gitRepoConfig := git.InitGit(logContext, platformEnvironment)
+
+		gitRepo := gitStorage.NewGitStorage(
+			logrus.WithField("context", "git-repo"),
+			gitRepoConfig,
+		)
+
+		customerID, _ := cmd.Flags().GetString("customer-id")
+		force, _ := cmd.Flags().GetBool("force")
+		kratosURL, _ := cmd.Flags().GetString("kratos-url")
+		backupTimeout, _ := cmd.Flags().GetDuration("backup-timeout")
+
+		if customerID == "" {
+			fmt.Println("An --customer-id  is required")
+			os.Exit(1)
+		}
+
+		customerContext := logContext.WithField("customer_id", customerID)
+
+		applications, err := gitRepo.GetApplications(customerID)
+		if err != nil {
+			customerContext.WithField("error", err).Fatal("Failed to get the applications")
+		}
+
+		err = platformCustomer.CanDelete(applications, force)
+		if err != nil {
+			customerContext.WithField("error", err).Fatal("Not deleting the customer")
+		}
+
+		k8sClient, _ := platformK8s.InitKubernetesClient()
+		for _, application := range applications {
+			applicationContext := customerContext.WithField("application_id", application.ID)
+			err = platformApplication.DeleteFromCluster(k8sClient, application.ID, backupTimeout, applicationContext)
+			if err != nil {
+				applicationContext.WithField("error", err).Fatal("Failed to delete the application from the cluster")
+			}
+		}
+
+		if kratosURL == "" {
+			customerContext.Warn("No --kratos-url, the users of the customer are left as they are")
+		} else {
+			kratosClient := user.NewKratosClientV5(&url.URL{
+				Scheme: "http",
+				Host:   kratosURL,
+			})
+			removed, err := platformCustomer.RemoveFromUsers(kratosClient, customerID)
+			if err != nil {
+				customerContext.WithField("error", err).Fatal("Failed to remove the customer from its users")
+			}
+			customerContext.WithField("users", removed).Info("Customer removed from its users")
+		}
+
+		err = gitRepo.DeleteCustomer(customerID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			customerContext.WithField("error", err).Fatal("Failed to delete the customer from git")
+		}
+
+		customerContext.Info("Customer deleted")
+	},
+}
+
+func init() {
+	deleteCustomerCMD.Flags().String("customer-id", "", "Customer ID to delete")
+	deleteCustomerCMD.Flags().Bool("force", false, "Delete the customer even if it has an application with a Prod environment")
+	deleteCustomerCMD.Flags().String("kratos-url", "", "Url to the kratos admin")
+	deleteCustomerCMD.Flags().Duration("backup-timeout", 30*time.Minute, "How long to wait for the mongo backups of each application")
+}
@@ -0,0 +1,138 @@
+package automate
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dolittle/platform-api/pkg/git"
+	platformApplication "github.com/dolittle/platform-api/pkg/platform/application"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	gitStorage "github.com/dolittle/platform-api/pkg/platform/storage/git"
+)
+
+var deleteEnvironmentCMD = &cobra.Command{
+	Use:   "delete-environment",
+	Short: "Delete an environment of an application from kubernetes and git",
+	Long: `
+Backs up mongo in the environment, then removes everything in it from the namespace of the application,
+and removes it and its microservices from what is stored about the application in git.
+
+	--customer-id=XXX
+		Customer ID of where the application lives
+
+	--application-id=XXX
+		Application the environment belongs to
+
+	--environment=XXX
+		Environment to delete
+
+	--force
+		Delete the environment even if it is Prod
+
+	--backup-timeout=30m
+		How long to wait for the mongo backup before giving up, nothing is deleted if it does not finish
+
+	go run main.go tools automate delete-environment \
+	--customer-id=XXX \
+	--application-id=XXX \
+	--environment=Dev
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Make sure we use git variables
+		git.SetupViper()
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.SetOutput(os.Stdout)
+
+		logContext := logrus.StandardLogger()
+		platformEnvironment := viper.GetString("tools.server.platformEnvironment")
+
+		gitRepoConfig := git.InitGit(logContext, platformEnvironment)
+
+		gitRepo := gitStorage.NewGitStorage(
+			logrus.WithField("context", "git-repo"),
+			gitRepoConfig,
+		)
+
+		customerID, _ := cmd.Flags().GetString("customer-id")
+		applicationID, _ := cmd.Flags().GetString("application-id")
+		environment, _ := cmd.Flags().GetString("environment")
+		force, _ := cmd.Flags().GetBool("force")
+		backupTimeout, _ := cmd.Flags().GetDuration("backup-timeout")
+
+		if customerID == "" {
+			fmt.Println("An --customer-id  is required")
+			os.Exit(1)
+		}
+
+		if applicationID == "" {
+			fmt.Println("An --application-id  is required")
+			os.Exit(1)
+		}
+
+		if environment == "" {
+			fmt.Println("An --environment  is required")
+			os.Exit(1)
+		}
+
+		environmentContext := logContext.WithFields(logrus.Fields{
+			"customer_id":    customerID,
+			"application_id": applicationID,
+			"environment":    environment,
+		})
+
+		application, err := gitRepo.GetApplication(customerID, applicationID)
+		if err != nil {
+			environmentContext.WithField("error", err).Fatal("Failed to get the application")
+		}
+
+		err = platformApplication.CanDeleteEnvironment(application, environment, force)
+		if err != nil {
+			environmentContext.WithField("error", err).Fatal("Not deleting the environment")
+		}
+
+		k8sClient, k8sConfig := platformK8s.InitKubernetesClient()
+		k8sDolittleRepo := platformK8s.NewK8sRepo(k8sClient, k8sConfig, logContext.WithField("context", "k8s-repo"))
+		err = platformApplication.DeleteEnvironmentFromCluster(k8sClient, k8sDolittleRepo, applicationID, environment, backupTimeout, environmentContext)
+		if err != nil {
+			environmentContext.WithField("error", err).Fatal("Failed to delete the environment from the cluster")
+		}
+
+		environments := make([]storage.JSONEnvironment, 0)
+		for _, item := range application.Environments {
+			if !strings.EqualFold(item.Name, environment) {
+				environments = append(environments, item)
+			}
+		}
+		application.Environments = environments
+
+		err = gitRepo.SaveApplication(application)
+		if err != nil {
+			environmentContext.WithField("error", err).Fatal("Failed to remove the environment from the application")
+		}
+
+		err = gitRepo.RemovePathAndPush(
+			gitRepo.GetMicroserviceDirectory(customerID, applicationID, environment),
+			fmt.Sprintf("Environment %s deleted from %s", environment, applicationID),
+		)
+		if err != nil {
+			environmentContext.WithField("error", err).Fatal("Failed to delete the microservices of the environment from git")
+		}
+
+		environmentContext.Info("Environment deleted")
+	},
+}
+
+func init() {
+	deleteEnvironmentCMD.Flags().String("customer-id", "", "Customer ID of where the application lives")
+	deleteEnvironmentCMD.Flags().String("application-id", "", "Application ID the environment belongs to")
+	deleteEnvironmentCMD.Flags().String("environment", "", "Environment to delete")
+	deleteEnvironmentCMD.Flags().Bool("force", false, "Delete the environment even if it is Prod")
+	deleteEnvironmentCMD.Flags().Duration("backup-timeout", 30*time.Minute, "How long to wait for the mongo backup")
+}
@@ -0,0 +1,182 @@
+package automate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dolittle/platform-api/pkg/git"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	gitStorage "github.com/dolittle/platform-api/pkg/platform/storage/git"
+)
+
+var maintainBackupsCMD = &cobra.Command{
+	Use:   "maintain-backups",
+	Short: "Prune and verify the mongo backups of every environment",
+	Long: `
+Goes through every environment of every application, or only those of the given customer or application.
+It is meant to be run on a schedule, by the cronjob from "tools job template maintain-backups",
+nothing waits for the jobs it starts.
+
+	--prune
+		Start a job removing the backups the retention of the environment does not keep,
+		environments without a retention are skipped
+
+	--verify
+		Start a job restoring the latest backup of the environment into a throwaway mongo,
+		the result is in the backup api
+
+	--customer-id=XXX
+		Only the applications of this customer
+
+	--application-id=XXX
+		Only this application, requires --customer-id
+
+	go run main.go tools automate maintain-backups --prune --verify
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Make sure we use git variables
+		git.SetupViper()
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.SetOutput(os.Stdout)
+
+		logContext := logrus.StandardLogger()
+		platformEnvironment := viper.GetString("tools.server.platformEnvironment")
+
+		gitRepoConfig := git.InitGit(logContext, platformEnvironment)
+
+		gitRepo := gitStorage.NewGitStorage(
+			logrus.WithField("context", "git-repo"),
+			gitRepoConfig,
+		)
+
+		customerID, _ := cmd.Flags().GetString("customer-id")
+		applicationID, _ := cmd.Flags().GetString("application-id")
+		prune, _ := cmd.Flags().GetBool("prune")
+		verify, _ := cmd.Flags().GetBool("verify")
+
+		if !prune && !verify {
+			fmt.Println("At least one of --prune or --verify is required")
+			os.Exit(1)
+		}
+
+		if applicationID != "" && customerID == "" {
+			fmt.Println("An --application-id requires a --customer-id")
+			os.Exit(1)
+		}
+
+		applications, err := getApplicationsToMaintain(gitRepo, customerID, applicationID)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Failed to get the applications")
+		}
+
+		k8sClient, _ := platformK8s.InitKubernetesClient()
+		getStore := backup.NewStoreResolver(k8sClient, gitRepo)
+		ctx := context.Background()
+
+		failed := false
+		for _, application := range applications {
+			namespace := fmt.Sprintf("application-%s", application.ID)
+			store, err := getStore(ctx, application.CustomerID, namespace)
+			if err != nil {
+				logContext.WithFields(logrus.Fields{
+					"customer_id":    application.CustomerID,
+					"application_id": application.ID,
+					"error":          err,
+				}).Error("Failed to get the backup store")
+				failed = true
+				continue
+			}
+
+			for _, environment := range application.Environments {
+				environmentContext := logContext.WithFields(logrus.Fields{
+					"customer_id":    application.CustomerID,
+					"application_id": application.ID,
+					"environment":    environment.Name,
+				})
+
+				if prune && environment.Mongo.BackupRetention != nil {
+					job, pruned, err := backup.PruneEnvironment(ctx, k8sClient, store, namespace, environment.Name, *environment.Mongo.BackupRetention)
+					if errors.Is(err, backup.ErrBackupJobRunning) {
+						environmentContext.WithField("error", err).Info("Skipping the prune until the next run")
+					} else if err != nil {
+						environmentContext.WithField("error", err).Error("Failed to prune the backups")
+						failed = true
+					} else if job != "" {
+						environmentContext.WithFields(logrus.Fields{
+							"job":    job,
+							"pruned": len(pruned),
+						}).Info("Pruning backups")
+					}
+				}
+
+				if verify {
+					job, name, err := backup.VerifyEnvironment(ctx, k8sClient, store, namespace, environment.Name)
+					if err != nil {
+						if errors.Is(err, backup.ErrNoBackups) {
+							environmentContext.Info("No backups to verify")
+							continue
+						}
+						environmentContext.WithField("error", err).Error("Failed to start the verification")
+						failed = true
+						continue
+					}
+					environmentContext.WithFields(logrus.Fields{
+						"job":    job,
+						"backup": name,
+					}).Info("Verifying backup")
+				}
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func getApplicationsToMaintain(gitRepo *gitStorage.GitStorage, customerID string, applicationID string) ([]storage.JSONApplication, error) {
+	if applicationID != "" {
+		application, err := gitRepo.GetApplication(customerID, applicationID)
+		if err != nil {
+			return nil, err
+		}
+		return []storage.JSONApplication{application}, nil
+	}
+
+	customerIDs := []string{customerID}
+	if customerID == "" {
+		customers, err := gitRepo.GetCustomers()
+		if err != nil {
+			return nil, err
+		}
+		customerIDs = make([]string, 0, len(customers))
+		for _, customer := range customers {
+			customerIDs = append(customerIDs, customer.ID)
+		}
+	}
+
+	applications := make([]storage.JSONApplication, 0)
+	for _, id := range customerIDs {
+		found, err := gitRepo.GetApplications(id)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, found...)
+	}
+	return applications, nil
+}
+
+func init() {
+	maintainBackupsCMD.Flags().Bool("prune", false, "Prune the backups of environments with a retention")
+	maintainBackupsCMD.Flags().Bool("verify", false, "Verify the latest backup of every environment")
+	maintainBackupsCMD.Flags().String("customer-id", "", "Only the applications of this customer")
+	maintainBackupsCMD.Flags().String("application-id", "", "Only this application")
+}
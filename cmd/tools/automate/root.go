@@ -18,4 +18,8 @@ func init() {
 	RootCmd.AddCommand(importDolittleConfigMapsCMD)
 	RootCmd.AddCommand(pullMicroserviceDeploymentCMD)
 	RootCmd.AddCommand(createApplicationCMD)
+	RootCmd.AddCommand(deleteApplicationCMD)
+	RootCmd.AddCommand(deleteCustomerCMD)
+	RootCmd.AddCommand(deleteEnvironmentCMD)
+	RootCmd.AddCommand(maintainBackupsCMD)
 }
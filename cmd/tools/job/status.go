@@ -2,24 +2,28 @@ package job
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/dolittle/platform-api/pkg/platform/job"
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var statusCMD = &cobra.Command{
 	Use:   "status",
 	Short: "Get status for a job",
 	Long: `
-	Outputs a State of each container in the job
+	Outputs the conditions of the job and the state of each container in it as json,
+	the same as GET /jobs/{jobID}
 
-	go run main.go tools jobs status XXX
+	go run main.go tools job status XXX
+	go run main.go tools job status XXX --logs --step=terraform-apply
 	`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Hmm it is on the pod level
-		// Init:Error, Completed, Init:5/9, Init:ErrImagePull, Init:ImagePullBackOff
 		jobID := args[0]
 		if jobID == "" {
 			fmt.Println("We cant give you the status of a job if we don't know what to look for, please add the job you want the status for")
@@ -31,51 +35,43 @@ var statusCMD = &cobra.Command{
 		ctx := context.TODO()
 		namespace := "system-api"
 
-		resource, err := client.BatchV1().Jobs(namespace).Get(ctx, jobID, metav1.GetOptions{})
+		resource, pods, err := jobK8s.GetJob(ctx, client, namespace, jobID)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
 
-		for _, condition := range resource.Status.Conditions {
-			fmt.Println(condition.Type)
+		showLogs, _ := cmd.Flags().GetBool("logs")
+		if !showLogs {
+			b, _ := json.MarshalIndent(job.NewJobStatus(resource, pods), "", "  ")
+			fmt.Println(string(b))
+			return
 		}
 
-		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("job-name=%s", jobID),
-		})
-
-		if err != nil {
-			fmt.Println(err.Error())
+		if len(pods) == 0 {
+			fmt.Println("The job has not started a pod yet")
 			return
 		}
 
-		for _, pod := range pods.Items {
-
-			fmt.Println(pod.Status.Phase, pod.Status.StartTime)
-			for _, status := range pod.Status.InitContainerStatuses {
-				cmd := fmt.Sprintf(`kubectl -n %s logs %s -c %s`,
-					pod.Namespace,
-					pod.Name,
-					status.Name,
-				)
-				fmt.Println(cmd)
-
-				if status.State.Running != nil {
-					fmt.Println("Running", status.State.Running.StartedAt)
-				}
-
-				if status.State.Waiting != nil {
-					fmt.Println("Waiting", status.State.Waiting.Message, status.State.Waiting.Reason)
-				}
-
-				if status.State.Terminated != nil {
-					fmt.Println("Terminated", status.State.Terminated.Message, status.State.Terminated.Reason)
-				}
-
-				fmt.Println("")
-				// req := client.CoreV1().Pods(namespace).GetLogs(podName, &podLogOptions)
+		step, _ := cmd.Flags().GetString("step")
+		for _, status := range job.JobSteps(pods[0]) {
+			if step != "" && status.Name != step {
+				continue
+			}
+			fmt.Printf("==> %s (%s) <==\n", status.Name, status.State)
+			if status.State == job.StepStateWaiting {
+				continue
 			}
+			err := jobK8s.StreamStepLogs(ctx, client, pods[0], status.Name, os.Stdout)
+			if err != nil {
+				fmt.Println(err.Error())
+			}
+			fmt.Println("")
 		}
 	},
 }
+
+func init() {
+	statusCMD.Flags().Bool("logs", false, "Output the logs of the steps instead of the status")
+	statusCMD.Flags().String("step", "", "Only output the logs of this step")
+}
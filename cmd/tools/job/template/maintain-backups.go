@@ -0,0 +1,57 @@
+package template
+
+import (
+	"fmt"
+	"os"
+
+	k8sJson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var maintainBackupsCMD = &cobra.Command{
+	Use:   "maintain-backups",
+	Short: "Create a k8s CronJob to prune and verify the mongo backups",
+	Long: `
+	Outputs a k8s cronjob running "tools automate maintain-backups --prune --verify" on the schedule,
+	apply it next to the platform-api
+
+	go run main.go tools job template maintain-backups \
+	--platform-environment="dev" \
+	--schedule="30 3 * * *" | kubectl apply -f -
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		schedule, _ := cmd.Flags().GetString("schedule")
+		if schedule == "" {
+			fmt.Println("--schedule is required")
+			return
+		}
+
+		createResourceConfig := jobK8s.CreateResourceConfigFromViper(viper.GetViper())
+		createResourceConfig.PlatformEnvironment = viper.GetString("tools.server.platformEnvironment")
+
+		resource := jobK8s.MaintainBackupsResource(createResourceConfig, schedule)
+
+		s := runtime.NewScheme()
+		serializer := k8sJson.NewSerializerWithOptions(
+			k8sJson.DefaultMetaFactory,
+			s,
+			s,
+			k8sJson.SerializerOptions{
+				Yaml:   true,
+				Pretty: true,
+				Strict: true,
+			},
+		)
+
+		serializer.Encode(resource, os.Stdout)
+	},
+}
+
+func init() {
+	maintainBackupsCMD.Flags().String("schedule", jobK8s.DefaultMaintainBackupsSchedule, "Cron schedule of the pruning and verifying, in UTC")
+}
@@ -11,4 +11,5 @@ var RootCMD = &cobra.Command{
 func init() {
 	RootCMD.AddCommand(customerCMD)
 	RootCMD.AddCommand(applicationCMD)
+	RootCMD.AddCommand(maintainBackupsCMD)
 }
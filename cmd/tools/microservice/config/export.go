@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configBundle"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportCMD = &cobra.Command{
+	Use:   "export <directory or .tar.gz>",
+	Short: "Export the configuration of a microservice",
+	Long: `
+	Writes the environment variables and config files of a microservice to a directory
+	(environment-variables.env, secret-environment-variables.env and config-files/) or a .tar.gz archive.
+
+	go run main.go tools microservice config export ./order-config \
+		--application cde2e951-d40a-3548-8b45-64c0ded97940 \
+		--environment dev \
+		--microservice-id 9f6a613f-d969-4938-a1ac-5b7df199bc40 \
+		--include-secrets
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.SetOutput(os.Stdout)
+
+		applicationID := viper.GetString("tools.microservice.config.application")
+		environment := viper.GetString("tools.microservice.config.environment")
+		microserviceID := viper.GetString("tools.microservice.config.microservice-id")
+		includeSecrets := viper.GetBool("tools.microservice.config.include-secrets")
+
+		logContext := logrus.StandardLogger().WithFields(logrus.Fields{
+			"application_id":  applicationID,
+			"environment":     environment,
+			"microservice_id": microserviceID,
+			"include_secrets": includeSecrets,
+			"path":            args[0],
+		})
+
+		k8sClient, k8sConfig := platformK8s.InitKubernetesClient()
+		k8sRepo := platformK8s.NewK8sRepo(k8sClient, k8sConfig, logContext.WithField("context", "k8s-repo"))
+		repo := configBundle.NewConfigBundleK8sRepo(k8sRepo, logContext.WithField("context", "config-bundle-repo"))
+
+		bundle, err := repo.GetBundle(applicationID, environment, microserviceID, includeSecrets)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Failed to get the configuration")
+		}
+
+		err = writeBundle(args[0], bundle)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Failed to write the configuration")
+		}
+
+		logContext.WithFields(logrus.Fields{
+			"environmentVariables": len(bundle.EnvironmentVariables),
+			"configFiles":          len(bundle.ConfigFiles),
+		}).Info("Exported configuration")
+	},
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configBundle"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configHistory"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var importCMD = &cobra.Command{
+	Use:   "import <directory or .tar.gz>",
+	Short: "Replace the configuration of a microservice",
+	Long: `
+	Replaces the environment variables and config files of a microservice with a bundle written by export.
+	Secret environment variables are left alone unless --include-secrets is given.
+	The changes are printed, with --dry-run nothing is changed.
+
+	go run main.go tools microservice config import ./order-config \
+		--application cde2e951-d40a-3548-8b45-64c0ded97940 \
+		--environment dev \
+		--microservice-id 9f6a613f-d969-4938-a1ac-5b7df199bc40 \
+		--dry-run
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.SetOutput(os.Stderr)
+
+		applicationID := viper.GetString("tools.microservice.config.application")
+		environment := viper.GetString("tools.microservice.config.environment")
+		microserviceID := viper.GetString("tools.microservice.config.microservice-id")
+		includeSecrets := viper.GetBool("tools.microservice.config.include-secrets")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		rollout, _ := cmd.Flags().GetBool("rollout")
+
+		logContext := logrus.StandardLogger().WithFields(logrus.Fields{
+			"application_id":  applicationID,
+			"environment":     environment,
+			"microservice_id": microserviceID,
+			"path":            args[0],
+			"dry_run":         dryRun,
+		})
+
+		bundle, err := readBundle(args[0])
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Failed to read the configuration")
+		}
+
+		if !includeSecrets {
+			bundle.SecretEnvironmentVariables = nil
+		}
+
+		err = bundle.Validate()
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Invalid configuration")
+		}
+
+		k8sClient, k8sConfig := platformK8s.InitKubernetesClient()
+		k8sRepo := platformK8s.NewK8sRepo(k8sClient, k8sConfig, logContext.WithField("context", "k8s-repo"))
+		repo := configBundle.NewConfigBundleK8sRepo(k8sRepo, logContext.WithField("context", "config-bundle-repo"))
+
+		current, err := repo.GetBundle(applicationID, environment, microserviceID, bundle.IncludesSecrets())
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Failed to get the configuration")
+		}
+
+		changes := configBundle.Plan(current, bundle)
+		b, _ := json.MarshalIndent(changes, "", "  ")
+		fmt.Println(string(b))
+
+		if dryRun || len(changes) == 0 {
+			return
+		}
+
+		history := configHistory.NewConfigHistory(
+			configHistory.NewConfigHistoryK8sRepo(k8sClient, 50, logContext.WithField("context", "config-history-repo")),
+			k8sRepo,
+			configHistory.NewSecretCipher(viper.GetString("tools.microservice.config.history-encryption-key")),
+			logContext.WithField("context", "config-history"),
+		)
+
+		err = history.Record(applicationID, environment, microserviceID, "tools", "before config bundle import")
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Failed to record config revision")
+		}
+
+		err = repo.ApplyBundle(applicationID, environment, microserviceID, bundle)
+		if err != nil {
+			logContext.WithField("error", err).Fatal("Failed to import the configuration")
+		}
+
+		err = history.Record(applicationID, environment, microserviceID, "tools", "import config bundle")
+		if err != nil {
+			logContext.WithField("error", err).Error("Failed to record config revision")
+		}
+
+		if rollout {
+			status, err := k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+			if err != nil {
+				logContext.WithField("error", err).Fatal("Failed to roll out the configuration")
+			}
+			logContext.WithField("rollout", status).Info("Rolling out the configuration")
+		}
+
+		logContext.WithField("changes", len(changes)).Info("Imported configuration")
+	},
+}
+
+func init() {
+	importCMD.Flags().Bool("dry-run", false, "Only print what would change")
+	importCMD.Flags().Bool("rollout", false, "Roll out new pods with the imported configuration")
+}
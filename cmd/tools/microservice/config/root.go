@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configBundle"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var RootCMD = &cobra.Command{
+	Use:   "config",
+	Short: "Export and import the environment variables and config files of a microservice",
+	Long:  ``,
+}
+
+func init() {
+	RootCMD.AddCommand(exportCMD)
+	RootCMD.AddCommand(importCMD)
+
+	RootCMD.PersistentFlags().String("application", "", "application id")
+	RootCMD.PersistentFlags().String("environment", "", "environment of the microservice")
+	RootCMD.PersistentFlags().String("microservice-id", "", "microservice id")
+	RootCMD.PersistentFlags().Bool("include-secrets", false, "Include the secret environment variables")
+	RootCMD.MarkPersistentFlagRequired("application")
+	RootCMD.MarkPersistentFlagRequired("environment")
+	RootCMD.MarkPersistentFlagRequired("microservice-id")
+
+	viper.BindPFlag("tools.microservice.config.application", RootCMD.PersistentFlags().Lookup("application"))
+	viper.BindPFlag("tools.microservice.config.environment", RootCMD.PersistentFlags().Lookup("environment"))
+	viper.BindPFlag("tools.microservice.config.microservice-id", RootCMD.PersistentFlags().Lookup("microservice-id"))
+	viper.BindPFlag("tools.microservice.config.include-secrets", RootCMD.PersistentFlags().Lookup("include-secrets"))
+
+	viper.BindEnv("tools.microservice.config.history-encryption-key", "CONFIG_HISTORY_ENCRYPTION_KEY")
+}
+
+// isArchive decides between a .tar.gz bundle and a directory with .env files and config files
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+func readBundle(path string) (configBundle.Bundle, error) {
+	if !isArchive(path) {
+		return configBundle.ReadDirectory(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return configBundle.Bundle{}, err
+	}
+	defer file.Close()
+	return configBundle.ReadArchive(file)
+}
+
+func writeBundle(path string, bundle configBundle.Bundle) error {
+	if !isArchive(path) {
+		return configBundle.WriteDirectory(path, bundle)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = configBundle.WriteArchive(file, bundle)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
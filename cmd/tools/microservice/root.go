@@ -1,6 +1,7 @@
 package microservice
 
 import (
+	"github.com/dolittle/platform-api/cmd/tools/microservice/config"
 	"github.com/dolittle/platform-api/cmd/tools/microservice/copy"
 	"github.com/spf13/cobra"
 )
@@ -13,4 +14,5 @@ var RootCMD = &cobra.Command{
 
 func init() {
 	RootCMD.AddCommand(copy.RootCMD)
+	RootCMD.AddCommand(config.RootCMD)
 }
@@ -39,6 +39,27 @@ func (_m *CustomerRepo) GetApplications(customerID string) ([]storage.JSONApplic
 	return r0, r1
 }
 
+// GetCustomer provides a mock function with given fields: customerID
+func (_m *CustomerRepo) GetCustomer(customerID string) (storage.JSONCustomer, error) {
+	ret := _m.Called(customerID)
+
+	var r0 storage.JSONCustomer
+	if rf, ok := ret.Get(0).(func(string) storage.JSONCustomer); ok {
+		r0 = rf(customerID)
+	} else {
+		r0 = ret.Get(0).(storage.JSONCustomer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(customerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCustomers provides a mock function with given fields:
 func (_m *CustomerRepo) GetCustomers() ([]platform.Customer, error) {
 	ret := _m.Called()
@@ -97,6 +118,20 @@ func (_m *CustomerRepo) SaveCustomer(customer storage.JSONCustomer) error {
 	return r0
 }
 
+// SaveStudioConfig provides a mock function with given fields: customerID, config
+func (_m *CustomerRepo) SaveStudioConfig(customerID string, config platform.StudioConfig) error {
+	ret := _m.Called(customerID, config)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, platform.StudioConfig) error); ok {
+		r0 = rf(customerID, config)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewCustomerRepo creates a new instance of CustomerRepo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
 func NewCustomerRepo(t testing.TB) *CustomerRepo {
 	mock := &CustomerRepo{}
@@ -3,6 +3,7 @@
 package configFiles
 
 import (
+	platform "github.com/dolittle/platform-api/pkg/platform"
 	microserviceconfigFiles "github.com/dolittle/platform-api/pkg/platform/microservice/configFiles"
 	mock "github.com/stretchr/testify/mock"
 
@@ -29,15 +30,15 @@ func (_m *ConfigFilesRepo) AddEntryToConfigFiles(applicationID string, environme
 }
 
 // GetConfigFilesNamesList provides a mock function with given fields: applicationID, environment, microserviceID
-func (_m *ConfigFilesRepo) GetConfigFilesNamesList(applicationID string, environment string, microserviceID string) ([]string, error) {
+func (_m *ConfigFilesRepo) GetConfigFilesNamesList(applicationID string, environment string, microserviceID string) ([]platform.ConfigFileInfo, error) {
 	ret := _m.Called(applicationID, environment, microserviceID)
 
-	var r0 []string
-	if rf, ok := ret.Get(0).(func(string, string, string) []string); ok {
+	var r0 []platform.ConfigFileInfo
+	if rf, ok := ret.Get(0).(func(string, string, string) []platform.ConfigFileInfo); ok {
 		r0 = rf(applicationID, environment, microserviceID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
+			r0 = ret.Get(0).([]platform.ConfigFileInfo)
 		}
 	}
 
@@ -87,6 +87,41 @@ func (_m *Repo) Exists(namespace string, customer k8s.Tenant, application k8s.Ap
 	return r0, r1
 }
 
+// GetStatus provides a mock function with given fields: applicationID, environment, microserviceID
+func (_m *Repo) GetStatus(applicationID string, environment string, microserviceID string) (platform.PurchaseOrderAPIStatus, error) {
+	ret := _m.Called(applicationID, environment, microserviceID)
+
+	var r0 platform.PurchaseOrderAPIStatus
+	if rf, ok := ret.Get(0).(func(string, string, string) platform.PurchaseOrderAPIStatus); ok {
+		r0 = rf(applicationID, environment, microserviceID)
+	} else {
+		r0 = ret.Get(0).(platform.PurchaseOrderAPIStatus)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(applicationID, environment, microserviceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateImages provides a mock function with given fields: applicationID, environment, microserviceID, headImage, runtimeImage
+func (_m *Repo) UpdateImages(applicationID string, environment string, microserviceID string, headImage string, runtimeImage string) error {
+	ret := _m.Called(applicationID, environment, microserviceID, headImage, runtimeImage)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, string) error); ok {
+		r0 = rf(applicationID, environment, microserviceID, headImage, runtimeImage)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewRepo creates a new instance of Repo. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
 func NewRepo(t testing.TB) *Repo {
 	mock := &Repo{}
@@ -15,6 +15,20 @@ type Repo struct {
 	mock.Mock
 }
 
+// DeleteApplication provides a mock function with given fields: customerID, applicationID
+func (_m *Repo) DeleteApplication(customerID string, applicationID string) error {
+	ret := _m.Called(customerID, applicationID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(customerID, applicationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteBusinessMoment provides a mock function with given fields: customerID, applicationID, environment, microserviceID, momentID
 func (_m *Repo) DeleteBusinessMoment(customerID string, applicationID string, environment string, microserviceID string, momentID string) error {
 	ret := _m.Called(customerID, applicationID, environment, microserviceID, momentID)
@@ -352,3 +366,52 @@ func NewRepo(t testing.TB) *Repo {
 
 	return mock
 }
+
+// GetBusinessMomentsVersions provides a mock function with given fields: customerID, applicationID, environment, microserviceID
+func (_m *Repo) GetBusinessMomentsVersions(customerID string, applicationID string, environment string, microserviceID string) (platform.HttpResponseBusinessMomentsVersions, error) {
+	ret := _m.Called(customerID, applicationID, environment, microserviceID)
+
+	var r0 platform.HttpResponseBusinessMomentsVersions
+	if rf, ok := ret.Get(0).(func(string, string, string, string) platform.HttpResponseBusinessMomentsVersions); ok {
+		r0 = rf(customerID, applicationID, environment, microserviceID)
+	} else {
+		r0 = ret.Get(0).(platform.HttpResponseBusinessMomentsVersions)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, string) error); ok {
+		r1 = rf(customerID, applicationID, environment, microserviceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveBusinessMomentsVersion provides a mock function with given fields: customerID, applicationID, environment, microserviceID, version
+func (_m *Repo) SaveBusinessMomentsVersion(customerID string, applicationID string, environment string, microserviceID string, version platform.BusinessMomentsVersion) error {
+	ret := _m.Called(customerID, applicationID, environment, microserviceID, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, platform.BusinessMomentsVersion) error); ok {
+		r0 = rf(customerID, applicationID, environment, microserviceID, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPublishedBusinessMomentsVersion provides a mock function with given fields: customerID, applicationID, environment, microserviceID, version
+func (_m *Repo) SetPublishedBusinessMomentsVersion(customerID string, applicationID string, environment string, microserviceID string, version int) error {
+	ret := _m.Called(customerID, applicationID, environment, microserviceID, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, int) error); ok {
+		r0 = rf(customerID, applicationID, environment, microserviceID, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -14,6 +14,20 @@ type RepoApplication struct {
 	mock.Mock
 }
 
+// DeleteApplication provides a mock function with given fields: customerID, applicationID
+func (_m *RepoApplication) DeleteApplication(customerID string, applicationID string) error {
+	ret := _m.Called(customerID, applicationID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(customerID, applicationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetApplication provides a mock function with given fields: customerID, applicationID
 func (_m *RepoApplication) GetApplication(customerID string, applicationID string) (platformstorage.JSONApplication, error) {
 	ret := _m.Called(customerID, applicationID)
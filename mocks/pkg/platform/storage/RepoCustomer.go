@@ -15,6 +15,41 @@ type RepoCustomer struct {
 	mock.Mock
 }
 
+// DeleteCustomer provides a mock function with given fields: customerID
+func (_m *RepoCustomer) DeleteCustomer(customerID string) error {
+	ret := _m.Called(customerID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(customerID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCustomer provides a mock function with given fields: customerID
+func (_m *RepoCustomer) GetCustomer(customerID string) (platformstorage.JSONCustomer, error) {
+	ret := _m.Called(customerID)
+
+	var r0 platformstorage.JSONCustomer
+	if rf, ok := ret.Get(0).(func(string) platformstorage.JSONCustomer); ok {
+		r0 = rf(customerID)
+	} else {
+		r0 = ret.Get(0).(platformstorage.JSONCustomer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(customerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCustomers provides a mock function with given fields:
 func (_m *RepoCustomer) GetCustomers() ([]platform.Customer, error) {
 	ret := _m.Called()
@@ -94,6 +94,40 @@ func LatestX(accountName string, accountKey string, shareName string) (ListRespo
 	}, nil
 }
 
+// ListFiles lists every file in the directory of the fileshare, with their size.
+// The size is what Azure last saw, it can lag behind while a file is being written
+func ListFiles(accountName string, accountKey string, shareName string, directory string) ([]FileInfo, error) {
+	credential, err := azfile.NewSharedKeyCredential(accountName, accountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	p := azfile.NewPipeline(credential, azfile.PipelineOptions{})
+	u, err := url.Parse(fmt.Sprintf("https://%s.file.core.windows.net", accountName))
+	if err != nil {
+		return nil, err
+	}
+	directoryURL := azfile.NewServiceURL(*u, p).NewShareURL(shareName).NewDirectoryURL(directory)
+
+	ctx := context.Background()
+	found := make([]FileInfo, 0)
+	for marker := (azfile.Marker{}); marker.NotDone(); {
+		listResponse, err := directoryURL.ListFilesAndDirectoriesSegment(ctx, marker, azfile.ListFilesAndDirectoriesOptions{})
+		if err != nil {
+			return nil, err
+		}
+		marker = listResponse.NextMarker
+
+		for _, fileEntry := range listResponse.FileItems {
+			found = append(found, FileInfo{
+				Name: fileEntry.Name,
+				Size: fileEntry.Properties.ContentLength,
+			})
+		}
+	}
+	return found, nil
+}
+
 // EnsureFileShareExists creates a fileshare with the provided name with a default quota in the given storage account if
 // it does not already exist.
 func EnsureFileShareExists(accountName, accountKey, shareName string) error {
@@ -6,3 +6,8 @@ type ListResponse struct {
 	Prefix      string   `json:"prefix"`
 	Files       []string `json:"files"`
 }
+
+type FileInfo struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"mime"
 	"net/http"
+	"os"
 
+	"github.com/dolittle/platform-api/pkg/platform"
 	"github.com/dolittle/platform-api/pkg/utils"
 )
 
@@ -74,3 +77,34 @@ func EnforceJSONHandler(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+type studioConfigRepo interface {
+	GetStudioConfig(customerID string) (platform.StudioConfig, error)
+}
+
+// RejectWritesForSuspendedCustomers only lets suspended customers read, customers without a studio config are let through.
+// Writes are refused when the studio config can not be read, so suspending can not be gotten around
+func RejectWritesForSuspendedCustomers(repo studioConfigRepo) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			studioConfig, err := repo.GetStudioConfig(r.Header.Get("Tenant-ID"))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check if the customer is suspended")
+				return
+			}
+
+			if err == nil && studioConfig.Suspended {
+				utils.RespondWithError(w, http.StatusForbidden, "Customer is suspended")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
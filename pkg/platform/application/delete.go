@@ -0,0 +1,74 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var ErrHasProductionEnvironment = errors.New("application has a production environment")
+
+// CanDelete stops applications with a Prod environment from being deleted, unless forced
+func CanDelete(application storage.JSONApplication, force bool) error {
+	if force {
+		return nil
+	}
+	for _, environment := range application.Environments {
+		if strings.EqualFold(environment.Name, "prod") {
+			return fmt.Errorf("%w: use force to delete it anyway", ErrHasProductionEnvironment)
+		}
+	}
+	return nil
+}
+
+// DeleteFromCluster backs up mongo in every environment, then removes the access to and the namespace of the application.
+// Nothing is deleted if the backups do not finish within the backupTimeout
+func DeleteFromCluster(client kubernetes.Interface, applicationID string, backupTimeout time.Duration, logContext logrus.FieldLogger) error {
+	ctx := context.TODO()
+	namespace := platformK8s.GetApplicationNamespace(applicationID)
+	logContext = logContext.WithFields(logrus.Fields{
+		"application_id": applicationID,
+		"namespace":      namespace,
+	})
+
+	_, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			logContext.Info("Namespace is already gone")
+			return nil
+		}
+		return err
+	}
+
+	jobs, err := backup.RunMongoBackups(ctx, client, namespace, "")
+	if err != nil {
+		return fmt.Errorf("failed to start the mongo backups: %w", err)
+	}
+	logContext.WithField("jobs", jobs).Info("Waiting for the mongo backups")
+
+	backupCtx, cancel := context.WithTimeout(ctx, backupTimeout)
+	defer cancel()
+	err = backup.WaitForJobs(backupCtx, client, namespace, jobs, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to back up mongo: %w", err)
+	}
+	logContext.Info("Mongo backed up")
+
+	err = k8s.DeleteNamespace(ctx, client, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to delete the namespace: %w", err)
+	}
+	logContext.Info("Namespace deleted")
+	return nil
+}
@@ -0,0 +1,139 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform/application"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/sirupsen/logrus"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Deleting an application", func() {
+	Describe("checking it can be deleted", func() {
+		var app storage.JSONApplication
+
+		BeforeEach(func() {
+			app = storage.JSONApplication{
+				Environments: []storage.JSONEnvironment{
+					{Name: "Dev"},
+					{Name: "Prod"},
+				},
+			}
+		})
+
+		It("should not delete an application with a Prod environment", func() {
+			err := application.CanDelete(app, false)
+			Expect(errors.Is(err, application.ErrHasProductionEnvironment)).To(BeTrue())
+		})
+
+		It("should delete it when forced", func() {
+			Expect(application.CanDelete(app, true)).To(Succeed())
+		})
+
+		It("should delete an application without a Prod environment", func() {
+			app.Environments = app.Environments[:1]
+			Expect(application.CanDelete(app, false)).To(Succeed())
+		})
+	})
+
+	Describe("from the cluster", func() {
+		var (
+			applicationID string
+			namespace     string
+			clientSet     *fake.Clientset
+			logger        logrus.FieldLogger
+			backupStatus  batchv1.JobConditionType
+			err           error
+		)
+
+		BeforeEach(func() {
+			applicationID = "11b6cf47-5d9f-438f-8116-0d9828654657"
+			namespace = "application-" + applicationID
+			backupStatus = batchv1.JobComplete
+			logger, _ = logrusTest.NewNullLogger()
+
+			clientSet = fake.NewSimpleClientset(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+				&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "developer", Namespace: namespace}},
+				&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "developer", Namespace: namespace}},
+				&v1beta1.CronJob{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "dev-mongo-backup",
+						Namespace: namespace,
+						Labels:    map[string]string{"infrastructure": "Mongo", "environment": "Dev"},
+					},
+				},
+			)
+
+			// The fake clientset does not run jobs, so they finish as soon as they are looked at
+			clientSet.PrependReactor("get", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				name := action.(k8stesting.GetAction).GetName()
+				return true, &batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Status: batchv1.JobStatus{
+						Conditions: []batchv1.JobCondition{
+							{Type: backupStatus, Status: corev1.ConditionTrue},
+						},
+					},
+				}, nil
+			})
+		})
+
+		JustBeforeEach(func() {
+			err = application.DeleteFromCluster(clientSet, applicationID, time.Second, logger)
+		})
+
+		It("should back up mongo and then remove the access and the namespace", func() {
+			Expect(err).To(BeNil())
+
+			jobs, _ := clientSet.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{})
+			Expect(jobs.Items).To(HaveLen(1))
+			Expect(jobs.Items[0].Name).To(HavePrefix("dev-mongo-backup-manual-"))
+
+			_, getErr := clientSet.RbacV1().RoleBindings(namespace).Get(context.TODO(), "developer", metav1.GetOptions{})
+			Expect(k8serrors.IsNotFound(getErr)).To(BeTrue())
+			_, getErr = clientSet.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+			Expect(k8serrors.IsNotFound(getErr)).To(BeTrue())
+		})
+
+		When("the backup fails", func() {
+			BeforeEach(func() {
+				backupStatus = batchv1.JobFailed
+			})
+
+			It("should not delete anything", func() {
+				Expect(errors.Is(err, backup.ErrBackupFailed)).To(BeTrue())
+				_, getErr := clientSet.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+				Expect(getErr).To(BeNil())
+				_, getErr = clientSet.RbacV1().RoleBindings(namespace).Get(context.TODO(), "developer", metav1.GetOptions{})
+				Expect(getErr).To(BeNil())
+			})
+		})
+
+		When("the namespace is already gone", func() {
+			BeforeEach(func() {
+				clientSet.CoreV1().Namespaces().Delete(context.TODO(), namespace, metav1.DeleteOptions{})
+			})
+
+			It("should be done", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+	})
+})
@@ -2,6 +2,7 @@ package application
 
 import (
 	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
 )
 
 type HttpResponseAccessUsers struct {
@@ -42,6 +43,32 @@ type HttpResponseApplication struct {
 	Microservices []platform.HttpMicroserviceBase `json:"microservices,omitempty"`
 }
 
+// HttpInputMongoSettings changes what is set, a backupRetentionDays of 0 keeps the backups forever
+type HttpInputMongoSettings struct {
+	Version             string `json:"version"`
+	VolumeSize          string `json:"volumeSize"`
+	CPURequest          string `json:"cpuRequest"`
+	MemoryRequest       string `json:"memoryRequest"`
+	CPULimit            string `json:"cpuLimit"`
+	MemoryLimit         string `json:"memoryLimit"`
+	BackupSchedule      string `json:"backupSchedule"`
+	BackupRetentionDays *int   `json:"backupRetentionDays"`
+}
+
+type HttpResponseMongoSettings struct {
+	ApplicationID string `json:"applicationId"`
+	Environment   string `json:"environment"`
+	// Settings are what is stored
+	Settings storage.JSONEnvironmentMongo `json:"settings"`
+	// Live is what is running in the cluster
+	Live storage.JSONEnvironmentMongo `json:"live"`
+}
+
+type HttpResponseBuildStatus struct {
+	ApplicationID string                  `json:"applicationId"`
+	Status        storage.JSONBuildStatus `json:"status"`
+}
+
 type HttpResponseEnvironment struct {
 	AutomationEnabled bool                                `json:"automationEnabled"`
 	Name              string                              `json:"name"`
@@ -0,0 +1,162 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/simple"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrProductionEnvironment = errors.New("environment is a production environment")
+	ErrLastEnvironment       = errors.New("an application needs at least one environment")
+)
+
+// IsEnvironmentNameValid allows the same names as an application, in any case
+func IsEnvironmentNameValid(name string) bool {
+	isValid := validation.NameIsDNSLabel(strings.ToLower(name), false)
+	return len(isValid) == 0
+}
+
+// NewEnvironmentInfo sets up the customer tenants and the welcome microservice of a new environment,
+// without customer tenants a development one is made
+func NewEnvironmentInfo(environment HttpInputApplicationEnvironment) storage.JSONEnvironment {
+	welcomeMicroserviceID := uuid.New().String()
+
+	customerTenants := make([]platform.CustomerTenantInfo, 0)
+
+	if len(environment.CustomerTenant) > 0 {
+		for _, customerTenant := range environment.CustomerTenant {
+			var customerTenantInfo platform.CustomerTenantInfo
+
+			if customerTenant.ID != "" {
+				customerTenantInfo = dolittleK8s.NewCustomerTenantInfo(environment.Name, welcomeMicroserviceID, customerTenant.ID)
+			} else {
+				customerTenantInfo = dolittleK8s.NewDevelopmentCustomerTenantInfo(environment.Name, welcomeMicroserviceID)
+			}
+
+			customerTenants = append(customerTenants, customerTenantInfo)
+		}
+	} else {
+		// Create one
+		customerTenantInfo := dolittleK8s.NewDevelopmentCustomerTenantInfo(environment.Name, welcomeMicroserviceID)
+		customerTenants = append(customerTenants, customerTenantInfo)
+	}
+
+	return storage.JSONEnvironment{
+		Name:                  environment.Name,
+		CustomerTenants:       customerTenants,
+		WelcomeMicroserviceID: welcomeMicroserviceID,
+		Mongo:                 NewDefaultMongoSettings(),
+	}
+}
+
+// FindEnvironment looks up the environment by name in any case, as that is how it is stored
+func FindEnvironment(application storage.JSONApplication, environment string) (storage.JSONEnvironment, bool) {
+	for _, item := range application.Environments {
+		if strings.EqualFold(item.Name, environment) {
+			return item, true
+		}
+	}
+	return storage.JSONEnvironment{}, false
+}
+
+// CreateEnvironment adds the environment to the namespace of an existing application, with mongo, its customer tenants
+// and the welcome microservice. What was made is removed again if it fails
+func CreateEnvironment(
+	client kubernetes.Interface,
+	storageRepo storage.RepoMicroservice,
+	simpleRepo simple.Repo,
+	k8sDolittleRepo platformK8s.K8sRepo,
+	application storage.JSONApplication,
+	environment storage.JSONEnvironment,
+	welcomeImage string,
+	logContext logrus.FieldLogger,
+) error {
+	tenantInfo := dolittleK8s.Tenant{
+		Name: application.CustomerName,
+		ID:   application.CustomerID,
+	}
+
+	applicationInfo := dolittleK8s.Application{
+		Name: application.Name,
+		ID:   application.ID,
+	}
+
+	namespace := platformK8s.GetApplicationNamespace(application.ID)
+	environmentResource := k8s.NewEnvironment(environment.Name, tenantInfo, applicationInfo, NewMongoSettings(application, environment), environment.CustomerTenants)
+
+	err := k8s.DoEnvironment(client, namespace, application.ID, environmentResource, k8sDolittleRepo)
+	if err == nil {
+		err = createWelcomeMicroservice(storageRepo, simpleRepo, namespace, tenantInfo, applicationInfo, environment, newWelcomeMicroservice(application, welcomeImage))
+	}
+	if err != nil {
+		if rollbackErr := k8s.DeleteEnvironment(client, k8sDolittleRepo, application.ID, environment.Name); rollbackErr != nil {
+			logContext.WithFields(logrus.Fields{
+				"error":       rollbackErr,
+				"environment": environment.Name,
+			}).Error("Failed to remove the environment after failing to create it")
+		}
+		return err
+	}
+	return nil
+}
+
+// CanDeleteEnvironment stops the last environment from being deleted, and Prod unless forced
+func CanDeleteEnvironment(application storage.JSONApplication, environment string, force bool) error {
+	if _, ok := FindEnvironment(application, environment); !ok {
+		return storage.ErrNotFound
+	}
+	if len(application.Environments) == 1 {
+		return ErrLastEnvironment
+	}
+	if strings.EqualFold(environment, "prod") && !force {
+		return fmt.Errorf("%w: use force to delete it anyway", ErrProductionEnvironment)
+	}
+	return nil
+}
+
+// DeleteEnvironmentFromCluster backs up mongo in the environment, then removes everything in it.
+// Nothing is deleted if the backup does not finish within the backupTimeout
+func DeleteEnvironmentFromCluster(client kubernetes.Interface, k8sDolittleRepo platformK8s.K8sRepo, applicationID string, environment string, backupTimeout time.Duration, logContext logrus.FieldLogger) error {
+	ctx := context.TODO()
+	namespace := platformK8s.GetApplicationNamespace(applicationID)
+	logContext = logContext.WithFields(logrus.Fields{
+		"application_id": applicationID,
+		"environment":    environment,
+	})
+
+	jobs, err := backup.RunMongoBackups(ctx, client, namespace, fmt.Sprintf("environment=%s", platformK8s.ParseLabel(environment)))
+	if err != nil {
+		return fmt.Errorf("failed to start the mongo backup: %w", err)
+	}
+	logContext.WithField("jobs", jobs).Info("Waiting for the mongo backup")
+
+	backupCtx, cancel := context.WithTimeout(ctx, backupTimeout)
+	defer cancel()
+	err = backup.WaitForJobs(backupCtx, client, namespace, jobs, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to back up mongo: %w", err)
+	}
+	logContext.Info("Mongo backed up")
+
+	err = k8s.DeleteEnvironment(client, k8sDolittleRepo, applicationID, environment)
+	if err != nil {
+		return fmt.Errorf("failed to delete the environment: %w", err)
+	}
+	logContext.Info("Environment deleted")
+	return nil
+}
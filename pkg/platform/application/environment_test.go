@@ -0,0 +1,153 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mockSimple "github.com/dolittle/platform-api/mocks/pkg/platform/microservice/simple"
+	mockStorage "github.com/dolittle/platform-api/mocks/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/platform/application"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/sirupsen/logrus"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("Environments of an application", func() {
+	Describe("checking the name", func() {
+		It("should allow names in any case", func() {
+			Expect(application.IsEnvironmentNameValid("Test")).To(BeTrue())
+		})
+
+		It("should not allow spaces", func() {
+			Expect(application.IsEnvironmentNameValid("My Test")).To(BeFalse())
+		})
+	})
+
+	Describe("checking it can be deleted", func() {
+		var app storage.JSONApplication
+
+		BeforeEach(func() {
+			app = storage.JSONApplication{
+				Environments: []storage.JSONEnvironment{
+					{Name: "Dev"},
+					{Name: "Prod"},
+				},
+			}
+		})
+
+		It("should find the environment in any case", func() {
+			Expect(application.CanDeleteEnvironment(app, "dev", false)).To(Succeed())
+		})
+
+		It("should not delete an environment that is not there", func() {
+			err := application.CanDeleteEnvironment(app, "Test", false)
+			Expect(errors.Is(err, storage.ErrNotFound)).To(BeTrue())
+		})
+
+		It("should not delete Prod", func() {
+			err := application.CanDeleteEnvironment(app, "Prod", false)
+			Expect(errors.Is(err, application.ErrProductionEnvironment)).To(BeTrue())
+		})
+
+		It("should delete Prod when forced", func() {
+			Expect(application.CanDeleteEnvironment(app, "Prod", true)).To(Succeed())
+		})
+
+		It("should not delete the last environment", func() {
+			app.Environments = app.Environments[:1]
+			err := application.CanDeleteEnvironment(app, "Dev", true)
+			Expect(errors.Is(err, application.ErrLastEnvironment)).To(BeTrue())
+		})
+	})
+
+	Describe("adding one", func() {
+		var (
+			app             storage.JSONApplication
+			environment     storage.JSONEnvironment
+			namespace       string
+			clientSet       *fake.Clientset
+			k8sDolittleRepo platformK8s.K8sRepo
+			storageRepo     *mockStorage.RepoMicroservice
+			simpleRepo      *mockSimple.Repo
+			logger          logrus.FieldLogger
+			err             error
+		)
+
+		BeforeEach(func() {
+			app = storage.JSONApplication{
+				ID:           "11b6cf47-5d9f-438f-8116-0d9828654657",
+				Name:         "Taco",
+				CustomerID:   "c0e4b6b4-51a6-4e41-8f05-6eab4b1d2b1c",
+				CustomerName: "Customer",
+				Environments: []storage.JSONEnvironment{
+					{Name: "Dev"},
+				},
+			}
+			environment = application.NewEnvironmentInfo(application.HttpInputApplicationEnvironment{Name: "Test"})
+			namespace = "application-" + app.ID
+			logger, _ = logrusTest.NewNullLogger()
+
+			clientSet = fake.NewSimpleClientset(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+				&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "developer", Namespace: namespace}},
+			)
+			k8sDolittleRepo = platformK8s.NewK8sRepo(clientSet, &rest.Config{}, logger)
+			storageRepo = new(mockStorage.RepoMicroservice)
+			simpleRepo = new(mockSimple.Repo)
+
+			storageRepo.On("SaveMicroservice", app.CustomerID, app.ID, "Test", environment.WelcomeMicroserviceID, mock.Anything).Return(nil)
+		})
+
+		JustBeforeEach(func() {
+			err = application.CreateEnvironment(clientSet, storageRepo, simpleRepo, k8sDolittleRepo, app, environment, "nginxdemos/hello:latest", logger)
+		})
+
+		When("it works", func() {
+			BeforeEach(func() {
+				simpleRepo.On("Create", namespace, mock.Anything, mock.Anything, environment.CustomerTenants, mock.Anything).Return(nil)
+			})
+
+			It("should create the tenants, mongo and the welcome microservice", func() {
+				Expect(err).To(BeNil())
+
+				_, getErr := clientSet.CoreV1().ConfigMaps(namespace).Get(context.TODO(), "test-tenants", metav1.GetOptions{})
+				Expect(getErr).To(BeNil())
+				_, getErr = clientSet.AppsV1().StatefulSets(namespace).Get(context.TODO(), "test-mongo", metav1.GetOptions{})
+				Expect(getErr).To(BeNil())
+
+				role, _ := clientSet.RbacV1().Roles(namespace).Get(context.TODO(), "developer", metav1.GetOptions{})
+				Expect(role.Rules).NotTo(BeEmpty())
+				storageRepo.AssertExpectations(GinkgoT())
+				simpleRepo.AssertExpectations(GinkgoT())
+			})
+		})
+
+		When("the welcome microservice fails", func() {
+			BeforeEach(func() {
+				simpleRepo.On("Create", namespace, mock.Anything, mock.Anything, environment.CustomerTenants, mock.Anything).Return(errors.New("fail"))
+			})
+
+			It("should remove what was created", func() {
+				Expect(err).NotTo(BeNil())
+
+				configMaps, _ := clientSet.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+				Expect(configMaps.Items).To(BeEmpty())
+				statefulSets, _ := clientSet.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+				Expect(statefulSets.Items).To(BeEmpty())
+
+				role, _ := clientSet.RbacV1().Roles(namespace).Get(context.TODO(), "developer", metav1.GetOptions{})
+				Expect(role.Rules).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -1,9 +1,6 @@
 package application
 
 import (
-	"fmt"
-
-	"github.com/dolittle/platform-api/pkg/azure"
 	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
 	"github.com/dolittle/platform-api/pkg/platform"
 	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
@@ -42,26 +39,7 @@ func CreateApplicationAndEnvironmentAndWelcomeMicroservice(
 		ID:   application.ID,
 	}
 
-	welcomeMicroservice := platform.HttpInputSimpleInfo{
-		MicroserviceBase: platform.MicroserviceBase{
-			Dolittle: platform.HttpInputDolittle{
-				ApplicationID:  application.ID,
-				CustomerID:     application.CustomerID,
-				MicroserviceID: "",
-			},
-			Name: "Welcome",
-			Kind: platform.MicroserviceKindSimple,
-		},
-		Extra: platform.HttpInputSimpleExtra{
-			Headimage:    welcomeImage,
-			Runtimeimage: "none",
-			Ingress: platform.HttpInputSimpleIngress{
-				Path:     "/welcome-to-dolittle",
-				Pathtype: string(networkingv1.PathTypePrefix),
-			},
-			Ispublic: true,
-		},
-	}
+	welcomeMicroservice := newWelcomeMicroservice(application, welcomeImage)
 
 	r := k8s.Resources{}
 	r.ServiceAccounts = k8s.NewServiceAccountsInfo(tenantInfo, applicationInfo)
@@ -75,14 +53,7 @@ func CreateApplicationAndEnvironmentAndWelcomeMicroservice(
 	// Create rbac
 	// Create environments
 	for _, environment := range application.Environments {
-		shareName := azure.CreateBackupFileShareName(application.Name, environment.Name)
-
-		mongoSettings := k8s.MongoSettings{
-			ShareName:       shareName,
-			CronJobSchedule: fmt.Sprintf("%d * * * *", GetRandomMinutes()),
-			VolumeSize:      "8Gi",
-		}
-
+		mongoSettings := NewMongoSettings(application, environment)
 		environmentResource := k8s.NewEnvironment(environment.Name, tenantInfo, applicationInfo, mongoSettings, environment.CustomerTenants)
 		r.Environments = append(r.Environments, environmentResource)
 	}
@@ -102,35 +73,70 @@ func CreateApplicationAndEnvironmentAndWelcomeMicroservice(
 	// Create welcome microservice
 	namespace := r.Namespace.Name
 	for _, environment := range application.Environments {
-		customerTenants := environment.CustomerTenants
-
-		skipMicroserviceCreation := len(customerTenants) == 0
-		if skipMicroserviceCreation {
-			continue
+		err := createWelcomeMicroservice(storageRepo, simpleRepo, namespace, tenantInfo, applicationInfo, environment, welcomeMicroservice)
+		if err != nil {
+			return err
 		}
+	}
 
-		microservice := welcomeMicroservice
-		microservice.Dolittle.MicroserviceID = customerTenants[0].MicroservicesRel[0].MicroserviceID
-		microservice.Environment = environment.Name
+	return nil
+}
 
-		// TODO Would be nice to hoist this to the creation of the application, so this is semi immutable
-		err = storageRepo.SaveMicroservice(
-			microservice.Dolittle.CustomerID,
-			microservice.Dolittle.ApplicationID,
-			microservice.Environment,
-			microservice.Dolittle.MicroserviceID,
-			microservice,
-		)
+func newWelcomeMicroservice(application storage.JSONApplication, welcomeImage string) platform.HttpInputSimpleInfo {
+	return platform.HttpInputSimpleInfo{
+		MicroserviceBase: platform.MicroserviceBase{
+			Dolittle: platform.HttpInputDolittle{
+				ApplicationID:  application.ID,
+				CustomerID:     application.CustomerID,
+				MicroserviceID: "",
+			},
+			Name: "Welcome",
+			Kind: platform.MicroserviceKindSimple,
+		},
+		Extra: platform.HttpInputSimpleExtra{
+			Headimage:    welcomeImage,
+			Runtimeimage: "none",
+			Ingress: platform.HttpInputSimpleIngress{
+				Path:     "/welcome-to-dolittle",
+				Pathtype: string(networkingv1.PathTypePrefix),
+			},
+			Ispublic: true,
+		},
+	}
+}
 
-		if err != nil {
-			return err
-		}
+// createWelcomeMicroservice creates the welcome microservice of the environment for its first customer tenant
+func createWelcomeMicroservice(
+	storageRepo storage.RepoMicroservice,
+	simpleRepo simple.Repo,
+	namespace string,
+	tenantInfo dolittleK8s.Tenant,
+	applicationInfo dolittleK8s.Application,
+	environment storage.JSONEnvironment,
+	welcomeMicroservice platform.HttpInputSimpleInfo,
+) error {
+	customerTenants := environment.CustomerTenants
 
-		err := simpleRepo.Create(namespace, tenantInfo, applicationInfo, customerTenants, microservice)
-		if err != nil {
-			return err
-		}
+	skipMicroserviceCreation := len(customerTenants) == 0
+	if skipMicroserviceCreation {
+		return nil
 	}
 
-	return nil
+	microservice := welcomeMicroservice
+	microservice.Dolittle.MicroserviceID = customerTenants[0].MicroservicesRel[0].MicroserviceID
+	microservice.Environment = environment.Name
+
+	// TODO Would be nice to hoist this to the creation of the application, so this is semi immutable
+	err := storageRepo.SaveMicroservice(
+		microservice.Dolittle.CustomerID,
+		microservice.Dolittle.ApplicationID,
+		microservice.Environment,
+		microservice.Dolittle.MicroserviceID,
+		microservice,
+	)
+	if err != nil {
+		return err
+	}
+
+	return simpleRepo.Create(namespace, tenantInfo, applicationInfo, customerTenants, microservice)
 }
@@ -69,75 +69,109 @@ func Do(client kubernetes.Interface, resources Resources, k8sRepo platformK8s.K8
 
 	// Environments
 	for _, environmentResource := range resources.Environments {
-		// Add customer tenants tenants.json
-		_, err = client.CoreV1().ConfigMaps(namespace).Create(context.TODO(), environmentResource.Tenants, metav1.CreateOptions{})
+		err = DoEnvironment(client, namespace, applicationID, environmentResource, k8sRepo)
 		if err != nil {
-			fmt.Println("err", err, environmentResource.Tenants.ObjectMeta.Name, namespace)
+			fmt.Println("err", err, namespace)
 			deleteNamespace(client, namespace)
 			return err
 		}
+	}
 
-		// NetworkPolicy
-		_, err = client.NetworkingV1().NetworkPolicies(namespace).Create(ctx, environmentResource.NetworkPolicy, metav1.CreateOptions{})
+	// Create local-dev bindings for developers testing locally
+	if resources.LocalDevRoleBindingToDeveloper != nil {
+		_, err = client.RbacV1().RoleBindings(namespace).Create(context.TODO(), resources.LocalDevRoleBindingToDeveloper, metav1.CreateOptions{})
 		if err != nil {
-			fmt.Println("err", err, environmentResource.NetworkPolicy.ObjectMeta.Name, namespace)
+			fmt.Println("err", err, resources.LocalDevRoleBindingToDeveloper.ObjectMeta.Name, namespace)
 			deleteNamespace(client, namespace)
 			return err
 		}
+	}
 
-		// Mongo
-		// Service
-		_, err = client.CoreV1().Services(namespace).Create(context.TODO(), environmentResource.Mongo.Service, metav1.CreateOptions{})
-		if err != nil {
-			fmt.Println("err", err, environmentResource.Mongo.Service.ObjectMeta.Name, namespace)
-			deleteNamespace(client, namespace)
-			return err
-		}
+	return nil
+}
 
-		// StatefulSet
-		_, err = client.AppsV1().StatefulSets(namespace).Create(context.TODO(), environmentResource.Mongo.StatefulSet, metav1.CreateOptions{})
-		if err != nil {
-			fmt.Println("err", err, environmentResource.Mongo.Service.ObjectMeta.Name, namespace)
-			deleteNamespace(client, namespace)
-			return err
-		}
+// DoEnvironment creates the resources of one environment in the namespace of the application.
+// Nothing is rolled back on failure, that is up to the caller
+func DoEnvironment(client kubernetes.Interface, namespace string, applicationID string, environmentResource EnvironmentResources, k8sRepo platformK8s.K8sRepo) error {
+	ctx := context.TODO()
 
-		// Cronjob
-		_, err = client.BatchV1beta1().CronJobs(namespace).Create(context.TODO(), environmentResource.Mongo.Cronjob, metav1.CreateOptions{})
-		if err != nil {
-			fmt.Println("err", err, environmentResource.Mongo.Cronjob.ObjectMeta.Name, namespace)
-			deleteNamespace(client, namespace)
-			return err
-		}
+	// Add customer tenants tenants.json
+	_, err := client.CoreV1().ConfigMaps(namespace).Create(ctx, environmentResource.Tenants, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("configmap %s: %w", environmentResource.Tenants.ObjectMeta.Name, err)
+	}
 
-		// Add specifc policy rules for this environment to the developer
-		for _, policyRule := range environmentResource.RbacRolePolicyRules {
-			err := k8sRepo.AddPolicyRule("developer", applicationID, policyRule)
-			if err != nil {
-				deleteNamespace(client, namespace)
-				return err
-			}
-		}
+	// NetworkPolicy
+	_, err = client.NetworkingV1().NetworkPolicies(namespace).Create(ctx, environmentResource.NetworkPolicy, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("network policy %s: %w", environmentResource.NetworkPolicy.ObjectMeta.Name, err)
 	}
 
-	// Create local-dev bindings for developers testing locally
-	if resources.LocalDevRoleBindingToDeveloper != nil {
-		_, err = client.RbacV1().RoleBindings(namespace).Create(context.TODO(), resources.LocalDevRoleBindingToDeveloper, metav1.CreateOptions{})
+	// Mongo
+	// Service
+	_, err = client.CoreV1().Services(namespace).Create(ctx, environmentResource.Mongo.Service, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("service %s: %w", environmentResource.Mongo.Service.ObjectMeta.Name, err)
+	}
+
+	// StatefulSet
+	_, err = client.AppsV1().StatefulSets(namespace).Create(ctx, environmentResource.Mongo.StatefulSet, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("statefulset %s: %w", environmentResource.Mongo.StatefulSet.ObjectMeta.Name, err)
+	}
+
+	// Cronjob
+	_, err = client.BatchV1beta1().CronJobs(namespace).Create(ctx, environmentResource.Mongo.Cronjob, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("cronjob %s: %w", environmentResource.Mongo.Cronjob.ObjectMeta.Name, err)
+	}
+
+	// Add specifc policy rules for this environment to the developer
+	for _, policyRule := range environmentResource.RbacRolePolicyRules {
+		err := k8sRepo.AddPolicyRule("developer", applicationID, policyRule)
 		if err != nil {
-			fmt.Println("err", err, resources.LocalDevRoleBindingToDeveloper.ObjectMeta.Name, namespace)
-			deleteNamespace(client, namespace)
-			return err
+			return fmt.Errorf("policy rule: %w", err)
 		}
 	}
-
 	return nil
 }
 
 func deleteNamespace(client kubernetes.Interface, namespace string) {
-	ctx := context.TODO()
-	err := client.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
+	err := DeleteNamespace(context.TODO(), client, namespace)
 	if err != nil {
 		log.Fatal(err)
 	}
 	// TODO maybe be less aggressive :P and call it undo add undo... slowly to an operator
 }
+
+// DeleteNamespace removes the access to the namespace before deleting it, as the namespace can take a while to go.
+// A namespace that is already gone is not an error
+func DeleteNamespace(ctx context.Context, client kubernetes.Interface, namespace string) error {
+	roleBindings, err := client.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, roleBinding := range roleBindings.Items {
+		err := client.RbacV1().RoleBindings(namespace).Delete(ctx, roleBinding.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	roles, err := client.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, role := range roles.Items {
+		err := client.RbacV1().Roles(namespace).Delete(ctx, role.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	err = client.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DeleteEnvironment removes everything labelled with the environment from the namespace of the application,
+// microservices and the mongo data included, and the access to it from the developer role
+func DeleteEnvironment(client kubernetes.Interface, k8sRepo platformK8s.K8sRepo, applicationID string, environment string) error {
+	ctx := context.TODO()
+	namespace := platformK8s.GetApplicationNamespace(applicationID)
+	opts := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("environment=%s", platformK8s.ParseLabel(environment)),
+	}
+	propagation := metav1.DeletePropagationBackground
+	deleteOpts := metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	}
+
+	ignoreNotFound := func(err error) error {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// The workloads go first, so nothing is left running against what is removed after
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range deployments.Items {
+		if err := ignoreNotFound(client.AppsV1().Deployments(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("deployment %s: %w", item.Name, err)
+		}
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	// The claims of a statefulset are only labelled with its selector, so they are found by name
+	statefulSetClaims := make([]string, 0)
+	for _, item := range statefulSets.Items {
+		if err := ignoreNotFound(client.AppsV1().StatefulSets(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("statefulset %s: %w", item.Name, err)
+		}
+
+		replicas := int32(1)
+		if item.Spec.Replicas != nil {
+			replicas = *item.Spec.Replicas
+		}
+		for _, template := range item.Spec.VolumeClaimTemplates {
+			for ordinal := int32(0); ordinal < replicas; ordinal++ {
+				statefulSetClaims = append(statefulSetClaims, fmt.Sprintf("%s-%s-%d", template.Name, item.Name, ordinal))
+			}
+		}
+	}
+
+	cronJobs, err := client.BatchV1beta1().CronJobs(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range cronJobs.Items {
+		if err := ignoreNotFound(client.BatchV1beta1().CronJobs(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("cronjob %s: %w", item.Name, err)
+		}
+	}
+
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range jobs.Items {
+		if err := ignoreNotFound(client.BatchV1().Jobs(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("job %s: %w", item.Name, err)
+		}
+	}
+
+	ingresses, err := client.NetworkingV1().Ingresses(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range ingresses.Items {
+		if err := ignoreNotFound(client.NetworkingV1().Ingresses(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("ingress %s: %w", item.Name, err)
+		}
+	}
+
+	services, err := client.CoreV1().Services(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range services.Items {
+		if err := ignoreNotFound(client.CoreV1().Services(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("service %s: %w", item.Name, err)
+		}
+	}
+
+	networkPolicies, err := client.NetworkingV1().NetworkPolicies(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range networkPolicies.Items {
+		if err := ignoreNotFound(client.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("network policy %s: %w", item.Name, err)
+		}
+	}
+
+	configMaps, err := client.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range configMaps.Items {
+		if err := ignoreNotFound(client.CoreV1().ConfigMaps(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("configmap %s: %w", item.Name, err)
+		}
+	}
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range secrets.Items {
+		if err := ignoreNotFound(client.CoreV1().Secrets(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("secret %s: %w", item.Name, err)
+		}
+	}
+
+	volumeClaims, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range volumeClaims.Items {
+		if err := ignoreNotFound(client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, item.Name, deleteOpts)); err != nil {
+			return fmt.Errorf("persistent volume claim %s: %w", item.Name, err)
+		}
+	}
+
+	for _, name := range statefulSetClaims {
+		if err := ignoreNotFound(client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, deleteOpts)); err != nil {
+			return fmt.Errorf("persistent volume claim %s: %w", name, err)
+		}
+	}
+
+	err = k8sRepo.RemovePolicyRule("developer", applicationID, NewMongoPortForwardPolicyRole(environment))
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("policy rule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+var (
+	ErrMongoDowngrade         = errors.New("mongo can not be downgraded")
+	ErrMongoUpgradeSkipsMajor = errors.New("mongo can only be upgraded one major version at a time")
+	ErrMongoVolumeShrink      = errors.New("the mongo volume can not be made smaller")
+	ErrMongoRequestOverLimit  = errors.New("a mongo resource request can not be more than its limit")
+
+	// mongoMajorVersions are the release series in the order mongo has to be upgraded through,
+	// setting the featureCompatibilityVersion after each one
+	mongoMajorVersions = []string{"3.6", "4.0", "4.2", "4.4", "5.0", "6.0", "7.0", "8.0"}
+)
+
+// GetMongoSettings reads how mongo in the environment is set up in the cluster
+func GetMongoSettings(client kubernetes.Interface, applicationID string, environment string) (MongoSettings, error) {
+	ctx := context.TODO()
+	namespace := platformK8s.GetApplicationNamespace(applicationID)
+	name := fmt.Sprintf("%s-mongo", strings.ToLower(environment))
+	settings := MongoSettings{}
+
+	statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return settings, err
+	}
+
+	if container := getContainer(statefulSet.Spec.Template.Spec.Containers, "mongo"); container != nil {
+		settings.Version = getImageTag(container.Image)
+		settings.CPURequest = quantityString(container.Resources.Requests, corev1.ResourceCPU)
+		settings.MemoryRequest = quantityString(container.Resources.Requests, corev1.ResourceMemory)
+		settings.CPULimit = quantityString(container.Resources.Limits, corev1.ResourceCPU)
+		settings.MemoryLimit = quantityString(container.Resources.Limits, corev1.ResourceMemory)
+	}
+
+	claims, err := getMongoVolumeClaims(ctx, client, statefulSet)
+	if err != nil {
+		return settings, err
+	}
+	if len(claims) > 0 {
+		settings.VolumeSize = quantityString(claims[0].Spec.Resources.Requests, corev1.ResourceStorage)
+	}
+
+	cronJob, err := client.BatchV1beta1().CronJobs(namespace).Get(ctx, fmt.Sprintf("%s-backup", name), metav1.GetOptions{})
+	if err != nil {
+		return settings, err
+	}
+	settings.CronJobSchedule = cronJob.Spec.Schedule
+	if container := getContainer(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers, "mongo-backup"); container != nil && len(container.Args) > 0 {
+		settings.BackupRetentionDays = getRetentionDays(container.Args[0])
+	}
+	return settings, nil
+}
+
+// ApplyMongoSettings updates the volumes, the statefulset and the backup cronjob of mongo in the environment.
+// What is empty in the settings is left as it is, except the retention that is always set.
+// Everything is checked before anything is changed, mongo is never downgraded nor upgraded more than one major version
+// and its volume never made smaller.
+// The volume claim templates of a statefulset can not be changed, so the volumes are resized directly.
+// They are resized first, as a bigger volume is harmless if the rest fails,
+// and the statefulset is put back when the cronjob can not be updated
+func ApplyMongoSettings(client kubernetes.Interface, application dolittleK8s.Application, environment string, settings MongoSettings) error {
+	ctx := context.TODO()
+	namespace := platformK8s.GetApplicationNamespace(application.ID)
+	name := fmt.Sprintf("%s-mongo", strings.ToLower(environment))
+	backupName := fmt.Sprintf("%s-backup", name)
+
+	statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	container := getContainer(statefulSet.Spec.Template.Spec.Containers, "mongo")
+	if container == nil {
+		return fmt.Errorf("statefulset %s has no mongo container", name)
+	}
+
+	if settings.Version != "" {
+		err = CheckMongoUpgrade(getImageTag(container.Image), settings.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	var volumeSize resource.Quantity
+	claims := make([]corev1.PersistentVolumeClaim, 0)
+	if settings.VolumeSize != "" {
+		volumeSize, err = resource.ParseQuantity(settings.VolumeSize)
+		if err != nil {
+			return fmt.Errorf("volume size %s: %w", settings.VolumeSize, err)
+		}
+
+		claims, err = getMongoVolumeClaims(ctx, client, statefulSet)
+		if err != nil {
+			return err
+		}
+		for _, claim := range claims {
+			current := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			if volumeSize.Cmp(current) < 0 {
+				return fmt.Errorf("%w: %s from %s to %s", ErrMongoVolumeShrink, claim.Name, current.String(), volumeSize.String())
+			}
+		}
+	}
+
+	resources, err := mergeMongoResources(container.Resources, settings)
+	if err != nil {
+		return err
+	}
+	err = checkMongoResources(resources)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claims {
+		current := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		if volumeSize.Cmp(current) == 0 {
+			continue
+		}
+
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			claim, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, claim.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = volumeSize
+			_, err = client.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, claim, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("persistent volume claim %s: %w", claim.Name, err)
+		}
+	}
+
+	previousImage := container.Image
+	previousResources := container.Resources
+	updateStatefulSet := func(image string, resources corev1.ResourceRequirements) error {
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			container := getContainer(statefulSet.Spec.Template.Spec.Containers, "mongo")
+			container.Image = image
+			container.Resources = resources
+			_, err = client.AppsV1().StatefulSets(namespace).Update(ctx, statefulSet, metav1.UpdateOptions{})
+			return err
+		})
+	}
+
+	image := previousImage
+	if settings.Version != "" {
+		image = MongoImage(settings.Version)
+	}
+	err = updateStatefulSet(image, resources)
+	if err != nil {
+		return fmt.Errorf("statefulset %s: %w", name, err)
+	}
+
+	archivePrefix := strings.ToLower(fmt.Sprintf(
+		"%s-%s",
+		platformK8s.ParseLabel(application.Name),
+		platformK8s.ParseLabel(environment),
+	))
+	mongoHost := fmt.Sprintf("%s.%s.svc.cluster.local:27017", name, namespace)
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cronJob, err := client.BatchV1beta1().CronJobs(namespace).Get(ctx, backupName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if settings.CronJobSchedule != "" {
+			cronJob.Spec.Schedule = settings.CronJobSchedule
+		}
+		if container := getContainer(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers, "mongo-backup"); container != nil {
+			if settings.Version != "" {
+				container.Image = MongoImage(settings.Version)
+			}
+			container.Args = []string{NewMongoBackupCommand(mongoHost, archivePrefix, settings.BackupRetentionDays)}
+		}
+		_, err = client.BatchV1beta1().CronJobs(namespace).Update(ctx, cronJob, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		if rollbackErr := updateStatefulSet(previousImage, previousResources); rollbackErr != nil {
+			return fmt.Errorf("cronjob %s: %w, and putting back statefulset %s failed: %s", backupName, err, name, rollbackErr.Error())
+		}
+		return fmt.Errorf("cronjob %s: %w", backupName, err)
+	}
+	return nil
+}
+
+// CheckMongoUpgrade returns ErrMongoDowngrade when to is older than from,
+// and ErrMongoUpgradeSkipsMajor when it is more than one major version newer, or a major version it does not know
+func CheckMongoUpgrade(from string, to string) error {
+	if CompareMongoVersions(to, from) < 0 {
+		return fmt.Errorf("%w: from %s to %s", ErrMongoDowngrade, from, to)
+	}
+
+	fromMajor := getMongoMajorVersion(from)
+	toMajor := getMongoMajorVersion(to)
+	if fromMajor == toMajor {
+		return nil
+	}
+
+	fromIndex := indexOf(mongoMajorVersions, fromMajor)
+	toIndex := indexOf(mongoMajorVersions, toMajor)
+	if fromIndex == -1 || toIndex != fromIndex+1 {
+		return fmt.Errorf("%w: from %s to %s, upgrade to %s first", ErrMongoUpgradeSkipsMajor, from, to, getNextMongoMajorVersion(fromIndex))
+	}
+	return nil
+}
+
+func getMongoMajorVersion(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+func getNextMongoMajorVersion(index int) string {
+	if index == -1 || index+1 >= len(mongoMajorVersions) {
+		return "a known major version"
+	}
+	return mongoMajorVersions[index+1]
+}
+
+func indexOf(values []string, value string) int {
+	for index, item := range values {
+		if item == value {
+			return index
+		}
+	}
+	return -1
+}
+
+func checkMongoResources(resources corev1.ResourceRequirements) error {
+	for name, request := range resources.Requests {
+		limit, ok := resources.Limits[name]
+		if ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%w: %s request %s is more than the limit %s", ErrMongoRequestOverLimit, name, request.String(), limit.String())
+		}
+	}
+	return nil
+}
+
+// CompareMongoVersions compares versions like 4.2.2 part by part, it is negative when a is older than b
+func CompareMongoVersions(a string, b string) int {
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+	for index := 0; index < len(partsA) || index < len(partsB); index++ {
+		var partA, partB int
+		if index < len(partsA) {
+			partA, _ = strconv.Atoi(partsA[index])
+		}
+		if index < len(partsB) {
+			partB, _ = strconv.Atoi(partsB[index])
+		}
+		if partA != partB {
+			return partA - partB
+		}
+	}
+	return 0
+}
+
+func mergeMongoResources(current corev1.ResourceRequirements, settings MongoSettings) (corev1.ResourceRequirements, error) {
+	merged := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+	for name, quantity := range current.Requests {
+		merged.Requests[name] = quantity
+	}
+	for name, quantity := range current.Limits {
+		merged.Limits[name] = quantity
+	}
+
+	overrides := []struct {
+		list  corev1.ResourceList
+		name  corev1.ResourceName
+		value string
+	}{
+		{merged.Requests, corev1.ResourceCPU, settings.CPURequest},
+		{merged.Requests, corev1.ResourceMemory, settings.MemoryRequest},
+		{merged.Limits, corev1.ResourceCPU, settings.CPULimit},
+		{merged.Limits, corev1.ResourceMemory, settings.MemoryLimit},
+	}
+	for _, override := range overrides {
+		if override.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(override.value)
+		if err != nil {
+			return merged, fmt.Errorf("%s %s: %w", override.name, override.value, err)
+		}
+		override.list[override.name] = quantity
+	}
+	return merged, nil
+}
+
+func getMongoVolumeClaims(ctx context.Context, client kubernetes.Interface, statefulSet *appsv1.StatefulSet) ([]corev1.PersistentVolumeClaim, error) {
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	claims := make([]corev1.PersistentVolumeClaim, 0)
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		for ordinal := int32(0); ordinal < replicas; ordinal++ {
+			name := fmt.Sprintf("%s-%s-%d", template.Name, statefulSet.Name, ordinal)
+			claim, err := client.CoreV1().PersistentVolumeClaims(statefulSet.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return claims, fmt.Errorf("persistent volume claim %s: %w", name, err)
+			}
+			claims = append(claims, *claim)
+		}
+	}
+	return claims, nil
+}
+
+func getContainer(containers []corev1.Container, name string) *corev1.Container {
+	for index := range containers {
+		if containers[index].Name == name {
+			return &containers[index]
+		}
+	}
+	return nil
+}
+
+func getImageTag(image string) string {
+	index := strings.LastIndex(image, ":")
+	if index == -1 {
+		return "latest"
+	}
+	return image[index+1:]
+}
+
+func getRetentionDays(command string) int {
+	index := strings.Index(command, "-mtime +")
+	if index == -1 {
+		return 0
+	}
+	fields := strings.Fields(command[index+len("-mtime +"):])
+	if len(fields) == 0 {
+		return 0
+	}
+	days, _ := strconv.Atoi(fields[0])
+	return days
+}
+
+func quantityString(list corev1.ResourceList, name corev1.ResourceName) string {
+	quantity, ok := list[name]
+	if !ok {
+		return ""
+	}
+	return quantity.String()
+}
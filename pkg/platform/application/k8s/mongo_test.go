@@ -0,0 +1,186 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+)
+
+var _ = Describe("Changing mongo of an environment", func() {
+	var (
+		customer    dolittleK8s.Tenant
+		application dolittleK8s.Application
+		namespace   string
+		clientSet   *fake.Clientset
+		settings    k8s.MongoSettings
+		err         error
+	)
+
+	BeforeEach(func() {
+		customer = dolittleK8s.Tenant{ID: "fake-customer-123", Name: "fake-customer"}
+		application = dolittleK8s.Application{ID: "fake-application-123", Name: "fake-application"}
+		namespace = "application-fake-application-123"
+
+		mongo := k8s.NewMongo("Dev", customer, application, k8s.MongoSettings{
+			ShareName:       "fake",
+			CronJobSchedule: "5 * * * *",
+			VolumeSize:      "8Gi",
+		})
+		clientSet = fake.NewSimpleClientset(
+			mongo.StatefulSet,
+			mongo.Cronjob,
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-mongo-storage-dev-mongo-0", Namespace: namespace},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("8Gi")},
+					},
+				},
+			},
+		)
+		settings = k8s.MongoSettings{}
+	})
+
+	JustBeforeEach(func() {
+		err = k8s.ApplyMongoSettings(clientSet, application, "Dev", settings)
+	})
+
+	It("should read what is running", func() {
+		live, getErr := k8s.GetMongoSettings(clientSet, application.ID, "Dev")
+		Expect(getErr).To(BeNil())
+		Expect(live).To(Equal(k8s.MongoSettings{
+			VolumeSize:      "8Gi",
+			CronJobSchedule: "5 * * * *",
+			Version:         "4.2.2",
+			CPURequest:      "50m",
+			MemoryRequest:   "512Mi",
+			CPULimit:        "2",
+			MemoryLimit:     "2Gi",
+		}))
+	})
+
+	When("changing everything", func() {
+		BeforeEach(func() {
+			settings = k8s.MongoSettings{
+				Version:             "4.4.1",
+				VolumeSize:          "16Gi",
+				CPURequest:          "100m",
+				MemoryLimit:         "4Gi",
+				CronJobSchedule:     "30 2 * * *",
+				BackupRetentionDays: 7,
+			}
+		})
+
+		It("should update the statefulset, the backup and the volume", func() {
+			Expect(err).To(BeNil())
+			live, _ := k8s.GetMongoSettings(clientSet, application.ID, "Dev")
+			Expect(live).To(Equal(k8s.MongoSettings{
+				VolumeSize:          "16Gi",
+				CronJobSchedule:     "30 2 * * *",
+				Version:             "4.4.1",
+				CPURequest:          "100m",
+				MemoryRequest:       "512Mi",
+				CPULimit:            "2",
+				MemoryLimit:         "4Gi",
+				BackupRetentionDays: 7,
+			}))
+
+			cronJob, _ := clientSet.BatchV1beta1().CronJobs(namespace).Get(context.TODO(), "dev-mongo-backup", metav1.GetOptions{})
+			Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image).To(Equal("dolittle/mongodb:4.4.1"))
+		})
+	})
+
+	When("downgrading", func() {
+		BeforeEach(func() {
+			settings = k8s.MongoSettings{Version: "4.0.1", MemoryLimit: "4Gi"}
+		})
+
+		It("should not change anything", func() {
+			Expect(errors.Is(err, k8s.ErrMongoDowngrade)).To(BeTrue())
+			live, _ := k8s.GetMongoSettings(clientSet, application.ID, "Dev")
+			Expect(live.Version).To(Equal("4.2.2"))
+			Expect(live.MemoryLimit).To(Equal("2Gi"))
+		})
+	})
+
+	When("the backup cronjob can not be updated", func() {
+		BeforeEach(func() {
+			settings = k8s.MongoSettings{Version: "4.4.1", VolumeSize: "16Gi", CronJobSchedule: "30 2 * * *"}
+			clientSet.PrependReactor("update", "cronjobs", func(action testing.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("fake failure")
+			})
+		})
+
+		It("should put the statefulset back and keep the bigger volume", func() {
+			Expect(err).ToNot(BeNil())
+			live, _ := k8s.GetMongoSettings(clientSet, application.ID, "Dev")
+			Expect(live.Version).To(Equal("4.2.2"))
+			Expect(live.CronJobSchedule).To(Equal("5 * * * *"))
+			Expect(live.VolumeSize).To(Equal("16Gi"))
+		})
+	})
+
+	When("skipping a major version", func() {
+		BeforeEach(func() {
+			settings = k8s.MongoSettings{Version: "6.0.1", VolumeSize: "16Gi"}
+		})
+
+		It("should not change anything", func() {
+			Expect(errors.Is(err, k8s.ErrMongoUpgradeSkipsMajor)).To(BeTrue())
+			live, _ := k8s.GetMongoSettings(clientSet, application.ID, "Dev")
+			Expect(live.Version).To(Equal("4.2.2"))
+			Expect(live.VolumeSize).To(Equal("8Gi"))
+		})
+	})
+
+	When("requesting more than the running limit", func() {
+		BeforeEach(func() {
+			settings = k8s.MongoSettings{MemoryRequest: "3Gi", VolumeSize: "16Gi"}
+		})
+
+		It("should not change anything", func() {
+			Expect(errors.Is(err, k8s.ErrMongoRequestOverLimit)).To(BeTrue())
+			live, _ := k8s.GetMongoSettings(clientSet, application.ID, "Dev")
+			Expect(live.MemoryRequest).To(Equal("512Mi"))
+			Expect(live.VolumeSize).To(Equal("8Gi"))
+		})
+	})
+
+	When("making the volume smaller", func() {
+		BeforeEach(func() {
+			settings = k8s.MongoSettings{VolumeSize: "4Gi", CronJobSchedule: "30 2 * * *"}
+		})
+
+		It("should not change anything", func() {
+			Expect(errors.Is(err, k8s.ErrMongoVolumeShrink)).To(BeTrue())
+			live, _ := k8s.GetMongoSettings(clientSet, application.ID, "Dev")
+			Expect(live.VolumeSize).To(Equal("8Gi"))
+			Expect(live.CronJobSchedule).To(Equal("5 * * * *"))
+		})
+	})
+
+	It("should compare versions part by part", func() {
+		Expect(k8s.CompareMongoVersions("4.10.0", "4.2.2")).To(BeNumerically(">", 0))
+		Expect(k8s.CompareMongoVersions("4.2", "4.2.0")).To(Equal(0))
+		Expect(k8s.CompareMongoVersions("3.6.23", "4.2.2")).To(BeNumerically("<", 0))
+	})
+
+	It("should only upgrade one major version at a time", func() {
+		Expect(k8s.CheckMongoUpgrade("4.2.2", "4.2.8")).To(Succeed())
+		Expect(k8s.CheckMongoUpgrade("4.2.2", "4.4.1")).To(Succeed())
+		Expect(k8s.CheckMongoUpgrade("4.4.1", "5.0.3")).To(Succeed())
+		Expect(errors.Is(k8s.CheckMongoUpgrade("4.2.2", "5.0.3"), k8s.ErrMongoUpgradeSkipsMajor)).To(BeTrue())
+		Expect(errors.Is(k8s.CheckMongoUpgrade("4.2.2", "9.0.0"), k8s.ErrMongoUpgradeSkipsMajor)).To(BeTrue())
+		Expect(errors.Is(k8s.CheckMongoUpgrade("4.2.2", "4.0.1"), k8s.ErrMongoDowngrade)).To(BeTrue())
+	})
+})
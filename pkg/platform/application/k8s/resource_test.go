@@ -88,6 +88,37 @@ var _ = Describe("Setting up an application", func() {
 			Expect(resources.StatefulSet.Spec.Template.Spec.Containers[0].Resources.Limits.Cpu().String()).To(Equal("2"))
 			Expect(resources.StatefulSet.Spec.Template.Spec.Containers[0].Resources.Limits.Memory().String()).To(Equal("2Gi"))
 		})
+
+		It("should use the default version of mongo", func() {
+			Expect(resources.StatefulSet.Spec.Template.Spec.Containers[0].Image).To(Equal("dolittle/mongodb:4.2.2"))
+			Expect(resources.Cronjob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image).To(Equal("dolittle/mongodb:4.2.2"))
+		})
+
+		When("the settings pick the version, resources and retention", func() {
+			BeforeEach(func() {
+				settings := k8s.MongoSettings{
+					ShareName:           "fake",
+					CronJobSchedule:     "* * * * *",
+					VolumeSize:          "8Gi",
+					Version:             "4.4.1",
+					MemoryLimit:         "4Gi",
+					BackupRetentionDays: 14,
+				}
+				resources = k8s.NewMongo(environment, customer, application, settings)
+			})
+
+			It("should use them and keep the rest from the environment", func() {
+				container := resources.StatefulSet.Spec.Template.Spec.Containers[0]
+				Expect(container.Image).To(Equal("dolittle/mongodb:4.4.1"))
+				Expect(container.Resources.Limits.Memory().String()).To(Equal("4Gi"))
+				Expect(container.Resources.Requests.Cpu().String()).To(Equal("50m"))
+			})
+
+			It("should remove the old backups after the backup", func() {
+				expect := `mongodump --host=todo-mongo.application-fake-application-123.svc.cluster.local:27017 --gzip --archive=/mnt/backup/fake-application-todo-$(date +%Y-%m-%d_%H-%M-%S).gz.mongodump && find /mnt/backup/ -maxdepth 1 -name 'fake-application-todo-*.gz.mongodump' -mtime +14 -delete`
+				Expect(resources.Cronjob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Args[0]).To(Equal(expect))
+			})
+		})
 	})
 	When("Creating the environment", func() {
 		var (
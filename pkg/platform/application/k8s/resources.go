@@ -78,10 +78,21 @@ type ServiceAccount struct {
 	Application     dolittleK8s.Application
 }
 
+const DefaultMongoVersion = "4.2.2"
+
 type MongoSettings struct {
 	ShareName       string
 	VolumeSize      string
 	CronJobSchedule string
+	// Version is the tag of the dolittle/mongodb image, DefaultMongoVersion when empty
+	Version string
+	// The resources override what is picked by the name of the environment
+	CPURequest    string
+	MemoryRequest string
+	CPULimit      string
+	MemoryLimit   string
+	// BackupRetentionDays removes older backups after each backup, 0 keeps them forever
+	BackupRetentionDays int
 }
 type MongoResources struct {
 	Service     *corev1.Service
@@ -494,9 +505,13 @@ func NewMongo(environment string, tenant dolittleK8s.Tenant, application dolittl
 		platformK8s.ParseLabel(environment),
 	))
 
-	archive := "/mnt/backup/" + archivePrefix + "-$(date +%Y-%m-%d_%H-%M-%S).gz.mongodump"
-
 	shareName := settings.ShareName
+	image := MongoImage(settings.Version)
+
+	mongoResources, err := NewMongoResourceRequirements(environment, settings)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	resource := MongoResources{
 		Service: &corev1.Service{
@@ -547,7 +562,7 @@ func NewMongo(environment string, tenant dolittleK8s.Tenant, application dolittl
 					Spec: corev1.PodSpec{Containers: []corev1.Container{
 						{
 							Name:  "mongo",
-							Image: "dolittle/mongodb:4.2.2",
+							Image: image,
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "mongo",
@@ -560,7 +575,7 @@ func NewMongo(environment string, tenant dolittleK8s.Tenant, application dolittl
 									MountPath: "/data/db",
 								},
 							},
-							Resources: getMongoResources(environment),
+							Resources: mongoResources,
 						}}},
 				},
 				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
@@ -613,7 +628,7 @@ func NewMongo(environment string, tenant dolittleK8s.Tenant, application dolittl
 								Containers: []corev1.Container{
 									{
 										Name:  "mongo-backup",
-										Image: "dolittle/mongodb:4.2.2",
+										Image: image,
 										Ports: []corev1.ContainerPort{
 											{
 												Name:          "mongo",
@@ -625,7 +640,7 @@ func NewMongo(environment string, tenant dolittleK8s.Tenant, application dolittl
 											"-c",
 										},
 										Args: []string{
-											fmt.Sprintf(`mongodump --host=%s --gzip --archive=%s`, mongoHost, archive),
+											NewMongoBackupCommand(mongoHost, archivePrefix, settings.BackupRetentionDays),
 										},
 
 										VolumeMounts: []corev1.VolumeMount{
@@ -695,6 +710,31 @@ func NewLocalDevRoleBindingToDeveloper(tenant dolittleK8s.Tenant, application do
 		},
 	}
 }
+
+// MongoImage is the dolittle/mongodb image of the version
+func MongoImage(version string) string {
+	if version == "" {
+		version = DefaultMongoVersion
+	}
+	return fmt.Sprintf("dolittle/mongodb:%s", version)
+}
+
+// NewMongoBackupCommand dumps mongo to the backup share, and removes the backups older than retentionDays of the environment
+func NewMongoBackupCommand(mongoHost string, archivePrefix string, retentionDays int) string {
+	archive := "/mnt/backup/" + archivePrefix + "-$(date +%Y-%m-%d_%H-%M-%S).gz.mongodump"
+	command := fmt.Sprintf(`mongodump --host=%s --gzip --archive=%s`, mongoHost, archive)
+	if retentionDays > 0 {
+		command = fmt.Sprintf(`%s && find /mnt/backup/ -maxdepth 1 -name '%s-*.gz.mongodump' -mtime +%d -delete`, command, archivePrefix, retentionDays)
+	}
+	return command
+}
+
+// NewMongoResourceRequirements starts from the resources picked by the name of the environment,
+// and overrides them with what is in the settings
+func NewMongoResourceRequirements(environment string, settings MongoSettings) (apiv1.ResourceRequirements, error) {
+	return mergeMongoResources(getMongoResources(environment), settings)
+}
+
 func getMongoResources(environment string) apiv1.ResourceRequirements {
 	switch strings.ToLower(environment) {
 	case "prod":
@@ -0,0 +1,145 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/azure"
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var (
+	ErrInvalidMongoSettings = errors.New("mongo settings are not valid")
+
+	mongoVersionPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+	cronFieldPattern    = regexp.MustCompile(`^(\*|[0-9]+(-[0-9]+)?)(/[0-9]+)?(,(\*|[0-9]+(-[0-9]+)?)(/[0-9]+)?)*$`)
+)
+
+// NewDefaultMongoSettings is how mongo is set up in a new environment, the backup runs at a random minute every hour
+func NewDefaultMongoSettings() storage.JSONEnvironmentMongo {
+	return storage.JSONEnvironmentMongo{
+		Version:        k8s.DefaultMongoVersion,
+		VolumeSize:     "8Gi",
+		BackupSchedule: fmt.Sprintf("%d * * * *", GetRandomMinutes()),
+	}
+}
+
+// NewMongoSettings is how mongo is created in the environment, environments made before the settings were stored get the defaults
+func NewMongoSettings(application storage.JSONApplication, environment storage.JSONEnvironment) k8s.MongoSettings {
+	settings := toK8sMongoSettings(MergeMongoSettings(NewDefaultMongoSettings(), environment.Mongo))
+	settings.ShareName = azure.CreateBackupFileShareName(application.Name, environment.Name)
+	return settings
+}
+
+func toK8sMongoSettings(settings storage.JSONEnvironmentMongo) k8s.MongoSettings {
+	return k8s.MongoSettings{
+		VolumeSize:          settings.VolumeSize,
+		CronJobSchedule:     settings.BackupSchedule,
+		Version:             settings.Version,
+		CPURequest:          settings.CPURequest,
+		MemoryRequest:       settings.MemoryRequest,
+		CPULimit:            settings.CPULimit,
+		MemoryLimit:         settings.MemoryLimit,
+		BackupRetentionDays: settings.BackupRetentionDays,
+	}
+}
+
+func fromK8sMongoSettings(settings k8s.MongoSettings) storage.JSONEnvironmentMongo {
+	return storage.JSONEnvironmentMongo{
+		Version:             settings.Version,
+		VolumeSize:          settings.VolumeSize,
+		CPURequest:          settings.CPURequest,
+		MemoryRequest:       settings.MemoryRequest,
+		CPULimit:            settings.CPULimit,
+		MemoryLimit:         settings.MemoryLimit,
+		BackupSchedule:      settings.CronJobSchedule,
+		BackupRetentionDays: settings.BackupRetentionDays,
+	}
+}
+
+// MergeMongoSettings overrides current with what is set in changes
+func MergeMongoSettings(current storage.JSONEnvironmentMongo, changes storage.JSONEnvironmentMongo) storage.JSONEnvironmentMongo {
+	override := func(current *string, change string) {
+		if change != "" {
+			*current = change
+		}
+	}
+	override(&current.Version, changes.Version)
+	override(&current.VolumeSize, changes.VolumeSize)
+	override(&current.CPURequest, changes.CPURequest)
+	override(&current.MemoryRequest, changes.MemoryRequest)
+	override(&current.CPULimit, changes.CPULimit)
+	override(&current.MemoryLimit, changes.MemoryLimit)
+	override(&current.BackupSchedule, changes.BackupSchedule)
+	if changes.BackupRetentionDays != 0 {
+		current.BackupRetentionDays = changes.BackupRetentionDays
+	}
+	return current
+}
+
+// ValidateMongoSettings checks what is set, empty is allowed everywhere.
+// A request can not be more than its limit when both are set
+func ValidateMongoSettings(settings storage.JSONEnvironmentMongo) error {
+	if settings.Version != "" && !mongoVersionPattern.MatchString(settings.Version) {
+		return fmt.Errorf("%w: version %s is not like 4.2.2", ErrInvalidMongoSettings, settings.Version)
+	}
+
+	quantities := map[string]string{
+		"volumeSize":    settings.VolumeSize,
+		"cpuRequest":    settings.CPURequest,
+		"memoryRequest": settings.MemoryRequest,
+		"cpuLimit":      settings.CPULimit,
+		"memoryLimit":   settings.MemoryLimit,
+	}
+	for name, value := range quantities {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil || quantity.Sign() <= 0 {
+			return fmt.Errorf("%w: %s %s is not a quantity", ErrInvalidMongoSettings, name, value)
+		}
+	}
+
+	requestsAndLimits := [][2]string{
+		{"cpuRequest", "cpuLimit"},
+		{"memoryRequest", "memoryLimit"},
+	}
+	for _, names := range requestsAndLimits {
+		request, limit := quantities[names[0]], quantities[names[1]]
+		if request == "" || limit == "" {
+			continue
+		}
+		requestQuantity, limitQuantity := resource.MustParse(request), resource.MustParse(limit)
+		if requestQuantity.Cmp(limitQuantity) > 0 {
+			return fmt.Errorf("%w: %s %s is more than %s %s", ErrInvalidMongoSettings, names[0], request, names[1], limit)
+		}
+	}
+
+	if settings.BackupSchedule != "" && !IsCronScheduleValid(settings.BackupSchedule) {
+		return fmt.Errorf("%w: backup schedule %s is not a cron schedule", ErrInvalidMongoSettings, settings.BackupSchedule)
+	}
+
+	if settings.BackupRetentionDays < 0 {
+		return fmt.Errorf("%w: backup retention days can not be negative", ErrInvalidMongoSettings)
+	}
+	return nil
+}
+
+// IsCronScheduleValid checks the schedule has the five fields of cron with numbers, ranges, lists and steps
+func IsCronScheduleValid(schedule string) bool {
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return false
+	}
+	for _, field := range fields {
+		if !cronFieldPattern.MatchString(field) {
+			return false
+		}
+	}
+	return true
+}
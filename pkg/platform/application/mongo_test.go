@@ -0,0 +1,63 @@
+package application_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform/application"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+)
+
+var _ = Describe("Mongo settings", func() {
+	It("should allow empty settings", func() {
+		Expect(application.ValidateMongoSettings(storage.JSONEnvironmentMongo{})).To(Succeed())
+	})
+
+	It("should allow settings that make sense", func() {
+		Expect(application.ValidateMongoSettings(storage.JSONEnvironmentMongo{
+			Version:             "4.4.1",
+			VolumeSize:          "16Gi",
+			CPURequest:          "100m",
+			MemoryLimit:         "4Gi",
+			BackupSchedule:      "*/15 1-5 * * 1,3",
+			BackupRetentionDays: 30,
+		})).To(Succeed())
+	})
+
+	It("should not allow settings that do not make sense", func() {
+		invalid := []storage.JSONEnvironmentMongo{
+			{Version: "latest"},
+			{VolumeSize: "big"},
+			{MemoryLimit: "0"},
+			{BackupSchedule: "every hour"},
+			{BackupSchedule: "5 * * *"},
+			{BackupRetentionDays: -1},
+			{CPURequest: "2", CPULimit: "500m"},
+			{MemoryRequest: "4Gi", MemoryLimit: "2Gi"},
+		}
+		for _, settings := range invalid {
+			err := application.ValidateMongoSettings(settings)
+			Expect(errors.Is(err, application.ErrInvalidMongoSettings)).To(BeTrue(), "%+v", settings)
+		}
+	})
+
+	It("should only change what is set", func() {
+		current := storage.JSONEnvironmentMongo{Version: "4.2.2", VolumeSize: "8Gi", BackupSchedule: "5 * * * *"}
+		merged := application.MergeMongoSettings(current, storage.JSONEnvironmentMongo{VolumeSize: "16Gi", BackupRetentionDays: 7})
+		Expect(merged).To(Equal(storage.JSONEnvironmentMongo{
+			Version:             "4.2.2",
+			VolumeSize:          "16Gi",
+			BackupSchedule:      "5 * * * *",
+			BackupRetentionDays: 7,
+		}))
+	})
+
+	It("should give environments made before the settings were stored the defaults", func() {
+		settings := application.NewMongoSettings(storage.JSONApplication{Name: "Taco"}, storage.JSONEnvironment{Name: "Dev"})
+		Expect(settings.Version).To(Equal("4.2.2"))
+		Expect(settings.VolumeSize).To(Equal("8Gi"))
+		Expect(application.IsCronScheduleValid(settings.CronJobSchedule)).To(BeTrue())
+	})
+})
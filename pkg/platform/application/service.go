@@ -2,24 +2,30 @@ package application
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"time"
 
+	"github.com/dolittle/platform-api/pkg/azure"
 	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
 	"github.com/dolittle/platform-api/pkg/k8s"
 	"github.com/dolittle/platform-api/pkg/platform"
+	applicationK8s "github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/job"
 	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/simple"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/welcome"
 	"github.com/dolittle/platform-api/pkg/platform/storage"
 	"github.com/dolittle/platform-api/pkg/utils"
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/thoas/go-funk"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -162,34 +168,7 @@ func (s *Service) Create(w http.ResponseWriter, r *http.Request) {
 	environments := input.Environments
 
 	for _, environment := range environments {
-		welcomeMicroserviceID := uuid.New().String()
-
-		customerTenants := make([]platform.CustomerTenantInfo, 0)
-
-		if len(environment.CustomerTenant) > 0 {
-			for _, customerTenant := range environment.CustomerTenant {
-				var customerTenantInfo platform.CustomerTenantInfo
-
-				if customerTenant.ID != "" {
-					customerTenantInfo = dolittleK8s.NewCustomerTenantInfo(environment.Name, welcomeMicroserviceID, customerTenant.ID)
-				} else {
-					customerTenantInfo = dolittleK8s.NewDevelopmentCustomerTenantInfo(environment.Name, welcomeMicroserviceID)
-				}
-
-				customerTenants = append(customerTenants, customerTenantInfo)
-			}
-		} else {
-			// Create one
-			customerTenantInfo := dolittleK8s.NewDevelopmentCustomerTenantInfo(environment.Name, welcomeMicroserviceID)
-			customerTenants = append(customerTenants, customerTenantInfo)
-		}
-
-		environmentInfo := storage.JSONEnvironment{
-			Name:                  environment.Name,
-			CustomerTenants:       customerTenants,
-			WelcomeMicroserviceID: welcomeMicroserviceID,
-		}
-		application.Environments = append(application.Environments, environmentInfo)
+		application.Environments = append(application.Environments, NewEnvironmentInfo(environment))
 	}
 
 	err = s.gitRepo.SaveApplication(application)
@@ -226,6 +205,303 @@ func (s *Service) Create(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// Delete starts the job deleting the application, see DeleteFromCluster for what it does.
+// Applications with a Prod environment are only deleted with ?force=true
+func (s *Service) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	force := r.FormValue("force") == "true"
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "Delete",
+		"customer_id":    customerID,
+		"application_id": applicationID,
+		"user_id":        userID,
+		"force":          force,
+	})
+
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Application %s not found", applicationID))
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to get the application")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	err = CanDelete(application, force)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	resource := jobK8s.DeleteApplicationResource(
+		s.jobResourceConfig,
+		customerID,
+		dolittleK8s.ShortInfo{
+			ID:   application.ID,
+			Name: application.Name,
+		},
+		force,
+	)
+
+	// The job decides whether a delete is in progress, the stored status stays deleting when the job
+	// is gone before the listener sees it fail
+	jobs := s.k8sClient.BatchV1().Jobs(resource.Namespace)
+	previous, err := jobs.Get(r.Context(), resource.Name, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		logContext.WithField("error", err).Error("Failed to get the previous job")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete application")
+		return
+	}
+
+	if err == nil {
+		if !jobK8s.IsJobFinished(*previous) {
+			utils.RespondWithError(w, http.StatusConflict, "Application is already being deleted")
+			return
+		}
+
+		// A job from an earlier attempt that finished is in the way
+		propagation := metav1.DeletePropagationBackground
+		err = jobs.Delete(r.Context(), resource.Name, metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			logContext.WithField("error", err).Error("Failed to remove the previous job")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete application")
+			return
+		}
+	}
+
+	previousStatus := application.Status
+	application.Status = storage.JSONBuildStatus{
+		State:     storage.BuildStatusStateDeleting,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	err = s.gitRepo.SaveApplication(application)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the status")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to storage")
+		return
+	}
+
+	err = jobK8s.DoJob(s.k8sClient, resource)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to create job to delete application")
+		application.Status = previousStatus
+		if err := s.gitRepo.SaveApplication(application); err != nil {
+			logContext.WithField("error", err).Error("Failed to restore the status")
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete application")
+		return
+	}
+
+	logContext.WithField("job_id", resource.Name).Info("Deleting application")
+	utils.RespondWithJSON(
+		w,
+		http.StatusAccepted,
+		map[string]string{
+			"jobId": resource.Name,
+		},
+	)
+}
+
+// AddEnvironment creates the environment in the namespace of the application, with its own mongo,
+// customer tenants and welcome microservice
+func (s *Service) AddEnvironment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "AddEnvironment",
+		"customer_id":    customerID,
+		"application_id": applicationID,
+		"user_id":        userID,
+	})
+
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	var input HttpInputApplicationEnvironment
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	err = json.Unmarshal(b, &input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if !IsEnvironmentNameValid(input.Name) {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, "Environment name is not valid")
+		return
+	}
+
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Application %s not found", applicationID))
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to get the application")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	if _, ok := FindEnvironment(application, input.Name); ok {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Sprintf("Environment %s already exists", input.Name))
+		return
+	}
+
+	terraformCustomer, err := s.gitRepo.GetTerraformTenant(customerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, platform.ErrStudioInfoMissing.Error())
+		return
+	}
+
+	// The mongo backups are written to the fileshare, so it has to be there first
+	err = azure.EnsureFileShareExists(
+		terraformCustomer.AzureStorageAccountName,
+		terraformCustomer.AzureStorageAccountKey,
+		azure.CreateBackupFileShareName(application.Name, input.Name),
+	)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to create the fileshare for the mongo backups")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create environment")
+		return
+	}
+
+	environment := NewEnvironmentInfo(input)
+	err = CreateEnvironment(s.k8sClient, s.gitRepo, s.simpleRepo, s.k8sDolittleRepo, application, environment, welcome.Image, logContext)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to create the environment")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create environment")
+		return
+	}
+
+	application.Environments = append(application.Environments, environment)
+	err = s.gitRepo.SaveApplication(application)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the environment")
+		if err := applicationK8s.DeleteEnvironment(s.k8sClient, s.k8sDolittleRepo, application.ID, environment.Name); err != nil {
+			logContext.WithField("error", err).Error("Failed to remove the environment after failing to save it")
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to storage")
+		return
+	}
+
+	logContext.WithField("environment", environment.Name).Info("Environment added")
+	utils.RespondWithJSON(w, http.StatusCreated, environment)
+}
+
+// DeleteEnvironment starts the job deleting the environment, see DeleteEnvironmentFromCluster for what it does.
+// Prod is only deleted with ?force=true, and the last environment of an application is never deleted
+func (s *Service) DeleteEnvironment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	environmentName := vars["environment"]
+	force := r.FormValue("force") == "true"
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "DeleteEnvironment",
+		"customer_id":    customerID,
+		"application_id": applicationID,
+		"environment":    environmentName,
+		"user_id":        userID,
+		"force":          force,
+	})
+
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Application %s not found", applicationID))
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to get the application")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	err = CanDeleteEnvironment(application, environmentName, force)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Environment %s not found", environmentName))
+			return
+		}
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	environment, _ := FindEnvironment(application, environmentName)
+	resource := jobK8s.DeleteEnvironmentResource(s.jobResourceConfig, customerID, applicationID, environment.Name, force)
+
+	jobs := s.k8sClient.BatchV1().Jobs(resource.Namespace)
+	previous, err := jobs.Get(r.Context(), resource.Name, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		logContext.WithField("error", err).Error("Failed to get the previous job")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete environment")
+		return
+	}
+
+	if err == nil {
+		if !jobK8s.IsJobFinished(*previous) {
+			utils.RespondWithError(w, http.StatusConflict, "An environment of the application is already being deleted")
+			return
+		}
+
+		// A job from an earlier attempt that finished is in the way
+		propagation := metav1.DeletePropagationBackground
+		err = jobs.Delete(r.Context(), resource.Name, metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			logContext.WithField("error", err).Error("Failed to remove the previous job")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete environment")
+			return
+		}
+	}
+
+	err = jobK8s.DoJob(s.k8sClient, resource)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to create job to delete environment")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete environment")
+		return
+	}
+
+	logContext.WithField("job_id", resource.Name).Info("Deleting environment")
+	utils.RespondWithJSON(
+		w,
+		http.StatusAccepted,
+		map[string]string{
+			"jobId": resource.Name,
+		},
+	)
+}
+
 func (s *Service) GetLiveApplications(w http.ResponseWriter, r *http.Request) {
 	customerID := r.Header.Get("Tenant-ID")
 	studioConfig, err := s.gitRepo.GetStudioConfig(customerID)
@@ -324,6 +600,50 @@ func (s *Service) GetByID(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
 
+// GetBuildStatus returns how far the job creating the application has got.
+// The namespace of the application does not exist until the job is done, so access is scoped by the customer.
+// Only the outcome is stored, while the job is running its progress is read from its pod
+func (s *Service) GetBuildStatus(w http.ResponseWriter, r *http.Request) {
+	customerID := r.Header.Get("Tenant-ID")
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "GetBuildStatus",
+		"customer_id":    customerID,
+		"application_id": applicationID,
+	})
+
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Application %s not found", applicationID))
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to get the application")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	status := application.Status
+	if status.State == storage.BuildStatusStateWaiting || status.State == storage.BuildStatusStatePending {
+		_, pods, err := jobK8s.GetJob(r.Context(), s.k8sClient, s.jobResourceConfig.Namespace, jobK8s.CreateApplicationJobName(application.ID))
+		if err != nil && !k8serrors.IsNotFound(err) {
+			logContext.WithField("error", err).Error("Failed to get the create job")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+			return
+		}
+		if len(pods) != 0 {
+			status = job.BuildStatusFromPod(&pods[0], status)
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, HttpResponseBuildStatus{
+		ApplicationID: application.ID,
+		Status:        status,
+	})
+}
+
 func (s *Service) GetApplications(w http.ResponseWriter, r *http.Request) {
 	customerID := r.Header.Get("Tenant-ID")
 
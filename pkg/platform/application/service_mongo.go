@@ -0,0 +1,194 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// GetMongoSettings shows how mongo in the environment is stored and how it is running
+func (s *Service) GetMongoSettings(w http.ResponseWriter, r *http.Request) {
+	application, environment, logContext, ok := s.getAdminEnvironment(w, r, "GetMongoSettings")
+	if !ok {
+		return
+	}
+
+	live, err := k8s.GetMongoSettings(s.k8sClient, application.ID, environment.Name)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		logContext.WithField("error", err).Error("Failed to get mongo from the cluster")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get mongo from the cluster")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, HttpResponseMongoSettings{
+		ApplicationID: application.ID,
+		Environment:   environment.Name,
+		Settings:      environment.Mongo,
+		Live:          fromK8sMongoSettings(live),
+	})
+}
+
+// UpdateMongoSettings changes how mongo in the environment is set up, and applies it to the running mongo.
+// backupRetentionDays can not be set on an environment with a backup retention
+func (s *Service) UpdateMongoSettings(w http.ResponseWriter, r *http.Request) {
+	application, environment, logContext, ok := s.getAdminEnvironment(w, r, "UpdateMongoSettings")
+	if !ok {
+		return
+	}
+
+	var input HttpInputMongoSettings
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Failed to read payload")
+		return
+	}
+	defer r.Body.Close()
+
+	err = json.Unmarshal(b, &input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	changes := storage.JSONEnvironmentMongo{
+		Version:        input.Version,
+		VolumeSize:     input.VolumeSize,
+		CPURequest:     input.CPURequest,
+		MemoryRequest:  input.MemoryRequest,
+		CPULimit:       input.CPULimit,
+		MemoryLimit:    input.MemoryLimit,
+		BackupSchedule: input.BackupSchedule,
+	}
+	if input.BackupRetentionDays != nil {
+		changes.BackupRetentionDays = *input.BackupRetentionDays
+	}
+
+	err = ValidateMongoSettings(changes)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	settings := MergeMongoSettings(environment.Mongo, changes)
+	if input.BackupRetentionDays != nil {
+		settings.BackupRetentionDays = *input.BackupRetentionDays
+	}
+
+	// A new request can be over a limit that was set before
+	err = ValidateMongoSettings(settings)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = backup.CheckRetentionConflict(settings)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusConflict, "The environment has a backup retention, removing backups by age would remove the ones it keeps")
+		return
+	}
+
+	// Only what changed is applied, the retention is part of the backup command so it is always set
+	apply := toK8sMongoSettings(changes)
+	apply.BackupRetentionDays = settings.BackupRetentionDays
+
+	err = k8s.ApplyMongoSettings(s.k8sClient, dolittleK8s.Application{ID: application.ID, Name: application.Name}, environment.Name, apply)
+	if err != nil {
+		if errors.Is(err, k8s.ErrMongoDowngrade) || errors.Is(err, k8s.ErrMongoUpgradeSkipsMajor) || errors.Is(err, k8s.ErrMongoVolumeShrink) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, k8s.ErrMongoRequestOverLimit) {
+			utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if k8serrors.IsNotFound(err) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Mongo in %s not found in the cluster", environment.Name))
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to apply the mongo settings")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to apply the mongo settings")
+		return
+	}
+
+	for index, item := range application.Environments {
+		if item.Name == environment.Name {
+			application.Environments[index].Mongo = settings
+		}
+	}
+
+	err = s.gitRepo.SaveApplication(application)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the mongo settings, they are applied to the cluster")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to storage")
+		return
+	}
+
+	live, err := k8s.GetMongoSettings(s.k8sClient, application.ID, environment.Name)
+	if err != nil {
+		logContext.WithField("error", err).Warn("Failed to get mongo from the cluster after applying the settings")
+	}
+
+	logContext.WithField("settings", settings).Info("Mongo settings applied")
+	utils.RespondWithJSON(w, http.StatusOK, HttpResponseMongoSettings{
+		ApplicationID: application.ID,
+		Environment:   environment.Name,
+		Settings:      settings,
+		Live:          fromK8sMongoSettings(live),
+	})
+}
+
+func (s *Service) getAdminEnvironment(w http.ResponseWriter, r *http.Request, method string) (storage.JSONApplication, storage.JSONEnvironment, logrus.FieldLogger, bool) {
+	vars := mux.Vars(r)
+	customerID := vars["customerID"]
+	applicationID := vars["applicationID"]
+	environmentName := vars["environment"]
+	userID := r.Header.Get("User-ID")
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         method,
+		"customer_id":    customerID,
+		"application_id": applicationID,
+		"environment":    environmentName,
+		"user_id":        userID,
+	})
+
+	hasAccess, err := s.roleBindingRepo.HasUserAdminAccess(userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check if user has access")
+		return storage.JSONApplication{}, storage.JSONEnvironment{}, logContext, false
+	}
+
+	if !hasAccess {
+		utils.RespondWithError(w, http.StatusForbidden, "You do not have access")
+		return storage.JSONApplication{}, storage.JSONEnvironment{}, logContext, false
+	}
+
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Application not found for this customer")
+			return application, storage.JSONEnvironment{}, logContext, false
+		}
+		logContext.WithField("error", err).Error("Storage has failed")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return application, storage.JSONEnvironment{}, logContext, false
+	}
+
+	environment, ok := FindEnvironment(application, environmentName)
+	if !ok {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Environment %s not found", environmentName))
+		return application, environment, logContext, false
+	}
+	return application, environment, logContext, true
+}
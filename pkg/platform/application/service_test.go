@@ -1,6 +1,7 @@
 package application_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,6 +25,9 @@ import (
 	"github.com/sirupsen/logrus"
 	logrusTest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/mock"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 )
@@ -128,6 +132,103 @@ var _ = Describe("Testing endpoints", func() {
 			Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
 		})
 	})
+	When("Getting the build status", func() {
+		var resp *http.Response
+
+		JustBeforeEach(func() {
+			url := fmt.Sprintf("http://studio/application/%s/build-status", applicationID)
+			req = httptest.NewRequest("GET", url, nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"applicationID": applicationID,
+			})
+			req.Header.Set("Tenant-ID", customerID)
+			w = httptest.NewRecorder()
+
+			service.GetBuildStatus(w, req)
+			resp = w.Result()
+		})
+
+		Context("and the create job failed", func() {
+			BeforeEach(func() {
+				gitRepo.On("GetApplication", customerID, applicationID).Return(storage.JSONApplication{
+					ID:         applicationID,
+					CustomerID: customerID,
+					Status: storage.JSONBuildStatus{
+						State:      storage.BuildStatusStateFinishedFailed,
+						StartedAt:  "2022-04-01T10:00:00Z",
+						FinishedAt: "2022-04-01T10:05:00Z",
+						FailedStep: "terraform",
+						Message:    "Error: timeout",
+					},
+				}, nil)
+			})
+
+			It("should return the stored status", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, _ := io.ReadAll(resp.Body)
+				Expect(body).To(MatchJSON(fmt.Sprintf(`{
+					"applicationId": "%s",
+					"status": {
+						"status": "finished:failed",
+						"startedAt": "2022-04-01T10:00:00Z",
+						"finishedAt": "2022-04-01T10:05:00Z",
+						"failedStep": "terraform",
+						"message": "Error: timeout"
+					}
+				}`, applicationID)))
+			})
+		})
+
+		Context("and the create job is running", func() {
+			BeforeEach(func() {
+				gitRepo.On("GetApplication", customerID, applicationID).Return(storage.JSONApplication{
+					ID:         applicationID,
+					CustomerID: customerID,
+					Status: storage.JSONBuildStatus{
+						State:     storage.BuildStatusStateWaiting,
+						StartedAt: "2022-04-01T10:00:00Z",
+					},
+				}, nil)
+
+				jobName := jobK8s.CreateApplicationJobName(applicationID)
+				clientSet.BatchV1().Jobs("").Create(context.TODO(), &batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{Name: jobName},
+				}, metav1.CreateOptions{})
+				clientSet.CoreV1().Pods("").Create(context.TODO(), &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   jobName + "-abcde",
+						Labels: map[string]string{"job-name": jobName},
+					},
+					Status: corev1.PodStatus{Phase: corev1.PodRunning},
+				}, metav1.CreateOptions{})
+			})
+
+			It("should return the progress from the pod without storing it", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, _ := io.ReadAll(resp.Body)
+				Expect(body).To(MatchJSON(fmt.Sprintf(`{
+					"applicationId": "%s",
+					"status": {
+						"status": "building",
+						"startedAt": "2022-04-01T10:00:00Z",
+						"finishedAt": ""
+					}
+				}`, applicationID)))
+				gitRepo.AssertNotCalled(GinkgoT(), "SaveApplication", mock.Anything)
+			})
+		})
+
+		Context("and the application does not belong to the customer", func() {
+			BeforeEach(func() {
+				gitRepo.On("GetApplication", customerID, applicationID).Return(storage.JSONApplication{}, storage.ErrNotFound)
+			})
+
+			It("should not be found", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
 	When("GetApplications", func() {
 		It("Has 1 application with 2 environments", func() {
 			gitRepo.On(
@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"context"
+	"path"
+	"time"
+
+	azureHelpers "github.com/dolittle/platform-api/pkg/azure"
+)
+
+type azureFilesStore struct {
+	accountName string
+	accountKey  string
+}
+
+// NewAzureFilesStore uses the file shares in the given storage account
+func NewAzureFilesStore(accountName string, accountKey string) BackupStore {
+	return azureFilesStore{
+		accountName: accountName,
+		accountKey:  accountKey,
+	}
+}
+
+func (s azureFilesStore) List(ctx context.Context, share string) ([]Backup, error) {
+	files, err := azureHelpers.ListFiles(s.accountName, s.accountKey, share, BackupDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]Backup, 0, len(files))
+	for _, file := range files {
+		createdAt, ok := ParseBackupTime(file.Name)
+		if !ok {
+			continue
+		}
+		backups = append(backups, Backup{
+			Name:      file.Name,
+			Path:      backupPath(share, file.Name),
+			Size:      file.Size,
+			CreatedAt: createdAt,
+		})
+	}
+	SortNewestFirst(backups)
+	return backups, nil
+}
+
+func (s azureFilesStore) CreateLink(ctx context.Context, share string, name string, expires time.Time) (string, error) {
+	if !IsBackupNameValid(name) {
+		return "", ErrInvalidBackupName
+	}
+	return azureHelpers.CreateLink(s.accountName, s.accountKey, share, path.Join(BackupDirectory, name), expires)
+}
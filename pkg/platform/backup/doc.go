@@ -1,6 +1,8 @@
 package backup
 
 import (
+	"time"
+
 	"github.com/dolittle/platform-api/pkg/platform"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/dolittle/platform-api/pkg/platform/storage"
@@ -12,6 +14,7 @@ type service struct {
 	gitRepo         storage.Repo
 	k8sDolittleRepo platformK8s.K8sRepo
 	k8sClient       kubernetes.Interface
+	getStore        StoreResolver
 	logContext      logrus.FieldLogger
 }
 
@@ -37,3 +40,101 @@ type HTTPDownloadLogsLinkResponse struct {
 	Url         string             `json:"url"`
 	Expires     string             `json:"expire"`
 }
+
+type Backup struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type HTTPResponseBackups struct {
+	ApplicationID string   `json:"applicationId"`
+	Environment   string   `json:"environment"`
+	Page          int      `json:"page"`
+	PageSize      int      `json:"pageSize"`
+	Total         int      `json:"total"`
+	Backups       []Backup `json:"backups"`
+}
+
+type HTTPResponseBackupJobs struct {
+	ApplicationID string   `json:"applicationId"`
+	Environment   string   `json:"environment"`
+	Jobs          []string `json:"jobs"`
+}
+
+type HTTPInputRestore struct {
+	Name string `json:"name"`
+	// TargetEnvironment defaults to the environment the backup was taken from
+	TargetEnvironment string `json:"targetEnvironment"`
+}
+
+type HTTPResponseRestore struct {
+	ApplicationID     string `json:"applicationId"`
+	Environment       string `json:"environment"`
+	TargetEnvironment string `json:"targetEnvironment"`
+	Name              string `json:"name"`
+	Job               string `json:"job"`
+}
+
+type RestoreStep struct {
+	// Name is mongo-safety-backup or mongo-restore
+	Name string `json:"name"`
+	// Status is one of waiting, running, succeeded or failed
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type Restore struct {
+	Job             string `json:"job"`
+	Name            string `json:"name"`
+	FromEnvironment string `json:"fromEnvironment"`
+	// Status is one of running, succeeded or failed
+	Status     string        `json:"status"`
+	Steps      []RestoreStep `json:"steps"`
+	StartedAt  *time.Time    `json:"startedAt,omitempty"`
+	FinishedAt *time.Time    `json:"finishedAt,omitempty"`
+	Message    string        `json:"message,omitempty"`
+}
+
+type HTTPResponseRestores struct {
+	ApplicationID string    `json:"applicationId"`
+	Environment   string    `json:"environment"`
+	Restores      []Restore `json:"restores"`
+}
+
+type Verification struct {
+	Environment string `json:"environment"`
+	// Status is one of running, succeeded, failed or never
+	Status     string     `json:"status"`
+	Job        string     `json:"job,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Message    string     `json:"message,omitempty"`
+}
+
+type HTTPResponseVerifications struct {
+	ApplicationID string         `json:"applicationId"`
+	Verifications []Verification `json:"verifications"`
+}
+
+type HTTPResponseVerify struct {
+	ApplicationID string `json:"applicationId"`
+	Environment   string `json:"environment"`
+	Name          string `json:"name"`
+	Job           string `json:"job"`
+}
+
+type HTTPResponseRetention struct {
+	ApplicationID string                       `json:"applicationId"`
+	Environment   string                       `json:"environment"`
+	Retention     *storage.JSONBackupRetention `json:"retention"`
+}
+
+type HTTPResponsePrune struct {
+	ApplicationID string   `json:"applicationId"`
+	Environment   string   `json:"environment"`
+	Job           string   `json:"job,omitempty"`
+	Pruned        []Backup `json:"pruned"`
+}
@@ -0,0 +1,177 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// JobKindLabel tells the jobs made from the backup cronjob apart, it is one of the JobKind values
+	JobKindLabel = "backup"
+	// BackupNameAnnotation is the archive the job works on
+	BackupNameAnnotation = "dolittle.io/backup-name"
+
+	JobKindRestore = "restore"
+	JobKindPrune   = "prune"
+	JobKindVerify  = "verify"
+)
+
+var (
+	ErrNoBackupCronJob  = errors.New("no mongo backup cronjob found for the environment")
+	ErrBackupJobRunning = errors.New("a restore or prune of the environment is still running")
+)
+
+// Restoring and verifying take longer than the 10 minutes given to the backups
+var longRunningActiveDeadlineSeconds = int64(3600)
+
+// newMongoBackupJob builds a job from the pod of the source cronjob, so it has the share with the backups mounted at /mnt/backup/.
+// The job is labelled as and runs the mongo image of the target cronjob
+func newMongoBackupJob(source v1beta1.CronJob, target v1beta1.CronJob, kind string, backupName string, command string) (*batchv1.Job, error) {
+	spec := *source.Spec.JobTemplate.Spec.DeepCopy()
+	if len(spec.Template.Spec.Containers) == 0 || len(target.Spec.JobTemplate.Spec.Template.Spec.Containers) == 0 {
+		return nil, ErrNoBackupCronJob
+	}
+
+	labels := map[string]string{}
+	for key, value := range target.Labels {
+		labels[key] = value
+	}
+	labels[JobKindLabel] = kind
+	spec.Template.Labels = labels
+	// Running it twice on failure could leave a mix of both attempts
+	backoffLimit := int32(0)
+	spec.BackoffLimit = &backoffLimit
+
+	container := &spec.Template.Spec.Containers[0]
+	container.Name = fmt.Sprintf("mongo-%s", kind)
+	container.Image = target.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image
+	container.Args = []string{command}
+
+	return &batchv1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			// The random suffix keeps jobs started in the same second apart
+			Name:      fmt.Sprintf("%s-mongo-%s-%d-%s", strings.ToLower(target.Labels["environment"]), kind, time.Now().UTC().Unix(), utilrand.String(5)),
+			Namespace: target.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				BackupNameAnnotation: backupName,
+			},
+		},
+		Spec: spec,
+	}, nil
+}
+
+func getMongoBackupCronJob(ctx context.Context, client kubernetes.Interface, namespace string, environment string) (v1beta1.CronJob, error) {
+	crons, err := client.BatchV1beta1().CronJobs(namespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: fmt.Sprintf("environment=%s,infrastructure=Mongo", platformK8s.ParseLabel(environment)),
+	})
+	if err != nil {
+		return v1beta1.CronJob{}, err
+	}
+
+	if len(crons.Items) == 0 {
+		return v1beta1.CronJob{}, ErrNoBackupCronJob
+	}
+	return crons.Items[0], nil
+}
+
+func getShareNameFromCronJob(cron v1beta1.CronJob) (string, error) {
+	for _, volume := range cron.Spec.JobTemplate.Spec.Template.Spec.Volumes {
+		if volume.AzureFile != nil {
+			return volume.AzureFile.ShareName, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s has no share", ErrNoBackupCronJob, cron.Name)
+}
+
+// getJobsOfKind lists the jobs of the kind in the environment, the latest first
+func getJobsOfKind(ctx context.Context, client kubernetes.Interface, namespace string, environment string, kind string) ([]batchv1.Job, error) {
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: fmt.Sprintf("environment=%s,infrastructure=Mongo,%s=%s", platformK8s.ParseLabel(environment), JobKindLabel, kind),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := jobs.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].CreationTimestamp.Equal(&items[j].CreationTimestamp) {
+			return items[i].Name > items[j].Name
+		}
+		return items[j].CreationTimestamp.Before(&items[i].CreationTimestamp)
+	})
+	return items, nil
+}
+
+// checkNoRunningJobs returns ErrBackupJobRunning while a job of one of the kinds is running in the environment
+func checkNoRunningJobs(ctx context.Context, client kubernetes.Interface, namespace string, environment string, kinds ...string) error {
+	for _, kind := range kinds {
+		jobs, err := getJobsOfKind(ctx, client, namespace, environment, kind)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if !jobK8s.IsJobFinished(job) {
+				return fmt.Errorf("%w: %s %s", ErrBackupJobRunning, kind, job.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// removeFinishedJobs removes the finished jobs of the kind in the environment, except the latest one,
+// so there is always one left to tell how it went last time
+func removeFinishedJobs(ctx context.Context, client kubernetes.Interface, namespace string, environment string, kind string) error {
+	jobs, err := getJobsOfKind(ctx, client, namespace, environment, kind)
+	if err != nil {
+		return err
+	}
+
+	propagation := metaV1.DeletePropagationBackground
+	for index, job := range jobs {
+		if index == 0 || getJobStatus(job) == JobStatusRunning {
+			continue
+		}
+		err := client.BatchV1().Jobs(namespace).Delete(ctx, job.Name, metaV1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+func getJobStatus(job batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return JobStatusSucceeded
+		case batchv1.JobFailed:
+			return JobStatusFailed
+		}
+	}
+	return JobStatusRunning
+}
@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+var ErrBackupFailed = errors.New("backup failed")
+
+// RunMongoBackups starts a job from each of the mongo backup cronjobs in the namespace matching the selector,
+// the same as "kubectl create job --from=cronjob/...", and returns the names of the jobs
+func RunMongoBackups(ctx context.Context, client kubernetes.Interface, namespace string, labelSelector string) ([]string, error) {
+	selector := "infrastructure=Mongo"
+	if labelSelector != "" {
+		selector = fmt.Sprintf("%s,%s", selector, labelSelector)
+	}
+
+	crons, err := client.BatchV1beta1().CronJobs(namespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(crons.Items))
+	suffix := time.Now().UTC().Unix()
+	for _, cron := range crons.Items {
+		annotations := map[string]string{
+			"cronjob.kubernetes.io/instantiate": "manual",
+		}
+		for key, value := range cron.Spec.JobTemplate.Annotations {
+			annotations[key] = value
+		}
+
+		job := &batchv1.Job{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        fmt.Sprintf("%s-manual-%d", cron.Name, suffix),
+				Namespace:   namespace,
+				Labels:      cron.Spec.JobTemplate.Labels,
+				Annotations: annotations,
+				OwnerReferences: []metaV1.OwnerReference{
+					*metaV1.NewControllerRef(&cron, batchv1.SchemeGroupVersion.WithKind("CronJob")),
+				},
+			},
+			Spec: cron.Spec.JobTemplate.Spec,
+		}
+		// CronJobs are still batch/v1beta1 in the cluster
+		job.OwnerReferences[0].APIVersion = "batch/v1beta1"
+
+		created, err := client.BatchV1().Jobs(namespace).Create(ctx, job, metaV1.CreateOptions{})
+		if err != nil {
+			return names, err
+		}
+		names = append(names, created.Name)
+	}
+	return names, nil
+}
+
+// WaitForJobs polls until every job has completed, returning ErrBackupFailed as soon as one of them fails.
+// The context decides how long to wait
+func WaitForJobs(ctx context.Context, client kubernetes.Interface, namespace string, names []string, interval time.Duration) error {
+	pending := map[string]bool{}
+	for _, name := range names {
+		pending[name] = true
+	}
+
+	return wait.PollImmediateUntil(interval, func() (bool, error) {
+		for name := range pending {
+			job, err := client.BatchV1().Jobs(namespace).Get(ctx, name, metaV1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			for _, condition := range job.Status.Conditions {
+				if condition.Status != corev1.ConditionTrue {
+					continue
+				}
+				switch condition.Type {
+				case batchv1.JobComplete:
+					delete(pending, name)
+				case batchv1.JobFailed:
+					return false, fmt.Errorf("%w: %s: %s", ErrBackupFailed, name, condition.Message)
+				}
+			}
+		}
+		return len(pending) == 0, nil
+	}, ctx.Done())
+}
@@ -0,0 +1,52 @@
+package backup_test
+
+import (
+	"context"
+
+	mockStorage "github.com/dolittle/platform-api/mocks/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Picking the backup store of a customer", func() {
+	var (
+		studioConfigs *mockStorage.Repo
+		clientSet     *fake.Clientset
+		resolve       backup.StoreResolver
+		namespace     string
+	)
+
+	BeforeEach(func() {
+		namespace = "application-fake-application-123"
+		studioConfigs = &mockStorage.Repo{}
+		clientSet = fake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "storage-account-secret", Namespace: namespace},
+			Data: map[string][]byte{
+				"azurestorageaccountname": []byte("account"),
+				"azurestorageaccountkey":  []byte("a2V5"),
+			},
+		})
+
+		resolve = backup.NewStoreResolver(clientSet, studioConfigs)
+	})
+
+	It("should use Azure Files when the customer has not chosen", func() {
+		studioConfigs.On("GetStudioConfig", "fake-customer-123").Return(platform.StudioConfig{}, nil)
+
+		store, err := resolve(context.Background(), "fake-customer-123", namespace)
+		Expect(err).To(BeNil())
+		Expect(store).To(Equal(backup.NewAzureFilesStore("account", "a2V5")))
+	})
+
+	It("should fail when the customer has chosen a store that is not supported", func() {
+		studioConfigs.On("GetStudioConfig", "fake-customer-123").Return(platform.StudioConfig{BackupStore: "s3"}, nil)
+
+		_, err := resolve(context.Background(), "fake-customer-123", namespace)
+		Expect(err).To(MatchError(backup.ErrUnknownStore))
+	})
+})
@@ -0,0 +1,199 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RestoreStepSafetyBackup backs up the target of the restore before anything is dropped
+	RestoreStepSafetyBackup = "mongo-safety-backup"
+	// RestoreStepRestore restores the backup into the target
+	RestoreStepRestore = "mongo-restore"
+	// RestoreFromEnvironmentAnnotation is the environment the backup of a restore was taken in
+	RestoreFromEnvironmentAnnotation = "dolittle.io/restore-from-environment"
+
+	RestoreStepStatusWaiting   = "waiting"
+	RestoreStepStatusRunning   = "running"
+	RestoreStepStatusSucceeded = "succeeded"
+	RestoreStepStatusFailed    = "failed"
+
+	safetyBackupVolumePrefix = "target-"
+)
+
+// ErrRestoreIntoProduction is returned when restoring into Prod without force
+var ErrRestoreIntoProduction = errors.New("restoring into Prod drops its collections, use force to restore anyway")
+
+// CanRestore stops restores into Prod unless forced
+func CanRestore(targetEnvironment string, force bool) error {
+	if strings.EqualFold(targetEnvironment, "prod") && !force {
+		return ErrRestoreIntoProduction
+	}
+	return nil
+}
+
+// NewMongoRestoreCommand restores the archive from the backup directory, replacing the collections that are in it
+func NewMongoRestoreCommand(mongoHost string, name string) string {
+	return fmt.Sprintf(`mongorestore --host=%s --gzip --archive=/mnt/backup/%s --drop`, mongoHost, name)
+}
+
+// NewMongoRestoreJob builds a job that reads the backup from the share of the source cronjob,
+// and restores it into the mongo of the target environment with the targets mongo image.
+// The target is backed up by an init container first, the restore only runs when that backup succeeded
+func NewMongoRestoreJob(source v1beta1.CronJob, target v1beta1.CronJob, targetEnvironment string, name string) (*batchv1.Job, error) {
+	if !IsBackupNameValid(name) {
+		return nil, ErrInvalidBackupName
+	}
+
+	mongoHost := fmt.Sprintf("%s-mongo.%s.svc.cluster.local:27017", strings.ToLower(targetEnvironment), target.Namespace)
+	job, err := newMongoBackupJob(source, target, JobKindRestore, name, NewMongoRestoreCommand(mongoHost, name))
+	if err != nil {
+		return nil, err
+	}
+
+	safetyBackup, volumes := newSafetyBackupContainer(target)
+	job.Spec.Template.Spec.InitContainers = append(job.Spec.Template.Spec.InitContainers, safetyBackup)
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, volumes...)
+	job.Spec.ActiveDeadlineSeconds = &longRunningActiveDeadlineSeconds
+	job.Annotations[RestoreFromEnvironmentAnnotation] = source.Labels["environment"]
+	return job, nil
+}
+
+// newSafetyBackupContainer is the backup container of the target cronjob, with the volumes it mounts renamed
+// so they do not clash with the share of the source
+func newSafetyBackupContainer(target v1beta1.CronJob) (corev1.Container, []corev1.Volume) {
+	pod := target.Spec.JobTemplate.Spec.Template.Spec
+	container := *pod.Containers[0].DeepCopy()
+	container.Name = RestoreStepSafetyBackup
+	container.Ports = nil
+
+	mounted := map[string]bool{}
+	for index := range container.VolumeMounts {
+		mount := &container.VolumeMounts[index]
+		mounted[mount.Name] = true
+		mount.Name = safetyBackupVolumePrefix + mount.Name
+	}
+
+	volumes := []corev1.Volume{}
+	for _, volume := range pod.Volumes {
+		if !mounted[volume.Name] {
+			continue
+		}
+		renamed := *volume.DeepCopy()
+		renamed.Name = safetyBackupVolumePrefix + volume.Name
+		volumes = append(volumes, renamed)
+	}
+	return container, volumes
+}
+
+// RunMongoRestore starts a job restoring the backup taken in the source environment into the target environment,
+// and returns the name of the job.
+// It returns ErrBackupJobRunning while a restore or prune of the target, or a prune of the source, is still running
+func RunMongoRestore(ctx context.Context, client kubernetes.Interface, namespace string, sourceEnvironment string, targetEnvironment string, name string) (string, error) {
+	err := checkNoRunningJobs(ctx, client, namespace, targetEnvironment, JobKindRestore, JobKindPrune)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkNoRunningJobs(ctx, client, namespace, sourceEnvironment, JobKindPrune)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := getMongoBackupCronJob(ctx, client, namespace, sourceEnvironment)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := getMongoBackupCronJob(ctx, client, namespace, targetEnvironment)
+	if err != nil {
+		return "", err
+	}
+
+	job, err := NewMongoRestoreJob(source, target, targetEnvironment, name)
+	if err != nil {
+		return "", err
+	}
+
+	created, err := client.BatchV1().Jobs(namespace).Create(ctx, job, metaV1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return created.Name, nil
+}
+
+// GetRestores lists the restores into the environment, the latest first, with how far each of them has got
+func GetRestores(ctx context.Context, client kubernetes.Interface, namespace string, environment string) ([]Restore, error) {
+	jobs, err := getJobsOfKind(ctx, client, namespace, environment, JobKindRestore)
+	if err != nil {
+		return nil, err
+	}
+
+	restores := make([]Restore, 0, len(jobs))
+	for _, job := range jobs {
+		// The job is not retried, so it has at most one pod
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metaV1.ListOptions{
+			LabelSelector: fmt.Sprintf("job-name=%s", job.Name),
+		})
+		if err != nil {
+			return nil, err
+		}
+		restores = append(restores, NewRestore(job, pods.Items))
+	}
+	return restores, nil
+}
+
+// NewRestore describes the restore job, the steps come from the first of its pods
+func NewRestore(job batchv1.Job, pods []corev1.Pod) Restore {
+	restore := Restore{
+		Job:             job.Name,
+		Name:            job.Annotations[BackupNameAnnotation],
+		FromEnvironment: job.Annotations[RestoreFromEnvironmentAnnotation],
+		Status:          getJobStatus(job),
+		Steps: []RestoreStep{
+			{Name: RestoreStepSafetyBackup, Status: RestoreStepStatusWaiting},
+			{Name: RestoreStepRestore, Status: RestoreStepStatusWaiting},
+		},
+	}
+	if job.Status.StartTime != nil {
+		restore.StartedAt = &job.Status.StartTime.Time
+	}
+	restore.FinishedAt, restore.Message = jobK8s.GetJobFinished(job)
+
+	if len(pods) == 0 {
+		return restore
+	}
+
+	pod := pods[0]
+	containers := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for index := range restore.Steps {
+		step := &restore.Steps[index]
+		for _, container := range containers {
+			if container.Name != step.Name {
+				continue
+			}
+			switch {
+			case container.State.Running != nil:
+				step.Status = RestoreStepStatusRunning
+			case container.State.Terminated != nil && container.State.Terminated.ExitCode == 0:
+				step.Status = RestoreStepStatusSucceeded
+			case container.State.Terminated != nil:
+				step.Status = RestoreStepStatusFailed
+				step.Message = container.State.Terminated.Message
+				if step.Message == "" {
+					step.Message = container.State.Terminated.Reason
+				}
+			}
+		}
+	}
+	return restore
+}
@@ -0,0 +1,164 @@
+package backup_test
+
+import (
+	"context"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Restoring a backup", func() {
+	var (
+		namespace string
+		clientSet *fake.Clientset
+	)
+
+	BeforeEach(func() {
+		customer := dolittleK8s.Tenant{ID: "fake-customer-123", Name: "fake-customer"}
+		application := dolittleK8s.Application{ID: "fake-application-123", Name: "fake-application"}
+		namespace = "application-fake-application-123"
+
+		dev := k8s.NewMongo("Dev", customer, application, k8s.MongoSettings{
+			ShareName:       "fake-application-dev-backup",
+			CronJobSchedule: "5 * * * *",
+			VolumeSize:      "8Gi",
+		})
+		test := k8s.NewMongo("Test", customer, application, k8s.MongoSettings{
+			ShareName:       "fake-application-test-backup",
+			CronJobSchedule: "5 * * * *",
+			VolumeSize:      "8Gi",
+			Version:         "5.0.3",
+		})
+		clientSet = fake.NewSimpleClientset(dev.Cronjob, test.Cronjob)
+	})
+
+	It("should restore from the share of the source into the mongo of the target", func() {
+		name, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+		Expect(err).To(BeNil())
+
+		job, err := clientSet.BatchV1().Jobs(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		Expect(job.Name).To(HavePrefix("test-mongo-restore-"))
+		Expect(job.Labels["environment"]).To(Equal("Test"))
+		Expect(*job.Spec.BackoffLimit).To(Equal(int32(0)))
+
+		pod := job.Spec.Template.Spec
+		Expect(pod.Volumes[0].AzureFile.ShareName).To(Equal("fake-application-dev-backup"))
+		Expect(pod.Containers[0].Image).To(Equal(k8s.MongoImage("5.0.3")))
+		Expect(pod.Containers[0].Name).To(Equal(backup.RestoreStepRestore))
+		Expect(pod.Containers[0].Args).To(Equal([]string{
+			"mongorestore --host=test-mongo.application-fake-application-123.svc.cluster.local:27017 --gzip --archive=/mnt/backup/fake-application-dev-2021-10-03_01-05-00.gz.mongodump --drop",
+		}))
+	})
+
+	It("should back up the target to its own share before restoring", func() {
+		name, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+		Expect(err).To(BeNil())
+
+		job, err := clientSet.BatchV1().Jobs(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+
+		pod := job.Spec.Template.Spec
+		Expect(pod.InitContainers).To(HaveLen(1))
+		safetyBackup := pod.InitContainers[0]
+		Expect(safetyBackup.Name).To(Equal(backup.RestoreStepSafetyBackup))
+		Expect(safetyBackup.Args[0]).To(HavePrefix("mongodump --host=test-mongo.application-fake-application-123.svc.cluster.local:27017"))
+		Expect(safetyBackup.VolumeMounts[0].Name).To(Equal("target-backup-storage"))
+
+		Expect(pod.Volumes).To(HaveLen(2))
+		Expect(pod.Volumes[0].AzureFile.ShareName).To(Equal("fake-application-dev-backup"))
+		Expect(pod.Volumes[1].Name).To(Equal("target-backup-storage"))
+		Expect(pod.Volumes[1].AzureFile.ShareName).To(Equal("fake-application-test-backup"))
+	})
+
+	It("should fail when the target has no mongo", func() {
+		_, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Prod", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+		Expect(err).To(MatchError(backup.ErrNoBackupCronJob))
+	})
+
+	It("should not allow names that are not archives", func() {
+		_, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Dev", "../../etc/passwd")
+		Expect(err).To(MatchError(backup.ErrInvalidBackupName))
+	})
+
+	It("should not start a restore while another restore into the target is running", func() {
+		_, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+		Expect(err).To(BeNil())
+
+		_, err = backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+		Expect(err).To(MatchError(backup.ErrBackupJobRunning))
+	})
+
+	It("should give restores started in the same second their own jobs", func() {
+		first, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+		Expect(err).To(BeNil())
+
+		job, err := clientSet.BatchV1().Jobs(namespace).Get(context.Background(), first, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		_, err = clientSet.BatchV1().Jobs(namespace).UpdateStatus(context.Background(), job, metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+
+		second, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+		Expect(err).To(BeNil())
+		Expect(second).ToNot(Equal(first))
+	})
+
+	It("should only restore into Prod when forced", func() {
+		Expect(backup.CanRestore("Prod", false)).To(MatchError(backup.ErrRestoreIntoProduction))
+		Expect(backup.CanRestore("Prod", true)).To(BeNil())
+		Expect(backup.CanRestore("Test", false)).To(BeNil())
+	})
+
+	Describe("listing the restores", func() {
+		var name string
+
+		BeforeEach(func() {
+			var err error
+			name, err = backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-03_01-05-00.gz.mongodump")
+			Expect(err).To(BeNil())
+		})
+
+		It("should wait on both steps before the pod starts", func() {
+			restores, err := backup.GetRestores(context.Background(), clientSet, namespace, "Test")
+			Expect(err).To(BeNil())
+			Expect(restores).To(HaveLen(1))
+			Expect(restores[0].Job).To(Equal(name))
+			Expect(restores[0].Name).To(Equal("fake-application-dev-2021-10-03_01-05-00.gz.mongodump"))
+			Expect(restores[0].FromEnvironment).To(Equal("Dev"))
+			Expect(restores[0].Status).To(Equal(backup.JobStatusRunning))
+			Expect(restores[0].Steps).To(Equal([]backup.RestoreStep{
+				{Name: backup.RestoreStepSafetyBackup, Status: backup.RestoreStepStatusWaiting},
+				{Name: backup.RestoreStepRestore, Status: backup.RestoreStepStatusWaiting},
+			}))
+		})
+
+		It("should not restore when the safety backup failed", func() {
+			job, err := clientSet.BatchV1().Jobs(namespace).Get(context.Background(), name, metav1.GetOptions{})
+			Expect(err).To(BeNil())
+
+			restore := backup.NewRestore(*job, []corev1.Pod{{
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{{
+						Name: backup.RestoreStepSafetyBackup,
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 1,
+							Reason:   "Error",
+						}},
+					}},
+				},
+			}})
+			Expect(restore.Steps).To(Equal([]backup.RestoreStep{
+				{Name: backup.RestoreStepSafetyBackup, Status: backup.RestoreStepStatusFailed, Message: "Error"},
+				{Name: backup.RestoreStepRestore, Status: backup.RestoreStepStatusWaiting},
+			}))
+		})
+	})
+})
@@ -0,0 +1,216 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrInvalidRetention     = errors.New("backup retention is not valid")
+	ErrConflictingRetention = errors.New("backupRetentionDays in the mongo settings removes backups by age, set it to 0 to use a backup retention")
+)
+
+// Keeps the job and its annotations within what kubernetes allows, the rest are pruned the next time
+const maxBackupsPerPrune = 500
+
+// ValidateRetention checks nothing is negative and that something is kept
+func ValidateRetention(retention storage.JSONBackupRetention) error {
+	if retention.Daily < 0 || retention.Weekly < 0 || retention.Monthly < 0 {
+		return fmt.Errorf("%w: daily, weekly and monthly can not be negative", ErrInvalidRetention)
+	}
+	if retention.Daily+retention.Weekly+retention.Monthly == 0 {
+		return fmt.Errorf("%w: at least one of daily, weekly or monthly has to keep a backup", ErrInvalidRetention)
+	}
+	return nil
+}
+
+// CheckRetentionConflict stops an environment from both removing backups by age after every backup and having a retention,
+// as removing by age would also remove the weekly and monthly backups the retention keeps
+func CheckRetentionConflict(mongo storage.JSONEnvironmentMongo) error {
+	if mongo.BackupRetentionDays > 0 && mongo.BackupRetention != nil {
+		return ErrConflictingRetention
+	}
+	return nil
+}
+
+// SelectBackupsToPrune keeps the latest backup of each of the last days, weeks and months that have backups,
+// as many of each as the retention says, and returns the rest. Weeks are ISO weeks, all in UTC.
+// The latest backup is always kept
+func SelectBackupsToPrune(backups []Backup, retention storage.JSONBackupRetention) []Backup {
+	sorted := make([]Backup, len(backups))
+	copy(sorted, backups)
+	SortNewestFirst(sorted)
+
+	type bucket struct {
+		limit int
+		seen  map[string]bool
+		key   func(backup Backup) string
+	}
+	buckets := []bucket{
+		{retention.Daily, map[string]bool{}, func(backup Backup) string {
+			return backup.CreatedAt.UTC().Format("2006-01-02")
+		}},
+		{retention.Weekly, map[string]bool{}, func(backup Backup) string {
+			year, week := backup.CreatedAt.UTC().ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{retention.Monthly, map[string]bool{}, func(backup Backup) string {
+			return backup.CreatedAt.UTC().Format("2006-01")
+		}},
+	}
+
+	prune := make([]Backup, 0)
+	for index, backup := range sorted {
+		keep := index == 0
+		for _, bucket := range buckets {
+			key := bucket.key(backup)
+			if bucket.seen[key] || len(bucket.seen) >= bucket.limit {
+				continue
+			}
+			bucket.seen[key] = true
+			keep = true
+		}
+
+		if !keep {
+			prune = append(prune, backup)
+		}
+	}
+	return prune
+}
+
+// NewMongoPruneCommand removes the archives from the backup directory
+func NewMongoPruneCommand(names []string) string {
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, "/mnt/backup/"+name)
+	}
+	return fmt.Sprintf("rm -fv -- %s", strings.Join(paths, " "))
+}
+
+// RunMongoPrune starts a job removing the backups of the environment, and returns the name of the job.
+// The finished prune jobs from before, except the latest, are removed.
+// It returns ErrBackupJobRunning while a restore or prune of the environment is still running
+func RunMongoPrune(ctx context.Context, client kubernetes.Interface, namespace string, environment string, names []string) (string, error) {
+	if len(names) == 0 {
+		return "", fmt.Errorf("%w: nothing to prune", ErrInvalidBackupName)
+	}
+	for _, name := range names {
+		if !IsBackupNameValid(name) {
+			return "", fmt.Errorf("%w: %s", ErrInvalidBackupName, name)
+		}
+	}
+
+	err := checkNoRunningJobs(ctx, client, namespace, environment, JobKindRestore, JobKindPrune)
+	if err != nil {
+		return "", err
+	}
+
+	cron, err := getMongoBackupCronJob(ctx, client, namespace, environment)
+	if err != nil {
+		return "", err
+	}
+
+	err = removeFinishedJobs(ctx, client, namespace, environment, JobKindPrune)
+	if err != nil {
+		return "", err
+	}
+
+	job, err := newMongoBackupJob(cron, cron, JobKindPrune, strings.Join(names, ","), NewMongoPruneCommand(names))
+	if err != nil {
+		return "", err
+	}
+
+	created, err := client.BatchV1().Jobs(namespace).Create(ctx, job, metaV1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return created.Name, nil
+}
+
+// PruneEnvironment removes the backups of the environment the retention does not keep, at most 500 at a time.
+// Backups a running restore or verification is reading are left for the next prune.
+// It returns the name of the job and what it removes, without a job when there is nothing to remove
+func PruneEnvironment(ctx context.Context, client kubernetes.Interface, store BackupStore, namespace string, environment string, retention storage.JSONBackupRetention) (string, []Backup, error) {
+	err := ValidateRetention(retention)
+	if err != nil {
+		return "", nil, err
+	}
+
+	backups, err := listEnvironmentBackups(ctx, client, store, namespace, environment)
+	if err != nil {
+		return "", nil, err
+	}
+
+	inUse, err := getBackupsInUse(ctx, client, namespace, environment)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pruned := make([]Backup, 0)
+	for _, backup := range SelectBackupsToPrune(backups, retention) {
+		// The next prune gets it when the job reading it has finished
+		if !inUse[backup.Name] {
+			pruned = append(pruned, backup)
+		}
+	}
+	if len(pruned) == 0 {
+		return "", pruned, nil
+	}
+	if len(pruned) > maxBackupsPerPrune {
+		// The oldest first
+		pruned = pruned[len(pruned)-maxBackupsPerPrune:]
+	}
+
+	names := make([]string, 0, len(pruned))
+	for _, backup := range pruned {
+		names = append(names, backup.Name)
+	}
+	job, err := RunMongoPrune(ctx, client, namespace, environment, names)
+	return job, pruned, err
+}
+
+// getBackupsInUse are the backups of the environment that running restores, into any environment, and verifications are reading
+func getBackupsInUse(ctx context.Context, client kubernetes.Interface, namespace string, environment string) (map[string]bool, error) {
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: fmt.Sprintf("infrastructure=Mongo,%s in (%s,%s)", JobKindLabel, JobKindRestore, JobKindVerify),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := map[string]bool{}
+	for _, job := range jobs.Items {
+		if jobK8s.IsJobFinished(job) {
+			continue
+		}
+
+		from := job.Labels["environment"]
+		if job.Labels[JobKindLabel] == JobKindRestore {
+			from = job.Annotations[RestoreFromEnvironmentAnnotation]
+		}
+		if strings.EqualFold(from, environment) {
+			inUse[job.Annotations[BackupNameAnnotation]] = true
+		}
+	}
+	return inUse, nil
+}
+
+func listEnvironmentBackups(ctx context.Context, client kubernetes.Interface, store BackupStore, namespace string, environment string) ([]Backup, error) {
+	cron, err := getMongoBackupCronJob(ctx, client, namespace, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	shareName, err := getShareNameFromCronJob(cron)
+	if err != nil {
+		return nil, err
+	}
+	return store.List(ctx, shareName)
+}
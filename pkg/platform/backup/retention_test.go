@@ -0,0 +1,161 @@
+package backup_test
+
+import (
+	"context"
+	"time"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func backupAt(createdAt time.Time) backup.Backup {
+	return backup.Backup{
+		Name:      "app-dev-" + createdAt.Format("2006-01-02_15-04-05") + ".gz.mongodump",
+		CreatedAt: createdAt,
+	}
+}
+
+func backupNames(backups []backup.Backup) []string {
+	names := make([]string, 0, len(backups))
+	for _, item := range backups {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+var _ = Describe("Backup retention", func() {
+	// Every 12 hours from Monday 2021-09-06 to Sunday 2021-10-10
+	backups := make([]backup.Backup, 0)
+	for at := time.Date(2021, 9, 6, 0, 0, 0, 0, time.UTC); at.Before(time.Date(2021, 10, 11, 0, 0, 0, 0, time.UTC)); at = at.Add(12 * time.Hour) {
+		backups = append(backups, backupAt(at))
+	}
+	kept := func(retention storage.JSONBackupRetention) []string {
+		pruned := map[string]bool{}
+		for _, name := range backupNames(backup.SelectBackupsToPrune(backups, retention)) {
+			pruned[name] = true
+		}
+		names := make([]string, 0)
+		for _, name := range backupNames(backups) {
+			if !pruned[name] {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	It("should keep the last backup of each of the latest days", func() {
+		Expect(kept(storage.JSONBackupRetention{Daily: 3})).To(Equal([]string{
+			"app-dev-2021-10-08_12-00-00.gz.mongodump",
+			"app-dev-2021-10-09_12-00-00.gz.mongodump",
+			"app-dev-2021-10-10_12-00-00.gz.mongodump",
+		}))
+	})
+
+	It("should keep the last backup of each of the latest weeks and months", func() {
+		Expect(kept(storage.JSONBackupRetention{Weekly: 2, Monthly: 2})).To(Equal([]string{
+			"app-dev-2021-09-30_12-00-00.gz.mongodump",
+			"app-dev-2021-10-03_12-00-00.gz.mongodump",
+			"app-dev-2021-10-10_12-00-00.gz.mongodump",
+		}))
+	})
+
+	It("should keep what any of them keeps", func() {
+		Expect(kept(storage.JSONBackupRetention{Daily: 2, Weekly: 2})).To(Equal([]string{
+			"app-dev-2021-10-03_12-00-00.gz.mongodump",
+			"app-dev-2021-10-09_12-00-00.gz.mongodump",
+			"app-dev-2021-10-10_12-00-00.gz.mongodump",
+		}))
+	})
+
+	It("should not allow keeping nothing", func() {
+		Expect(backup.ValidateRetention(storage.JSONBackupRetention{})).To(MatchError(backup.ErrInvalidRetention))
+		Expect(backup.ValidateRetention(storage.JSONBackupRetention{Daily: 7, Weekly: -1})).To(MatchError(backup.ErrInvalidRetention))
+		Expect(backup.ValidateRetention(storage.JSONBackupRetention{Monthly: 12})).To(BeNil())
+	})
+
+	It("should not remove backups by age and by retention", func() {
+		retention := &storage.JSONBackupRetention{Daily: 7, Monthly: 12}
+		Expect(backup.CheckRetentionConflict(storage.JSONEnvironmentMongo{BackupRetentionDays: 30, BackupRetention: retention})).To(MatchError(backup.ErrConflictingRetention))
+		Expect(backup.CheckRetentionConflict(storage.JSONEnvironmentMongo{BackupRetention: retention})).To(BeNil())
+		Expect(backup.CheckRetentionConflict(storage.JSONEnvironmentMongo{BackupRetentionDays: 30})).To(BeNil())
+	})
+
+	Describe("pruning an environment", func() {
+		var (
+			store     backup.BackupStore
+			namespace string
+			clientSet *fake.Clientset
+		)
+
+		BeforeEach(func() {
+			store = fakeStore{"fake-application-dev-backup": {
+				"fake-application-dev-2021-10-01_01-05-00.gz.mongodump",
+				"fake-application-dev-2021-10-02_01-05-00.gz.mongodump",
+				"fake-application-dev-2021-10-02_02-05-00.gz.mongodump",
+			}}
+
+			namespace = "application-fake-application-123"
+			mongo := k8s.NewMongo("Dev",
+				dolittleK8s.Tenant{ID: "fake-customer-123", Name: "fake-customer"},
+				dolittleK8s.Application{ID: "fake-application-123", Name: "fake-application"},
+				k8s.MongoSettings{ShareName: "fake-application-dev-backup", CronJobSchedule: "5 * * * *", VolumeSize: "8Gi"},
+			)
+			clientSet = fake.NewSimpleClientset(mongo.Cronjob)
+		})
+
+		It("should start a job removing what is not kept", func() {
+			job, pruned, err := backup.PruneEnvironment(context.Background(), clientSet, store, namespace, "Dev", storage.JSONBackupRetention{Daily: 1})
+			Expect(err).To(BeNil())
+			Expect(backupNames(pruned)).To(Equal([]string{
+				"fake-application-dev-2021-10-02_01-05-00.gz.mongodump",
+				"fake-application-dev-2021-10-01_01-05-00.gz.mongodump",
+			}))
+
+			created, err := clientSet.BatchV1().Jobs(namespace).Get(context.Background(), job, metav1.GetOptions{})
+			Expect(err).To(BeNil())
+			Expect(created.Labels[backup.JobKindLabel]).To(Equal(backup.JobKindPrune))
+			Expect(created.Spec.Template.Spec.Containers[0].Args).To(Equal([]string{
+				"rm -fv -- /mnt/backup/fake-application-dev-2021-10-02_01-05-00.gz.mongodump /mnt/backup/fake-application-dev-2021-10-01_01-05-00.gz.mongodump",
+			}))
+		})
+
+		It("should not start a job while a restore into the environment is running", func() {
+			_, err := backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Dev", "fake-application-dev-2021-10-02_02-05-00.gz.mongodump")
+			Expect(err).To(BeNil())
+
+			_, _, err = backup.PruneEnvironment(context.Background(), clientSet, store, namespace, "Dev", storage.JSONBackupRetention{Daily: 1})
+			Expect(err).To(MatchError(backup.ErrBackupJobRunning))
+		})
+
+		It("should leave the backups a running restore is reading for the next prune", func() {
+			test := k8s.NewMongo("Test",
+				dolittleK8s.Tenant{ID: "fake-customer-123", Name: "fake-customer"},
+				dolittleK8s.Application{ID: "fake-application-123", Name: "fake-application"},
+				k8s.MongoSettings{ShareName: "fake-application-test-backup", CronJobSchedule: "5 * * * *", VolumeSize: "8Gi"},
+			)
+			_, err := clientSet.BatchV1beta1().CronJobs(namespace).Create(context.Background(), test.Cronjob, metav1.CreateOptions{})
+			Expect(err).To(BeNil())
+			_, err = backup.RunMongoRestore(context.Background(), clientSet, namespace, "Dev", "Test", "fake-application-dev-2021-10-01_01-05-00.gz.mongodump")
+			Expect(err).To(BeNil())
+
+			_, pruned, err := backup.PruneEnvironment(context.Background(), clientSet, store, namespace, "Dev", storage.JSONBackupRetention{Daily: 1})
+			Expect(err).To(BeNil())
+			Expect(backupNames(pruned)).To(Equal([]string{
+				"fake-application-dev-2021-10-02_01-05-00.gz.mongodump",
+			}))
+		})
+
+		It("should not start a job when there is nothing to prune", func() {
+			job, pruned, err := backup.PruneEnvironment(context.Background(), clientSet, fakeStore{}, namespace, "Dev", storage.JSONBackupRetention{Daily: 7})
+			Expect(err).To(BeNil())
+			Expect(job).To(BeEmpty())
+			Expect(pruned).To(BeEmpty())
+		})
+	})
+})
@@ -5,11 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	azureHelpers "github.com/dolittle/platform-api/pkg/azure"
 	"github.com/dolittle/platform-api/pkg/platform"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/dolittle/platform-api/pkg/platform/storage"
@@ -20,12 +21,20 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func NewService(logContext logrus.FieldLogger, gitRepo storage.Repo, k8sDolittleRepo platformK8s.K8sRepo, k8sClient kubernetes.Interface) service {
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// How many backups GetLatestByApplication returns
+	latestCount = 20
+)
+
+func NewService(logContext logrus.FieldLogger, gitRepo storage.Repo, k8sDolittleRepo platformK8s.K8sRepo, k8sClient kubernetes.Interface, getStore StoreResolver) service {
 	return service{
 		logContext:      logContext,
 		gitRepo:         gitRepo,
 		k8sDolittleRepo: k8sDolittleRepo,
 		k8sClient:       k8sClient,
+		getStore:        getStore,
 	}
 }
 
@@ -63,17 +72,13 @@ func (s *service) GetLatestByApplication(w http.ResponseWriter, r *http.Request)
 
 	namespace := fmt.Sprintf("application-%s", applicationID)
 
-	azureStorageInfo, err := getStorageAccountInfo(r.Context(), namespace, s.k8sClient)
+	store, err := s.getStore(ctx, customerID, namespace)
 	if err != nil {
-		logContext.WithFields(logrus.Fields{
-			"error": err,
-			"where": "getStorageAccountInfo",
-		}).Error("lookup error")
-		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		respondWithStoreError(w, logContext, err)
 		return
 	}
 
-	azureShareName, err := getShareName(ctx, namespace, s.k8sClient, metaV1.ListOptions{
+	shareName, err := getShareName(ctx, namespace, s.k8sClient, metaV1.ListOptions{
 		LabelSelector: fmt.Sprintf("environment=%s,infrastructure=Mongo", environment),
 	})
 
@@ -86,23 +91,28 @@ func (s *service) GetLatestByApplication(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	latest, err := azureHelpers.LatestX(azureStorageInfo.Name, azureStorageInfo.Key, azureShareName)
+	backups, err := store.List(ctx, shareName)
 	if err != nil {
 		logContext.WithFields(logrus.Fields{
 			"error": err,
-			"where": "azureHelpers.LatestX(azureStorageInfo.Name, azureStorageInfo.Key, azureShareName)",
+			"where": "store.List(ctx, shareName)",
 		}).Error("lookup error")
 		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
 		return
 	}
 
+	files := make([]string, 0, latestCount)
+	for _, backup := range Paginate(backups, 1, latestCount) {
+		files = append(files, backup.Path)
+	}
+
 	utils.RespondWithJSON(w, http.StatusOK, HTTPDownloadLogsLatestResponse{
 		Application: platform.ShortInfo{
 			ID:   applicationInfo.ID,
 			Name: applicationInfo.Name,
 		},
 		Environment: environment,
-		Files:       latest.Files,
+		Files:       files,
 	})
 }
 
@@ -144,17 +154,13 @@ func (s *service) CreateLink(w http.ResponseWriter, r *http.Request) {
 
 	// Create link
 	namespace := fmt.Sprintf("application-%s", input.ApplicationID)
-	azureStorageInfo, err := getStorageAccountInfo(r.Context(), namespace, s.k8sClient)
+	store, err := s.getStore(ctx, customerID, namespace)
 	if err != nil {
-		logContext.WithFields(logrus.Fields{
-			"error": err,
-			"where": "getStorageAccountInfo",
-		}).Error("lookup error")
-		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		respondWithStoreError(w, logContext, err)
 		return
 	}
 
-	azureShareName, err := getShareName(ctx, namespace, s.k8sClient, metaV1.ListOptions{
+	shareName, err := getShareName(ctx, namespace, s.k8sClient, metaV1.ListOptions{
 		LabelSelector: fmt.Sprintf("environment=%s,infrastructure=Mongo", input.Environment),
 	})
 
@@ -167,25 +173,19 @@ func (s *service) CreateLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	checkShareName := fmt.Sprintf("/%s/mongo", azureShareName)
-	if !strings.HasPrefix(input.FilePath, checkShareName) {
+	fileShareName, fileName, err := ParseBackupPath(input.FilePath)
+	if err != nil || fileShareName != shareName {
 		utils.RespondWithError(w, http.StatusUnprocessableEntity, "Not valid for this application")
 		return
 	}
 
-	// Cleanup path
-	filePath := input.FilePath
-	filePath = strings.TrimLeft(filePath, "/")
-	filePath = strings.TrimLeft(filePath, azureShareName)
-	filePath = strings.TrimLeft(filePath, "/")
-
 	expires := time.Now().UTC().Add(48 * time.Hour)
 
-	url, err := azureHelpers.CreateLink(azureStorageInfo.Name, azureStorageInfo.Key, azureShareName, filePath, expires)
+	url, err := store.CreateLink(ctx, shareName, fileName, expires)
 	if err != nil {
 		logContext.WithFields(logrus.Fields{
 			"error": err,
-			"where": "azureHelpers.CreateLink",
+			"where": "store.CreateLink",
 		}).Error("lookup error")
 		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
 		return
@@ -201,6 +201,313 @@ func (s *service) CreateLink(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetAll lists the mongo backups of the environment, the latest first, with their size and when they were taken
+func (s *service) GetAll(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	page, pageSize, err := getPage(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "GetAll",
+		"customer_id":    application.CustomerID,
+		"application_id": application.ID,
+		"environment":    environment,
+	})
+
+	backups, ok := s.listBackups(w, r, logContext, application.CustomerID, application.ID, environment)
+	if !ok {
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, HTTPResponseBackups{
+		ApplicationID: application.ID,
+		Environment:   environment,
+		Page:          page,
+		PageSize:      pageSize,
+		Total:         len(backups),
+		Backups:       Paginate(backups, page, pageSize),
+	})
+}
+
+// Create starts an on-demand backup of the environments mongo from its backup cronjob
+func (s *service) Create(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "Create",
+		"customer_id":    application.CustomerID,
+		"application_id": application.ID,
+		"environment":    environment,
+	})
+
+	namespace := fmt.Sprintf("application-%s", application.ID)
+	jobs, err := RunMongoBackups(r.Context(), s.k8sClient, namespace, fmt.Sprintf("environment=%s", platformK8s.ParseLabel(environment)))
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to start the backup")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to start the backup")
+		return
+	}
+
+	if len(jobs) == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("No mongo backup found for environment %s", environment))
+		return
+	}
+
+	logContext.WithField("jobs", jobs).Info("Backup started")
+	utils.RespondWithJSON(w, http.StatusAccepted, HTTPResponseBackupJobs{
+		ApplicationID: application.ID,
+		Environment:   environment,
+		Jobs:          jobs,
+	})
+}
+
+// Restore starts a job restoring a backup of the environment into the same or another environment of the application.
+// The collections in the backup are dropped in the target before they are restored, so the job backs up the target first.
+// Prod is only restored into with ?force=true, follow the job with GetRestores
+func (s *service) Restore(w http.ResponseWriter, r *http.Request) {
+	var input HTTPInputRestore
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	err = json.Unmarshal(b, &input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if !IsBackupNameValid(input.Name) {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, ErrInvalidBackupName.Error())
+		return
+	}
+
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	targetEnvironment := environment
+	if input.TargetEnvironment != "" {
+		found, exists := findEnvironment(application, input.TargetEnvironment)
+		if !exists {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Environment %s not found", input.TargetEnvironment))
+			return
+		}
+		targetEnvironment = found
+	}
+
+	force := r.URL.Query().Get("force") == "true"
+	if err := CanRestore(targetEnvironment, force); err != nil {
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":             "Restore",
+		"customer_id":        application.CustomerID,
+		"application_id":     application.ID,
+		"environment":        environment,
+		"target_environment": targetEnvironment,
+		"backup":             input.Name,
+		"force":              force,
+	})
+
+	backups, ok := s.listBackups(w, r, logContext, application.CustomerID, application.ID, environment)
+	if !ok {
+		return
+	}
+
+	if _, exists := findBackup(backups, input.Name); !exists {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Backup %s not found", input.Name))
+		return
+	}
+
+	namespace := fmt.Sprintf("application-%s", application.ID)
+	job, err := RunMongoRestore(r.Context(), s.k8sClient, namespace, environment, targetEnvironment, input.Name)
+	if err != nil {
+		if errors.Is(err, ErrNoBackupCronJob) {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrBackupJobRunning) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to start the restore")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to start the restore")
+		return
+	}
+
+	logContext.WithField("job", job).Info("Restore started")
+	utils.RespondWithJSON(w, http.StatusAccepted, HTTPResponseRestore{
+		ApplicationID:     application.ID,
+		Environment:       environment,
+		TargetEnvironment: targetEnvironment,
+		Name:              input.Name,
+		Job:               job,
+	})
+}
+
+// GetRestores lists the restores into the environment, with the state of the safety backup and the restore of each
+func (s *service) GetRestores(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	namespace := fmt.Sprintf("application-%s", application.ID)
+	restores, err := GetRestores(r.Context(), s.k8sClient, namespace, environment)
+	if err != nil {
+		s.logContext.WithFields(logrus.Fields{
+			"method":         "GetRestores",
+			"customer_id":    application.CustomerID,
+			"application_id": application.ID,
+			"environment":    environment,
+			"error":          err,
+		}).Error("Failed to get the restores")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, HTTPResponseRestores{
+		ApplicationID: application.ID,
+		Environment:   environment,
+		Restores:      restores,
+	})
+}
+
+func (s *service) listBackups(w http.ResponseWriter, r *http.Request, logContext logrus.FieldLogger, customerID string, applicationID string, environment string) ([]Backup, bool) {
+	ctx := r.Context()
+	namespace := fmt.Sprintf("application-%s", applicationID)
+
+	shareName, err := getShareName(ctx, namespace, s.k8sClient, metaV1.ListOptions{
+		LabelSelector: fmt.Sprintf("environment=%s,infrastructure=Mongo", platformK8s.ParseLabel(environment)),
+	})
+	if err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+			"where": "getShareName",
+		}).Error("lookup error")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return nil, false
+	}
+
+	store, err := s.getStore(ctx, customerID, namespace)
+	if err != nil {
+		respondWithStoreError(w, logContext, err)
+		return nil, false
+	}
+
+	backups, err := store.List(ctx, shareName)
+	if err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+			"where": "store.List",
+		}).Error("lookup error")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return nil, false
+	}
+	return backups, true
+}
+
+// respondWithStoreError logs why the store of the customer could not be reached
+func respondWithStoreError(w http.ResponseWriter, logContext logrus.FieldLogger, err error) {
+	logContext.WithFields(logrus.Fields{
+		"error": err,
+		"where": "s.getStore",
+	}).Error("lookup error")
+	utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+}
+
+// getEnvironment checks the user can modify the application, and returns the environment with the casing it is stored with
+func (s *service) getEnvironment(w http.ResponseWriter, r *http.Request, environmentName string) (storage.JSONApplication, string, bool) {
+	application, ok := s.getApplication(w, r)
+	if !ok {
+		return application, "", false
+	}
+
+	environment, exists := findEnvironment(application, environmentName)
+	if !exists {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Environment %s not found", environmentName))
+		return application, "", false
+	}
+	return application, environment, true
+}
+
+// getApplication checks the user can modify the application before it is returned
+func (s *service) getApplication(w http.ResponseWriter, r *http.Request) (storage.JSONApplication, bool) {
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	applicationID := mux.Vars(r)["applicationID"]
+
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return storage.JSONApplication{}, false
+	}
+
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Application %s not found", applicationID))
+			return application, false
+		}
+		s.logContext.WithFields(logrus.Fields{
+			"error":          err,
+			"customer_id":    customerID,
+			"application_id": applicationID,
+		}).Error("Failed to get the application")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return application, false
+	}
+	return application, true
+}
+
+func findEnvironment(application storage.JSONApplication, environmentName string) (string, bool) {
+	for _, environment := range application.Environments {
+		if strings.EqualFold(environment.Name, environmentName) {
+			return environment.Name, true
+		}
+	}
+	return "", false
+}
+
+// getPage reads the page and pageSize query parameters, defaulting to the first page of 20
+func getPage(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+	page := 1
+	pageSize := defaultPageSize
+
+	if value := query.Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, errors.New("page must be a number from 1")
+		}
+		page = parsed
+	}
+
+	if value := query.Get("pageSize"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPageSize {
+			return 0, 0, fmt.Errorf("pageSize must be a number from 1 to %d", maxPageSize)
+		}
+		pageSize = parsed
+	}
+	return page, pageSize, nil
+}
+
 func getStorageAccountInfo(ctx context.Context, namespace string, client kubernetes.Interface) (AzureStorageInfo, error) {
 	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, "storage-account-secret", metaV1.GetOptions{})
 	if err != nil {
@@ -0,0 +1,234 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// GetRetention is which backups of the environment are kept when they are pruned
+func (s *service) GetRetention(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, HTTPResponseRetention{
+		ApplicationID: application.ID,
+		Environment:   environment,
+		Retention:     getRetention(application, environment),
+	})
+}
+
+// UpdateRetention stores which backups of the environment are kept, they are removed the next time it is pruned.
+// Environments removing backups by age with backupRetentionDays can not have a retention
+func (s *service) UpdateRetention(w http.ResponseWriter, r *http.Request) {
+	var input storage.JSONBackupRetention
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	err = json.Unmarshal(b, &input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	err = ValidateRetention(input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "UpdateRetention",
+		"customer_id":    application.CustomerID,
+		"application_id": application.ID,
+		"environment":    environment,
+	})
+
+	for index := range application.Environments {
+		if application.Environments[index].Name != environment {
+			continue
+		}
+		mongo := &application.Environments[index].Mongo
+		retention := input
+		mongo.BackupRetention = &retention
+		if err := CheckRetentionConflict(*mongo); err != nil {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+	}
+
+	err = s.gitRepo.SaveApplication(application)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the backup retention")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to storage")
+		return
+	}
+
+	logContext.WithField("retention", input).Info("Backup retention changed")
+	utils.RespondWithJSON(w, http.StatusOK, HTTPResponseRetention{
+		ApplicationID: application.ID,
+		Environment:   environment,
+		Retention:     &input,
+	})
+}
+
+// Prune starts a job removing the backups of the environment its retention does not keep
+func (s *service) Prune(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	retention := getRetention(application, environment)
+	if retention == nil {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Environment %s has no backup retention", environment))
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "Prune",
+		"customer_id":    application.CustomerID,
+		"application_id": application.ID,
+		"environment":    environment,
+	})
+
+	namespace := fmt.Sprintf("application-%s", application.ID)
+	store, err := s.getStore(r.Context(), application.CustomerID, namespace)
+	if err != nil {
+		respondWithStoreError(w, logContext, err)
+		return
+	}
+
+	job, pruned, err := PruneEnvironment(r.Context(), s.k8sClient, store, namespace, environment, *retention)
+	if err != nil {
+		if errors.Is(err, ErrNoBackupCronJob) {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrBackupJobRunning) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to prune the backups")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to prune the backups")
+		return
+	}
+
+	status := http.StatusOK
+	if job != "" {
+		status = http.StatusAccepted
+		logContext.WithFields(logrus.Fields{
+			"job":    job,
+			"pruned": len(pruned),
+		}).Info("Pruning backups")
+	}
+	utils.RespondWithJSON(w, status, HTTPResponsePrune{
+		ApplicationID: application.ID,
+		Environment:   environment,
+		Job:           job,
+		Pruned:        pruned,
+	})
+}
+
+// Verify starts a job restoring the latest backup of the environment into a throwaway mongo
+func (s *service) Verify(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r, mux.Vars(r)["environment"])
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "Verify",
+		"customer_id":    application.CustomerID,
+		"application_id": application.ID,
+		"environment":    environment,
+	})
+
+	namespace := fmt.Sprintf("application-%s", application.ID)
+	store, err := s.getStore(r.Context(), application.CustomerID, namespace)
+	if err != nil {
+		respondWithStoreError(w, logContext, err)
+		return
+	}
+
+	job, name, err := VerifyEnvironment(r.Context(), s.k8sClient, store, namespace, environment)
+	if err != nil {
+		if errors.Is(err, ErrNoBackupCronJob) || errors.Is(err, ErrNoBackups) {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to start the verification")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to start the verification")
+		return
+	}
+
+	logContext.WithFields(logrus.Fields{
+		"job":    job,
+		"backup": name,
+	}).Info("Verifying backup")
+	utils.RespondWithJSON(w, http.StatusAccepted, HTTPResponseVerify{
+		ApplicationID: application.ID,
+		Environment:   environment,
+		Name:          name,
+		Job:           job,
+	})
+}
+
+// GetVerifications is how the latest verification of each environment in the application went
+func (s *service) GetVerifications(w http.ResponseWriter, r *http.Request) {
+	application, ok := s.getApplication(w, r)
+	if !ok {
+		return
+	}
+
+	namespace := fmt.Sprintf("application-%s", application.ID)
+	verifications := make([]Verification, 0, len(application.Environments))
+	for _, environment := range application.Environments {
+		verification, err := GetLatestVerification(r.Context(), s.k8sClient, namespace, environment.Name)
+		if err != nil {
+			s.logContext.WithFields(logrus.Fields{
+				"method":         "GetVerifications",
+				"customer_id":    application.CustomerID,
+				"application_id": application.ID,
+				"environment":    environment.Name,
+				"error":          err,
+			}).Error("Failed to get the verification")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+			return
+		}
+		verifications = append(verifications, verification)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, HTTPResponseVerifications{
+		ApplicationID: application.ID,
+		Verifications: verifications,
+	})
+}
+
+func getRetention(application storage.JSONApplication, environmentName string) *storage.JSONBackupRetention {
+	for _, environment := range application.Environments {
+		if strings.EqualFold(environment.Name, environmentName) {
+			return environment.Mongo.BackupRetention
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// BackupDirectory is where the mongo backup cronjob writes its archives, relative to the root of the share
+	BackupDirectory = "mongo"
+	BackupExtension = ".gz.mongodump"
+)
+
+var (
+	ErrInvalidBackupName = errors.New("invalid backup name")
+	ErrUnknownStore      = errors.New("backup store is not supported")
+
+	// The cronjob names the archives {application}-{environment}-YYYY-MM-DD_HH-MM-SS.gz.mongodump
+	backupTimestamp = regexp.MustCompile(`(\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2})\.gz\.mongodump$`)
+)
+
+// BackupStore is where the mongo backups of an environment are kept.
+// The share is the name the backup cronjob mounts, the backups are in its BackupDirectory
+type BackupStore interface {
+	List(ctx context.Context, share string) ([]Backup, error)
+	// CreateLink makes a link to download the backup with until it expires
+	CreateLink(ctx context.Context, share string, name string, expires time.Time) (string, error)
+}
+
+type studioConfigRepo interface {
+	GetStudioConfig(customerID string) (platform.StudioConfig, error)
+}
+
+// StoreResolver finds the store the customer has chosen, for the applications namespace
+type StoreResolver func(ctx context.Context, customerID string, namespace string) (BackupStore, error)
+
+// NewStoreResolver picks the store from the studio config of the customer.
+// Azure Files uses the storage account from the "storage-account-secret" in the namespace,
+// the same one the backup cronjob mounts its share with.
+// It is the only store, as the backup cronjob and the restore, prune and verify jobs read and write the mounted share
+func NewStoreResolver(client kubernetes.Interface, studioConfigs studioConfigRepo) StoreResolver {
+	return func(ctx context.Context, customerID string, namespace string) (BackupStore, error) {
+		studioConfig, err := studioConfigs.GetStudioConfig(customerID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch studioConfig.BackupStore {
+		case "", platform.BackupStoreAzureFiles:
+			info, err := getStorageAccountInfo(ctx, namespace, client)
+			if err != nil {
+				return nil, err
+			}
+			return NewAzureFilesStore(info.Name, info.Key), nil
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownStore, studioConfig.BackupStore)
+		}
+	}
+}
+
+// ParseBackupTime reads the time the backup was taken from the name of the archive, in UTC
+func ParseBackupTime(name string) (time.Time, bool) {
+	match := backupTimestamp.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	createdAt, err := time.Parse("2006-01-02_15-04-05", match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
+
+// IsBackupNameValid checks that the name is a single archive in the backup directory
+func IsBackupNameValid(name string) bool {
+	if name == "" || strings.ContainsAny(name, `/\'"$ `) {
+		return false
+	}
+	return strings.HasSuffix(name, BackupExtension)
+}
+
+// SortNewestFirst sorts the backups by when they were taken, the latest first
+func SortNewestFirst(backups []Backup) {
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].Name > backups[j].Name
+		}
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+}
+
+// Paginate returns the given page of backups, pages start at 1
+func Paginate(backups []Backup, page int, pageSize int) []Backup {
+	start := (page - 1) * pageSize
+	if page < 1 || pageSize < 1 || start >= len(backups) {
+		return []Backup{}
+	}
+
+	end := start + pageSize
+	if end > len(backups) {
+		end = len(backups)
+	}
+	return backups[start:end]
+}
+
+func findBackup(backups []Backup, name string) (Backup, bool) {
+	for _, backup := range backups {
+		if backup.Name == name {
+			return backup, true
+		}
+	}
+	return Backup{}, false
+}
+
+// backupPath is the same format as the files from azure.LatestX
+func backupPath(share string, name string) string {
+	return "/" + path.Join(share, BackupDirectory, name)
+}
+
+// ParseBackupPath splits a path from backupPath into the share and the name of the backup
+func ParseBackupPath(backupPath string) (string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(backupPath, "/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] != BackupDirectory || !IsBackupNameValid(parts[2]) {
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidBackupName, backupPath)
+	}
+	return parts[0], parts[2], nil
+}
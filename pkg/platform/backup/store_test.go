@@ -0,0 +1,74 @@
+package backup_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeStore has the names of the backups in each share
+type fakeStore map[string][]string
+
+func (s fakeStore) List(ctx context.Context, share string) ([]backup.Backup, error) {
+	backups := make([]backup.Backup, 0, len(s[share]))
+	for _, name := range s[share] {
+		createdAt, _ := backup.ParseBackupTime(name)
+		backups = append(backups, backup.Backup{
+			Name:      name,
+			Path:      "/" + share + "/" + backup.BackupDirectory + "/" + name,
+			CreatedAt: createdAt,
+		})
+	}
+	backup.SortNewestFirst(backups)
+	return backups, nil
+}
+
+func (s fakeStore) CreateLink(ctx context.Context, share string, name string, expires time.Time) (string, error) {
+	return "", errors.New("not supported")
+}
+
+var _ = Describe("Backup store", func() {
+	Describe("paginating", func() {
+		backups := []backup.Backup{{Name: "1"}, {Name: "2"}, {Name: "3"}, {Name: "4"}, {Name: "5"}}
+
+		It("should return the given page", func() {
+			Expect(backup.Paginate(backups, 1, 2)).To(Equal([]backup.Backup{{Name: "1"}, {Name: "2"}}))
+			Expect(backup.Paginate(backups, 3, 2)).To(Equal([]backup.Backup{{Name: "5"}}))
+		})
+
+		It("should return nothing after the last page", func() {
+			Expect(backup.Paginate(backups, 4, 2)).To(BeEmpty())
+		})
+	})
+
+	Describe("backup names", func() {
+		It("should only allow archives in the backup directory", func() {
+			Expect(backup.IsBackupNameValid("app-dev-2021-10-03_01-05-00.gz.mongodump")).To(BeTrue())
+			for _, name := range []string{"", "notes.txt", "../app-dev-2021-10-03_01-05-00.gz.mongodump", "a b.gz.mongodump", "$(rm).gz.mongodump"} {
+				Expect(backup.IsBackupNameValid(name)).To(BeFalse(), name)
+			}
+		})
+
+		It("should split the path of a backup", func() {
+			share, name, err := backup.ParseBackupPath("/app-dev-backup/mongo/app-dev-2021-10-03_01-05-00.gz.mongodump")
+			Expect(err).To(BeNil())
+			Expect(share).To(Equal("app-dev-backup"))
+			Expect(name).To(Equal("app-dev-2021-10-03_01-05-00.gz.mongodump"))
+
+			for _, path := range []string{"/app-dev-backup/app-dev-2021-10-03_01-05-00.gz.mongodump", "/app-dev-backup/mongo/../mongo/x.gz.mongodump", "/app-dev-backup/mongo/notes.txt"} {
+				_, _, err := backup.ParseBackupPath(path)
+				Expect(err).To(MatchError(backup.ErrInvalidBackupName), path)
+			}
+		})
+
+		It("should read when the backup was taken", func() {
+			createdAt, ok := backup.ParseBackupTime("my-app-prod-2021-11-12_13-14-15.gz.mongodump")
+			Expect(ok).To(BeTrue())
+			Expect(createdAt).To(Equal(time.Date(2021, 11, 12, 13, 14, 15, 0, time.UTC)))
+		})
+	})
+})
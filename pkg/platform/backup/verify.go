@@ -0,0 +1,108 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var ErrNoBackups = errors.New("no backups found for the environment")
+
+// JobStatusNever is the status of an environment that has not been verified
+const JobStatusNever = "never"
+
+// NewMongoVerifyCommand starts a throwaway mongo in the container and restores the archive into it,
+// the command fails if the archive can not be restored
+func NewMongoVerifyCommand(name string) string {
+	return fmt.Sprintf(
+		`set -e; mkdir -p /tmp/verify; `+
+			`mongod --dbpath=/tmp/verify --bind_ip=127.0.0.1 --port=27018 --fork --logpath=/tmp/verify/mongod.log; `+
+			`mongorestore --host=127.0.0.1:27018 --gzip --archive=/mnt/backup/%s --objcheck --stopOnError; `+
+			`mongod --dbpath=/tmp/verify --shutdown`,
+		name,
+	)
+}
+
+// NewMongoVerifyJob builds a job that restores the backup into a throwaway mongo, with the same image the environment runs
+func NewMongoVerifyJob(cron v1beta1.CronJob, name string) (*batchv1.Job, error) {
+	if !IsBackupNameValid(name) {
+		return nil, ErrInvalidBackupName
+	}
+
+	job, err := newMongoBackupJob(cron, cron, JobKindVerify, name, NewMongoVerifyCommand(name))
+	if err != nil {
+		return nil, err
+	}
+
+	job.Spec.ActiveDeadlineSeconds = &longRunningActiveDeadlineSeconds
+	// The throwaway mongo is not for connecting to
+	job.Spec.Template.Spec.Containers[0].Ports = []corev1.ContainerPort{}
+	return job, nil
+}
+
+// VerifyEnvironment starts a job verifying the latest backup of the environment, and returns the name of the job and the backup.
+// The finished verify jobs from before, except the latest, are removed
+func VerifyEnvironment(ctx context.Context, client kubernetes.Interface, store BackupStore, namespace string, environment string) (string, string, error) {
+	backups, err := listEnvironmentBackups(ctx, client, store, namespace, environment)
+	if err != nil {
+		return "", "", err
+	}
+	if len(backups) == 0 {
+		return "", "", ErrNoBackups
+	}
+	latest := backups[0]
+
+	cron, err := getMongoBackupCronJob(ctx, client, namespace, environment)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = removeFinishedJobs(ctx, client, namespace, environment, JobKindVerify)
+	if err != nil {
+		return "", "", err
+	}
+
+	job, err := NewMongoVerifyJob(cron, latest.Name)
+	if err != nil {
+		return "", "", err
+	}
+
+	created, err := client.BatchV1().Jobs(namespace).Create(ctx, job, metaV1.CreateOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	return created.Name, latest.Name, nil
+}
+
+// GetLatestVerification is how the latest verification of the environment went
+func GetLatestVerification(ctx context.Context, client kubernetes.Interface, namespace string, environment string) (Verification, error) {
+	verification := Verification{
+		Environment: environment,
+		Status:      JobStatusNever,
+	}
+
+	jobs, err := getJobsOfKind(ctx, client, namespace, environment, JobKindVerify)
+	if err != nil {
+		return verification, err
+	}
+	if len(jobs) == 0 {
+		return verification, nil
+	}
+
+	job := jobs[0]
+	verification.Job = job.Name
+	verification.Name = job.Annotations[BackupNameAnnotation]
+	verification.Status = getJobStatus(job)
+	if job.Status.StartTime != nil {
+		verification.StartedAt = &job.Status.StartTime.Time
+	}
+	verification.FinishedAt, verification.Message = jobK8s.GetJobFinished(job)
+	return verification, nil
+}
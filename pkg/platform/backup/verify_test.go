@@ -0,0 +1,105 @@
+package backup_test
+
+import (
+	"context"
+	"time"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/backup"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Verifying backups", func() {
+	var (
+		store     backup.BackupStore
+		namespace string
+		clientSet *fake.Clientset
+	)
+
+	BeforeEach(func() {
+		store = fakeStore{"fake-application-dev-backup": {
+			"fake-application-dev-2021-10-01_01-05-00.gz.mongodump",
+			"fake-application-dev-2021-10-02_01-05-00.gz.mongodump",
+		}}
+
+		namespace = "application-fake-application-123"
+		mongo := k8s.NewMongo("Dev",
+			dolittleK8s.Tenant{ID: "fake-customer-123", Name: "fake-customer"},
+			dolittleK8s.Application{ID: "fake-application-123", Name: "fake-application"},
+			k8s.MongoSettings{ShareName: "fake-application-dev-backup", CronJobSchedule: "5 * * * *", VolumeSize: "8Gi"},
+		)
+		clientSet = fake.NewSimpleClientset(mongo.Cronjob)
+	})
+
+	It("should never have been verified", func() {
+		verification, err := backup.GetLatestVerification(context.Background(), clientSet, namespace, "Dev")
+		Expect(err).To(BeNil())
+		Expect(verification).To(Equal(backup.Verification{Environment: "Dev", Status: backup.JobStatusNever}))
+	})
+
+	It("should restore the latest backup into a throwaway mongo", func() {
+		job, name, err := backup.VerifyEnvironment(context.Background(), clientSet, store, namespace, "Dev")
+		Expect(err).To(BeNil())
+		Expect(name).To(Equal("fake-application-dev-2021-10-02_01-05-00.gz.mongodump"))
+
+		created, err := clientSet.BatchV1().Jobs(namespace).Get(context.Background(), job, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		Expect(created.Spec.Template.Spec.Containers[0].Args).To(Equal([]string{backup.NewMongoVerifyCommand(name)}))
+		Expect(backup.NewMongoVerifyCommand(name)).To(ContainSubstring("--host=127.0.0.1:27018 --gzip --archive=/mnt/backup/" + name))
+
+		verification, err := backup.GetLatestVerification(context.Background(), clientSet, namespace, "Dev")
+		Expect(err).To(BeNil())
+		Expect(verification.Status).To(Equal(backup.JobStatusRunning))
+		Expect(verification.Name).To(Equal(name))
+	})
+
+	It("should tell how the latest verification went and remove the ones before it", func() {
+		finishedAt := metav1.NewTime(time.Date(2021, 10, 2, 3, 0, 0, 0, time.UTC))
+		for _, item := range []struct {
+			name      string
+			condition batchv1.JobConditionType
+		}{
+			{"dev-mongo-verify-1633100000", batchv1.JobComplete},
+			{"dev-mongo-verify-1633200000", batchv1.JobFailed},
+		} {
+			_, err := clientSet.BatchV1().Jobs(namespace).Create(context.Background(), &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        item.name,
+					Namespace:   namespace,
+					Labels:      map[string]string{"environment": "Dev", "infrastructure": "Mongo", backup.JobKindLabel: backup.JobKindVerify},
+					Annotations: map[string]string{backup.BackupNameAnnotation: "fake-application-dev-2021-10-02_01-05-00.gz.mongodump"},
+				},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{
+						{Type: item.condition, Status: corev1.ConditionTrue, LastTransitionTime: finishedAt, Message: "done"},
+					},
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).To(BeNil())
+		}
+
+		verification, err := backup.GetLatestVerification(context.Background(), clientSet, namespace, "Dev")
+		Expect(err).To(BeNil())
+		Expect(verification.Job).To(Equal("dev-mongo-verify-1633200000"))
+		Expect(verification.Status).To(Equal(backup.JobStatusFailed))
+		Expect(verification.FinishedAt.Equal(finishedAt.Time)).To(BeTrue())
+
+		_, _, err = backup.VerifyEnvironment(context.Background(), clientSet, store, namespace, "Dev")
+		Expect(err).To(BeNil())
+		_, err = clientSet.BatchV1().Jobs(namespace).Get(context.Background(), "dev-mongo-verify-1633100000", metav1.GetOptions{})
+		Expect(err).ToNot(BeNil())
+		_, err = clientSet.BatchV1().Jobs(namespace).Get(context.Background(), "dev-mongo-verify-1633200000", metav1.GetOptions{})
+		Expect(err).To(BeNil())
+	})
+
+	It("should fail without backups", func() {
+		_, _, err := backup.VerifyEnvironment(context.Background(), clientSet, fakeStore{}, namespace, "Dev")
+		Expect(err).To(MatchError(backup.ErrNoBackups))
+	})
+})
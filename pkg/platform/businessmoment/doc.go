@@ -1,6 +1,8 @@
 package businessmoment
 
 import (
+	"sync"
+
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/businessmomentsadaptor"
 	"github.com/dolittle/platform-api/pkg/platform/storage"
@@ -14,4 +16,6 @@ type service struct {
 	gitRepo               storage.Repo
 	k8sDolittleRepo       platformK8s.K8sRepo
 	k8sBusinessMomentRepo businessmomentsadaptor.Repo
+	// publishLock keeps publishing and rolling back from interleaving their configmap and git writes
+	publishLock *sync.Mutex
 }
@@ -0,0 +1,438 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dop251/goja"
+)
+
+const (
+	StepFilter     = "filter"
+	StepTransform  = "transform"
+	StepEmbedding  = "embedding"
+	StepProjection = "projection"
+
+	// DefaultTimeout is how long a single call into the code is allowed to run
+	DefaultTimeout = 2 * time.Second
+	// MaxRunDuration is how long a whole test run is allowed to take
+	MaxRunDuration = 10 * time.Second
+	// MaxPayloads is the most payloads a single test run accepts
+	MaxPayloads = 100
+	// MaxConcurrentRuns is how many test runs the process runs at the same time
+	MaxConcurrentRuns = 4
+	// MaxResultBytes is the most the results of a single test run can add up to
+	MaxResultBytes = 1 << 20
+
+	maxCallStackSize = 256
+	maxLogLines      = 100
+	maxLogBytes      = 16 << 10
+	maxValueBytes    = 64 << 10
+	truncatedLog     = "... (truncated)"
+	// maxHeapGrowth is how much the heap may grow while the code runs, it is measured for the whole
+	// process so it only stops code that allocates far more than a test run should need
+	maxHeapGrowth       = 256 << 20
+	heapCheckInterval   = 10 * time.Millisecond
+	heapObjectsBytesKey = "/memory/classes/heap/objects:bytes"
+)
+
+var (
+	ErrInvalidCode     = errors.New("invalid code")
+	ErrTimeout         = errors.New("code took too long")
+	ErrRunTimeout      = fmt.Errorf("the test run took longer than %s", MaxRunDuration)
+	ErrTooMuchMemory   = errors.New("code used too much memory")
+	ErrTooManyPayloads = fmt.Errorf("too many payloads, the limit is %d", MaxPayloads)
+	ErrResultTooLarge  = fmt.Errorf("the results are larger than %d bytes", MaxResultBytes)
+	ErrTooManyRuns     = errors.New("too many test runs at the same time, try again later")
+	errValueTooLarge   = fmt.Errorf("the value is larger than %d bytes", maxValueBytes)
+)
+
+// concurrentRuns holds a slot for each test run in progress
+var concurrentRuns = make(chan struct{}, MaxConcurrentRuns)
+
+// Code is the user authored JavaScript of an entity and a moment, each one a function expression.
+// An empty filter keeps every payload, an empty transform uses the payload as the entity
+// and an empty embedding or projection is skipped.
+//
+//	filter(data) => boolean
+//	transform(data) => entity
+//	embedding(data, entity) => moment
+//	projection(state, moment) => state
+type Code struct {
+	Filter     string
+	Transform  string
+	Embedding  string
+	Projection string
+}
+
+func NewCode(entity platform.Entity, moment platform.BusinessMoment) Code {
+	return Code{
+		Filter:     entity.FilterCode,
+		Transform:  entity.TransformCode,
+		Embedding:  moment.EmbeddingCode,
+		Projection: moment.ProjectionCode,
+	}
+}
+
+type sandbox struct {
+	vm        *goja.Runtime
+	stringify goja.Callable
+	timeout   time.Duration
+	logs      []string
+	logBytes  int
+
+	stopMutex sync.Mutex
+	stopErr   error
+}
+
+// Run executes the code against each payload in a fresh JavaScript interpreter, without access to
+// the network or the filesystem. The projection state is carried from one payload to the next.
+// Code that fails to compile is an ErrInvalidCode, failures while running are reported per payload.
+// The whole run stops with ErrRunTimeout after MaxRunDuration, with ErrTooMuchMemory when the heap grows
+// too much and with the error of the context when it is done. At most MaxConcurrentRuns run at the same time,
+// the others fail straight away with ErrTooManyRuns
+func Run(ctx context.Context, code Code, payloads []json.RawMessage, timeout time.Duration) ([]platform.BusinessMomentTestRunResult, error) {
+	if len(payloads) > MaxPayloads {
+		return nil, ErrTooManyPayloads
+	}
+
+	select {
+	case concurrentRuns <- struct{}{}:
+		defer func() { <-concurrentRuns }()
+	default:
+		return nil, ErrTooManyRuns
+	}
+
+	s := newSandbox(timeout)
+	ctx, cancel := context.WithTimeout(ctx, MaxRunDuration)
+	defer cancel()
+	stopWatching := s.watch(ctx)
+	defer stopWatching()
+
+	results, err := s.runAll(code, payloads)
+	if stopErr := s.stopped(); stopErr != nil {
+		return nil, stopErr
+	}
+	return results, err
+}
+
+func (s *sandbox) runAll(code Code, payloads []json.RawMessage) ([]platform.BusinessMomentTestRunResult, error) {
+
+	filter, err := s.compile(StepFilter, code.Filter)
+	if err != nil {
+		return nil, err
+	}
+	transform, err := s.compile(StepTransform, code.Transform)
+	if err != nil {
+		return nil, err
+	}
+	embedding, err := s.compile(StepEmbedding, code.Embedding)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := s.compile(StepProjection, code.Projection)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]platform.BusinessMomentTestRunResult, 0, len(payloads))
+	resultBytes := 0
+	state := goja.Null()
+	for index, payload := range payloads {
+		if err := s.stopped(); err != nil {
+			return nil, err
+		}
+		s.logs = []string{}
+		s.logBytes = 0
+		result := platform.BusinessMomentTestRunResult{
+			Index: index,
+		}
+
+		var data interface{}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			result.Error = fmt.Sprintf("payload is not valid JSON: %s", err.Error())
+			result.Logs = s.logs
+			results = append(results, result)
+			continue
+		}
+		dataValue := s.vm.ToValue(data)
+
+		step, err := func() (string, error) {
+			if filter != nil {
+				keep, err := s.call(filter, dataValue)
+				if err != nil {
+					return StepFilter, err
+				}
+				if !keep.ToBoolean() {
+					result.Filtered = true
+					return "", nil
+				}
+			}
+
+			entity := dataValue
+			if transform != nil {
+				entity, err = s.call(transform, dataValue)
+				if err != nil {
+					return StepTransform, err
+				}
+			}
+			if result.Entity, err = s.exportValue(entity); err != nil {
+				return StepTransform, err
+			}
+
+			if embedding == nil {
+				return "", nil
+			}
+			moment, err := s.call(embedding, dataValue, entity)
+			if err != nil {
+				return StepEmbedding, err
+			}
+			if result.Moment, err = s.exportValue(moment); err != nil {
+				return StepEmbedding, err
+			}
+
+			if projection == nil {
+				return "", nil
+			}
+			next, err := s.call(projection, state, moment)
+			if err != nil {
+				return StepProjection, err
+			}
+			state = next
+			if result.State, err = s.exportValue(state); err != nil {
+				return StepProjection, err
+			}
+			return "", nil
+		}()
+		if err != nil {
+			result.Step = step
+			result.Error = err.Error()
+		}
+
+		result.Logs = s.logs
+		resultBytes += s.logBytes + len(result.Error) + rawLength(result.Entity) + rawLength(result.Moment) + rawLength(result.State)
+		if resultBytes > MaxResultBytes {
+			return nil, ErrResultTooLarge
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Validate compiles the code without running it, returning the first code that is an ErrInvalidCode
+func Validate(code Code) error {
+	s := newSandbox(DefaultTimeout)
+	steps := []struct {
+		step string
+		code string
+	}{
+		{StepFilter, code.Filter},
+		{StepTransform, code.Transform},
+		{StepEmbedding, code.Embedding},
+		{StepProjection, code.Projection},
+	}
+	for _, step := range steps {
+		if _, err := s.compile(step.step, step.code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newSandbox(timeout time.Duration) *sandbox {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	s := &sandbox{
+		vm:      goja.New(),
+		timeout: timeout,
+		logs:    []string{},
+	}
+	s.vm.SetMaxCallStackSize(maxCallStackSize)
+	s.stringify, _ = goja.AssertFunction(s.vm.Get("JSON").ToObject(s.vm).Get("stringify"))
+
+	log := func(call goja.FunctionCall) goja.Value {
+		if len(s.logs) >= maxLogLines || s.logBytes >= maxLogBytes {
+			return goja.Undefined()
+		}
+		parts := make([]string, 0, len(call.Arguments))
+		for _, argument := range call.Arguments {
+			parts = append(parts, s.format(argument))
+		}
+		line := strings.Join(parts, " ")
+		if left := maxLogBytes - s.logBytes; len(line) > left {
+			// Back up to the start of a rune so the line stays valid UTF-8
+			for left > 0 && !utf8.RuneStart(line[left]) {
+				left--
+			}
+			line = line[:left] + truncatedLog
+		}
+		s.logBytes += len(line)
+		s.logs = append(s.logs, line)
+		return goja.Undefined()
+	}
+	console := s.vm.NewObject()
+	console.Set("log", log)
+	console.Set("info", log)
+	console.Set("warn", log)
+	console.Set("error", log)
+	s.vm.Set("console", console)
+	return s
+}
+
+// compile turns the code into a function, returning nil when there is no code
+func (s *sandbox) compile(step string, code string) (goja.Callable, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, nil
+	}
+
+	value, err := s.run(func() (goja.Value, error) {
+		return s.vm.RunString(fmt.Sprintf("(%s\n)", code))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidCode, step, err.Error())
+	}
+
+	function, ok := goja.AssertFunction(value)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: must be a function", ErrInvalidCode, step)
+	}
+	return function, nil
+}
+
+func (s *sandbox) call(function goja.Callable, arguments ...goja.Value) (goja.Value, error) {
+	return s.run(func() (goja.Value, error) {
+		return function(goja.Undefined(), arguments...)
+	})
+}
+
+// run interrupts the code when it runs for longer than the timeout
+func (s *sandbox) run(do func() (goja.Value, error)) (goja.Value, error) {
+	if err := s.stopped(); err != nil {
+		return nil, err
+	}
+	timer := time.AfterFunc(s.timeout, func() {
+		s.vm.Interrupt(ErrTimeout)
+	})
+	defer func() {
+		timer.Stop()
+		s.vm.ClearInterrupt()
+	}()
+
+	value, err := do()
+	if err != nil {
+		if stopErr := s.stopped(); stopErr != nil {
+			return nil, stopErr
+		}
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			return nil, ErrTimeout
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+// watch stops the code when the context is done or the heap grows by more than maxHeapGrowth
+func (s *sandbox) watch(ctx context.Context) func() {
+	done := make(chan struct{})
+	go func() {
+		samples := []metrics.Sample{{Name: heapObjectsBytesKey}}
+		heapBytes := func() uint64 {
+			metrics.Read(samples)
+			if samples[0].Value.Kind() != metrics.KindUint64 {
+				return 0
+			}
+			return samples[0].Value.Uint64()
+		}
+		start := heapBytes()
+
+		ticker := time.NewTicker(heapCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					s.stop(ErrRunTimeout)
+				} else {
+					s.stop(ctx.Err())
+				}
+				return
+			case <-ticker.C:
+				if heap := heapBytes(); heap > start && heap-start > maxHeapGrowth {
+					s.stop(ErrTooMuchMemory)
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// stop interrupts the code and keeps it from running again
+func (s *sandbox) stop(err error) {
+	s.stopMutex.Lock()
+	defer s.stopMutex.Unlock()
+	if s.stopErr == nil {
+		s.stopErr = err
+	}
+	s.vm.Interrupt(err)
+}
+
+func (s *sandbox) stopped() error {
+	s.stopMutex.Lock()
+	defer s.stopMutex.Unlock()
+	return s.stopErr
+}
+
+// exportValue is export that refuses values larger than maxValueBytes
+func (s *sandbox) exportValue(value goja.Value) (interface{}, error) {
+	exported := s.export(value)
+	if rawLength(exported) > maxValueBytes {
+		return nil, errValueTooLarge
+	}
+	return exported, nil
+}
+
+// export goes through JSON.stringify, so the result only holds what JSON can
+func (s *sandbox) export(value goja.Value) interface{} {
+	if value == nil || goja.IsUndefined(value) {
+		return nil
+	}
+	text, err := s.stringify(goja.Undefined(), value)
+	if err != nil || goja.IsUndefined(text) {
+		return value.String()
+	}
+	return json.RawMessage(text.String())
+}
+
+func (s *sandbox) format(value goja.Value) string {
+	if _, ok := value.Export().(string); ok {
+		return value.String()
+	}
+	if exported, ok := s.export(value).(json.RawMessage); ok {
+		return string(exported)
+	}
+	return value.String()
+}
+
+func rawLength(exported interface{}) int {
+	switch value := exported.(type) {
+	case json.RawMessage:
+		return len(value)
+	case string:
+		return len(value)
+	}
+	return 0
+}
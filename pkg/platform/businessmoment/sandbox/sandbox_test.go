@@ -0,0 +1,274 @@
+package sandbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/businessmoment/sandbox"
+)
+
+var _ = Describe("Sandbox", func() {
+	var (
+		code     sandbox.Code
+		payloads []json.RawMessage
+		timeout  time.Duration
+		results  []platform.BusinessMomentTestRunResult
+		err      error
+	)
+
+	BeforeEach(func() {
+		code = sandbox.Code{
+			Filter:     `(data) => data.type === "order"`,
+			Transform:  `(data) => ({ id: data.id, total: data.lines.reduce((sum, line) => sum + line, 0) })`,
+			Embedding:  `(data, entity) => { console.log("embedding", entity.id); return { orderId: entity.id, total: entity.total } }`,
+			Projection: `function (state, moment) { return { count: (state ? state.count : 0) + 1, last: moment.orderId } }`,
+		}
+		payloads = []json.RawMessage{
+			json.RawMessage(`{"type":"order","id":"1","lines":[1,2]}`),
+			json.RawMessage(`{"type":"invoice","id":"2","lines":[]}`),
+			json.RawMessage(`{"type":"order","id":"3","lines":[5]}`),
+		}
+		timeout = time.Second
+	})
+
+	JustBeforeEach(func() {
+		results, err = sandbox.Run(context.TODO(), code, payloads, timeout)
+	})
+
+	It("should run every step for the payloads that pass the filter", func() {
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].Filtered).To(BeFalse())
+		Expect(results[0].Entity).To(MatchJSON(`{"id":"1","total":3}`))
+		Expect(results[0].Moment).To(MatchJSON(`{"orderId":"1","total":3}`))
+		Expect(results[0].Logs).To(Equal([]string{"embedding 1"}))
+	})
+
+	It("should mark the payloads that do not pass the filter", func() {
+		Expect(results[1].Filtered).To(BeTrue())
+		Expect(results[1].Entity).To(BeNil())
+	})
+
+	It("should carry the projection state between payloads", func() {
+		Expect(results[0].State).To(MatchJSON(`{"count":1,"last":"1"}`))
+		Expect(results[2].State).To(MatchJSON(`{"count":2,"last":"3"}`))
+	})
+
+	When("the code throws", func() {
+		BeforeEach(func() {
+			code.Transform = `(data) => { throw new Error("no lines") }`
+		})
+
+		It("should report the step and the error for each payload", func() {
+			Expect(err).To(BeNil())
+			Expect(results[0].Step).To(Equal(sandbox.StepTransform))
+			Expect(results[0].Error).To(ContainSubstring("no lines"))
+		})
+	})
+
+	When("the code does not compile", func() {
+		BeforeEach(func() {
+			code.Filter = `(data) => {`
+		})
+
+		It("should fail with invalid code", func() {
+			Expect(errors.Is(err, sandbox.ErrInvalidCode)).To(BeTrue())
+		})
+	})
+
+	When("the code is not a function", func() {
+		BeforeEach(func() {
+			code.Embedding = `42`
+		})
+
+		It("should fail with invalid code", func() {
+			Expect(errors.Is(err, sandbox.ErrInvalidCode)).To(BeTrue())
+		})
+	})
+
+	When("the code never finishes", func() {
+		BeforeEach(func() {
+			code.Filter = `(data) => { while (true) {} }`
+			payloads = payloads[:1]
+		})
+
+		It("should be stopped", func() {
+			Expect(err).To(BeNil())
+			Expect(results[0].Step).To(Equal(sandbox.StepFilter))
+			Expect(results[0].Error).To(Equal(sandbox.ErrTimeout.Error()))
+		})
+	})
+
+	When("the code tries to reach outside the sandbox", func() {
+		BeforeEach(func() {
+			code.Filter = `(data) => require("fs")`
+		})
+
+		It("should not find anything", func() {
+			Expect(results[0].Error).To(ContainSubstring("require is not defined"))
+		})
+	})
+
+	When("there is no code", func() {
+		BeforeEach(func() {
+			code = sandbox.Code{}
+		})
+
+		It("should use the payload as the entity", func() {
+			Expect(results[0].Entity).To(MatchJSON(payloads[0]))
+			Expect(results[0].Moment).To(BeNil())
+		})
+	})
+
+	When("the code logs too much", func() {
+		BeforeEach(func() {
+			code.Filter = `(data) => { for (let i = 0; i < 10; i++) { console.log("x".repeat(10000)) } return true }`
+			payloads = payloads[:1]
+		})
+
+		It("should truncate the logs", func() {
+			Expect(err).To(BeNil())
+			Expect(len(results[0].Logs)).To(BeNumerically("<", 10))
+			Expect(results[0].Logs[len(results[0].Logs)-1]).To(HaveSuffix("(truncated)"))
+		})
+	})
+
+	When("the code logs too much text that is not ascii", func() {
+		BeforeEach(func() {
+			code.Filter = `(data) => { console.log("x" + "é".repeat(10000)); return true }`
+			payloads = payloads[:1]
+		})
+
+		It("should truncate the logs on a rune boundary", func() {
+			Expect(err).To(BeNil())
+			Expect(results[0].Logs[0]).To(HaveSuffix("(truncated)"))
+			Expect(utf8.ValidString(results[0].Logs[0])).To(BeTrue())
+		})
+	})
+
+	When("the code returns a value that is too large", func() {
+		BeforeEach(func() {
+			code.Transform = `(data) => "x".repeat(100000)`
+			payloads = payloads[:1]
+		})
+
+		It("should report it for the payload", func() {
+			Expect(err).To(BeNil())
+			Expect(results[0].Step).To(Equal(sandbox.StepTransform))
+			Expect(results[0].Entity).To(BeNil())
+		})
+	})
+
+	When("the results add up to too much", func() {
+		BeforeEach(func() {
+			code.Filter = ""
+			code.Transform = `(data) => "x".repeat(60000)`
+			code.Embedding = ""
+			payloads = make([]json.RawMessage, 20)
+			for index := range payloads {
+				payloads[index] = json.RawMessage(`{}`)
+			}
+		})
+
+		It("should fail", func() {
+			Expect(err).To(Equal(sandbox.ErrResultTooLarge))
+		})
+	})
+
+	When("the code allocates too much", func() {
+		BeforeEach(func() {
+			code.Filter = `(data) => { const all = []; while (true) { all.push("x".repeat(10000000) + all.length) } }`
+			payloads = payloads[:1]
+			timeout = sandbox.MaxRunDuration
+		})
+
+		It("should be stopped", func() {
+			Expect(err).To(Equal(sandbox.ErrTooMuchMemory))
+		})
+	})
+
+	When("there are too many payloads", func() {
+		BeforeEach(func() {
+			payloads = make([]json.RawMessage, sandbox.MaxPayloads+1)
+		})
+
+		It("should fail", func() {
+			Expect(err).To(Equal(sandbox.ErrTooManyPayloads))
+		})
+	})
+})
+
+var _ = Describe("Running code for longer than the request", func() {
+	It("should stop when the context is done", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		started := time.Now()
+		_, err := sandbox.Run(ctx, sandbox.Code{Filter: `(data) => { while (true) {} }`}, []json.RawMessage{json.RawMessage(`{}`)}, time.Minute)
+		Expect(err).To(Equal(sandbox.ErrRunTimeout))
+		Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+	})
+
+	It("should stop when the request is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		_, err := sandbox.Run(ctx, sandbox.Code{Filter: `(data) => { while (true) {} }`}, []json.RawMessage{json.RawMessage(`{}`)}, time.Minute)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+
+	It("should not run more than the limit at the same time", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		code := sandbox.Code{Filter: `(data) => { while (true) {} }`}
+		payloads := []json.RawMessage{json.RawMessage(`{}`)}
+
+		done := make(chan struct{})
+		for i := 0; i < sandbox.MaxConcurrentRuns; i++ {
+			go func() {
+				sandbox.Run(ctx, code, payloads, time.Minute)
+				done <- struct{}{}
+			}()
+		}
+		Eventually(func() error {
+			_, err := sandbox.Run(ctx, sandbox.Code{}, payloads, time.Minute)
+			return err
+		}).Should(Equal(sandbox.ErrTooManyRuns))
+
+		cancel()
+		for i := 0; i < sandbox.MaxConcurrentRuns; i++ {
+			<-done
+		}
+	})
+})
+
+var _ = Describe("Validating code", func() {
+	It("should accept function expressions and empty code", func() {
+		err := sandbox.Validate(sandbox.Code{
+			Filter:    `function (data) { return true }`,
+			Transform: `(data) => data`,
+		})
+		Expect(err).To(BeNil())
+	})
+
+	It("should not run the code", func() {
+		err := sandbox.Validate(sandbox.Code{
+			Filter: `(data) => { while (true) {} }`,
+		})
+		Expect(err).To(BeNil())
+	})
+
+	It("should reject code that does not compile", func() {
+		err := sandbox.Validate(sandbox.Code{
+			Projection: `(state, moment) => {`,
+		})
+		Expect(errors.Is(err, sandbox.ErrInvalidCode)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(sandbox.StepProjection))
+	})
+})
@@ -0,0 +1,434 @@
+package businessmoment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	KindMoment = "moment"
+	KindEntity = "entity"
+
+	DriftOnlyInFrom = "only-in-from"
+	DriftOnlyInTo   = "only-in-to"
+	DriftChanged    = "changed"
+
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// Query filters business moments and entities, empty fields match everything
+type Query struct {
+	Environment    string
+	MicroserviceID string
+	EntityTypeID   string
+	Kind           string
+	// Name matches any part of the name, ignoring case
+	Name   string
+	Offset int
+	Limit  int
+}
+
+// Search filters the business moments of each environment and returns the requested page,
+// ordered by environment, microservice, kind and name
+func Search(applicationID string, environments []platform.HttpResponseBusinessMoments, query Query) platform.HttpResponseBusinessMomentsSearch {
+	items := make([]platform.BusinessMomentsSearchItem, 0)
+	name := strings.ToLower(query.Name)
+
+	for _, data := range environments {
+		if query.Environment != "" && !strings.EqualFold(query.Environment, data.Environment) {
+			continue
+		}
+
+		if query.Kind == "" || query.Kind == KindEntity {
+			for _, entity := range data.Entities {
+				entity := entity
+				if query.MicroserviceID != "" && entity.MicroserviceID != query.MicroserviceID {
+					continue
+				}
+				if query.EntityTypeID != "" && entity.Entity.EntityTypeID != query.EntityTypeID {
+					continue
+				}
+				if !strings.Contains(strings.ToLower(entity.Entity.Name), name) {
+					continue
+				}
+				items = append(items, platform.BusinessMomentsSearchItem{
+					Kind:           KindEntity,
+					ApplicationID:  applicationID,
+					Environment:    data.Environment,
+					MicroserviceID: entity.MicroserviceID,
+					Entity:         &entity.Entity,
+				})
+			}
+		}
+
+		if query.Kind == "" || query.Kind == KindMoment {
+			for _, moment := range data.Moments {
+				moment := moment
+				if query.MicroserviceID != "" && moment.MicroserviceID != query.MicroserviceID {
+					continue
+				}
+				if query.EntityTypeID != "" && moment.Moment.EntityTypeID != query.EntityTypeID {
+					continue
+				}
+				if !strings.Contains(strings.ToLower(moment.Moment.Name), name) {
+					continue
+				}
+				items = append(items, platform.BusinessMomentsSearchItem{
+					Kind:           KindMoment,
+					ApplicationID:  applicationID,
+					Environment:    data.Environment,
+					MicroserviceID: moment.MicroserviceID,
+					Moment:         &moment.Moment,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Environment != b.Environment {
+			return a.Environment < b.Environment
+		}
+		if a.MicroserviceID != b.MicroserviceID {
+			return a.MicroserviceID < b.MicroserviceID
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return searchItemName(a) < searchItemName(b)
+	})
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	page := make([]platform.BusinessMomentsSearchItem, 0)
+	if query.Offset < len(items) {
+		end := query.Offset + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		page = items[query.Offset:end]
+	}
+
+	return platform.HttpResponseBusinessMomentsSearch{
+		ApplicationID: applicationID,
+		Total:         len(items),
+		Offset:        query.Offset,
+		Limit:         limit,
+		Items:         page,
+	}
+}
+
+// Compare finds the moments and entities that differ between two environments.
+// Moments are matched on their uuid and entities on their entityTypeId, as the microservice ids differ
+func Compare(from platform.HttpResponseBusinessMoments, to platform.HttpResponseBusinessMoments) []platform.BusinessMomentsDrift {
+	drift := make([]platform.BusinessMomentsDrift, 0)
+
+	entityIDs := map[string]bool{}
+	fromEntities := map[string]platform.Entity{}
+	for _, entity := range from.Entities {
+		fromEntities[entity.Entity.EntityTypeID] = entity.Entity
+		entityIDs[entity.Entity.EntityTypeID] = true
+	}
+	toEntities := map[string]platform.Entity{}
+	for _, entity := range to.Entities {
+		toEntities[entity.Entity.EntityTypeID] = entity.Entity
+		entityIDs[entity.Entity.EntityTypeID] = true
+	}
+	for _, id := range sortedKeys(entityIDs) {
+		a, inFrom := fromEntities[id]
+		b, inTo := toEntities[id]
+		switch {
+		case !inTo:
+			drift = append(drift, platform.BusinessMomentsDrift{Kind: KindEntity, ID: id, Name: a.Name, State: DriftOnlyInFrom})
+		case !inFrom:
+			drift = append(drift, platform.BusinessMomentsDrift{Kind: KindEntity, ID: id, Name: b.Name, State: DriftOnlyInTo})
+		default:
+			changes := changedFields(map[string][2]string{
+				"name":              {a.Name, b.Name},
+				"idNameForRetrival": {a.IdNameForRetrival, b.IdNameForRetrival},
+				"filterCode":        {a.FilterCode, b.FilterCode},
+				"transformCode":     {a.TransformCode, b.TransformCode},
+			})
+			if len(changes) != 0 {
+				drift = append(drift, platform.BusinessMomentsDrift{Kind: KindEntity, ID: id, Name: b.Name, State: DriftChanged, Changes: changes})
+			}
+		}
+	}
+
+	momentIDs := map[string]bool{}
+	fromMoments := map[string]platform.BusinessMoment{}
+	for _, moment := range from.Moments {
+		fromMoments[moment.Moment.UUID] = moment.Moment
+		momentIDs[moment.Moment.UUID] = true
+	}
+	toMoments := map[string]platform.BusinessMoment{}
+	for _, moment := range to.Moments {
+		toMoments[moment.Moment.UUID] = moment.Moment
+		momentIDs[moment.Moment.UUID] = true
+	}
+	for _, id := range sortedKeys(momentIDs) {
+		a, inFrom := fromMoments[id]
+		b, inTo := toMoments[id]
+		switch {
+		case !inTo:
+			drift = append(drift, platform.BusinessMomentsDrift{Kind: KindMoment, ID: id, Name: a.Name, State: DriftOnlyInFrom})
+		case !inFrom:
+			drift = append(drift, platform.BusinessMomentsDrift{Kind: KindMoment, ID: id, Name: b.Name, State: DriftOnlyInTo})
+		default:
+			changes := changedFields(map[string][2]string{
+				"name":           {a.Name, b.Name},
+				"entityTypeId":   {a.EntityTypeID, b.EntityTypeID},
+				"embeddingCode":  {a.EmbeddingCode, b.EmbeddingCode},
+				"projectionCode": {a.ProjectionCode, b.ProjectionCode},
+			})
+			if len(changes) != 0 {
+				drift = append(drift, platform.BusinessMomentsDrift{Kind: KindMoment, ID: id, Name: b.Name, State: DriftChanged, Changes: changes})
+			}
+		}
+	}
+	return drift
+}
+
+func (s *service) Search(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	params := r.URL.Query()
+	query := Query{
+		Environment:    params.Get("environment"),
+		MicroserviceID: params.Get("microserviceId"),
+		EntityTypeID:   params.Get("entityTypeId"),
+		Kind:           params.Get("kind"),
+		Name:           params.Get("name"),
+	}
+
+	if query.Kind != "" && query.Kind != KindMoment && query.Kind != KindEntity {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("kind must be %s or %s", KindMoment, KindEntity))
+		return
+	}
+
+	var err error
+	query.Offset, err = intQueryParam(params.Get("offset"), 0)
+	if err != nil || query.Offset < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "offset must be a number, 0 or more")
+		return
+	}
+	query.Limit, err = intQueryParam(params.Get("limit"), defaultSearchLimit)
+	if err != nil || query.Limit < 1 || query.Limit > maxSearchLimit {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be a number between 1 and %d", maxSearchLimit))
+		return
+	}
+
+	environments, err := s.getBusinessMomentsByEnvironment(customerID, applicationID, query.Environment)
+	if err != nil {
+		s.logContext.WithFields(logrus.Fields{
+			"method":         "Search",
+			"customer_id":    customerID,
+			"application_id": applicationID,
+			"error":          err,
+		}).Error("Could not get the business moments")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, Search(applicationID, environments, query))
+}
+
+func (s *service) Compare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+
+	if from == "" || to == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "from and to environments are required")
+		return
+	}
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "Compare",
+		"customer_id":    customerID,
+		"application_id": applicationID,
+		"from":           from,
+		"to":             to,
+	})
+
+	fromData, err := s.getPublishedBusinessMomentsByEnvironment(customerID, applicationID, from)
+	if err != nil {
+		logContext.WithError(err).Error("Could not get the business moments")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+	toData, err := s.getPublishedBusinessMomentsByEnvironment(customerID, applicationID, to)
+	if err != nil {
+		logContext.WithError(err).Error("Could not get the business moments")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	if len(fromData) == 0 || len(toData) == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Environment not found in the application")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, platform.HttpResponseBusinessMomentsCompare{
+		ApplicationID: applicationID,
+		From:          fromData[0].Environment,
+		To:            toData[0].Environment,
+		Drift:         Compare(fromData[0], toData[0]),
+	})
+}
+
+// getBusinessMomentsByEnvironment gets the business moments of each environment of the application,
+// or only the given one
+func (s *service) getBusinessMomentsByEnvironment(customerID string, applicationID string, environment string) ([]platform.HttpResponseBusinessMoments, error) {
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	environments := make([]platform.HttpResponseBusinessMoments, 0)
+	for _, applicationEnvironment := range application.Environments {
+		if environment != "" && !strings.EqualFold(environment, applicationEnvironment.Name) {
+			continue
+		}
+
+		data, err := s.gitRepo.GetBusinessMoments(customerID, applicationID, strings.ToLower(applicationEnvironment.Name))
+		if err != nil {
+			return nil, err
+		}
+		data.Environment = applicationEnvironment.Name
+		environments = append(environments, data)
+	}
+	return environments, nil
+}
+
+// getPublishedBusinessMomentsByEnvironment gets the published business moments of the given environment,
+// which is what its adaptors run, returning nothing when the application does not have the environment
+func (s *service) getPublishedBusinessMomentsByEnvironment(customerID string, applicationID string, environment string) ([]platform.HttpResponseBusinessMoments, error) {
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	microservices, err := s.gitRepo.GetMicroservices(customerID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	environments := make([]platform.HttpResponseBusinessMoments, 0)
+	for _, applicationEnvironment := range application.Environments {
+		if !strings.EqualFold(environment, applicationEnvironment.Name) {
+			continue
+		}
+		environments = append(environments, PublishedBusinessMoments(applicationID, applicationEnvironment.Name, microservices))
+	}
+	return environments, nil
+}
+
+// PublishedBusinessMoments gets the moments and entities of the version each business moments adaptor in the
+// environment has published, an adaptor that has not published anything has none
+func PublishedBusinessMoments(applicationID string, environment string, microservices []platform.HttpMicroserviceBase) platform.HttpResponseBusinessMoments {
+	data := platform.HttpResponseBusinessMoments{
+		ApplicationID: applicationID,
+		Environment:   environment,
+		Moments:       make([]platform.HttpInputBusinessMoment, 0),
+		Entities:      make([]platform.HttpInputBusinessMomentEntity, 0),
+	}
+
+	for _, microservice := range microservices {
+		if microservice.Kind != platform.MicroserviceKindBusinessMomentsAdaptor || !strings.EqualFold(microservice.Environment, environment) {
+			continue
+		}
+
+		b, _ := json.Marshal(microservice)
+		var adaptor platform.HttpInputBusinessMomentAdaptorInfo
+		if err := json.Unmarshal(b, &adaptor); err != nil {
+			continue
+		}
+
+		for _, version := range adaptor.Extra.Versions {
+			if version.Version != adaptor.Extra.PublishedVersion {
+				continue
+			}
+			for _, moment := range version.Moments {
+				data.Moments = append(data.Moments, platform.HttpInputBusinessMoment{
+					ApplicationID:  applicationID,
+					Environment:    environment,
+					MicroserviceID: adaptor.Dolittle.MicroserviceID,
+					Moment:         moment,
+				})
+			}
+			for _, entity := range version.Entities {
+				data.Entities = append(data.Entities, platform.HttpInputBusinessMomentEntity{
+					ApplicationID:  applicationID,
+					Environment:    environment,
+					MicroserviceID: adaptor.Dolittle.MicroserviceID,
+					Entity:         entity,
+				})
+			}
+		}
+	}
+	return data
+}
+
+func intQueryParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func searchItemName(item platform.BusinessMomentsSearchItem) string {
+	if item.Moment != nil {
+		return item.Moment.Name
+	}
+	return item.Entity.Name
+}
+
+func changedFields(fields map[string][2]string) []string {
+	changes := make([]string, 0)
+	for field, values := range fields {
+		if values[0] != values[1] {
+			changes = append(changes, field)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
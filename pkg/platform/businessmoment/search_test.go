@@ -0,0 +1,229 @@
+package businessmoment_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/businessmoment"
+)
+
+var _ = Describe("Searching business moments", func() {
+	var (
+		applicationID string
+		environments  []platform.HttpResponseBusinessMoments
+		query         businessmoment.Query
+		result        platform.HttpResponseBusinessMomentsSearch
+	)
+
+	newEnvironment := func(environment string, microserviceID string, moments []platform.BusinessMoment, entities []platform.Entity) platform.HttpResponseBusinessMoments {
+		data := platform.HttpResponseBusinessMoments{
+			ApplicationID: applicationID,
+			Environment:   environment,
+		}
+		for _, moment := range moments {
+			data.Moments = append(data.Moments, platform.HttpInputBusinessMoment{
+				ApplicationID:  applicationID,
+				Environment:    environment,
+				MicroserviceID: microserviceID,
+				Moment:         moment,
+			})
+		}
+		for _, entity := range entities {
+			data.Entities = append(data.Entities, platform.HttpInputBusinessMomentEntity{
+				ApplicationID:  applicationID,
+				Environment:    environment,
+				MicroserviceID: microserviceID,
+				Entity:         entity,
+			})
+		}
+		return data
+	}
+
+	BeforeEach(func() {
+		applicationID = "11b6cf47-5d9f-438f-8116-0d9828654657"
+		environments = []platform.HttpResponseBusinessMoments{
+			newEnvironment("Dev", "dev-adaptor",
+				[]platform.BusinessMoment{
+					{UUID: "order-placed", Name: "Order placed", EntityTypeID: "order"},
+					{UUID: "order-shipped", Name: "Order shipped", EntityTypeID: "order"},
+					{UUID: "invoice-sent", Name: "Invoice sent", EntityTypeID: "invoice"},
+				},
+				[]platform.Entity{
+					{EntityTypeID: "order", Name: "Order"},
+					{EntityTypeID: "invoice", Name: "Invoice"},
+				},
+			),
+			newEnvironment("Prod", "prod-adaptor",
+				[]platform.BusinessMoment{
+					{UUID: "order-placed", Name: "Order placed", EntityTypeID: "order"},
+				},
+				[]platform.Entity{
+					{EntityTypeID: "order", Name: "Order"},
+				},
+			),
+		}
+		query = businessmoment.Query{}
+	})
+
+	JustBeforeEach(func() {
+		result = businessmoment.Search(applicationID, environments, query)
+	})
+
+	It("should return everything ordered by environment, microservice, kind and name", func() {
+		Expect(result.Total).To(Equal(7))
+		Expect(result.Items[0].Environment).To(Equal("Dev"))
+		Expect(result.Items[0].Kind).To(Equal(businessmoment.KindEntity))
+		Expect(result.Items[0].Entity.Name).To(Equal("Invoice"))
+		Expect(result.Items[2].Kind).To(Equal(businessmoment.KindMoment))
+		Expect(result.Items[6].Environment).To(Equal("Prod"))
+	})
+
+	When("filtering by environment, kind and name", func() {
+		BeforeEach(func() {
+			query.Environment = "dev"
+			query.Kind = businessmoment.KindMoment
+			query.Name = "ORDER"
+		})
+
+		It("should only return the matching moments", func() {
+			Expect(result.Total).To(Equal(2))
+			Expect(result.Items[0].Moment.UUID).To(Equal("order-placed"))
+			Expect(result.Items[1].Moment.UUID).To(Equal("order-shipped"))
+		})
+	})
+
+	When("filtering by microservice and entity type", func() {
+		BeforeEach(func() {
+			query.MicroserviceID = "dev-adaptor"
+			query.EntityTypeID = "invoice"
+		})
+
+		It("should return the entity and its moments", func() {
+			Expect(result.Total).To(Equal(2))
+			Expect(result.Items[0].Entity.EntityTypeID).To(Equal("invoice"))
+			Expect(result.Items[1].Moment.UUID).To(Equal("invoice-sent"))
+		})
+	})
+
+	When("paginating", func() {
+		BeforeEach(func() {
+			query.Offset = 5
+			query.Limit = 5
+		})
+
+		It("should return the rest and the total", func() {
+			Expect(result.Total).To(Equal(7))
+			Expect(result.Limit).To(Equal(5))
+			Expect(result.Items).To(HaveLen(2))
+		})
+	})
+
+	When("the offset is past the end", func() {
+		BeforeEach(func() {
+			query.Offset = 100
+		})
+
+		It("should return an empty page", func() {
+			Expect(result.Items).To(BeEmpty())
+		})
+	})
+
+	Describe("comparing environments", func() {
+		var drift []platform.BusinessMomentsDrift
+
+		JustBeforeEach(func() {
+			drift = businessmoment.Compare(environments[0], environments[1])
+		})
+
+		It("should find what is only in one of them", func() {
+			Expect(drift).To(ConsistOf(
+				platform.BusinessMomentsDrift{Kind: businessmoment.KindEntity, ID: "invoice", Name: "Invoice", State: businessmoment.DriftOnlyInFrom},
+				platform.BusinessMomentsDrift{Kind: businessmoment.KindMoment, ID: "invoice-sent", Name: "Invoice sent", State: businessmoment.DriftOnlyInFrom},
+				platform.BusinessMomentsDrift{Kind: businessmoment.KindMoment, ID: "order-shipped", Name: "Order shipped", State: businessmoment.DriftOnlyInFrom},
+			))
+		})
+
+		When("the code differs", func() {
+			BeforeEach(func() {
+				environments[1].Moments[0].Moment.EmbeddingCode = "(data) => data"
+				environments[1].Entities = append(environments[1].Entities, platform.HttpInputBusinessMomentEntity{
+					Entity: platform.Entity{EntityTypeID: "customer", Name: "Customer"},
+				})
+			})
+
+			It("should list the changed fields", func() {
+				Expect(drift).To(ContainElement(platform.BusinessMomentsDrift{
+					Kind:    businessmoment.KindMoment,
+					ID:      "order-placed",
+					Name:    "Order placed",
+					State:   businessmoment.DriftChanged,
+					Changes: []string{"embeddingCode"},
+				}))
+				Expect(drift).To(ContainElement(platform.BusinessMomentsDrift{
+					Kind:  businessmoment.KindEntity,
+					ID:    "customer",
+					Name:  "Customer",
+					State: businessmoment.DriftOnlyInTo,
+				}))
+			})
+		})
+	})
+
+	Describe("getting the published business moments", func() {
+		var (
+			microservices []platform.HttpMicroserviceBase
+			published     platform.HttpResponseBusinessMoments
+		)
+
+		newAdaptor := func(environment string, microserviceID string, extra platform.HttpInputBusinessMomentAdaptorExtra) platform.HttpMicroserviceBase {
+			return platform.HttpMicroserviceBase{
+				MicroserviceBase: platform.MicroserviceBase{
+					Kind:        platform.MicroserviceKindBusinessMomentsAdaptor,
+					Environment: environment,
+					Dolittle:    platform.HttpInputDolittle{MicroserviceID: microserviceID},
+				},
+				Extra: extra,
+			}
+		}
+
+		BeforeEach(func() {
+			microservices = []platform.HttpMicroserviceBase{
+				newAdaptor("Dev", "dev-adaptor", platform.HttpInputBusinessMomentAdaptorExtra{
+					Moments:          []platform.BusinessMoment{{UUID: "order-draft", Name: "Order draft"}},
+					Entities:         []platform.Entity{{EntityTypeID: "draft", Name: "Draft"}},
+					PublishedVersion: 1,
+					Versions: []platform.BusinessMomentsVersion{
+						{
+							Version:  1,
+							Moments:  []platform.BusinessMoment{{UUID: "order-placed", Name: "Order placed"}},
+							Entities: []platform.Entity{{EntityTypeID: "order", Name: "Order"}},
+						},
+						{
+							Version: 2,
+							Moments: []platform.BusinessMoment{{UUID: "order-shipped", Name: "Order shipped"}},
+						},
+					},
+				}),
+				newAdaptor("Prod", "prod-adaptor", platform.HttpInputBusinessMomentAdaptorExtra{
+					Moments: []platform.BusinessMoment{{UUID: "order-placed", Name: "Order placed"}},
+				}),
+			}
+		})
+
+		It("should only return the published version of the adaptors in the environment", func() {
+			published = businessmoment.PublishedBusinessMoments(applicationID, "Dev", microservices)
+			Expect(published.Moments).To(HaveLen(1))
+			Expect(published.Moments[0].Moment.UUID).To(Equal("order-placed"))
+			Expect(published.Moments[0].MicroserviceID).To(Equal("dev-adaptor"))
+			Expect(published.Entities).To(HaveLen(1))
+			Expect(published.Entities[0].Entity.EntityTypeID).To(Equal("order"))
+		})
+
+		It("should return nothing for an adaptor that has not published", func() {
+			published = businessmoment.PublishedBusinessMoments(applicationID, "Prod", microservices)
+			Expect(published.Moments).To(BeEmpty())
+			Expect(published.Entities).To(BeEmpty())
+		})
+	})
+})
@@ -1,12 +1,19 @@
 package businessmoment
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/businessmoment/sandbox"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/businessmomentsadaptor"
 	"github.com/dolittle/platform-api/pkg/platform/storage"
@@ -23,6 +30,7 @@ func NewService(logContext logrus.FieldLogger, gitRepo storage.Repo, k8sDolittle
 		k8sDolittleRepo:       k8sDolittleRepo,
 		k8sClient:             k8sClient,
 		k8sBusinessMomentRepo: businessmomentsadaptor.NewK8sRepo(k8sClient),
+		publishLock:           &sync.Mutex{},
 	}
 }
 
@@ -58,12 +66,6 @@ func (s *service) DeleteMoment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.eventUpdateConfigmap(customerID, applicationID, environment, microserviceID)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong whilst updating business moments to microservice")
-		return
-	}
-
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
 		"message":        "Moment removed",
 		"customer_id":    customerID,
@@ -106,12 +108,6 @@ func (s *service) DeleteEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.eventUpdateConfigmap(customerID, applicationID, environment, microserviceID)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong whilst updating business moments to microservice")
-		return
-	}
-
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
 		"message":        "Entity removed",
 		"customer_id":    customerID,
@@ -174,12 +170,6 @@ func (s *service) SaveEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.eventUpdateConfigmap(customerID, input.ApplicationID, input.Environment, input.MicroserviceID)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong whilst updating business moments to microservice")
-		return
-	}
-
 	utils.RespondWithJSON(w, http.StatusOK, input)
 }
 
@@ -235,12 +225,6 @@ func (s *service) SaveMoment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.eventUpdateConfigmap(customerID, applicationID, input.Environment, input.MicroserviceID)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong whilst updating business moments to microservice")
-		return
-	}
-
 	utils.RespondWithJSON(w, http.StatusOK, input)
 }
 
@@ -266,40 +250,109 @@ func (s *service) GetMoments(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusOK, data)
 }
 
-func (s *service) eventUpdateConfigmap(customerID string, applicationID string, environment string, microserviceID string) error {
-	logContext := s.logContext
-	environment = strings.ToLower(environment)
+// TestRun runs the entity and moment code against sample payloads without saving anything
+func (s *service) TestRun(w http.ResponseWriter, r *http.Request) {
+	var input platform.HttpInputBusinessMomentTestRun
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
 
-	//  TODO this should be an event
-	data, err := s.gitRepo.GetBusinessMoments(customerID, applicationID, environment)
+	err = json.Unmarshal(b, &input)
 	if err != nil {
-		logContext.WithFields(logrus.Fields{
-			"error":  err,
-			"method": "s.gitRepo.GetBusinessMoments",
-		}).Error("issue updating configmap")
-		return err
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	applicationID := input.ApplicationID
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
 	}
 
-	configMap, err := s.k8sBusinessMomentRepo.GetBusinessMomentsConfigmap(applicationID, environment, microserviceID)
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":          "TestRun",
+		"customer_id":     customerID,
+		"application_id":  applicationID,
+		"environment":     input.Environment,
+		"microservice_id": input.MicroserviceID,
+	})
+
+	source := "request"
+	payloads := input.Payloads
+	if len(payloads) == 0 {
+		source = "rawdata"
+		payloads, err = s.getLatestRawData(applicationID, input.MicroserviceID)
+		if err != nil {
+			logContext.WithError(err).Error("Could not get the latest raw data")
+			utils.RespondWithError(w, http.StatusBadGateway, "Not able to get the latest raw data from the business moments adaptor, send payloads instead")
+			return
+		}
+	}
+
+	results, err := sandbox.Run(r.Context(), sandbox.NewCode(input.Entity, input.Moment), payloads, sandbox.DefaultTimeout)
 	if err != nil {
-		// TODO defend and make?
-		logContext.WithFields(logrus.Fields{
-			"error":  err,
-			"method": "s.k8sBusinessMomentRepo.GetBusinessMomentsConfigmap",
-		}).Error("issue updating configmap")
-		return err
+		if errors.Is(err, sandbox.ErrInvalidCode) ||
+			errors.Is(err, sandbox.ErrTooManyPayloads) ||
+			errors.Is(err, sandbox.ErrRunTimeout) ||
+			errors.Is(err, sandbox.ErrTooMuchMemory) ||
+			errors.Is(err, sandbox.ErrResultTooLarge) {
+			utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if errors.Is(err, sandbox.ErrTooManyRuns) {
+			utils.RespondWithError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			logContext.Debug("The request was canceled while running the code")
+			return
+		}
+		logContext.WithError(err).Error("Could not run the code")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
 	}
 
-	dataBytes, _ := json.Marshal(data)
-	err = s.k8sBusinessMomentRepo.SaveBusinessMomentsConfigmap(configMap, dataBytes)
+	utils.RespondWithJSON(w, http.StatusOK, platform.HttpResponseBusinessMomentTestRun{
+		ApplicationID:  applicationID,
+		Environment:    input.Environment,
+		MicroserviceID: input.MicroserviceID,
+		Source:         source,
+		Results:        results,
+	})
+}
+
+// getLatestRawData asks the business moments adaptor for the raw data it has seen lately
+func (s *service) getLatestRawData(applicationID string, microserviceID string) ([]json.RawMessage, error) {
+	dnsSRV, err := s.k8sDolittleRepo.GetMicroserviceDNS(applicationID, microserviceID)
 	if err != nil {
-		// TODO
-		logContext.WithFields(logrus.Fields{
-			"error":  err,
-			"method": "s.k8sBusinessMomentRepo.SaveBusinessMomentsConfigmap",
-		}).Error("issue updating configmap")
-		return err
+		return nil, err
 	}
 
-	return nil
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+	response, err := client.Get(fmt.Sprintf("http://%s/rawdata", strings.TrimSuffix(dnsSRV, "/")))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("raw data responded with %d", response.StatusCode)
+	}
+
+	var payloads []json.RawMessage
+	err = json.NewDecoder(io.LimitReader(response.Body, 5*1024*1024)).Decode(&payloads)
+	if err != nil {
+		return nil, err
+	}
+	if len(payloads) > sandbox.MaxPayloads {
+		payloads = payloads[len(payloads)-sandbox.MaxPayloads:]
+	}
+	return payloads, nil
 }
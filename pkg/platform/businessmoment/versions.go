@@ -0,0 +1,316 @@
+package businessmoment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/businessmoment/sandbox"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const annotationBusinessMomentsVersion = "dolittle.io/business-moments-version"
+
+// ValidateBusinessMoments checks the drafts can be published, returning every problem found
+func ValidateBusinessMoments(moments []platform.BusinessMoment, entities []platform.Entity) []string {
+	problems := make([]string, 0)
+
+	entityTypeIDs := map[string]bool{}
+	for _, entity := range entities {
+		if entity.EntityTypeID == "" {
+			problems = append(problems, fmt.Sprintf("entity %s is missing an entityTypeId", entity.Name))
+			continue
+		}
+		if entityTypeIDs[entity.EntityTypeID] {
+			problems = append(problems, fmt.Sprintf("entity %s is there more than once", entity.EntityTypeID))
+		}
+		entityTypeIDs[entity.EntityTypeID] = true
+
+		err := sandbox.Validate(sandbox.Code{
+			Filter:    entity.FilterCode,
+			Transform: entity.TransformCode,
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("entity %s: %s", entity.EntityTypeID, err.Error()))
+		}
+	}
+
+	momentIDs := map[string]bool{}
+	for _, moment := range moments {
+		if moment.UUID == "" {
+			problems = append(problems, fmt.Sprintf("moment %s is missing a uuid", moment.Name))
+			continue
+		}
+		if momentIDs[moment.UUID] {
+			problems = append(problems, fmt.Sprintf("moment %s is there more than once", moment.UUID))
+		}
+		momentIDs[moment.UUID] = true
+
+		if !entityTypeIDs[moment.EntityTypeID] {
+			problems = append(problems, fmt.Sprintf("moment %s uses entity %s which does not exist", moment.UUID, moment.EntityTypeID))
+		}
+
+		err := sandbox.Validate(sandbox.Code{
+			Embedding:  moment.EmbeddingCode,
+			Projection: moment.ProjectionCode,
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("moment %s: %s", moment.UUID, err.Error()))
+		}
+	}
+	return problems
+}
+
+func (s *service) GetVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	environment := strings.ToLower(vars["environment"])
+	microserviceID := vars["microserviceID"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	versions, err := s.gitRepo.GetBusinessMomentsVersions(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		s.respondWithStorageError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, versions)
+}
+
+// Publish validates the drafts and makes them the next version the adaptor runs
+func (s *service) Publish(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	environment := strings.ToLower(vars["environment"])
+	microserviceID := vars["microserviceID"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":          "Publish",
+		"customer_id":     customerID,
+		"application_id":  applicationID,
+		"environment":     environment,
+		"microservice_id": microserviceID,
+	})
+
+	s.publishLock.Lock()
+	defer s.publishLock.Unlock()
+
+	versions, err := s.gitRepo.GetBusinessMomentsVersions(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		s.respondWithStorageError(w, err)
+		return
+	}
+
+	problems := ValidateBusinessMoments(versions.DraftMoments, versions.DraftEntities)
+	if len(problems) != 0 {
+		utils.RespondWithJSON(w, http.StatusUnprocessableEntity, platform.HttpResponseInvalidBusinessMoments{
+			Message: "The business moments can not be published",
+			Errors:  problems,
+		})
+		return
+	}
+
+	latest := 0
+	for _, version := range versions.Versions {
+		if version.Version > latest {
+			latest = version.Version
+		}
+	}
+
+	version := platform.BusinessMomentsVersion{
+		Version:     latest + 1,
+		PublishedAt: time.Now().UTC().Format(time.RFC3339),
+		PublishedBy: userID,
+		Moments:     versions.DraftMoments,
+		Entities:    versions.DraftEntities,
+	}
+
+	restore, err := s.publishToConfigmap(applicationID, environment, microserviceID, version)
+	if err != nil {
+		logContext.WithError(err).Error("Could not update the business moments configmap")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong whilst updating business moments to microservice")
+		return
+	}
+
+	err = s.gitRepo.SaveBusinessMomentsVersion(customerID, applicationID, environment, microserviceID, version)
+	if err != nil {
+		logContext.WithError(err).Error("Could not save the business moments version")
+		if restoreErr := restore(); restoreErr != nil {
+			logContext.WithError(restoreErr).Error("Could not restore the business moments configmap")
+		}
+		if errors.Is(err, storage.ErrVersionConflict) {
+			utils.RespondWithError(w, http.StatusConflict, fmt.Sprintf("Version %d was published by someone else, try again", version.Version))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, version)
+}
+
+// RollbackVersion makes a previously published version the one the adaptor runs, the drafts are left alone
+func (s *service) RollbackVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	environment := strings.ToLower(vars["environment"])
+	microserviceID := vars["microserviceID"]
+
+	versionNumber, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "version must be a number")
+		return
+	}
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":          "RollbackVersion",
+		"customer_id":     customerID,
+		"application_id":  applicationID,
+		"environment":     environment,
+		"microservice_id": microserviceID,
+		"version":         versionNumber,
+	})
+
+	s.publishLock.Lock()
+	defer s.publishLock.Unlock()
+
+	versions, err := s.gitRepo.GetBusinessMomentsVersions(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		s.respondWithStorageError(w, err)
+		return
+	}
+
+	var version *platform.BusinessMomentsVersion
+	for index := range versions.Versions {
+		if versions.Versions[index].Version == versionNumber {
+			version = &versions.Versions[index]
+			break
+		}
+	}
+	if version == nil {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Version %d not found", versionNumber))
+		return
+	}
+
+	restore, err := s.publishToConfigmap(applicationID, environment, microserviceID, *version)
+	if err != nil {
+		logContext.WithError(err).Error("Could not update the business moments configmap")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong whilst updating business moments to microservice")
+		return
+	}
+
+	err = s.gitRepo.SetPublishedBusinessMomentsVersion(customerID, applicationID, environment, microserviceID, version.Version)
+	if err != nil {
+		logContext.WithError(err).Error("Could not save the published business moments version")
+		if restoreErr := restore(); restoreErr != nil {
+			logContext.WithError(restoreErr).Error("Could not restore the business moments configmap")
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, version)
+}
+
+// publishToConfigmap replaces the business moments of the adaptor with the version in one update,
+// returning a function that puts back what was there before
+func (s *service) publishToConfigmap(applicationID string, environment string, microserviceID string, version platform.BusinessMomentsVersion) (func() error, error) {
+	configMap, err := s.k8sBusinessMomentRepo.GetBusinessMomentsConfigmap(applicationID, environment, microserviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousData := configMap.Data["businessmoments.json"]
+	previousVersion := configMap.Annotations[annotationBusinessMomentsVersion]
+
+	data := platform.HttpResponseBusinessMoments{
+		ApplicationID: applicationID,
+		Environment:   environment,
+		Moments:       make([]platform.HttpInputBusinessMoment, 0),
+		Entities:      make([]platform.HttpInputBusinessMomentEntity, 0),
+	}
+	for _, moment := range version.Moments {
+		data.Moments = append(data.Moments, platform.HttpInputBusinessMoment{
+			ApplicationID:  applicationID,
+			Environment:    environment,
+			MicroserviceID: microserviceID,
+			Moment:         moment,
+		})
+	}
+	for _, entity := range version.Entities {
+		data.Entities = append(data.Entities, platform.HttpInputBusinessMomentEntity{
+			ApplicationID:  applicationID,
+			Environment:    environment,
+			MicroserviceID: microserviceID,
+			Entity:         entity,
+		})
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[annotationBusinessMomentsVersion] = strconv.Itoa(version.Version)
+	err = s.k8sBusinessMomentRepo.SaveBusinessMomentsConfigmap(configMap, dataBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	restore := func() error {
+		current, err := s.k8sBusinessMomentRepo.GetBusinessMomentsConfigmap(applicationID, environment, microserviceID)
+		if err != nil {
+			return err
+		}
+		if previousVersion == "" {
+			delete(current.Annotations, annotationBusinessMomentsVersion)
+		} else {
+			current.Annotations[annotationBusinessMomentsVersion] = previousVersion
+		}
+		return s.k8sBusinessMomentRepo.SaveBusinessMomentsConfigmap(current, []byte(previousData))
+	}
+	return restore, nil
+}
+
+func (s *service) respondWithStorageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, "Not able to find microservice in the storage")
+	case errors.Is(err, storage.ErrNotBusinessMomentsAdaptor):
+		utils.RespondWithError(w, http.StatusBadRequest, "Not Business moment to find microservice in the storage")
+	default:
+		s.logContext.WithError(err).Error("storage")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+	}
+}
@@ -0,0 +1,83 @@
+package businessmoment_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/businessmoment"
+)
+
+var _ = Describe("Validating business moments", func() {
+	var (
+		moments  []platform.BusinessMoment
+		entities []platform.Entity
+		problems []string
+	)
+
+	BeforeEach(func() {
+		entities = []platform.Entity{
+			{
+				Name:          "Order",
+				EntityTypeID:  "order",
+				FilterCode:    `(data) => data.type === "order"`,
+				TransformCode: `(data) => ({ id: data.id })`,
+			},
+		}
+		moments = []platform.BusinessMoment{
+			{
+				Name:           "Order placed",
+				UUID:           "order-placed",
+				EntityTypeID:   "order",
+				EmbeddingCode:  `(data, entity) => ({ orderId: entity.id })`,
+				ProjectionCode: `(state, moment) => moment`,
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		problems = businessmoment.ValidateBusinessMoments(moments, entities)
+	})
+
+	It("should find no problems", func() {
+		Expect(problems).To(BeEmpty())
+	})
+
+	When("the code does not compile", func() {
+		BeforeEach(func() {
+			entities[0].TransformCode = `(data) => {`
+			moments[0].ProjectionCode = `not a function`
+		})
+
+		It("should find a problem in both", func() {
+			Expect(problems).To(HaveLen(2))
+			Expect(problems[0]).To(ContainSubstring("entity order"))
+			Expect(problems[1]).To(ContainSubstring("moment order-placed"))
+		})
+	})
+
+	When("a moment uses an entity that does not exist", func() {
+		BeforeEach(func() {
+			moments[0].EntityTypeID = "invoice"
+		})
+
+		It("should find the problem", func() {
+			Expect(problems).To(ConsistOf(ContainSubstring("uses entity invoice which does not exist")))
+		})
+	})
+
+	When("ids are missing or used twice", func() {
+		BeforeEach(func() {
+			entities = append(entities, entities[0], platform.Entity{Name: "Nameless"})
+			moments = append(moments, moments[0])
+		})
+
+		It("should find each problem", func() {
+			Expect(problems).To(ConsistOf(
+				ContainSubstring("entity order is there more than once"),
+				ContainSubstring("entity Nameless is missing an entityTypeId"),
+				ContainSubstring("moment order-placed is there more than once"),
+			))
+		})
+	})
+})
@@ -0,0 +1,50 @@
+package customer
+
+import (
+	"fmt"
+
+	"github.com/dolittle/platform-api/pkg/platform/application"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/platform/user"
+)
+
+// CanDelete stops customers with an application with a Prod environment from being deleted, unless forced
+func CanDelete(applications []storage.JSONApplication, force bool) error {
+	for _, app := range applications {
+		err := application.CanDelete(app, force)
+		if err != nil {
+			return fmt.Errorf("application %s: %w", app.Name, err)
+		}
+	}
+	return nil
+}
+
+// RemoveFromUsers takes the customer out of every user in kratos that has access to it, and returns the ids of those users
+func RemoveFromUsers(kratosClient user.KratosClientV5, customerID string) ([]string, error) {
+	removed := make([]string, 0)
+	users, err := kratosClient.GetUsers()
+	if err != nil {
+		return removed, err
+	}
+
+	for _, kratosUser := range users {
+		if !user.UserCustomersContains(kratosUser, customerID) {
+			continue
+		}
+
+		tenants := make([]string, 0, len(kratosUser.Traits.Tenants))
+		for _, tenant := range kratosUser.Traits.Tenants {
+			if tenant != customerID {
+				tenants = append(tenants, tenant)
+			}
+		}
+		kratosUser.Traits.Tenants = tenants
+
+		err := kratosClient.UpdateUser(kratosUser)
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, kratosUser.ID)
+	}
+	return removed, nil
+}
@@ -0,0 +1,78 @@
+package customer_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mockUser "github.com/dolittle/platform-api/mocks/pkg/platform/user"
+	"github.com/dolittle/platform-api/pkg/platform/application"
+	"github.com/dolittle/platform-api/pkg/platform/customer"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/platform/user"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Deleting a customer", func() {
+	Describe("checking it can be deleted", func() {
+		var applications []storage.JSONApplication
+
+		BeforeEach(func() {
+			applications = []storage.JSONApplication{
+				{Name: "Taco", Environments: []storage.JSONEnvironment{{Name: "Dev"}}},
+				{Name: "Burrito", Environments: []storage.JSONEnvironment{{Name: "Dev"}, {Name: "Prod"}}},
+			}
+		})
+
+		It("should not delete a customer with an application with a Prod environment", func() {
+			err := customer.CanDelete(applications, false)
+			Expect(errors.Is(err, application.ErrHasProductionEnvironment)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("Burrito"))
+		})
+
+		It("should delete it when forced", func() {
+			Expect(customer.CanDelete(applications, true)).To(Succeed())
+		})
+
+		It("should delete a customer without applications", func() {
+			Expect(customer.CanDelete([]storage.JSONApplication{}, false)).To(Succeed())
+		})
+	})
+
+	Describe("removing it from its users", func() {
+		var (
+			customerID   string
+			kratosClient *mockUser.KratosClientV5
+		)
+
+		BeforeEach(func() {
+			customerID = "453e04a7-4f9d-42f2-b36c-d51fa2c83fa3"
+			kratosClient = new(mockUser.KratosClientV5)
+			kratosClient.On("GetUsers").Return([]user.KratosUser{
+				{ID: "both", Traits: user.KratosUserTraits{Tenants: []string{"other", customerID}}},
+				{ID: "other", Traits: user.KratosUserTraits{Tenants: []string{"other"}}},
+			}, nil)
+		})
+
+		It("should only update the users of the customer", func() {
+			kratosClient.On("UpdateUser", user.KratosUser{
+				ID:     "both",
+				Traits: user.KratosUserTraits{Tenants: []string{"other"}},
+			}).Return(nil)
+
+			removed, err := customer.RemoveFromUsers(kratosClient, customerID)
+			Expect(err).To(BeNil())
+			Expect(removed).To(Equal([]string{"both"}))
+			kratosClient.AssertNumberOfCalls(GinkgoT(), "UpdateUser", 1)
+		})
+
+		It("should stop when a user can not be updated", func() {
+			kratosClient.On("UpdateUser", mock.Anything).Return(errors.New("kratos is down"))
+
+			removed, err := customer.RemoveFromUsers(kratosClient, customerID)
+			Expect(err).NotTo(BeNil())
+			Expect(removed).To(BeEmpty())
+		})
+	})
+})
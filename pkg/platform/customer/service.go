@@ -2,8 +2,12 @@ package customer
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/mail"
+	"os"
 
 	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
 	"github.com/dolittle/platform-api/pkg/k8s"
@@ -15,7 +19,9 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/thoas/go-funk"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -29,13 +35,22 @@ type HTTPStudioConfig struct {
 	BuildOverwrite       bool     `json:"buildOverwrite"`
 	DisabledEnvironments []string `json:"disabledEnvironments"`
 	CanCreateApplication bool     `json:"canCreateApplication"`
+	Suspended            bool     `json:"suspended"`
+}
+
+type HTTPResponseSuspend struct {
+	CustomerID  string `json:"customerId"`
+	Suspended   bool   `json:"suspended"`
+	Deployments int    `json:"deployments"`
 }
 
 type CustomerRepo interface {
 	GetCustomers() ([]platform.Customer, error)
+	GetCustomer(customerID string) (storage.JSONCustomer, error)
 	SaveCustomer(customer storage.JSONCustomer) error
 	GetApplications(customerID string) ([]storage.JSONApplication, error)
 	GetStudioConfig(customerID string) (platform.StudioConfig, error)
+	SaveStudioConfig(customerID string, config platform.StudioConfig) error
 }
 
 type service struct {
@@ -52,6 +67,11 @@ type HttpCustomerInput struct {
 	Name string `json:"name"`
 }
 
+type HttpCustomerUpdateInput struct {
+	Name    string                    `json:"name"`
+	Contact *platform.CustomerContact `json:"contact"`
+}
+
 func NewService(
 	k8sclient kubernetes.Interface,
 	storageRepo CustomerRepo,
@@ -217,6 +237,7 @@ func (s *service) GetOne(w http.ResponseWriter, r *http.Request) {
 			BuildOverwrite:       studioConfig.BuildOverwrite,
 			DisabledEnvironments: studioConfig.DisabledEnvironments,
 			CanCreateApplication: studioConfig.CanCreateApplication,
+			Suspended:            studioConfig.Suspended,
 		},
 	}
 
@@ -233,6 +254,330 @@ func (s *service) GetOne(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
 
+// Update renames the customer and replaces its contact.
+// The resources in the cluster are labelled with the name the customer had when they were made, and keep it
+func (s *service) Update(w http.ResponseWriter, r *http.Request) {
+	if !s.hasAdminAccess(w, r) {
+		return
+	}
+
+	var input HttpCustomerUpdateInput
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	err = json.Unmarshal(b, &input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if !IsCustomerNameValid(input.Name) {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, "Customer name is not valid")
+		return
+	}
+
+	if input.Contact != nil && !IsContactValid(*input.Contact) {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, "Contact email is not valid")
+		return
+	}
+
+	customer, ok := s.getCustomer(w, mux.Vars(r)["customerID"])
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":      "Update",
+		"customer_id": customer.ID,
+		"old_name":    customer.Name,
+		"name":        input.Name,
+	})
+
+	customer.Name = input.Name
+	customer.Contact = input.Contact
+	err = s.storageRepo.SaveCustomer(customer)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save customer")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save customer")
+		return
+	}
+
+	logContext.Info("Customer updated")
+	utils.RespondWithJSON(w, http.StatusOK, platform.Customer{
+		ID:      customer.ID,
+		Name:    customer.Name,
+		Contact: customer.Contact,
+	})
+}
+
+// Suspend stops the customer from changing anything through the api, then scales every deployment of its applications to zero
+func (s *service) Suspend(w http.ResponseWriter, r *http.Request) {
+	if !s.hasAdminAccess(w, r) {
+		return
+	}
+
+	customer, ok := s.getCustomer(w, mux.Vars(r)["customerID"])
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":      "Suspend",
+		"customer_id": customer.ID,
+	})
+
+	applicationIDs, err := s.getApplicationIDs(customer.ID)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to get the applications")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get the applications")
+		return
+	}
+
+	err = s.setSuspended(customer.ID, true)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the studio config")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to suspend customer")
+		return
+	}
+
+	scaled, err := SuspendApplications(r.Context(), s.k8sclient, applicationIDs)
+	if err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error":       err,
+			"deployments": scaled,
+		}).Error("Failed to scale down the deployments")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to scale down the deployments, suspend the customer again to retry")
+		return
+	}
+
+	logContext.WithField("deployments", scaled).Info("Customer suspended")
+	utils.RespondWithJSON(w, http.StatusOK, HTTPResponseSuspend{
+		CustomerID:  customer.ID,
+		Suspended:   true,
+		Deployments: scaled,
+	})
+}
+
+// Resume scales the deployments of the customer back up to what they were before it was suspended, then lets it make changes again
+func (s *service) Resume(w http.ResponseWriter, r *http.Request) {
+	if !s.hasAdminAccess(w, r) {
+		return
+	}
+
+	customer, ok := s.getCustomer(w, mux.Vars(r)["customerID"])
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":      "Resume",
+		"customer_id": customer.ID,
+	})
+
+	applicationIDs, err := s.getApplicationIDs(customer.ID)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to get the applications")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get the applications")
+		return
+	}
+
+	scaled, err := ResumeApplications(r.Context(), s.k8sclient, applicationIDs)
+	if err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error":       err,
+			"deployments": scaled,
+		}).Error("Failed to scale up the deployments")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to scale up the deployments, resume the customer again to retry")
+		return
+	}
+
+	err = s.setSuspended(customer.ID, false)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the studio config")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to resume customer")
+		return
+	}
+
+	logContext.WithField("deployments", scaled).Info("Customer resumed")
+	utils.RespondWithJSON(w, http.StatusOK, HTTPResponseSuspend{
+		CustomerID:  customer.ID,
+		Suspended:   false,
+		Deployments: scaled,
+	})
+}
+
+// Delete suspends the customer and starts the job deleting it, see DeleteCustomerResource for what it does.
+// Customers with an application with a Prod environment are only deleted with ?force=true
+func (s *service) Delete(w http.ResponseWriter, r *http.Request) {
+	if !s.hasAdminAccess(w, r) {
+		return
+	}
+
+	force := r.FormValue("force") == "true"
+	customer, ok := s.getCustomer(w, mux.Vars(r)["customerID"])
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":      "Delete",
+		"customer_id": customer.ID,
+		"user_id":     r.Header.Get("User-ID"),
+		"force":       force,
+	})
+
+	applications, err := s.storageRepo.GetApplications(customer.ID)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to get the applications")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get the applications")
+		return
+	}
+
+	err = CanDelete(applications, force)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	applicationIDs := make([]string, 0, len(applications))
+	for _, application := range applications {
+		applicationIDs = append(applicationIDs, application.ID)
+	}
+
+	resource := jobK8s.DeleteCustomerResource(
+		s.jobResourceConfig,
+		dolittleK8s.ShortInfo{
+			ID:   customer.ID,
+			Name: customer.Name,
+		},
+		applicationIDs,
+		force,
+	)
+
+	previous, err := s.k8sclient.BatchV1().Jobs(resource.Namespace).Get(r.Context(), resource.Name, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		logContext.WithField("error", err).Error("Failed to get the previous job")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete customer")
+		return
+	}
+
+	if err == nil {
+		if !jobK8s.IsJobFinished(*previous) {
+			utils.RespondWithError(w, http.StatusConflict, "Customer is already being deleted")
+			return
+		}
+
+		// A job from an earlier attempt that failed is in the way
+		propagation := metav1.DeletePropagationBackground
+		err = s.k8sclient.BatchV1().Jobs(resource.Namespace).Delete(r.Context(), resource.Name, metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			logContext.WithField("error", err).Error("Failed to remove the previous job")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete customer")
+			return
+		}
+	}
+
+	// Nothing should be changed while the customer is being deleted
+	err = s.setSuspended(customer.ID, true)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the studio config")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to storage")
+		return
+	}
+
+	err = jobK8s.DoJob(s.k8sclient, resource)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to create job to delete customer")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete customer")
+		return
+	}
+
+	logContext.WithField("job_id", resource.Name).Info("Deleting customer")
+	utils.RespondWithJSON(
+		w,
+		http.StatusAccepted,
+		map[string]string{
+			"jobId": resource.Name,
+		},
+	)
+}
+
+func (s *service) hasAdminAccess(w http.ResponseWriter, r *http.Request) bool {
+	userID := r.Header.Get("User-ID")
+	hasAccess, err := s.roleBindingRepo.HasUserAdminAccess(userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check if user has access")
+		return false
+	}
+
+	if !hasAccess {
+		utils.RespondWithError(w, http.StatusForbidden, "You do not have access")
+		return false
+	}
+	return true
+}
+
+func (s *service) getCustomer(w http.ResponseWriter, customerID string) (storage.JSONCustomer, bool) {
+	customer, err := s.storageRepo.GetCustomer(customerID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Customer %s not found", customerID))
+			return customer, false
+		}
+		s.logContext.WithFields(logrus.Fields{
+			"method":      "getCustomer",
+			"customer_id": customerID,
+			"error":       err,
+		}).Error("Failed to get customer")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get customer")
+		return customer, false
+	}
+	return customer, true
+}
+
+func (s *service) getApplicationIDs(customerID string) ([]string, error) {
+	applications, err := s.storageRepo.GetApplications(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	applicationIDs := make([]string, 0, len(applications))
+	for _, application := range applications {
+		applicationIDs = append(applicationIDs, application.ID)
+	}
+	return applicationIDs, nil
+}
+
+// setSuspended keeps the rest of the studio config, a customer without one gets one with only the suspension
+func (s *service) setSuspended(customerID string, suspended bool) error {
+	studioConfig, err := s.storageRepo.GetStudioConfig(customerID)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err == nil && studioConfig.Suspended == suspended {
+		return nil
+	}
+
+	studioConfig.Suspended = suspended
+	return s.storageRepo.SaveStudioConfig(customerID, studioConfig)
+}
+
+// IsContactValid checks the email is a plain address, the rest of the contact is free text
+func IsContactValid(contact platform.CustomerContact) bool {
+	if contact.Email == "" {
+		return true
+	}
+	address, err := mail.ParseAddress(contact.Email)
+	return err == nil && address.Address == contact.Email
+}
+
 func IsCustomerNameValid(name string) bool {
 	isValid := validation.NameIsDNSLabel(name, false)
 	return len(isValid) == 0
@@ -0,0 +1,185 @@
+package customer_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mockK8s "github.com/dolittle/platform-api/mocks/pkg/k8s"
+	mockCustomer "github.com/dolittle/platform-api/mocks/pkg/platform/customer"
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/customer"
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/gorilla/mux"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Customer service", func() {
+	var (
+		customerID      string
+		applicationID   string
+		userID          string
+		repo            *mockCustomer.CustomerRepo
+		roleBindingRepo *mockK8s.RepoRoleBinding
+		objects         []runtime.Object
+		clientSet       *fake.Clientset
+		router          *mux.Router
+		recorder        *httptest.ResponseRecorder
+	)
+
+	serve := func(method string, url string, body string) {
+		request := httptest.NewRequest(method, url, strings.NewReader(body))
+		request.Header.Set("User-ID", userID)
+		router.ServeHTTP(recorder, request)
+	}
+
+	BeforeEach(func() {
+		customerID = "453e04a7-4f9d-42f2-b36c-d51fa2c83fa3"
+		applicationID = "11b6cf47-5d9f-438f-8116-0d9828654657"
+		userID = "local-dev"
+		repo = new(mockCustomer.CustomerRepo)
+		roleBindingRepo = new(mockK8s.RepoRoleBinding)
+		roleBindingRepo.On("HasUserAdminAccess", userID).Return(true, nil)
+		objects = []runtime.Object{}
+		recorder = httptest.NewRecorder()
+
+		repo.On("GetCustomer", customerID).Return(storage.JSONCustomer{ID: customerID, Name: "Taco"}, nil)
+		repo.On("GetCustomer", mock.Anything).Return(storage.JSONCustomer{}, storage.ErrNotFound)
+	})
+
+	JustBeforeEach(func() {
+		clientSet = fake.NewSimpleClientset(objects...)
+		logger, _ := logrusTest.NewNullLogger()
+		service := customer.NewService(
+			clientSet,
+			repo,
+			jobK8s.CreateResourceConfig{Namespace: "system-api"},
+			logger,
+			roleBindingRepo,
+		)
+		router = mux.NewRouter()
+		router.HandleFunc("/customer/{customerID}", service.Update).Methods(http.MethodPut)
+		router.HandleFunc("/customer/{customerID}", service.Delete).Methods(http.MethodDelete)
+		router.HandleFunc("/customer/{customerID}/suspend", service.Suspend).Methods(http.MethodPost)
+		router.HandleFunc("/customer/{customerID}/resume", service.Resume).Methods(http.MethodPost)
+	})
+
+	Describe("updating a customer", func() {
+		It("should rename it and replace its contact", func() {
+			repo.On("SaveCustomer", storage.JSONCustomer{
+				ID:      customerID,
+				Name:    "burrito",
+				Contact: &platform.CustomerContact{Name: "Jane", Email: "jane@example.com"},
+			}).Return(nil)
+
+			serve(http.MethodPut, "/customer/"+customerID, `{"name": "burrito", "contact": {"name": "Jane", "email": "jane@example.com"}}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var response platform.Customer
+			json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(response.Name).To(Equal("burrito"))
+			Expect(response.Contact.Email).To(Equal("jane@example.com"))
+		})
+
+		It("should not accept an email that is not a plain address", func() {
+			serve(http.MethodPut, "/customer/"+customerID, `{"name": "burrito", "contact": {"email": "Jane <jane@example.com>"}}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			repo.AssertNotCalled(GinkgoT(), "SaveCustomer", mock.Anything)
+		})
+
+		It("should not accept an invalid name", func() {
+			serve(http.MethodPut, "/customer/"+customerID, `{"name": "Not Valid"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should not find a customer that does not exist", func() {
+			serve(http.MethodPut, "/customer/unknown", `{"name": "burrito"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("suspending a customer", func() {
+		BeforeEach(func() {
+			replicas := int32(1)
+			objects = append(objects, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-welcome", Namespace: "application-" + applicationID},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			})
+
+			repo.On("GetApplications", customerID).Return([]storage.JSONApplication{{ID: applicationID}}, nil)
+			repo.On("GetStudioConfig", customerID).Return(platform.StudioConfig{BackupStore: platform.BackupStoreAzureFiles}, nil)
+		})
+
+		It("should block changes and scale down the deployments", func() {
+			repo.On("SaveStudioConfig", customerID, platform.StudioConfig{
+				BackupStore: platform.BackupStoreAzureFiles,
+				Suspended:   true,
+			}).Return(nil)
+
+			serve(http.MethodPost, "/customer/"+customerID+"/suspend", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var response customer.HTTPResponseSuspend
+			json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(response.Suspended).To(BeTrue())
+			Expect(response.Deployments).To(Equal(1))
+			repo.AssertExpectations(GinkgoT())
+
+			deployment, _ := clientSet.AppsV1().Deployments("application-"+applicationID).Get(context.TODO(), "dev-welcome", metav1.GetOptions{})
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(0)))
+		})
+	})
+
+	Describe("deleting a customer", func() {
+		BeforeEach(func() {
+			repo.On("GetApplications", customerID).Return([]storage.JSONApplication{
+				{ID: applicationID, Name: "Taco", Environments: []storage.JSONEnvironment{{Name: "Prod"}}},
+			}, nil)
+			repo.On("GetStudioConfig", customerID).Return(platform.StudioConfig{}, nil)
+			repo.On("SaveStudioConfig", customerID, platform.StudioConfig{Suspended: true}).Return(nil)
+		})
+
+		It("should not delete a customer with a Prod environment unless forced", func() {
+			serve(http.MethodDelete, "/customer/"+customerID, "")
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+			repo.AssertNotCalled(GinkgoT(), "SaveStudioConfig", mock.Anything, mock.Anything)
+		})
+
+		It("should suspend the customer and start the job", func() {
+			serve(http.MethodDelete, "/customer/"+customerID+"?force=true", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusAccepted))
+			repo.AssertExpectations(GinkgoT())
+
+			job, err := clientSet.BatchV1().Jobs("system-api").Get(context.TODO(), jobK8s.DeleteCustomerJobName(customerID), metav1.GetOptions{})
+			Expect(err).To(BeNil())
+			Expect(job.Spec.Template.Spec.InitContainers).To(ContainElement(HaveField("Name", "terraform-destroy")))
+		})
+
+		It("should not start another job while one is running", func() {
+			clientSet.BatchV1().Jobs("system-api").Create(context.TODO(), &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: jobK8s.DeleteCustomerJobName(customerID), Namespace: "system-api"},
+			}, metav1.CreateOptions{})
+
+			serve(http.MethodDelete, "/customer/"+customerID+"?force=true", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+})
@@ -0,0 +1,83 @@
+package customer
+
+import (
+	"context"
+	"strconv"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SuspendedReplicasAnnotation is how many replicas the deployment had before it was suspended
+const SuspendedReplicasAnnotation = "dolittle.io/suspended-replicas"
+
+// SuspendApplications scales every deployment of the applications to zero, and returns how many were scaled down.
+// The replicas are kept on the deployment, deployments that are already suspended are left as they are
+func SuspendApplications(ctx context.Context, client kubernetes.Interface, applicationIDs []string) (int, error) {
+	return updateDeployments(ctx, client, applicationIDs, func(deployment *appsv1.Deployment) bool {
+		if _, ok := deployment.Annotations[SuspendedReplicasAnnotation]; ok {
+			return false
+		}
+
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if deployment.Annotations == nil {
+			deployment.Annotations = make(map[string]string)
+		}
+		deployment.Annotations[SuspendedReplicasAnnotation] = strconv.Itoa(int(replicas))
+
+		zero := int32(0)
+		deployment.Spec.Replicas = &zero
+		return true
+	})
+}
+
+// ResumeApplications scales the deployments suspended by SuspendApplications back up, and returns how many were scaled up
+func ResumeApplications(ctx context.Context, client kubernetes.Interface, applicationIDs []string) (int, error) {
+	return updateDeployments(ctx, client, applicationIDs, func(deployment *appsv1.Deployment) bool {
+		value, ok := deployment.Annotations[SuspendedReplicasAnnotation]
+		if !ok {
+			return false
+		}
+
+		replicas, err := strconv.Atoi(value)
+		if err != nil || replicas < 0 {
+			replicas = 1
+		}
+		delete(deployment.Annotations, SuspendedReplicasAnnotation)
+
+		restored := int32(replicas)
+		deployment.Spec.Replicas = &restored
+		return true
+	})
+}
+
+// updateDeployments saves the deployments in the namespaces of the applications that change says it changed
+func updateDeployments(ctx context.Context, client kubernetes.Interface, applicationIDs []string, change func(deployment *appsv1.Deployment) bool) (int, error) {
+	updated := 0
+	for _, applicationID := range applicationIDs {
+		namespace := platformK8s.GetApplicationNamespace(applicationID)
+		deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return updated, err
+		}
+
+		for _, deployment := range deployments.Items {
+			deployment := deployment
+			if !change(&deployment) {
+				continue
+			}
+
+			_, err := client.AppsV1().Deployments(namespace).Update(ctx, &deployment, metav1.UpdateOptions{})
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,80 @@
+package customer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform/customer"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Suspending the applications of a customer", func() {
+	var (
+		applicationIDs []string
+		namespace      string
+		clientSet      *fake.Clientset
+	)
+
+	newDeployment := func(name string, replicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+
+	getDeployment := func(name string) *appsv1.Deployment {
+		deployment, err := clientSet.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		return deployment
+	}
+
+	BeforeEach(func() {
+		applicationIDs = []string{"11b6cf47-5d9f-438f-8116-0d9828654657"}
+		namespace = "application-11b6cf47-5d9f-438f-8116-0d9828654657"
+		clientSet = fake.NewSimpleClientset(
+			newDeployment("dev-welcome", 2),
+			newDeployment("prod-welcome", 0),
+		)
+	})
+
+	It("should scale the deployments to zero and remember their replicas", func() {
+		scaled, err := customer.SuspendApplications(context.TODO(), clientSet, applicationIDs)
+		Expect(err).To(BeNil())
+		Expect(scaled).To(Equal(2))
+
+		deployment := getDeployment("dev-welcome")
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(0)))
+		Expect(deployment.Annotations[customer.SuspendedReplicasAnnotation]).To(Equal("2"))
+	})
+
+	It("should leave deployments that are already suspended", func() {
+		customer.SuspendApplications(context.TODO(), clientSet, applicationIDs)
+		scaled, err := customer.SuspendApplications(context.TODO(), clientSet, applicationIDs)
+		Expect(err).To(BeNil())
+		Expect(scaled).To(Equal(0))
+		Expect(getDeployment("dev-welcome").Annotations[customer.SuspendedReplicasAnnotation]).To(Equal("2"))
+	})
+
+	It("should scale the deployments back up when resumed", func() {
+		customer.SuspendApplications(context.TODO(), clientSet, applicationIDs)
+		scaled, err := customer.ResumeApplications(context.TODO(), clientSet, applicationIDs)
+		Expect(err).To(BeNil())
+		Expect(scaled).To(Equal(2))
+
+		deployment := getDeployment("dev-welcome")
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+		Expect(deployment.Annotations).NotTo(HaveKey(customer.SuspendedReplicasAnnotation))
+		Expect(*getDeployment("prod-welcome").Spec.Replicas).To(Equal(int32(0)))
+	})
+
+	It("should not scale up deployments that were not suspended", func() {
+		scaled, err := customer.ResumeApplications(context.TODO(), clientSet, applicationIDs)
+		Expect(err).To(BeNil())
+		Expect(scaled).To(Equal(0))
+		Expect(*getDeployment("prod-welcome").Spec.Replicas).To(Equal(int32(0)))
+	})
+})
@@ -0,0 +1,170 @@
+package customertenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// generatedHostDomain is where the hosts made by the platform are
+const generatedHostDomain = "dolittle.cloud"
+
+var (
+	ErrInvalidCustomerTenantID = errors.New("customer tenant id is not a valid uuid")
+	ErrInvalidHost             = errors.New("host is not valid")
+	ErrAlreadyExists           = errors.New("customer tenant already exists")
+	ErrHostInUse               = errors.New("host is already in use")
+	ErrReservedHost            = fmt.Errorf("hosts under %s are made by the platform", generatedHostDomain)
+	ErrUnverifiedHost          = errors.New("host is not under a domain the customer has verified")
+	ErrLastCustomerTenant      = errors.New("an environment needs at least one customer tenant")
+)
+
+// NewCustomerTenant checks the input and fills in what is missing, the customer tenant id and a host under dolittle.cloud.
+// Custom hosts have to be under one of the verified domains of the customer, and can not be under dolittle.cloud.
+// A host without a secret name gets one made from the host
+func NewCustomerTenant(input HttpInputCustomerTenant, environment string, verifiedDomains []string) (platform.CustomerTenantInfo, error) {
+	customerTenantID := input.CustomerTenantID
+	if customerTenantID == "" {
+		customerTenantID = uuid.New().String()
+	}
+	if _, err := uuid.Parse(customerTenantID); err != nil {
+		return platform.CustomerTenantInfo{}, ErrInvalidCustomerTenantID
+	}
+
+	hosts := make([]platform.CustomerTenantHost, 0)
+	for _, host := range input.Hosts {
+		host.Host = strings.ToLower(host.Host)
+		if errs := validation.IsDNS1123Subdomain(host.Host); len(errs) > 0 {
+			return platform.CustomerTenantInfo{}, fmt.Errorf("%w: %s %s", ErrInvalidHost, host.Host, strings.Join(errs, ", "))
+		}
+		if isUnderDomain(host.Host, generatedHostDomain) {
+			return platform.CustomerTenantInfo{}, fmt.Errorf("%w: %s", ErrReservedHost, host.Host)
+		}
+		if !isUnderAnyDomain(host.Host, verifiedDomains) {
+			return platform.CustomerTenantInfo{}, fmt.Errorf("%w: %s", ErrUnverifiedHost, host.Host)
+		}
+
+		if host.SecretName == "" {
+			host.SecretName = fmt.Sprintf("%s-certificate", strings.ReplaceAll(host.Host, ".", "-"))
+		}
+		if errs := validation.IsDNS1123Subdomain(host.SecretName); len(errs) > 0 {
+			return platform.CustomerTenantInfo{}, fmt.Errorf("%w: secret name %s %s", ErrInvalidHost, host.SecretName, strings.Join(errs, ", "))
+		}
+		hosts = append(hosts, host)
+	}
+
+	if len(hosts) == 0 {
+		hosts = append(hosts, dolittleK8s.NewCustomerTenantHost(""))
+	}
+
+	return platform.CustomerTenantInfo{
+		Alias:            input.Alias,
+		Environment:      environment,
+		CustomerTenantID: customerTenantID,
+		Hosts:            hosts,
+		MicroservicesRel: make([]platform.CustomerTenantMicroserviceRel, 0),
+	}, nil
+}
+
+// AddCustomerTenant adds the customer tenant to the environment, its id and hosts have to be unique within it
+func AddCustomerTenant(environment storage.JSONEnvironment, customerTenant platform.CustomerTenantInfo) (storage.JSONEnvironment, error) {
+	for _, current := range environment.CustomerTenants {
+		if current.CustomerTenantID == customerTenant.CustomerTenantID {
+			return environment, ErrAlreadyExists
+		}
+
+		for _, currentHost := range current.Hosts {
+			for _, host := range customerTenant.Hosts {
+				if strings.EqualFold(currentHost.Host, host.Host) {
+					return environment, fmt.Errorf("%w: %s", ErrHostInUse, host.Host)
+				}
+			}
+		}
+	}
+
+	customerTenants := make([]platform.CustomerTenantInfo, 0, len(environment.CustomerTenants)+1)
+	customerTenants = append(customerTenants, environment.CustomerTenants...)
+	environment.CustomerTenants = append(customerTenants, customerTenant)
+	return environment, nil
+}
+
+// RemoveCustomerTenant removes the customer tenant from the environment, the last one is never removed
+func RemoveCustomerTenant(environment storage.JSONEnvironment, customerTenantID string) (storage.JSONEnvironment, error) {
+	customerTenants := make([]platform.CustomerTenantInfo, 0)
+	for _, current := range environment.CustomerTenants {
+		if current.CustomerTenantID != customerTenantID {
+			customerTenants = append(customerTenants, current)
+		}
+	}
+
+	if len(customerTenants) == len(environment.CustomerTenants) {
+		return environment, storage.ErrNotFound
+	}
+	if len(customerTenants) == 0 {
+		return environment, ErrLastCustomerTenant
+	}
+
+	environment.CustomerTenants = customerTenants
+	return environment, nil
+}
+
+// EnsureHostsAreFree checks the hosts are not used by a customer tenant of any of the applications, or by any ingress in the cluster
+func EnsureHostsAreFree(ctx context.Context, client kubernetes.Interface, applications []storage.JSONApplication, hosts []platform.CustomerTenantHost) error {
+	used := map[string]bool{}
+	for _, application := range applications {
+		for _, environment := range application.Environments {
+			for _, customerTenant := range environment.CustomerTenants {
+				for _, host := range customerTenant.Hosts {
+					used[strings.ToLower(host.Host)] = true
+				}
+			}
+		}
+	}
+
+	ingresses, err := client.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, ingress := range ingresses.Items {
+		for _, rule := range ingress.Spec.Rules {
+			used[strings.ToLower(rule.Host)] = true
+		}
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				used[strings.ToLower(host)] = true
+			}
+		}
+	}
+
+	for _, host := range hosts {
+		if used[strings.ToLower(host.Host)] {
+			return fmt.Errorf("%w: %s", ErrHostInUse, host.Host)
+		}
+	}
+	return nil
+}
+
+func isUnderAnyDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if isUnderDomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnderDomain is true for the domain itself and its subdomains
+func isUnderDomain(host string, domain string) bool {
+	host = strings.ToLower(host)
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
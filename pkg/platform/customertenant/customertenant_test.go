@@ -0,0 +1,168 @@
+package customertenant_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/customertenant"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Customer tenants", func() {
+	Describe("making one from the input", func() {
+		It("should make the id and a host when they are missing", func() {
+			customerTenant, err := customertenant.NewCustomerTenant(customertenant.HttpInputCustomerTenant{Alias: "Test"}, "Dev", nil)
+			Expect(err).To(BeNil())
+			Expect(customerTenant.CustomerTenantID).To(HaveLen(36))
+			Expect(customerTenant.Environment).To(Equal("Dev"))
+			Expect(customerTenant.Hosts).To(HaveLen(1))
+			Expect(customerTenant.Hosts[0].Host).To(HaveSuffix(".dolittle.cloud"))
+		})
+
+		It("should keep custom hosts and name the secret after the host", func() {
+			customerTenant, err := customertenant.NewCustomerTenant(customertenant.HttpInputCustomerTenant{
+				CustomerTenantID: "445f8ea8-1a6f-40d7-b2fc-796dba92dc44",
+				Hosts: []platform.CustomerTenantHost{
+					{Host: "Shop.Example.com"},
+					{Host: "api.example.com", SecretName: "api-tls"},
+				},
+			}, "Prod", []string{"example.com"})
+			Expect(err).To(BeNil())
+			Expect(customerTenant.CustomerTenantID).To(Equal("445f8ea8-1a6f-40d7-b2fc-796dba92dc44"))
+			Expect(customerTenant.Hosts).To(Equal([]platform.CustomerTenantHost{
+				{Host: "shop.example.com", SecretName: "shop-example-com-certificate"},
+				{Host: "api.example.com", SecretName: "api-tls"},
+			}))
+		})
+
+		It("should not allow an id that is not a uuid", func() {
+			_, err := customertenant.NewCustomerTenant(customertenant.HttpInputCustomerTenant{CustomerTenantID: "tenant"}, "Dev", nil)
+			Expect(err).To(Equal(customertenant.ErrInvalidCustomerTenantID))
+		})
+
+		It("should not allow hosts that are not valid", func() {
+			_, err := customertenant.NewCustomerTenant(customertenant.HttpInputCustomerTenant{
+				Hosts: []platform.CustomerTenantHost{{Host: "not a host"}},
+			}, "Dev", []string{"example.com"})
+			Expect(errors.Is(err, customertenant.ErrInvalidHost)).To(BeTrue())
+		})
+
+		It("should not allow hosts under a domain the customer has not verified", func() {
+			_, err := customertenant.NewCustomerTenant(customertenant.HttpInputCustomerTenant{
+				Hosts: []platform.CustomerTenantHost{{Host: "shop.notexample.com"}},
+			}, "Dev", []string{"example.com"})
+			Expect(errors.Is(err, customertenant.ErrUnverifiedHost)).To(BeTrue())
+		})
+
+		It("should not allow hosts under dolittle.cloud", func() {
+			_, err := customertenant.NewCustomerTenant(customertenant.HttpInputCustomerTenant{
+				Hosts: []platform.CustomerTenantHost{{Host: "someone-else.dolittle.cloud"}},
+			}, "Dev", []string{"dolittle.cloud"})
+			Expect(errors.Is(err, customertenant.ErrReservedHost)).To(BeTrue())
+		})
+	})
+
+	Describe("checking hosts are free", func() {
+		var (
+			applications []storage.JSONApplication
+			clientSet    *fake.Clientset
+		)
+
+		BeforeEach(func() {
+			applications = []storage.JSONApplication{
+				{
+					Environments: []storage.JSONEnvironment{
+						{
+							Name: "Prod",
+							CustomerTenants: []platform.CustomerTenantInfo{
+								{Hosts: []platform.CustomerTenantHost{{Host: "shop.example.com"}}},
+							},
+						},
+					},
+				},
+			}
+			clientSet = fake.NewSimpleClientset(&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod-web", Namespace: "application-of-another-customer"},
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "api.example.com"}},
+				},
+			})
+		})
+
+		It("should allow hosts that are not used", func() {
+			err := customertenant.EnsureHostsAreFree(context.TODO(), clientSet, applications, []platform.CustomerTenantHost{{Host: "new.example.com"}})
+			Expect(err).To(BeNil())
+		})
+
+		It("should not allow a host used by another application", func() {
+			err := customertenant.EnsureHostsAreFree(context.TODO(), clientSet, applications, []platform.CustomerTenantHost{{Host: "shop.example.com"}})
+			Expect(errors.Is(err, customertenant.ErrHostInUse)).To(BeTrue())
+		})
+
+		It("should not allow a host used by an ingress anywhere in the cluster", func() {
+			err := customertenant.EnsureHostsAreFree(context.TODO(), clientSet, applications, []platform.CustomerTenantHost{{Host: "API.example.com"}})
+			Expect(errors.Is(err, customertenant.ErrHostInUse)).To(BeTrue())
+		})
+	})
+
+	Describe("changing the ones of an environment", func() {
+		var environment storage.JSONEnvironment
+
+		BeforeEach(func() {
+			environment = storage.JSONEnvironment{
+				Name: "Dev",
+				CustomerTenants: []platform.CustomerTenantInfo{
+					{
+						CustomerTenantID: "445f8ea8-1a6f-40d7-b2fc-796dba92dc44",
+						Hosts:            []platform.CustomerTenantHost{{Host: "shop.example.com"}},
+					},
+				},
+			}
+		})
+
+		It("should add a new one", func() {
+			updated, err := customertenant.AddCustomerTenant(environment, platform.CustomerTenantInfo{CustomerTenantID: "7cd3a1c4-f25e-4f4e-a2d3-24cd4bd0ab39"})
+			Expect(err).To(BeNil())
+			Expect(updated.CustomerTenants).To(HaveLen(2))
+			Expect(environment.CustomerTenants).To(HaveLen(1))
+		})
+
+		It("should not add one that is there", func() {
+			_, err := customertenant.AddCustomerTenant(environment, platform.CustomerTenantInfo{CustomerTenantID: "445f8ea8-1a6f-40d7-b2fc-796dba92dc44"})
+			Expect(err).To(Equal(customertenant.ErrAlreadyExists))
+		})
+
+		It("should not share hosts", func() {
+			_, err := customertenant.AddCustomerTenant(environment, platform.CustomerTenantInfo{
+				CustomerTenantID: "7cd3a1c4-f25e-4f4e-a2d3-24cd4bd0ab39",
+				Hosts:            []platform.CustomerTenantHost{{Host: "shop.example.com"}},
+			})
+			Expect(errors.Is(err, customertenant.ErrHostInUse)).To(BeTrue())
+		})
+
+		It("should remove one", func() {
+			environment, _ = customertenant.AddCustomerTenant(environment, platform.CustomerTenantInfo{CustomerTenantID: "7cd3a1c4-f25e-4f4e-a2d3-24cd4bd0ab39"})
+			updated, err := customertenant.RemoveCustomerTenant(environment, "445f8ea8-1a6f-40d7-b2fc-796dba92dc44")
+			Expect(err).To(BeNil())
+			Expect(updated.CustomerTenants).To(HaveLen(1))
+			Expect(updated.CustomerTenants[0].CustomerTenantID).To(Equal("7cd3a1c4-f25e-4f4e-a2d3-24cd4bd0ab39"))
+		})
+
+		It("should not remove one that is not there", func() {
+			_, err := customertenant.RemoveCustomerTenant(environment, "7cd3a1c4-f25e-4f4e-a2d3-24cd4bd0ab39")
+			Expect(err).To(Equal(storage.ErrNotFound))
+		})
+
+		It("should not remove the last one", func() {
+			_, err := customertenant.RemoveCustomerTenant(environment, "445f8ea8-1a6f-40d7-b2fc-796dba92dc44")
+			Expect(err).To(Equal(customertenant.ErrLastCustomerTenant))
+		})
+	})
+})
@@ -0,0 +1,17 @@
+package customertenant
+
+import "github.com/dolittle/platform-api/pkg/platform"
+
+type HttpInputCustomerTenant struct {
+	// CustomerTenantID is made when empty
+	CustomerTenantID string `json:"customerTenantId"`
+	Alias            string `json:"alias"`
+	// Hosts get a generated host under dolittle.cloud when empty
+	Hosts []platform.CustomerTenantHost `json:"hosts"`
+}
+
+type HttpResponseCustomerTenants struct {
+	ApplicationID   string                        `json:"applicationId"`
+	Environment     string                        `json:"environment"`
+	CustomerTenants []platform.CustomerTenantInfo `json:"customerTenants"`
+}
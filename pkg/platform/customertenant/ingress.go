@@ -20,10 +20,14 @@ func CreateIngresses(isProduction bool, customerTenants []platform.CustomerTenan
 
 	ingresses := make([]*networkingv1.Ingress, 0)
 	for _, customerTenant := range customerTenants {
-		for _, config := range customerTenant.Hosts {
+		for index, config := range customerTenant.Hosts {
 			// At this point we are assumed secret name is correct
 			ingress := dolittleK8s.NewMicroserviceIngressWithEmptyRules(isProduction, microservice)
 			newName := fmt.Sprintf("%s-%s", ingress.ObjectMeta.Name, customerTenant.CustomerTenantID[0:7])
+			// The first host keeps the name it has always had
+			if index > 0 {
+				newName = fmt.Sprintf("%s-%d", newName, index)
+			}
 			ingress.ObjectMeta.Name = newName
 			ingress = dolittleK8s.AddCustomerTenantIDToIngress(customerTenant.CustomerTenantID, ingress)
 			ingress.Spec.TLS = dolittleK8s.AddIngressTLS([]string{config.Host}, config.SecretName)
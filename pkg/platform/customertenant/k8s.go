@@ -0,0 +1,237 @@
+package customertenant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform"
+	applicationK8s "github.com/dolittle/platform-api/pkg/platform/application/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/automate"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SyncToCluster makes the tenants configmap, the ingresses and the resources.json of every microservice
+// in the environment match its customer tenants.
+// The microservices have to be restarted to pick up the changes to resources.json
+func SyncToCluster(ctx context.Context, client kubernetes.Interface, isProduction bool, application storage.JSONApplication, environment storage.JSONEnvironment) error {
+	namespace := platformK8s.GetApplicationNamespace(application.ID)
+
+	err := updateTenantsConfigMap(ctx, client, namespace, application, environment)
+	if err != nil {
+		return fmt.Errorf("tenants configmap: %w", err)
+	}
+
+	err = updateMicroserviceResources(ctx, client, namespace, environment)
+	if err != nil {
+		return fmt.Errorf("microservice resources: %w", err)
+	}
+
+	err = updateIngresses(ctx, client, isProduction, namespace, environment)
+	if err != nil {
+		return fmt.Errorf("ingresses: %w", err)
+	}
+	return nil
+}
+
+// GetMicroservicesRel finds the microservices running in the environment, to relate them to a customer tenant
+func GetMicroservicesRel(ctx context.Context, client kubernetes.Interface, applicationID string, environment string, customerTenantID string) ([]platform.CustomerTenantMicroserviceRel, error) {
+	relationships := make([]platform.CustomerTenantMicroserviceRel, 0)
+
+	configMaps, err := getDolittleConfigMaps(ctx, client, platformK8s.GetApplicationNamespace(applicationID), environment)
+	if err != nil {
+		return relationships, err
+	}
+
+	for _, configMap := range configMaps {
+		microserviceID := configMap.Annotations["dolittle.io/microservice-id"]
+		if microserviceID == "" {
+			continue
+		}
+		relationships = append(relationships, platform.CustomerTenantMicroserviceRel{
+			MicroserviceID: microserviceID,
+			Hash:           dolittleK8s.ResourcePrefix(microserviceID, customerTenantID),
+		})
+	}
+	return relationships, nil
+}
+
+func updateTenantsConfigMap(ctx context.Context, client kubernetes.Interface, namespace string, application storage.JSONApplication, environment storage.JSONEnvironment) error {
+	tenant := dolittleK8s.Tenant{
+		ID:   application.CustomerID,
+		Name: application.CustomerName,
+	}
+	applicationInfo := dolittleK8s.Application{
+		ID:   application.ID,
+		Name: application.Name,
+	}
+	desired := applicationK8s.NewTenantsConfigMap(environment.Name, tenant, applicationInfo, environment.CustomerTenants)
+
+	current, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+
+	current.Data = desired.Data
+	_, err = client.CoreV1().ConfigMaps(namespace).Update(ctx, current, metav1.UpdateOptions{})
+	return err
+}
+
+func updateMicroserviceResources(ctx context.Context, client kubernetes.Interface, namespace string, environment storage.JSONEnvironment) error {
+	configMaps, err := getDolittleConfigMaps(ctx, client, namespace, environment.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, configMap := range configMaps {
+		data, ok := configMap.Data["resources.json"]
+		if !ok {
+			continue
+		}
+
+		var current dolittleK8s.MicroserviceResources
+		err := json.Unmarshal([]byte(data), &current)
+		if err != nil {
+			return fmt.Errorf("failed to parse resources.json in %s: %w", configMap.Name, err)
+		}
+
+		microservice := automate.ConvertObjectMetaToMicroservice(&configMap)
+		desired := dolittleK8s.NewMicroserviceResources(microservice, environment.CustomerTenants)
+
+		// The customer tenants that stay keep what they have, it might have been changed by hand
+		changed := false
+		for customerTenantID := range current {
+			if _, ok := desired[customerTenantID]; !ok {
+				delete(current, customerTenantID)
+				changed = true
+			}
+		}
+		for customerTenantID, resource := range desired {
+			if _, ok := current[customerTenantID]; !ok {
+				current[customerTenantID] = resource
+				changed = true
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		b, _ := json.MarshalIndent(current, "", "  ")
+		configMap.Data["resources.json"] = string(b)
+		_, err = client.CoreV1().ConfigMaps(namespace).Update(ctx, &configMap, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("configmap %s: %w", configMap.Name, err)
+		}
+	}
+	return nil
+}
+
+// updateIngresses removes the ingresses of customer tenants that are gone, and gives the new ones the same ingress
+// as the others on every microservice that is exposed
+func updateIngresses(ctx context.Context, client kubernetes.Interface, isProduction bool, namespace string, environment storage.JSONEnvironment) error {
+	ingresses, err := client.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("environment=%s", platformK8s.ParseLabel(environment.Name)),
+	})
+	if err != nil {
+		return err
+	}
+
+	byMicroservice := make(map[string][]networkingv1.Ingress)
+	for _, ingress := range ingresses.Items {
+		microserviceID := ingress.Annotations["dolittle.io/microservice-id"]
+		if microserviceID == "" || getCustomerTenantID(ingress) == "" {
+			continue
+		}
+		byMicroservice[microserviceID] = append(byMicroservice[microserviceID], ingress)
+	}
+
+	wanted := make(map[string]bool)
+	for _, customerTenant := range environment.CustomerTenants {
+		wanted[customerTenant.CustomerTenantID] = true
+	}
+
+	for _, current := range byMicroservice {
+		exposed := make(map[string]bool)
+		for _, ingress := range current {
+			customerTenantID := getCustomerTenantID(ingress)
+			if wanted[customerTenantID] {
+				exposed[customerTenantID] = true
+				continue
+			}
+
+			err := client.NetworkingV1().Ingresses(namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return fmt.Errorf("ingress %s: %w", ingress.Name, err)
+			}
+		}
+
+		missing := make([]platform.CustomerTenantInfo, 0)
+		for _, customerTenant := range environment.CustomerTenants {
+			if !exposed[customerTenant.CustomerTenantID] {
+				missing = append(missing, customerTenant)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		template := current[0]
+		if len(template.Spec.Rules) == 0 || template.Spec.Rules[0].HTTP == nil || len(template.Spec.Rules[0].HTTP.Paths) == 0 {
+			continue
+		}
+		path := template.Spec.Rules[0].HTTP.Paths[0]
+		if path.Backend.Service == nil {
+			continue
+		}
+
+		ingressInfo := platform.HttpInputSimpleIngress{
+			Path:     path.Path,
+			Pathtype: string(networkingv1.PathTypePrefix),
+		}
+		if path.PathType != nil {
+			ingressInfo.Pathtype = string(*path.PathType)
+		}
+
+		microservice := automate.ConvertObjectMetaToMicroservice(&template)
+		for _, ingress := range CreateIngresses(isProduction, missing, microservice, path.Backend.Service.Name, ingressInfo) {
+			_, err := client.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, metav1.CreateOptions{})
+			if err != nil && !k8serrors.IsAlreadyExists(err) {
+				return fmt.Errorf("ingress %s: %w", ingress.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func getCustomerTenantID(ingress networkingv1.Ingress) string {
+	snippet := ingress.Annotations["nginx.ingress.kubernetes.io/configuration-snippet"]
+	return platformK8s.GetCustomerTenantIDFromNginxConfigurationSnippet(snippet)
+}
+
+func getDolittleConfigMaps(ctx context.Context, client kubernetes.Interface, namespace string, environment string) ([]corev1.ConfigMap, error) {
+	configMaps, err := automate.GetDolittleConfigMaps(ctx, client, namespace)
+	if err != nil {
+		return configMaps, err
+	}
+
+	results := make([]corev1.ConfigMap, 0)
+	for _, configMap := range configMaps {
+		if configMap.Labels["environment"] != platformK8s.ParseLabel(environment) {
+			continue
+		}
+		results = append(results, configMap)
+	}
+	return results, nil
+}
@@ -0,0 +1,153 @@
+package customertenant_test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/customertenant"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Syncing customer tenants to the cluster", func() {
+	var (
+		application    storage.JSONApplication
+		environment    storage.JSONEnvironment
+		microservice   dolittleK8s.Microservice
+		namespace      string
+		oldTenant      platform.CustomerTenantInfo
+		newTenant      platform.CustomerTenantInfo
+		clientSet      *fake.Clientset
+		err            error
+		microserviceID string
+	)
+
+	BeforeEach(func() {
+		microserviceID = "ffb20e4f-9f5e-4b8f-a0a5-4a1d1ec1e4f0"
+		application = storage.JSONApplication{
+			ID:           "11b6cf47-5d9f-438f-8116-0d9828654657",
+			Name:         "Taco",
+			CustomerID:   "c0e4b6b4-51a6-4e41-8f05-6eab4b1d2b1c",
+			CustomerName: "Customer",
+		}
+		namespace = "application-" + application.ID
+		microservice = dolittleK8s.Microservice{
+			ID:          microserviceID,
+			Name:        "Welcome",
+			Tenant:      dolittleK8s.Tenant{ID: application.CustomerID, Name: application.CustomerName},
+			Application: dolittleK8s.Application{ID: application.ID, Name: application.Name},
+			Environment: "Dev",
+			Kind:        platform.MicroserviceKindSimple,
+		}
+		oldTenant = dolittleK8s.NewCustomerTenantInfo("Dev", microserviceID, "445f8ea8-1a6f-40d7-b2fc-796dba92dc44")
+		newTenant = dolittleK8s.NewCustomerTenantInfo("Dev", microserviceID, "7cd3a1c4-f25e-4f4e-a2d3-24cd4bd0ab39")
+
+		ingresses := customertenant.CreateIngresses(false, []platform.CustomerTenantInfo{oldTenant}, microservice, "dev-welcome", platform.HttpInputSimpleIngress{
+			Path:     "/welcome",
+			Pathtype: "Prefix",
+		})
+
+		clientSet = fake.NewSimpleClientset(
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-tenants", Namespace: namespace},
+				Data:       map[string]string{"tenants.json": "{}"},
+			},
+			dolittleK8s.NewMicroserviceConfigmap(microservice, []platform.CustomerTenantInfo{oldTenant}),
+			ingresses[0],
+		)
+
+		environment = storage.JSONEnvironment{
+			Name:            "Dev",
+			CustomerTenants: []platform.CustomerTenantInfo{newTenant},
+		}
+	})
+
+	JustBeforeEach(func() {
+		err = customertenant.SyncToCluster(context.TODO(), clientSet, false, application, environment)
+	})
+
+	It("should write the customer tenants to the tenants configmap", func() {
+		Expect(err).To(BeNil())
+		configMap, _ := clientSet.CoreV1().ConfigMaps(namespace).Get(context.TODO(), "dev-tenants", metav1.GetOptions{})
+
+		var tenants platform.RuntimeTenantsIDS
+		json.Unmarshal([]byte(configMap.Data["tenants.json"]), &tenants)
+		Expect(tenants).To(HaveKey(newTenant.CustomerTenantID))
+		Expect(tenants).NotTo(HaveKey(oldTenant.CustomerTenantID))
+	})
+
+	It("should update the resources of the microservice", func() {
+		Expect(err).To(BeNil())
+		configMap, _ := clientSet.CoreV1().ConfigMaps(namespace).Get(context.TODO(), "dev-welcome-dolittle", metav1.GetOptions{})
+
+		var resources dolittleK8s.MicroserviceResources
+		json.Unmarshal([]byte(configMap.Data["resources.json"]), &resources)
+		Expect(resources).To(HaveKey(newTenant.CustomerTenantID))
+		Expect(resources).NotTo(HaveKey(oldTenant.CustomerTenantID))
+	})
+
+	It("should replace the ingress of the removed customer tenant", func() {
+		Expect(err).To(BeNil())
+		ingresses, _ := clientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), metav1.ListOptions{})
+		Expect(ingresses.Items).To(HaveLen(1))
+
+		ingress := ingresses.Items[0]
+		Expect(ingress.Name).To(Equal("dev-welcome-7cd3a1c"))
+		Expect(ingress.Spec.TLS[0].Hosts).To(Equal([]string{newTenant.Hosts[0].Host}))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/welcome"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(Equal("dev-welcome"))
+	})
+
+	When("a customer tenant is added", func() {
+		BeforeEach(func() {
+			environment.CustomerTenants = []platform.CustomerTenantInfo{oldTenant, newTenant}
+		})
+
+		It("should keep the ingress of the customer tenant that was there", func() {
+			Expect(err).To(BeNil())
+			ingresses, _ := clientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), metav1.ListOptions{})
+			names := make([]string, 0)
+			for _, ingress := range ingresses.Items {
+				names = append(names, ingress.Name)
+			}
+			Expect(names).To(ConsistOf("dev-welcome-445f8ea", "dev-welcome-7cd3a1c"))
+		})
+	})
+
+	It("should relate the microservices of the environment", func() {
+		relationships, err := customertenant.GetMicroservicesRel(context.TODO(), clientSet, application.ID, "Dev", newTenant.CustomerTenantID)
+		Expect(err).To(BeNil())
+		Expect(relationships).To(Equal([]platform.CustomerTenantMicroserviceRel{
+			{MicroserviceID: microserviceID, Hash: "ffb20e4_7cd3a1c"},
+		}))
+	})
+})
+
+var _ = Describe("Ingresses for customer tenants", func() {
+	It("should give every host its own name", func() {
+		customerTenant := platform.CustomerTenantInfo{
+			CustomerTenantID: "445f8ea8-1a6f-40d7-b2fc-796dba92dc44",
+			Hosts: []platform.CustomerTenantHost{
+				{Host: "shop.example.com", SecretName: "shop"},
+				{Host: "api.example.com", SecretName: "api"},
+			},
+		}
+		microservice := dolittleK8s.Microservice{
+			Name:        "Welcome",
+			Environment: "Dev",
+			Application: dolittleK8s.Application{ID: "11b6cf47-5d9f-438f-8116-0d9828654657"},
+		}
+
+		ingresses := customertenant.CreateIngresses(false, []platform.CustomerTenantInfo{customerTenant}, microservice, "dev-welcome", platform.HttpInputSimpleIngress{Path: "/", Pathtype: "Prefix"})
+		Expect(ingresses).To(HaveLen(2))
+		Expect(ingresses[0].Name).To(Equal("dev-welcome-445f8ea"))
+		Expect(ingresses[1].Name).To(Equal("dev-welcome-445f8ea-1"))
+	})
+})
@@ -0,0 +1,251 @@
+package customertenant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CustomerTenantRepo is the storage of the applications and the studio config of their customer
+type CustomerTenantRepo interface {
+	storage.RepoApplication
+	GetStudioConfig(customerID string) (platform.StudioConfig, error)
+}
+
+type service struct {
+	k8sClient       kubernetes.Interface
+	storageRepo     CustomerTenantRepo
+	k8sDolittleRepo platformK8s.K8sRepo
+	isProduction    bool
+	logContext      logrus.FieldLogger
+}
+
+func NewService(
+	k8sClient kubernetes.Interface,
+	storageRepo CustomerTenantRepo,
+	k8sDolittleRepo platformK8s.K8sRepo,
+	isProduction bool,
+	logContext logrus.FieldLogger,
+) service {
+	return service{
+		k8sClient:       k8sClient,
+		storageRepo:     storageRepo,
+		k8sDolittleRepo: k8sDolittleRepo,
+		isProduction:    isProduction,
+		logContext:      logContext,
+	}
+}
+
+// GetAll lists the customer tenants of the environment, as they are stored
+func (s *service) GetAll(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r)
+	if !ok {
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, HttpResponseCustomerTenants{
+		ApplicationID:   application.ID,
+		Environment:     environment.Name,
+		CustomerTenants: environment.CustomerTenants,
+	})
+}
+
+// Create adds a customer tenant to the environment, with an ingress on every exposed microservice
+// and resources in every microservice
+func (s *service) Create(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r)
+	if !ok {
+		return
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "Create",
+		"customer_id":    application.CustomerID,
+		"application_id": application.ID,
+		"environment":    environment.Name,
+	})
+
+	var input HttpInputCustomerTenant
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	err = json.Unmarshal(b, &input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	studioConfig, err := s.storageRepo.GetStudioConfig(application.CustomerID)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logContext.WithField("error", err).Error("Failed to get the studio config")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	customerTenant, err := NewCustomerTenant(input, environment.Name, studioConfig.VerifiedDomains)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	applications, err := s.storageRepo.GetApplications(application.CustomerID)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to get the applications of the customer")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+	err = EnsureHostsAreFree(r.Context(), s.k8sClient, applications, customerTenant.Hosts)
+	if err != nil {
+		if errors.Is(err, ErrHostInUse) {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		logContext.WithField("error", err).Error("Failed to check the hosts are free")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	customerTenant.MicroservicesRel, err = GetMicroservicesRel(r.Context(), s.k8sClient, application.ID, environment.Name, customerTenant.CustomerTenantID)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to find the microservices in the environment")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return
+	}
+
+	updated, err := AddCustomerTenant(environment, customerTenant)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if !s.save(w, r, logContext, application, environment, updated) {
+		return
+	}
+
+	logContext.WithField("customer_tenant_id", customerTenant.CustomerTenantID).Info("Customer tenant added")
+	utils.RespondWithJSON(w, http.StatusCreated, customerTenant)
+}
+
+// Delete removes the customer tenant from the environment, its data in mongo is left as it is
+func (s *service) Delete(w http.ResponseWriter, r *http.Request) {
+	application, environment, ok := s.getEnvironment(w, r)
+	if !ok {
+		return
+	}
+
+	customerTenantID := mux.Vars(r)["customerTenantID"]
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":             "Delete",
+		"customer_id":        application.CustomerID,
+		"application_id":     application.ID,
+		"environment":        environment.Name,
+		"customer_tenant_id": customerTenantID,
+	})
+
+	updated, err := RemoveCustomerTenant(environment, customerTenantID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Customer tenant %s not found", customerTenantID))
+			return
+		}
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if !s.save(w, r, logContext, application, environment, updated) {
+		return
+	}
+
+	logContext.Info("Customer tenant removed")
+	utils.RespondWithJSON(w, http.StatusOK, HttpResponseCustomerTenants{
+		ApplicationID:   application.ID,
+		Environment:     updated.Name,
+		CustomerTenants: updated.CustomerTenants,
+	})
+}
+
+// save updates the cluster first, then storage. If storage fails the cluster is put back as it was
+func (s *service) save(w http.ResponseWriter, r *http.Request, logContext logrus.FieldLogger, application storage.JSONApplication, previous storage.JSONEnvironment, updated storage.JSONEnvironment) bool {
+	err := SyncToCluster(r.Context(), s.k8sClient, s.isProduction, application, updated)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to update the cluster")
+		s.restore(r, logContext, application, previous)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update the customer tenants")
+		return false
+	}
+
+	for index, environment := range application.Environments {
+		if environment.Name == updated.Name {
+			application.Environments[index] = updated
+		}
+	}
+
+	err = s.storageRepo.SaveApplication(application)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the customer tenants")
+		s.restore(r, logContext, application, previous)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to storage")
+		return false
+	}
+	return true
+}
+
+func (s *service) restore(r *http.Request, logContext logrus.FieldLogger, application storage.JSONApplication, previous storage.JSONEnvironment) {
+	err := SyncToCluster(r.Context(), s.k8sClient, s.isProduction, application, previous)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to put the cluster back as it was")
+	}
+}
+
+func (s *service) getEnvironment(w http.ResponseWriter, r *http.Request) (storage.JSONApplication, storage.JSONEnvironment, bool) {
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	environmentName := vars["environment"]
+
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return storage.JSONApplication{}, storage.JSONEnvironment{}, false
+	}
+
+	application, err := s.storageRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Application %s not found", applicationID))
+			return application, storage.JSONEnvironment{}, false
+		}
+		s.logContext.WithFields(logrus.Fields{
+			"error":          err,
+			"customer_id":    customerID,
+			"application_id": applicationID,
+		}).Error("Failed to get the application")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return application, storage.JSONEnvironment{}, false
+	}
+
+	for _, environment := range application.Environments {
+		if strings.EqualFold(environment.Name, environmentName) {
+			return application, environment, true
+		}
+	}
+
+	utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Environment %s not found", environmentName))
+	return application, storage.JSONEnvironment{}, false
+}
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"encoding/json"
 	"errors"
 
 	authv1 "k8s.io/api/authorization/v1"
@@ -180,6 +181,9 @@ type HttpInputBusinessMomentAdaptorExtra struct {
 	Connector    interface{}            `json:"connector"`
 	Moments      []BusinessMoment       `json:"moments"`
 	Entities     []Entity               `json:"entities"`
+	// Moments and Entities are the drafts, the adaptor runs the published version
+	PublishedVersion int                      `json:"publishedVersion,omitempty"`
+	Versions         []BusinessMomentsVersion `json:"versions,omitempty"`
 }
 
 type HttpInputBusinessMomentAdaptorConnectorWebhook struct {
@@ -216,9 +220,27 @@ type HttpInputRawDataLogIngestorExtra struct {
 }
 
 type RawDataLogIngestorWebhookConfig struct {
-	Kind          string `json:"kind"`
-	UriSuffix     string `json:"uriSuffix"`
-	Authorization string `json:"authorization"`
+	Kind          string                           `json:"kind"`
+	UriSuffix     string                           `json:"uriSuffix"`
+	Authorization string                           `json:"authorization"`
+	Schema        *RawDataLogIngestorWebhookSchema `json:"schema,omitempty"`
+}
+
+const (
+	RawDataLogIngestorOnInvalidReject     = "reject"
+	RawDataLogIngestorOnInvalidDeadLetter = "dead-letter"
+)
+
+// RawDataLogIngestorWebhookSchema is the JSON Schema the payloads of a webhook are validated against
+// Either Inline or ConfigFile should be set
+type RawDataLogIngestorWebhookSchema struct {
+	Inline interface{} `json:"inline,omitempty"`
+	// ConfigFile is the name of a file in the config-files configmap
+	ConfigFile string `json:"configFile,omitempty"`
+	// OnInvalid is reject (default) or dead-letter
+	OnInvalid string `json:"onInvalid,omitempty"`
+	// DeadLetterTopic defaults to the topic with the suffix .dead-letter
+	DeadLetterTopic string `json:"deadLetterTopic,omitempty"`
 }
 
 type HttpResponseMicroservices struct {
@@ -255,6 +277,12 @@ type StudioConfig struct {
 	BuildOverwrite       bool     `json:"build_overwrite"`
 	DisabledEnvironments []string `json:"disabled_environments"`
 	CanCreateApplication bool     `json:"can_create_application"`
+	// BackupStore is where the backups of the customer are, BackupStoreAzureFiles when empty
+	BackupStore string `json:"backup_store,omitempty"`
+	// Suspended customers can not change anything through the api, their deployments are scaled to zero
+	Suspended bool `json:"suspended,omitempty"`
+	// VerifiedDomains are the domains the customer has shown they own, custom hosts of customer tenants have to be under one of them
+	VerifiedDomains []string `json:"verified_domains,omitempty"`
 }
 
 type Entity struct {
@@ -287,6 +315,93 @@ type HttpInputBusinessMoment struct {
 	Moment         BusinessMoment `json:"moment"`
 }
 
+// BusinessMomentsVersion is a published snapshot of the moments and entities of an adaptor
+type BusinessMomentsVersion struct {
+	Version     int              `json:"version"`
+	PublishedAt string           `json:"publishedAt"`
+	PublishedBy string           `json:"publishedBy"`
+	Moments     []BusinessMoment `json:"moments"`
+	Entities    []Entity         `json:"entities"`
+}
+
+type HttpResponseBusinessMomentsVersions struct {
+	ApplicationID    string                   `json:"applicationId"`
+	Environment      string                   `json:"environment"`
+	MicroserviceID   string                   `json:"microserviceId"`
+	PublishedVersion int                      `json:"publishedVersion"`
+	DraftMoments     []BusinessMoment         `json:"draftMoments"`
+	DraftEntities    []Entity                 `json:"draftEntities"`
+	Versions         []BusinessMomentsVersion `json:"versions"`
+}
+
+type BusinessMomentsSearchItem struct {
+	Kind           string          `json:"kind"`
+	ApplicationID  string          `json:"applicationId"`
+	Environment    string          `json:"environment"`
+	MicroserviceID string          `json:"microserviceId"`
+	Moment         *BusinessMoment `json:"moment,omitempty"`
+	Entity         *Entity         `json:"entity,omitempty"`
+}
+
+type HttpResponseBusinessMomentsSearch struct {
+	ApplicationID string                      `json:"applicationId"`
+	Total         int                         `json:"total"`
+	Offset        int                         `json:"offset"`
+	Limit         int                         `json:"limit"`
+	Items         []BusinessMomentsSearchItem `json:"items"`
+}
+
+// BusinessMomentsDrift is a moment or entity that is not the same in two environments
+type BusinessMomentsDrift struct {
+	Kind    string   `json:"kind"`
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	State   string   `json:"state"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+type HttpResponseBusinessMomentsCompare struct {
+	ApplicationID string                 `json:"applicationId"`
+	From          string                 `json:"from"`
+	To            string                 `json:"to"`
+	Drift         []BusinessMomentsDrift `json:"drift"`
+}
+
+type HttpResponseInvalidBusinessMoments struct {
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
+type HttpInputBusinessMomentTestRun struct {
+	ApplicationID  string         `json:"applicationId"`
+	Environment    string         `json:"environment"`
+	MicroserviceID string         `json:"microserviceId"`
+	Entity         Entity         `json:"entity"`
+	Moment         BusinessMoment `json:"moment"`
+	// Payloads are sample raw data, when empty the latest raw data from the adaptor is used
+	Payloads []json.RawMessage `json:"payloads"`
+}
+
+type HttpResponseBusinessMomentTestRun struct {
+	ApplicationID  string                        `json:"applicationId"`
+	Environment    string                        `json:"environment"`
+	MicroserviceID string                        `json:"microserviceId"`
+	Source         string                        `json:"source"`
+	Results        []BusinessMomentTestRunResult `json:"results"`
+}
+
+// BusinessMomentTestRunResult is what the code did with one payload, Step is where it stopped if it failed
+type BusinessMomentTestRunResult struct {
+	Index    int         `json:"index"`
+	Filtered bool        `json:"filtered"`
+	Entity   interface{} `json:"entity,omitempty"`
+	Moment   interface{} `json:"moment,omitempty"`
+	State    interface{} `json:"state,omitempty"`
+	Logs     []string    `json:"logs"`
+	Step     string      `json:"step,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
 type HttpResponseBusinessMoments struct {
 	ApplicationID string `json:"application_id"`
 	Environment   string `json:"environment"`
@@ -306,6 +421,8 @@ type HttpInputPurchaseOrderExtra struct {
 	Ingress        HttpInputSimpleIngress            `json:"ingress"`
 	Webhooks       []RawDataLogIngestorWebhookConfig `json:"webhooks"`
 	RawDataLogName string                            `json:"rawDataLogName"`
+	// RawDataLogMicroserviceID is set by the platform when the purchase order api created the raw data log
+	RawDataLogMicroserviceID string `json:"rawDataLogMicroserviceId,omitempty"`
 }
 
 type TerraformApplication struct {
@@ -364,12 +481,53 @@ type RuntimeStateFailingPartition struct {
 	RetryTime          string `json:"retryTime"`
 }
 
+type RawDataLogIngestorWebhookStats struct {
+	UriSuffix             string `json:"uriSuffix"`
+	Accepted              int64  `json:"accepted"`
+	RejectedAuthorization int64  `json:"rejectedAuthorization"`
+	BadPayload            int64  `json:"badPayload"`
+	WriteFailures         int64  `json:"writeFailures"`
+	InvalidPayload        int64  `json:"invalidPayload"`
+	DeadLettered          int64  `json:"deadLettered"`
+	LastReceived          string `json:"lastReceived"`
+	LastError             string `json:"lastError"`
+}
+
+// RawDataLogIngestorConfigStatus is the microservice config the ingestor is running with
+type RawDataLogIngestorConfigStatus struct {
+	Version         int    `json:"version"`
+	Hash            string `json:"hash"`
+	LoadedAt        string `json:"loadedAt"`
+	LastReloadError string `json:"lastReloadError"`
+}
+
+type HttpResponseRawDataLogIngestorWebhookStats struct {
+	ApplicationID  string                           `json:"applicationId"`
+	Environment    string                           `json:"environment"`
+	MicroserviceID string                           `json:"microserviceId"`
+	Webhooks       []RawDataLogIngestorWebhookStats `json:"webhooks"`
+	Config         RawDataLogIngestorConfigStatus   `json:"config"`
+}
+
 type PurchaseOrderStatus struct {
 	Status              string `json:"status"`
 	LastReceivedPayload string `json:"lastReceivedPayload"`
 	Error               string `json:"error"`
 }
 
+type PurchaseOrderAPIStatus struct {
+	MicroserviceID    string `json:"microserviceId"`
+	Environment       string `json:"environment"`
+	HeadImage         string `json:"headImage"`
+	RuntimeImage      string `json:"runtimeImage"`
+	Replicas          int32  `json:"replicas"`
+	AvailableReplicas int32  `json:"availableReplicas"`
+	RawDataLogName    string `json:"rawDataLogName"`
+	// RawDataLogMicroserviceID is only set when the purchase order api created the raw data log
+	RawDataLogMicroserviceID string `json:"rawDataLogMicroserviceId"`
+	RawDataLogExists         bool   `json:"rawDataLogExists"`
+}
+
 type IngressURLWithCustomerTenantID struct {
 	URL              string `json:"url"`
 	CustomerTenantID string `json:"customerTenantID"`
@@ -379,6 +537,43 @@ type StudioEnvironmentVariable struct {
 	Name     string `json:"name"`
 	Value    string `json:"value"`
 	IsSecret bool   `json:"isSecret"`
+	// Reference is set when the secret value comes from an external secret store, the value is then left empty
+	Reference *SecretReference `json:"reference,omitempty"`
+}
+
+const (
+	SecretStoreVault         = "vault"
+	SecretStoreAzureKeyVault = "azure-key-vault"
+	SecretStoreFile          = "file"
+)
+
+// BackupStoreAzureFiles is the only store for now, the backup cronjob and the jobs made from it mount the share
+const BackupStoreAzureFiles = "azure-files"
+
+// SecretReference points at an entry in an external secret store
+type SecretReference struct {
+	Store string `json:"store"`
+	// Path is "{mount}/data/{secret}" for vault, "{vault name}/{secret name}" for azure-key-vault and relative to the directory for file
+	Path string `json:"path"`
+	// Key picks a field from the entry, required for vault
+	Key string `json:"key,omitempty"`
+}
+
+const (
+	RolloutStatusUnchanged   = "unchanged"
+	RolloutStatusProgressing = "progressing"
+	RolloutStatusComplete    = "complete"
+	RolloutStatusFailed      = "failed"
+)
+
+// MicroserviceRolloutStatus is the state of the rolling update triggered by a configuration change
+type MicroserviceRolloutStatus struct {
+	ConfigChecksum    string `json:"configChecksum"`
+	Status            string `json:"status"`
+	Replicas          int32  `json:"replicas"`
+	UpdatedReplicas   int32  `json:"updatedReplicas"`
+	AvailableReplicas int32  `json:"availableReplicas"`
+	Message           string `json:"message"`
 }
 
 type HttpResponseEnvironmentVariables struct {
@@ -386,19 +581,36 @@ type HttpResponseEnvironmentVariables struct {
 	MicroserviceID string                      `json:"microserviceId"`
 	Environment    string                      `json:"environment"`
 	Data           []StudioEnvironmentVariable `json:"data"`
+	Rollout        *MicroserviceRolloutStatus  `json:"rollout,omitempty"`
 }
 type HttpResponseConfigFilesNamesList struct {
-	ApplicationID  string   `json:"applicationId"`
-	MicroserviceID string   `json:"microserviceId"`
-	Environment    string   `json:"environment"`
-	Data           []string `json:"data"`
+	ApplicationID  string                     `json:"applicationId"`
+	MicroserviceID string                     `json:"microserviceId"`
+	Environment    string                     `json:"environment"`
+	Data           []string                   `json:"data"`
+	Files          []ConfigFileInfo           `json:"files"`
+	TotalSize      int                        `json:"totalSize"`
+	MaxTotalSize   int                        `json:"maxTotalSize"`
+	Rollout        *MicroserviceRolloutStatus `json:"rollout,omitempty"`
+}
+
+// ConfigFileInfo describes a config file without its content
+type ConfigFileInfo struct {
+	Name        string `json:"name"`
+	Size        int    `json:"size"`
+	ContentType string `json:"contentType"`
+	// UpdatedAt is empty for files uploaded before it was tracked
+	UpdatedAt string `json:"updatedAt"`
+	// Template is true when the file was rendered as a Go template when uploaded
+	Template bool `json:"template"`
 }
 
 type HttpResponseDeleteConfigFile struct {
-	ApplicationID  string `json:"applicationId"`
-	MicroserviceID string `json:"microserviceId"`
-	Environment    string `json:"environment"`
-	Success        bool   `json:"success"`
+	ApplicationID  string                     `json:"applicationId"`
+	MicroserviceID string                     `json:"microserviceId"`
+	Environment    string                     `json:"environment"`
+	Success        bool                       `json:"success"`
+	Rollout        *MicroserviceRolloutStatus `json:"rollout,omitempty"`
 }
 
 type HttpRequestDeleteConfigFile struct {
@@ -408,6 +620,69 @@ type HttpRequestDeleteConfigFile struct {
 	Key            string `json:"key"`
 }
 
+// ConfigRevision is a snapshot of a microservices environment variables, secret environment variables and config files
+type ConfigRevision struct {
+	Revision    int    `json:"revision"`
+	CreatedAt   string `json:"createdAt"`
+	CreatedBy   string `json:"createdBy"`
+	Reason      string `json:"reason"`
+	ContentHash string `json:"contentHash"`
+}
+
+type HttpResponseConfigRevisions struct {
+	ApplicationID  string           `json:"applicationId"`
+	MicroserviceID string           `json:"microserviceId"`
+	Environment    string           `json:"environment"`
+	Revisions      []ConfigRevision `json:"revisions"`
+}
+
+const (
+	ConfigRevisionKindEnvironmentVariable       = "environmentVariable"
+	ConfigRevisionKindSecretEnvironmentVariable = "secretEnvironmentVariable"
+	ConfigRevisionKindConfigFile                = "configFile"
+
+	ConfigRevisionChangeAdded   = "added"
+	ConfigRevisionChangeRemoved = "removed"
+	ConfigRevisionChangeChanged = "changed"
+)
+
+// ConfigRevisionChange only includes the values for non secret environment variables
+type ConfigRevisionChange struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+type HttpResponseConfigRevisionDiff struct {
+	ApplicationID  string                 `json:"applicationId"`
+	MicroserviceID string                 `json:"microserviceId"`
+	Environment    string                 `json:"environment"`
+	From           int                    `json:"from"`
+	To             int                    `json:"to"`
+	Changes        []ConfigRevisionChange `json:"changes"`
+}
+
+type HttpResponseConfigRevisionRollback struct {
+	Revision ConfigRevision             `json:"revision"`
+	Rollout  *MicroserviceRolloutStatus `json:"rollout,omitempty"`
+}
+
+type HttpResponseConfigBundleImport struct {
+	ApplicationID  string                     `json:"applicationId"`
+	MicroserviceID string                     `json:"microserviceId"`
+	Environment    string                     `json:"environment"`
+	DryRun         bool                       `json:"dryRun"`
+	Changes        []ConfigRevisionChange     `json:"changes"`
+	Rollout        *MicroserviceRolloutStatus `json:"rollout,omitempty"`
+}
+
+type HttpResponseInvalidConfigBundle struct {
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
 type MicroserviceMetadataShortInfo struct {
 	CustomerID       string `json:"customerId"`
 	CustomerName     string `json:"customerName"`
@@ -455,6 +730,14 @@ type CustomerTenantMicroserviceRel struct {
 }
 
 type Customer struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Contact *CustomerContact `json:"contact,omitempty"`
+}
+
+// CustomerContact is who to reach at the customer, it is only kept for the platform admins
+type CustomerContact struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
 }
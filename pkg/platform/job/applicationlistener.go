@@ -4,10 +4,12 @@ package job
 // Inspired by https://github.com/heptiolabs/eventrouter/blob/master/main.go
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/dolittle/platform-api/pkg/platform/storage"
 	gitStorage "github.com/dolittle/platform-api/pkg/platform/storage/git"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -22,6 +24,7 @@ type applicationController struct {
 	podInformer     coreinformers.PodInformer
 	logContext      logrus.FieldLogger
 	gitSync         gitStorage.GitSync
+	repo            storage.RepoApplication
 }
 
 func (c *applicationController) Run(stopCh chan struct{}) error {
@@ -47,62 +50,138 @@ func (c *applicationController) podUpdate(old, new interface{}) {
 	oldPod := old.(*corev1.Pod)
 	newPod := new.(*corev1.Pod)
 
-	c.logContext.Infof(
-		"POD UPDATED. %s/%s %s",
-		oldPod.Namespace, oldPod.Name, newPod.Status.Phase,
-	)
-
-	for _, status := range newPod.Status.InitContainerStatuses {
-		// TODO we can warn in teams that there is a problem
-		cmd := fmt.Sprintf(`kubectl -n %s logs %s -c %s`,
-			newPod.Namespace,
-			newPod.Name,
-			status.Name,
-		)
-		fmt.Println(cmd)
-
-		if status.State.Running != nil {
-			fmt.Println("Running", status.State.Running.StartedAt)
+	customerID := newPod.Annotations["dolittle.io/tenant-id"]
+	applicationID := newPod.Annotations["dolittle.io/application-id"]
+	logContext := c.logContext.WithFields(logrus.Fields{
+		"customer_id":    customerID,
+		"application_id": applicationID,
+		"pod":            fmt.Sprintf("%s/%s", newPod.Namespace, newPod.Name),
+		"phase":          newPod.Status.Phase,
+	})
+	logContext.Infof("POD UPDATED. %s/%s %s", oldPod.Namespace, oldPod.Name, newPod.Status.Phase)
+	logContainerStates(logContext, newPod)
+
+	if newPod.Status.Phase == corev1.PodSucceeded {
+		// trigger gitPull
+		err := c.gitSync.Pull()
+		if err != nil {
+			logContext.WithFields(logrus.Fields{
+				"error":   err,
+				"context": "application-job-update-repo",
+			}).Fatal("Failed to update repo")
 		}
 
-		if status.State.Waiting != nil {
-			fmt.Println("Waiting", status.State.Waiting.Message, status.State.Waiting.Reason)
-		}
+		logContext.WithField("context", "application-job-update-repo").
+			Info("Repo updated with changes after job successfully ran")
+	}
 
-		if status.State.Terminated != nil {
-			fmt.Println("Terminated", status.State.Terminated.Message, status.State.Terminated.Reason)
+	if strings.HasPrefix(newPod.Name, "delete-application-") {
+		c.updateDeleteStatus(logContext, customerID, applicationID, newPod)
+		return
+	}
+	if strings.HasPrefix(newPod.Name, "delete-environment-") {
+		logEnvironmentDeleted(logContext, newPod)
+		return
+	}
+	c.updateBuildStatus(logContext, customerID, applicationID, newPod)
+}
+
+// updateDeleteStatus records the outcome of the delete job. Nothing is written while it runs,
+// as the job removes the application from git and would conflict with it
+func (c *applicationController) updateDeleteStatus(logContext logrus.FieldLogger, customerID string, applicationID string, pod *corev1.Pod) {
+	logContext = logContext.WithField("context", "application-delete-status")
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		_, err := c.repo.GetApplication(customerID, applicationID)
+		if errors.Is(err, storage.ErrNotFound) {
+			logContext.Info("Application deleted")
+			return
+		}
+		logContext.WithField("error", err).Error("Application is still in storage after the delete job succeeded")
+	case corev1.PodFailed:
+		application, err := c.repo.GetApplication(customerID, applicationID)
+		if err != nil {
+			logContext.WithField("error", err).Error("Failed to get the application")
+			return
 		}
 
-		fmt.Println("")
-	}
+		status := BuildStatusFromPod(pod, application.Status)
+		status.State = storage.BuildStatusStateDeleteFailed
+		if status == application.Status {
+			return
+		}
 
-	if newPod.Status.Phase == "Succeeded" {
-		// trigger gitPull
-		err := c.gitSync.Pull()
+		application.Status = status
+		err = c.repo.SaveApplication(application)
 		if err != nil {
-			c.logContext.WithFields(logrus.Fields{
-				"error":   err,
-				"context": "application-created-update-repo",
-			}).Fatal("Failed to update repo")
+			logContext.WithField("error", err).Error("Failed to save the delete status")
+			return
 		}
 
-		customerID := newPod.Annotations["dolittle.io/tenant-id"]
-		applicationID := newPod.Annotations["dolittle.io/application-id"]
+		logContext.WithFields(logrus.Fields{
+			"failed_step": status.FailedStep,
+			"message":     status.Message,
+		}).Error("Failed to delete application")
+	}
+}
 
-		c.logContext.WithFields(logrus.Fields{
-			"customer_id":    customerID,
-			"application_id": applicationID,
-			"context":        "application-created-update-repo",
-		}).Info("Repo updated with changes after job successfully ran")
+// logEnvironmentDeleted logs the outcome of the job deleting an environment, the application keeps its status
+// as the rest of it is not affected
+func logEnvironmentDeleted(logContext logrus.FieldLogger, pod *corev1.Pod) {
+	logContext = logContext.WithField("context", "environment-delete-status")
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		logContext.Info("Environment deleted")
+	case corev1.PodFailed:
+		status := BuildStatusFromPod(pod, storage.JSONBuildStatus{})
+		logContext.WithFields(logrus.Fields{
+			"failed_step": status.FailedStep,
+			"message":     status.Message,
+		}).Error("Failed to delete environment")
 	}
 }
 
+// updateBuildStatus records why the create job failed. Nothing is written while it runs or when it succeeds,
+// as the job writes the application to git itself and would conflict with it. GetBuildStatus reads the progress from the pod
+func (c *applicationController) updateBuildStatus(logContext logrus.FieldLogger, customerID string, applicationID string, pod *corev1.Pod) {
+	logContext = logContext.WithField("context", "application-build-status")
+
+	if pod.Status.Phase != corev1.PodFailed {
+		return
+	}
+
+	application, err := c.repo.GetApplication(customerID, applicationID)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to get the application")
+		return
+	}
+
+	status := BuildStatusFromPod(pod, application.Status)
+	if status == application.Status {
+		return
+	}
+
+	application.Status = status
+	err = c.repo.SaveApplication(application)
+	if err != nil {
+		logContext.WithField("error", err).Error("Failed to save the build status")
+		return
+	}
+
+	logContext.WithFields(logrus.Fields{
+		"state":       status.State,
+		"failed_step": status.FailedStep,
+	}).Info("Build status updated")
+}
+
 func (c *applicationController) podDelete(obj interface{}) {
 	pod := obj.(*corev1.Pod)
 	c.logContext.Infof("POD DELETED: %s/%s", pod.Namespace, pod.Name)
 }
 
-func NewApplicationListenerController(informerFactory informers.SharedInformerFactory, gitSync gitStorage.GitSync, logContext logrus.FieldLogger) *applicationController {
+func NewApplicationListenerController(informerFactory informers.SharedInformerFactory, gitSync gitStorage.GitSync, repo storage.RepoApplication, logContext logrus.FieldLogger) *applicationController {
 	podInformer := informerFactory.Core().V1().Pods()
 
 	c := &applicationController{
@@ -110,6 +189,7 @@ func NewApplicationListenerController(informerFactory informers.SharedInformerFa
 		podInformer:     podInformer,
 		logContext:      logContext,
 		gitSync:         gitSync,
+		repo:            repo,
 	}
 
 	// FilteringResourceEventHandler
@@ -120,7 +200,9 @@ func NewApplicationListenerController(informerFactory informers.SharedInformerFa
 				return false
 			}
 
-			if !strings.HasPrefix(pod.Name, "create-application-") {
+			if !strings.HasPrefix(pod.Name, "create-application-") &&
+				!strings.HasPrefix(pod.Name, "delete-application-") &&
+				!strings.HasPrefix(pod.Name, "delete-environment-") {
 				return false
 			}
 
@@ -145,9 +227,9 @@ func NewApplicationListenerController(informerFactory informers.SharedInformerFa
 	return c
 }
 
-func NewApplicationJobListener(client kubernetes.Interface, gitSync gitStorage.GitSync, logContext logrus.FieldLogger) {
+func NewApplicationJobListener(client kubernetes.Interface, gitSync gitStorage.GitSync, repo storage.RepoApplication, logContext logrus.FieldLogger) {
 	factory := informers.NewSharedInformerFactoryWithOptions(client, time.Hour*24, informers.WithNamespace("system-api"))
-	controller := NewApplicationListenerController(factory, gitSync, logContext)
+	controller := NewApplicationListenerController(factory, gitSync, repo, logContext)
 	stop := make(chan struct{})
 	defer close(stop)
 	err := controller.Run(stop)
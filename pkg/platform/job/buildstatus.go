@@ -0,0 +1,105 @@
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuildStatusFromPod works out the build status from the pod running the create job.
+// The first container, init containers included, that terminated with a non zero exit code
+// is the failing step and its termination message, or reason, becomes the message
+func BuildStatusFromPod(pod *corev1.Pod, current storage.JSONBuildStatus) storage.JSONBuildStatus {
+	status := current
+
+	switch pod.Status.Phase {
+	case corev1.PodPending, corev1.PodRunning:
+		status.State = storage.BuildStatusStatePending
+	case corev1.PodSucceeded:
+		status.State = storage.BuildStatusStateFinishedSuccess
+	case corev1.PodFailed:
+		status.State = storage.BuildStatusStateFinishedFailed
+	default:
+		return status
+	}
+
+	if status.StartedAt == "" && pod.Status.StartTime != nil {
+		status.StartedAt = formatTime(*pod.Status.StartTime)
+	}
+
+	status.FailedStep = ""
+	status.Message = ""
+	containers := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	containers = append(containers, pod.Status.ContainerStatuses...)
+	var finishedAt metav1.Time
+	for _, container := range containers {
+		terminated := container.State.Terminated
+		if terminated == nil {
+			continue
+		}
+		if terminated.FinishedAt.After(finishedAt.Time) {
+			finishedAt = terminated.FinishedAt
+		}
+		if terminated.ExitCode == 0 || status.FailedStep != "" {
+			continue
+		}
+		status.FailedStep = container.Name
+		status.Message = terminated.Message
+		if status.Message == "" {
+			status.Message = terminated.Reason
+		}
+	}
+
+	if status.FailedStep != "" && status.State != storage.BuildStatusStateFinishedSuccess {
+		status.State = storage.BuildStatusStateFinishedFailed
+	}
+
+	finished := status.State == storage.BuildStatusStateFinishedSuccess || status.State == storage.BuildStatusStateFinishedFailed
+	switch {
+	case !finished:
+		status.FinishedAt = ""
+	case !finishedAt.IsZero():
+		status.FinishedAt = formatTime(finishedAt)
+	case status.FinishedAt == "":
+		status.FinishedAt = formatTime(metav1.Now())
+	}
+	return status
+}
+
+func formatTime(t metav1.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// logContainerStates logs where each init container of the job is, with the command to get its logs
+func logContainerStates(logContext logrus.FieldLogger, pod *corev1.Pod) {
+	for _, status := range pod.Status.InitContainerStatuses {
+		// TODO we can warn in teams that there is a problem
+		containerContext := logContext.WithFields(logrus.Fields{
+			"container": status.Name,
+			"logs":      fmt.Sprintf(`kubectl -n %s logs %s -c %s`, pod.Namespace, pod.Name, status.Name),
+		})
+
+		if status.State.Running != nil {
+			containerContext.WithField("started_at", status.State.Running.StartedAt).Info("Running")
+		}
+
+		if status.State.Waiting != nil {
+			containerContext.WithFields(logrus.Fields{
+				"reason":  status.State.Waiting.Reason,
+				"message": status.State.Waiting.Message,
+			}).Info("Waiting")
+		}
+
+		if status.State.Terminated != nil {
+			containerContext.WithFields(logrus.Fields{
+				"reason":    status.State.Terminated.Reason,
+				"message":   status.State.Terminated.Message,
+				"exit_code": status.State.Terminated.ExitCode,
+			}).Info("Terminated")
+		}
+	}
+}
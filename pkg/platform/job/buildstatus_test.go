@@ -0,0 +1,130 @@
+package job_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform/job"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Build status from the create job pod", func() {
+	var (
+		startedAt  time.Time
+		finishedAt time.Time
+		pod        *corev1.Pod
+		current    storage.JSONBuildStatus
+		status     storage.JSONBuildStatus
+	)
+
+	terminated := func(name string, exitCode int32, reason string, message string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name: name,
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode:   exitCode,
+					Reason:     reason,
+					Message:    message,
+					FinishedAt: metav1.NewTime(finishedAt),
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		startedAt = time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC)
+		finishedAt = startedAt.Add(5 * time.Minute)
+		started := metav1.NewTime(startedAt)
+		pod = &corev1.Pod{
+			Status: corev1.PodStatus{
+				Phase:     corev1.PodRunning,
+				StartTime: &started,
+			},
+		}
+		current = storage.JSONBuildStatus{
+			State: storage.BuildStatusStateWaiting,
+		}
+	})
+
+	JustBeforeEach(func() {
+		status = job.BuildStatusFromPod(pod, current)
+	})
+
+	When("the job is running", func() {
+		It("should be building since the pod started", func() {
+			Expect(status).To(Equal(storage.JSONBuildStatus{
+				State:     storage.BuildStatusStatePending,
+				StartedAt: "2022-04-01T10:00:00Z",
+			}))
+		})
+	})
+
+	When("the status already has a start time", func() {
+		BeforeEach(func() {
+			current.StartedAt = "2022-04-01T09:59:00Z"
+		})
+
+		It("should keep it", func() {
+			Expect(status.StartedAt).To(Equal("2022-04-01T09:59:00Z"))
+		})
+	})
+
+	When("the job succeeded", func() {
+		BeforeEach(func() {
+			pod.Status.Phase = corev1.PodSucceeded
+			pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+				terminated("git-pull", 0, "Completed", ""),
+			}
+		})
+
+		It("should be finished when the last container finished", func() {
+			Expect(status.State).To(Equal(storage.BuildStatusStateFinishedSuccess))
+			Expect(status.FinishedAt).To(Equal("2022-04-01T10:05:00Z"))
+			Expect(status.FailedStep).To(BeEmpty())
+		})
+	})
+
+	When("a step failed", func() {
+		BeforeEach(func() {
+			pod.Status.Phase = corev1.PodFailed
+			pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+				terminated("git-pull", 0, "Completed", ""),
+				terminated("terraform", 1, "Error", "Error: timeout while waiting for state"),
+			}
+		})
+
+		It("should record the step and the message from the container", func() {
+			Expect(status.State).To(Equal(storage.BuildStatusStateFinishedFailed))
+			Expect(status.FailedStep).To(Equal("terraform"))
+			Expect(status.Message).To(Equal("Error: timeout while waiting for state"))
+			Expect(status.FinishedAt).To(Equal("2022-04-01T10:05:00Z"))
+		})
+
+		When("the container has no termination message", func() {
+			BeforeEach(func() {
+				pod.Status.InitContainerStatuses[1] = terminated("terraform", 137, "OOMKilled", "")
+			})
+
+			It("should use the reason", func() {
+				Expect(status.Message).To(Equal("OOMKilled"))
+			})
+		})
+	})
+
+	When("a step failed before the pod phase caught up", func() {
+		BeforeEach(func() {
+			pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+				terminated("git-pull", 128, "Error", "could not read from remote"),
+			}
+		})
+
+		It("should be failed", func() {
+			Expect(status.State).To(Equal(storage.BuildStatusStateFinishedFailed))
+			Expect(status.FailedStep).To(Equal("git-pull"))
+		})
+	})
+})
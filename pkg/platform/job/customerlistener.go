@@ -52,29 +52,7 @@ func (c *customerController) podUpdate(old, new interface{}) {
 		oldPod.Namespace, oldPod.Name, newPod.Status.Phase,
 	)
 
-	for _, status := range newPod.Status.InitContainerStatuses {
-		// TODO we can warn in teams that there is a problem
-		cmd := fmt.Sprintf(`kubectl -n %s logs %s -c %s`,
-			newPod.Namespace,
-			newPod.Name,
-			status.Name,
-		)
-		fmt.Println(cmd)
-
-		if status.State.Running != nil {
-			fmt.Println("Running", status.State.Running.StartedAt)
-		}
-
-		if status.State.Waiting != nil {
-			fmt.Println("Waiting", status.State.Waiting.Message, status.State.Waiting.Reason)
-		}
-
-		if status.State.Terminated != nil {
-			fmt.Println("Terminated", status.State.Terminated.Message, status.State.Terminated.Reason)
-		}
-
-		fmt.Println("")
-	}
+	logContainerStates(c.logContext.WithField("pod", fmt.Sprintf("%s/%s", newPod.Namespace, newPod.Name)), newPod)
 
 	if newPod.Status.Phase == "Succeeded" {
 		// trigger gitPull
@@ -82,7 +60,7 @@ func (c *customerController) podUpdate(old, new interface{}) {
 		if err != nil {
 			c.logContext.WithFields(logrus.Fields{
 				"error":   err,
-				"context": "customer-created-update-repo",
+				"context": "customer-job-update-repo",
 			}).Fatal("Failed to update repo")
 		}
 
@@ -90,7 +68,7 @@ func (c *customerController) podUpdate(old, new interface{}) {
 
 		c.logContext.WithFields(logrus.Fields{
 			"customer_id": customerID,
-			"context":     "customer-created-update-repo",
+			"context":     "customer-job-update-repo",
 		}).Info("Repo updated with changes after job successfully ran")
 	}
 }
@@ -118,7 +96,7 @@ func NewCustomerListenerController(informerFactory informers.SharedInformerFacto
 				return false
 			}
 
-			if !strings.HasPrefix(pod.Name, "create-customer-") {
+			if !strings.HasPrefix(pod.Name, "create-customer-") && !strings.HasPrefix(pod.Name, "delete-customer-") {
 				return false
 			}
 
@@ -1 +1,43 @@
 package job
+
+const (
+	JobStatePending   = "pending"
+	JobStateRunning   = "running"
+	JobStateSucceeded = "succeeded"
+	JobStateFailed    = "failed"
+
+	StepStateWaiting    = "waiting"
+	StepStateRunning    = "running"
+	StepStateTerminated = "terminated"
+)
+
+type HttpResponseJobStatus struct {
+	ID            string          `json:"id"`
+	CustomerID    string          `json:"customerId"`
+	ApplicationID string          `json:"applicationId,omitempty"`
+	State         string          `json:"state"`
+	StartedAt     string          `json:"startedAt,omitempty"`
+	FinishedAt    string          `json:"finishedAt,omitempty"`
+	Conditions    []JobCondition  `json:"conditions"`
+	Pod           string          `json:"pod,omitempty"`
+	Steps         []JobStepStatus `json:"steps"`
+}
+
+type JobCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// JobStepStatus is the state of one of the containers of the job, init containers included
+type JobStepStatus struct {
+	Name       string `json:"name"`
+	State      string `json:"state"`
+	Reason     string `json:"reason,omitempty"`
+	Message    string `json:"message,omitempty"`
+	ExitCode   *int32 `json:"exitCode,omitempty"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
@@ -1,7 +1,6 @@
 package k8s
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 
@@ -12,6 +11,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// CreateApplicationJobName is the unique identifier of the job creating the application
+func CreateApplicationJobName(applicationID string) string {
+	return fmt.Sprintf("create-application-%s", applicationID)
+}
+
 func CreateApplicationResource(config CreateResourceConfig, customerID string, application dolittleK8s.ShortInfo) *batchv1.Job {
 	namespace := config.Namespace
 	gitRemote := config.GitRemote
@@ -34,7 +38,7 @@ func CreateApplicationResource(config CreateResourceConfig, customerID string, a
 	terrformFileName := fmt.Sprintf("customer_%s_%s", customerID, applicationID)
 
 	// Unique identifier of the job
-	name := fmt.Sprintf("create-application-%s", applicationID)
+	name := CreateApplicationJobName(applicationID)
 	if len(name) >= 64 {
 		panic("Not allowed due to kuberentes restriction")
 	}
@@ -127,7 +131,9 @@ git add ./Source/V3/platform-api/%s/%s;
 git status;
 git commit -m "Application created %s";
 git log -1;
-GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no" git push origin %s;
+export GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no";
+git pull --rebase -X theirs origin %[4]s;
+git push origin %[4]s;
 							`,
 								platformEnvironment,
 								customerID,
@@ -155,11 +161,6 @@ GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o Stric
 	}
 }
 
-func DeleteApplicationResource() error {
-	// TODO
-	return errors.New("TODO: currently we lock this in azure")
-}
-
 // buildApplicationInCluster
 // We rely on  next steps to write to git
 func buildApplicationInCluster(platformImage string, platformEnvironment string, customerID string, applicationID string, isProduction bool) corev1.Container {
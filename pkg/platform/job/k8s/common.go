@@ -18,6 +18,7 @@ type CreateResourceConfig struct {
 	ApiSecrets          string
 	GitBranch           string
 	ServiceAccountName  string
+	KratosURL           string
 }
 
 func CreateResourceConfigFromViper(v *viper.Viper) CreateResourceConfig {
@@ -38,6 +39,7 @@ func CreateResourceConfigFromViper(v *viper.Viper) CreateResourceConfig {
 		GitRemote:           v.GetString("tools.jobs.git.remote.url"),
 		GitBranch:           v.GetString("tools.jobs.git.remote.branch"),
 		ServiceAccountName:  "system-api-manager",
+		KratosURL:           v.GetString("tools.server.kratos.url"),
 	}
 }
 
@@ -144,7 +146,9 @@ git add ./Source/V3/Azure/%s.tf;
 git status;
 git commit -m "Adding %s";
 git log -1;
-GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no" git push origin %s;
+export GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no";
+git pull --rebase -X theirs origin %[3]s;
+git push origin %[3]s;
 `,
 			name,
 			name,
@@ -192,7 +196,9 @@ git add ./Source/V3/platform-api/%s/%s;
 git status;
 git commit -m "Adding terraform json to studio for customer %s";
 git log -1;
-GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no" git push origin %s;
+export GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no";
+git pull --rebase -X theirs origin %[4]s;
+git push origin %[4]s;
 `,
 			platformEnvironment,
 			customerID,
@@ -248,7 +254,9 @@ git add ./Source/V3/platform-api/%s/%s;
 git status;
 git commit -m "Adding studio json to studio for customer %s";
 git log -1;
-GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no" git push origin %s;
+export GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no";
+git pull --rebase -X theirs origin %[4]s;
+git push origin %[4]s;
 `,
 			platformEnvironment,
 			customerID,
@@ -2,7 +2,6 @@ package k8s
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
@@ -143,8 +142,3 @@ func DoJob(client kubernetes.Interface, job *batchv1.Job) error {
 	}
 	return nil
 }
-
-func DeleteCustomerResource() error {
-	// TODO
-	return errors.New("TODO: currently we lock this in azure")
-}
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"fmt"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeleteApplicationJobName is the unique identifier of the job deleting the application
+func DeleteApplicationJobName(applicationID string) string {
+	return fmt.Sprintf("delete-application-%s", applicationID)
+}
+
+// DeleteApplicationResource backs up and removes the application from the cluster, then destroys its terraform module
+// and removes it from git. Git is only pushed once everything else is done, so a failed job can be run again
+func DeleteApplicationResource(config CreateResourceConfig, customerID string, application dolittleK8s.ShortInfo, force bool) *batchv1.Job {
+	namespace := config.Namespace
+	apiSecrets := config.ApiSecrets
+	branch := config.GitBranch
+	platformImage := config.PlatformImage
+	platformEnvironment := config.PlatformEnvironment
+	applicationID := application.ID
+
+	terrformFileName := fmt.Sprintf("customer_%s_%s", customerID, applicationID)
+
+	name := DeleteApplicationJobName(applicationID)
+	annotations := platformK8s.GetAnnotationsForApplication(customerID, applicationID)
+	backoffLimit := int32(0)
+
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "KUBECONFIG",
+			Value: "incluster",
+		},
+	}
+	envVars = append(envVars, envVarGitNotInUse()...)
+
+	terraformBaseContainer := terraformBase(platformImage, apiSecrets)
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: batchv1.JobSpec{
+			// Deleting is not something to retry without someone looking at why it failed
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: config.ServiceAccountName,
+					RestartPolicy:      "Never",
+					Volumes: []corev1.Volume{
+						{
+							Name:         "shared-data",
+							VolumeSource: corev1.VolumeSource{},
+						},
+						{
+							Name: "secrets",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: apiSecrets,
+									Items: []corev1.KeyToPath{
+										{
+											Key:  "SSH_KEY_PUBLIC",
+											Path: "operations.pub",
+										},
+										{
+											Key:  "SSH_KEY_PRIVATE",
+											Path: "operations",
+										},
+									},
+								},
+							},
+						},
+					},
+					InitContainers: []corev1.Container{
+						sshSetup(),
+						gitSetup(platformImage, config.GitRemote, branch, config.GitUserEmail, config.GitUserName),
+						{
+							Name:            "delete-application",
+							ImagePullPolicy: "Always",
+							Image:           platformImage,
+							Env:             envVars,
+							Command: []string{
+								"sh",
+								"-c",
+								fmt.Sprintf(`
+/app/bin/app tools automate delete-application \
+--platform-environment="%s" \
+--customer-id="%s" \
+--application-id="%s" \
+--force="%t"
+`,
+									platformEnvironment,
+									customerID,
+									applicationID,
+									force,
+								),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "shared-data",
+									MountPath: "/pod-data",
+								},
+							},
+						},
+						terraformInit(terraformBaseContainer),
+						terraformDestroy(terraformBaseContainer, terrformFileName),
+						gitUpdate(platformImage, "application-deleted", []string{
+							"sh",
+							"-c",
+							fmt.Sprintf(`
+cd /pod-data/git;
+rm -f ./Source/V3/Azure/%[1]s.tf;
+git add -A ./Source/V3/Azure/%[1]s.tf ./Source/V3/platform-api/%[2]s/%[3]s/%[4]s;
+git status;
+git commit -m "Application deleted %[4]s";
+git log -1;
+export GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no";
+git pull --rebase -X theirs origin %[5]s;
+git push origin %[5]s;
+`,
+								terrformFileName,
+								platformEnvironment,
+								customerID,
+								applicationID,
+								branch,
+							),
+						}),
+					},
+					Containers: []corev1.Container{
+						{
+							Name:  "summary",
+							Image: "busybox",
+							Command: []string{
+								"sh",
+								"-c",
+								`echo "jobs done"`,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,167 @@
+package k8s
+
+import (
+	"fmt"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeleteCustomerJobName is the unique identifier of the job deleting the customer
+func DeleteCustomerJobName(customerID string) string {
+	return fmt.Sprintf("delete-customer-%s", customerID)
+}
+
+// DeleteCustomerResource backs up and removes the applications of the customer from the cluster, removes the customer
+// from its users in kratos, destroys the terraform modules of the customer and its applications and then removes it from git.
+// Git is only pushed once everything else is done, so a failed job can be run again
+func DeleteCustomerResource(config CreateResourceConfig, customer dolittleK8s.ShortInfo, applicationIDs []string, force bool) *batchv1.Job {
+	namespace := config.Namespace
+	apiSecrets := config.ApiSecrets
+	branch := config.GitBranch
+	platformImage := config.PlatformImage
+	platformEnvironment := config.PlatformEnvironment
+	customerID := customer.ID
+
+	terrformFileName := fmt.Sprintf("customer_%s", customerID)
+	terraformModules := make([]string, 0, len(applicationIDs)+1)
+	for _, applicationID := range applicationIDs {
+		terraformModules = append(terraformModules, fmt.Sprintf("customer_%s_%s", customerID, applicationID))
+	}
+	// The applications use the resources of the customer, so it goes last
+	terraformModules = append(terraformModules, terrformFileName)
+
+	name := DeleteCustomerJobName(customerID)
+	labels := platformK8s.GetLabelsForCustomer(customer.Name)
+	annotations := platformK8s.GetAnnotationsForCustomer(customerID)
+	backoffLimit := int32(0)
+
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "KUBECONFIG",
+			Value: "incluster",
+		},
+	}
+	envVars = append(envVars, envVarGitNotInUse()...)
+
+	terraformBaseContainer := terraformBase(platformImage, apiSecrets)
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: batchv1.JobSpec{
+			// Deleting is not something to retry without someone looking at why it failed
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: config.ServiceAccountName,
+					RestartPolicy:      "Never",
+					Volumes: []corev1.Volume{
+						{
+							Name:         "shared-data",
+							VolumeSource: corev1.VolumeSource{},
+						},
+						{
+							Name: "secrets",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: apiSecrets,
+									Items: []corev1.KeyToPath{
+										{
+											Key:  "SSH_KEY_PUBLIC",
+											Path: "operations.pub",
+										},
+										{
+											Key:  "SSH_KEY_PRIVATE",
+											Path: "operations",
+										},
+									},
+								},
+							},
+						},
+					},
+					InitContainers: []corev1.Container{
+						sshSetup(),
+						gitSetup(platformImage, config.GitRemote, branch, config.GitUserEmail, config.GitUserName),
+						{
+							Name:            "delete-customer",
+							ImagePullPolicy: "Always",
+							Image:           platformImage,
+							Env:             envVars,
+							Command: []string{
+								"sh",
+								"-c",
+								fmt.Sprintf(`
+/app/bin/app tools automate delete-customer \
+--platform-environment="%s" \
+--customer-id="%s" \
+--kratos-url="%s" \
+--force="%t"
+`,
+									platformEnvironment,
+									customerID,
+									config.KratosURL,
+									force,
+								),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "shared-data",
+									MountPath: "/pod-data",
+								},
+							},
+						},
+						terraformInit(terraformBaseContainer),
+						terraformDestroy(terraformBaseContainer, terraformModules...),
+						gitUpdate(platformImage, "customer-deleted", []string{
+							"sh",
+							"-c",
+							fmt.Sprintf(`
+cd /pod-data/git;
+rm -f ./Source/V3/Azure/%[1]s.tf ./Source/V3/Azure/%[1]s_*.tf;
+# Quoted so git matches the removed files in the index
+git add -A -- './Source/V3/Azure/%[1]s.tf' './Source/V3/Azure/%[1]s_*.tf' ./Source/V3/platform-api/%[2]s/%[3]s;
+git status;
+git commit -m "Customer deleted %[3]s";
+git log -1;
+export GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no";
+git pull --rebase -X theirs origin %[4]s;
+git push origin %[4]s;
+`,
+								terrformFileName,
+								platformEnvironment,
+								customerID,
+								branch,
+							),
+						}),
+					},
+					Containers: []corev1.Container{
+						{
+							Name:  "summary",
+							Image: "busybox",
+							Command: []string{
+								"sh",
+								"-c",
+								`echo "jobs done"`,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
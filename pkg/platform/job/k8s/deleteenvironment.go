@@ -0,0 +1,151 @@
+package k8s
+
+import (
+	"fmt"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeleteEnvironmentJobName is the unique identifier of the job deleting an environment of the application.
+// The environment is not part of it, as the name would be too long for a label, so only one can be deleted at a time
+func DeleteEnvironmentJobName(applicationID string) string {
+	return fmt.Sprintf("delete-environment-%s", applicationID)
+}
+
+// DeleteEnvironmentResource backs up and removes the environment from the cluster, then removes it from git
+func DeleteEnvironmentResource(config CreateResourceConfig, customerID string, applicationID string, environment string, force bool) *batchv1.Job {
+	namespace := config.Namespace
+	apiSecrets := config.ApiSecrets
+	branch := config.GitBranch
+	platformImage := config.PlatformImage
+	platformEnvironment := config.PlatformEnvironment
+
+	name := DeleteEnvironmentJobName(applicationID)
+	annotations := platformK8s.GetAnnotationsForApplication(customerID, applicationID)
+	backoffLimit := int32(0)
+
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "KUBECONFIG",
+			Value: "incluster",
+		},
+	}
+	envVars = append(envVars, envVarGitNotInUse()...)
+
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: batchv1.JobSpec{
+			// Deleting is not something to retry without someone looking at why it failed
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: config.ServiceAccountName,
+					RestartPolicy:      "Never",
+					Volumes: []corev1.Volume{
+						{
+							Name:         "shared-data",
+							VolumeSource: corev1.VolumeSource{},
+						},
+						{
+							Name: "secrets",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: apiSecrets,
+									Items: []corev1.KeyToPath{
+										{
+											Key:  "SSH_KEY_PUBLIC",
+											Path: "operations.pub",
+										},
+										{
+											Key:  "SSH_KEY_PRIVATE",
+											Path: "operations",
+										},
+									},
+								},
+							},
+						},
+					},
+					InitContainers: []corev1.Container{
+						sshSetup(),
+						gitSetup(platformImage, config.GitRemote, branch, config.GitUserEmail, config.GitUserName),
+						{
+							Name:            "delete-environment",
+							ImagePullPolicy: "Always",
+							Image:           platformImage,
+							Env:             envVars,
+							Command: []string{
+								"sh",
+								"-c",
+								fmt.Sprintf(`
+/app/bin/app tools automate delete-environment \
+--platform-environment="%s" \
+--customer-id="%s" \
+--application-id="%s" \
+--environment="%s" \
+--force="%t"
+`,
+									platformEnvironment,
+									customerID,
+									applicationID,
+									environment,
+									force,
+								),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "shared-data",
+									MountPath: "/pod-data",
+								},
+							},
+						},
+						gitUpdate(platformImage, "environment-deleted", []string{
+							"sh",
+							"-c",
+							fmt.Sprintf(`
+cd /pod-data/git;
+git add -A ./Source/V3/platform-api/%[1]s/%[2]s/%[3]s;
+git status;
+git commit -m "Environment %[4]s deleted from %[3]s";
+git log -1;
+export GIT_SSH_COMMAND="ssh -i /pod-data/.ssh/operations -o IdentitiesOnly=yes -o StrictHostKeyChecking=no";
+git pull --rebase -X theirs origin %[5]s;
+git push origin %[5]s;
+`,
+								platformEnvironment,
+								customerID,
+								applicationID,
+								environment,
+								branch,
+							),
+						}),
+					},
+					Containers: []corev1.Container{
+						{
+							Name:  "summary",
+							Image: "busybox",
+							Command: []string{
+								"sh",
+								"-c",
+								`echo "jobs done"`,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package k8s
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaintainBackupsCronJobName is the cronjob pruning and verifying the mongo backups of every environment
+	MaintainBackupsCronJobName = "maintain-backups"
+	// DefaultMaintainBackupsSchedule is after the backups of the night, before the working day in Europe
+	DefaultMaintainBackupsSchedule = "30 3 * * *"
+)
+
+// MaintainBackupsResource runs maintain-backups with --prune and --verify on the schedule,
+// reading the applications from a clone of the git repo that is never pushed
+func MaintainBackupsResource(config CreateResourceConfig, schedule string) *v1beta1.CronJob {
+	platformImage := config.PlatformImage
+	backoffLimit := int32(0)
+	successfulJobsHistoryLimit := int32(3)
+	failedJobsHistoryLimit := int32(3)
+
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "KUBECONFIG",
+			Value: "incluster",
+		},
+	}
+	envVars = append(envVars, envVarGitNotInUse()...)
+
+	return &v1beta1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CronJob",
+			APIVersion: "batch/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MaintainBackupsCronJobName,
+			Namespace: config.Namespace,
+		},
+		Spec: v1beta1.CronJobSpec{
+			Schedule: schedule,
+			// The next run picks up what a slow run did not get to
+			ConcurrencyPolicy:          v1beta1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &successfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     &failedJobsHistoryLimit,
+			JobTemplate: v1beta1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							ServiceAccountName: config.ServiceAccountName,
+							RestartPolicy:      "Never",
+							Volumes: []corev1.Volume{
+								{
+									Name:         "shared-data",
+									VolumeSource: corev1.VolumeSource{},
+								},
+								{
+									Name: "secrets",
+									VolumeSource: corev1.VolumeSource{
+										Secret: &corev1.SecretVolumeSource{
+											SecretName: config.ApiSecrets,
+											Items: []corev1.KeyToPath{
+												{
+													Key:  "SSH_KEY_PUBLIC",
+													Path: "operations.pub",
+												},
+												{
+													Key:  "SSH_KEY_PRIVATE",
+													Path: "operations",
+												},
+											},
+										},
+									},
+								},
+							},
+							InitContainers: []corev1.Container{
+								sshSetup(),
+								gitSetup(platformImage, config.GitRemote, config.GitBranch, config.GitUserEmail, config.GitUserName),
+							},
+							Containers: []corev1.Container{
+								{
+									Name:            "maintain-backups",
+									ImagePullPolicy: "Always",
+									Image:           platformImage,
+									Env:             envVars,
+									Command: []string{
+										"sh",
+										"-c",
+										fmt.Sprintf(`
+/app/bin/app tools automate maintain-backups \
+--platform-environment="%s" \
+--prune \
+--verify
+`,
+											config.PlatformEnvironment,
+										),
+									},
+									VolumeMounts: []corev1.VolumeMount{
+										{
+											Name:      "shared-data",
+											MountPath: "/pod-data",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
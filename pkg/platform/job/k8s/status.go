@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetJob returns the job and its pods, newest pod first
+func GetJob(ctx context.Context, client kubernetes.Interface, namespace string, jobID string) (*batchv1.Job, []corev1.Pod, error) {
+	job, err := client.BatchV1().Jobs(namespace).Get(ctx, jobID, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobID),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := pods.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[j].CreationTimestamp.Before(&items[i].CreationTimestamp)
+	})
+	return job, items, nil
+}
+
+// GetJobFinished is when the job completed or failed and the message it finished with, nil when it has not.
+// A job waiting for its first pod or between retries has no active pods, but is not finished
+func GetJobFinished(job batchv1.Job) (*time.Time, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		if condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed {
+			finishedAt := condition.LastTransitionTime.Time
+			return &finishedAt, condition.Message
+		}
+	}
+	return nil, ""
+}
+
+// IsJobFinished is true once the job has completed or failed
+func IsJobFinished(job batchv1.Job) bool {
+	finishedAt, _ := GetJobFinished(job)
+	return finishedAt != nil
+}
+
+// StreamStepLogs copies the logs the step, the container of the same name, in the pod has written so far to w
+func StreamStepLogs(ctx context.Context, client kubernetes.Interface, pod corev1.Pod, step string, w io.Writer) error {
+	stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: step,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	_, err = io.Copy(w, stream)
+	return err
+}
@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 )
@@ -117,6 +118,26 @@ func terraformApply(base corev1.Container, name string) corev1.Container {
 	return copy
 }
 
+// names are currently the filenames without .tf suffix, every module is destroyed in one go
+func terraformDestroy(base corev1.Container, names ...string) corev1.Container {
+	targets := make([]string, 0, len(names))
+	for _, name := range names {
+		targets = append(targets, fmt.Sprintf(`-target="module.%s"`, name))
+	}
+
+	copy := base
+	copy.Name = "terraform-destroy"
+	copy.Command = []string{
+		"sh",
+		"-c",
+		fmt.Sprintf(
+			`terraform destroy %s -auto-approve -no-color`,
+			strings.Join(targets, " "),
+		),
+	}
+	return copy
+}
+
 func terraformOutputJSON(base corev1.Container) corev1.Container {
 	copy := base
 	copy.Name = "terraform-output-json"
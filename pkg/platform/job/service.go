@@ -0,0 +1,156 @@
+package job
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+type service struct {
+	logContext logrus.FieldLogger
+	k8sClient  kubernetes.Interface
+	namespace  string
+}
+
+func NewService(logContext logrus.FieldLogger, k8sClient kubernetes.Interface, namespace string) *service {
+	return &service{
+		logContext: logContext,
+		k8sClient:  k8sClient,
+		namespace:  namespace,
+	}
+}
+
+// GetStatus returns the conditions of the job and the state of each of its steps
+func (s *service) GetStatus(w http.ResponseWriter, r *http.Request) {
+	job, pods, ok := s.getJob(w, r)
+	if !ok {
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, NewJobStatus(job, pods))
+}
+
+// GetLogs streams the logs the steps of the job have written so far as plain text, one after the other.
+// With ?step= only that step is streamed. There is no following a step, the server write timeout would cut it,
+// so clients poll until the step is terminated
+func (s *service) GetLogs(w http.ResponseWriter, r *http.Request) {
+	job, pods, ok := s.getJob(w, r)
+	if !ok {
+		return
+	}
+
+	if len(pods) == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "The job has not started a pod yet")
+		return
+	}
+
+	pod := pods[0]
+	steps := JobSteps(pod)
+	stepName := r.FormValue("step")
+	if stepName != "" {
+		var found *JobStepStatus
+		for index := range steps {
+			if steps[index].Name == stepName {
+				found = &steps[index]
+				break
+			}
+		}
+		if found == nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Step %s is not part of the job", stepName))
+			return
+		}
+		if found.State == StepStateWaiting {
+			utils.RespondWithError(w, http.StatusConflict, fmt.Sprintf("Step %s has not started", stepName))
+			return
+		}
+		steps = []JobStepStatus{*found}
+	}
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method": "GetLogs",
+		"job_id": job.Name,
+		"pod":    pod.Name,
+	})
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	out := newFlushWriter(w)
+
+	for _, step := range steps {
+		if stepName == "" {
+			fmt.Fprintf(out, "==> %s (%s) <==\n", step.Name, step.State)
+		}
+		if step.State == StepStateWaiting {
+			continue
+		}
+
+		err := jobK8s.StreamStepLogs(r.Context(), s.k8sClient, pod, step.Name, out)
+		if err != nil {
+			// The status is already sent, all we can do is tell the reader
+			logContext.WithFields(logrus.Fields{
+				"error": err,
+				"step":  step.Name,
+			}).Error("Failed to stream the logs")
+			fmt.Fprintf(out, "\nfailed to get the logs of %s\n", step.Name)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// getJob responds with not found unless the job belongs to the customer
+func (s *service) getJob(w http.ResponseWriter, r *http.Request) (*batchv1.Job, []corev1.Pod, bool) {
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+	customerID := r.Header.Get("Tenant-ID")
+
+	job, pods, err := jobK8s.GetJob(r.Context(), s.k8sClient, s.namespace, jobID)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Job %s not found", jobID))
+			return nil, nil, false
+		}
+		s.logContext.WithFields(logrus.Fields{
+			"method": "getJob",
+			"job_id": jobID,
+			"error":  err,
+		}).Error("Failed to get the job")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Something has gone wrong")
+		return nil, nil, false
+	}
+
+	// Not telling the customer the job of someone else exists
+	owner, ok := job.Annotations["dolittle.io/tenant-id"]
+	if !ok || customerID == "" || owner != platformK8s.ParseLabel(customerID) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Job %s not found", jobID))
+		return nil, nil, false
+	}
+	return job, pods, true
+}
+
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func newFlushWriter(w http.ResponseWriter) io.Writer {
+	flusher, _ := w.(http.Flusher)
+	return flushWriter{w: w, flusher: flusher}
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if f.flusher != nil {
+		f.flusher.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,251 @@
+package job_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform/job"
+	jobK8s "github.com/dolittle/platform-api/pkg/platform/job/k8s"
+	"github.com/gorilla/mux"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type jobService interface {
+	GetStatus(w http.ResponseWriter, r *http.Request)
+	GetLogs(w http.ResponseWriter, r *http.Request)
+}
+
+var _ = Describe("Job service", func() {
+	var (
+		namespace  string
+		customerID string
+		jobID      string
+		clientSet  *fake.Clientset
+		service    jobService
+		resp       *http.Response
+	)
+
+	request := func(handler http.HandlerFunc, url string, tenantID string) *http.Response {
+		req := httptest.NewRequest("GET", url, nil)
+		req = mux.SetURLVars(req, map[string]string{"jobID": jobID})
+		req.Header.Set("Tenant-ID", tenantID)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Result()
+	}
+
+	BeforeEach(func() {
+		namespace = "system-api"
+		customerID = "453e04a7-4f9d-42f2-b36c-d51fa2c83fa3"
+		jobID = "create-application-11b6cf47"
+		startedAt := metav1.NewTime(time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC))
+		annotations := map[string]string{
+			"dolittle.io/tenant-id":      customerID,
+			"dolittle.io/application-id": "11b6cf47",
+		}
+
+		clientSet = fake.NewSimpleClientset(
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        jobID,
+					Namespace:   namespace,
+					Annotations: annotations,
+				},
+				Status: batchv1.JobStatus{
+					Active:    1,
+					StartTime: &startedAt,
+				},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        jobID + "-abcde",
+					Namespace:   namespace,
+					Labels:      map[string]string{"job-name": jobID},
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "ssh-setup"},
+						{Name: "terraform-apply"},
+					},
+					Containers: []corev1.Container{
+						{Name: "summary"},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: "ssh-setup",
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"},
+							},
+						},
+						{
+							Name: "terraform-apply",
+							State: corev1.ContainerState{
+								Running: &corev1.ContainerStateRunning{StartedAt: startedAt},
+							},
+						},
+					},
+				},
+			},
+		)
+		logger, _ := logrusTest.NewNullLogger()
+		service = job.NewService(logger, clientSet, namespace)
+	})
+
+	Describe("getting the status", func() {
+		JustBeforeEach(func() {
+			resp = request(service.GetStatus, "http://studio/jobs/"+jobID, customerID)
+		})
+
+		It("should return the state of the job and each step", func() {
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var status job.HttpResponseJobStatus
+			body, _ := io.ReadAll(resp.Body)
+			Expect(json.Unmarshal(body, &status)).To(Succeed())
+			Expect(status.State).To(Equal(job.JobStateRunning))
+			Expect(status.CustomerID).To(Equal(customerID))
+			Expect(status.StartedAt).To(Equal("2022-04-01T10:00:00Z"))
+			Expect(status.Steps).To(HaveLen(3))
+			Expect(status.Steps[0].State).To(Equal(job.StepStateTerminated))
+			Expect(*status.Steps[0].ExitCode).To(Equal(int32(0)))
+			Expect(status.Steps[1].State).To(Equal(job.StepStateRunning))
+			Expect(status.Steps[2]).To(Equal(job.JobStepStatus{Name: "summary", State: job.StepStateWaiting}))
+		})
+
+		When("the job has failed", func() {
+			BeforeEach(func() {
+				resource, _ := clientSet.BatchV1().Jobs(namespace).Get(context.TODO(), jobID, metav1.GetOptions{})
+				resource.Status.Active = 0
+				resource.Status.Conditions = []batchv1.JobCondition{
+					{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+				}
+				clientSet.BatchV1().Jobs(namespace).UpdateStatus(context.TODO(), resource, metav1.UpdateOptions{})
+			})
+
+			It("should be failed", func() {
+				var status job.HttpResponseJobStatus
+				body, _ := io.ReadAll(resp.Body)
+				json.Unmarshal(body, &status)
+				Expect(status.State).To(Equal(job.JobStateFailed))
+				Expect(status.Conditions[0].Reason).To(Equal("BackoffLimitExceeded"))
+			})
+		})
+
+		When("the job belongs to another customer", func() {
+			BeforeEach(func() {
+				customerID = "another-customer"
+			})
+
+			It("should not be found", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("the job does not exist", func() {
+			BeforeEach(func() {
+				jobID = "create-application-missing"
+			})
+
+			It("should not be found", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Describe("getting the logs", func() {
+		var url string
+
+		BeforeEach(func() {
+			url = "http://studio/jobs/" + jobID + "/logs"
+		})
+
+		JustBeforeEach(func() {
+			resp = request(service.GetLogs, url, customerID)
+		})
+
+		It("should stream the steps that have started", func() {
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/plain"))
+			body, _ := io.ReadAll(resp.Body)
+			Expect(string(body)).To(Equal(
+				"==> ssh-setup (terminated) <==\nfake logs\n" +
+					"==> terraform-apply (running) <==\nfake logs\n" +
+					"==> summary (waiting) <==\n",
+			))
+		})
+
+		When("asking for one step", func() {
+			BeforeEach(func() {
+				url += "?step=terraform-apply"
+			})
+
+			It("should only stream that step", func() {
+				body, _ := io.ReadAll(resp.Body)
+				Expect(string(body)).To(Equal("fake logs\n"))
+			})
+		})
+
+		When("asking for a step that has not started", func() {
+			BeforeEach(func() {
+				url += "?step=summary"
+			})
+
+			It("should say so", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+			})
+		})
+
+		When("asking for a step that is not in the job", func() {
+			BeforeEach(func() {
+				url += "?step=rm-rf"
+			})
+
+			It("should be a bad request", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the job belongs to another customer", func() {
+			BeforeEach(func() {
+				customerID = "another-customer"
+			})
+
+			It("should not be found", func() {
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
+
+var _ = Describe("Job finished", func() {
+	It("should not be finished while waiting for a pod or between retries", func() {
+		Expect(jobK8s.IsJobFinished(batchv1.Job{})).To(BeFalse())
+		Expect(jobK8s.IsJobFinished(batchv1.Job{Status: batchv1.JobStatus{Failed: 1}})).To(BeFalse())
+	})
+
+	It("should be finished once it completed or failed", func() {
+		for _, conditionType := range []batchv1.JobConditionType{batchv1.JobComplete, batchv1.JobFailed} {
+			job := batchv1.Job{Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue, Message: "done"}},
+			}}
+			Expect(jobK8s.IsJobFinished(job)).To(BeTrue())
+			_, message := jobK8s.GetJobFinished(job)
+			Expect(message).To(Equal("done"))
+		}
+	})
+})
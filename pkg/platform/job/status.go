@@ -0,0 +1,106 @@
+package job
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewJobStatus describes the job, the steps come from the newest of its pods
+func NewJobStatus(job *batchv1.Job, pods []corev1.Pod) HttpResponseJobStatus {
+	status := HttpResponseJobStatus{
+		ID:            job.Name,
+		CustomerID:    job.Annotations["dolittle.io/tenant-id"],
+		ApplicationID: job.Annotations["dolittle.io/application-id"],
+		State:         jobState(job),
+		StartedAt:     formatOptionalTime(job.Status.StartTime),
+		FinishedAt:    formatOptionalTime(job.Status.CompletionTime),
+		Conditions:    make([]JobCondition, 0, len(job.Status.Conditions)),
+		Steps:         make([]JobStepStatus, 0),
+	}
+
+	for _, condition := range job.Status.Conditions {
+		status.Conditions = append(status.Conditions, JobCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: formatOptionalTime(&condition.LastTransitionTime),
+		})
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue && status.FinishedAt == "" {
+			status.FinishedAt = formatOptionalTime(&condition.LastTransitionTime)
+		}
+	}
+
+	if len(pods) == 0 {
+		return status
+	}
+
+	pod := pods[0]
+	status.Pod = pod.Name
+	status.Steps = JobSteps(pod)
+	return status
+}
+
+// JobSteps lists the containers of the pod in the order they run, with their state
+func JobSteps(pod corev1.Pod) []JobStepStatus {
+	states := map[string]corev1.ContainerState{}
+	for _, container := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		states[container.Name] = container.State
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	steps := make([]JobStepStatus, 0, len(containers))
+	for _, container := range containers {
+		step := JobStepStatus{
+			Name:  container.Name,
+			State: StepStateWaiting,
+		}
+
+		state := states[container.Name]
+		switch {
+		case state.Terminated != nil:
+			exitCode := state.Terminated.ExitCode
+			step.State = StepStateTerminated
+			step.Reason = state.Terminated.Reason
+			step.Message = state.Terminated.Message
+			step.ExitCode = &exitCode
+			step.StartedAt = formatOptionalTime(&state.Terminated.StartedAt)
+			step.FinishedAt = formatOptionalTime(&state.Terminated.FinishedAt)
+		case state.Running != nil:
+			step.State = StepStateRunning
+			step.StartedAt = formatOptionalTime(&state.Running.StartedAt)
+		case state.Waiting != nil:
+			step.Reason = state.Waiting.Reason
+			step.Message = state.Waiting.Message
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func jobState(job *batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return JobStateSucceeded
+		case batchv1.JobFailed:
+			return JobStateFailed
+		}
+	}
+
+	if job.Status.Active > 0 {
+		return JobStateRunning
+	}
+	return JobStatePending
+}
+
+func formatOptionalTime(t *metav1.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return formatTime(*t)
+}
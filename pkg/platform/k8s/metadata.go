@@ -2,6 +2,9 @@ package k8s
 
 import "strings"
 
+// ConfigFilesMetadataAnnotation holds the metadata of the config files of a microservice as JSON, keyed by file name
+const ConfigFilesMetadataAnnotation = "dolittle.io/config-files-metadata"
+
 func ParseLabel(input string) string {
 	// https://app.asana.com/0/0/1201457681486811/f
 	// a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')"
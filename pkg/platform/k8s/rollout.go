@@ -0,0 +1,184 @@
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ConfigChecksumAnnotation is stamped on the pod template, changing it triggers a rolling update
+const ConfigChecksumAnnotation = "dolittle.io/config-checksum"
+
+// RolloutMicroserviceConfig stamps the checksum of the environment variables, secret environment variables
+// and config files on the pod template of the microservice, which rolls out new pods when they changed
+func (r *K8sRepo) RolloutMicroserviceConfig(applicationID string, environment string, microserviceID string) (platform.MicroserviceRolloutStatus, error) {
+	status := platform.MicroserviceRolloutStatus{}
+	client := r.k8sClient
+	ctx := context.TODO()
+	namespace := GetApplicationNamespace(applicationID)
+
+	deployment, err := r.k8sRepoV2.GetDeployment(namespace, environment, microserviceID)
+	if err != nil {
+		return status, err
+	}
+
+	checksum, err := r.getConfigChecksum(applicationID, deployment.Name)
+	if err != nil {
+		return status, err
+	}
+	status.ConfigChecksum = checksum
+
+	if deployment.Spec.Template.Annotations[ConfigChecksumAnnotation] == checksum {
+		status = GetRolloutStatus(deployment)
+		status.ConfigChecksum = checksum
+		status.Status = platform.RolloutStatusUnchanged
+		return status, nil
+	}
+
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[ConfigChecksumAnnotation] = checksum
+
+	// Surge new pods before taking old ones down
+	// The api server defaults every deployment to a rolling update of 25%, so only Recreate is left alone
+	if deployment.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		maxSurge := intstr.FromInt(1)
+		maxUnavailable := intstr.FromInt(0)
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxSurge:       &maxSurge,
+				MaxUnavailable: &maxUnavailable,
+			},
+		}
+	}
+
+	updated, err := client.AppsV1().Deployments(namespace).Update(ctx, &deployment, metav1.UpdateOptions{})
+	if err != nil {
+		return status, err
+	}
+
+	status = GetRolloutStatus(*updated)
+	status.ConfigChecksum = checksum
+	return status, nil
+}
+
+// TryRolloutMicroserviceConfig is RolloutMicroserviceConfig for configuration that has already been saved,
+// a failure is logged and reported in the status instead of returned
+func (r *K8sRepo) TryRolloutMicroserviceConfig(applicationID string, environment string, microserviceID string) *platform.MicroserviceRolloutStatus {
+	status, err := r.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+	if err != nil {
+		r.logContext.WithFields(logrus.Fields{
+			"error":           err,
+			"application_id":  applicationID,
+			"environment":     environment,
+			"microservice_id": microserviceID,
+		}).Error("failed to roll out configuration")
+		status.Status = platform.RolloutStatusFailed
+		status.Message = err.Error()
+	}
+	return &status
+}
+
+// GetRolloutStatus reports how far the deployment has come rolling out its latest pod template
+func GetRolloutStatus(deployment appsv1.Deployment) platform.MicroserviceRolloutStatus {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	status := platform.MicroserviceRolloutStatus{
+		ConfigChecksum:    deployment.Spec.Template.Annotations[ConfigChecksumAnnotation],
+		Status:            platform.RolloutStatusProgressing,
+		Replicas:          replicas,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			status.Status = platform.RolloutStatusFailed
+			status.Message = condition.Message
+			return status
+		}
+	}
+
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		status.Message = "waiting for the rollout to start"
+		return status
+	}
+
+	if deployment.Status.UpdatedReplicas < replicas {
+		status.Message = fmt.Sprintf("%d of %d new pods have been updated", deployment.Status.UpdatedReplicas, replicas)
+		return status
+	}
+
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		status.Message = fmt.Sprintf("%d old pods are pending termination", deployment.Status.Replicas-deployment.Status.UpdatedReplicas)
+		return status
+	}
+
+	if deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas {
+		status.Message = fmt.Sprintf("%d of %d updated pods are available", deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas)
+		return status
+	}
+
+	status.Status = platform.RolloutStatusComplete
+	return status
+}
+
+func (r *K8sRepo) getConfigChecksum(applicationID string, name string) (string, error) {
+	configMap, err := r.GetConfigMap(applicationID, GetMicroserviceEnvironmentVariableConfigmapName(name))
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := r.GetSecret(r.logContext, applicationID, GetMicroserviceEnvironmentVariableSecretName(name))
+	if err != nil {
+		return "", err
+	}
+
+	configFiles, err := r.GetConfigMap(applicationID, GetMicroserviceConfigFilesConfigmapName(name))
+	if err != nil {
+		return "", err
+	}
+
+	secretData := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		secretData[key] = value
+	}
+	// StringData is only set on objects we have written, before the api server merges it into Data
+	for key, value := range secret.StringData {
+		secretData[key] = []byte(value)
+	}
+
+	// json.Marshal sorts map keys, which keeps the checksum stable
+	b, err := json.Marshal(struct {
+		EnvironmentVariables map[string]string `json:"environmentVariables"`
+		Secret               map[string][]byte `json:"secret"`
+		ConfigFiles          corev1.ConfigMap  `json:"configFiles"`
+	}{
+		EnvironmentVariables: configMap.Data,
+		Secret:               secretData,
+		ConfigFiles: corev1.ConfigMap{
+			Data:       configFiles.Data,
+			BinaryData: configFiles.BinaryData,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,170 @@
+package k8s_test
+
+import (
+	"context"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("Rolling out microservice config", func() {
+	var (
+		applicationID  string
+		environment    string
+		microserviceID string
+		namespace      string
+		clientSet      *fake.Clientset
+		k8sRepo        platformK8s.K8sRepo
+	)
+
+	getDeployment := func() *appsv1.Deployment {
+		deployment, err := clientSet.AppsV1().Deployments(namespace).Get(context.TODO(), "dev-order", metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		return deployment
+	}
+
+	BeforeEach(func() {
+		applicationID = "fake-application-123"
+		environment = "dev"
+		microserviceID = "fake-microservice-123"
+		namespace = "application-" + applicationID
+
+		clientSet = fake.NewSimpleClientset(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dev-order",
+					Namespace: namespace,
+					Labels: map[string]string{
+						"tenant":       "fake-tenant",
+						"application":  "fake-application",
+						"environment":  environment,
+						"microservice": "order",
+					},
+					Annotations: map[string]string{
+						"dolittle.io/microservice-id": microserviceID,
+					},
+				},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-order-env-variables", Namespace: namespace},
+				Data:       map[string]string{"LOG_LEVEL": "info"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-order-secret-env-variables", Namespace: namespace},
+				Data:       map[string][]byte{"PASSWORD": []byte("hunter2")},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-order-config-files", Namespace: namespace},
+			},
+		)
+		logger, _ := logrusTest.NewNullLogger()
+		k8sRepo = platformK8s.NewK8sRepo(clientSet, &rest.Config{}, logger.WithField("context", "k8s-repo"))
+	})
+
+	It("should stamp the checksum and surge new pods", func() {
+		status, err := k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+		Expect(err).To(BeNil())
+		Expect(status.Status).To(Equal(platform.RolloutStatusProgressing))
+		Expect(status.ConfigChecksum).ToNot(BeEmpty())
+
+		deployment := getDeployment()
+		Expect(deployment.Spec.Template.Annotations[platformK8s.ConfigChecksumAnnotation]).To(Equal(status.ConfigChecksum))
+		Expect(deployment.Spec.Strategy.Type).To(Equal(appsv1.RollingUpdateDeploymentStrategyType))
+		Expect(deployment.Spec.Strategy.RollingUpdate.MaxSurge.IntValue()).To(Equal(1))
+		Expect(deployment.Spec.Strategy.RollingUpdate.MaxUnavailable.IntValue()).To(Equal(0))
+	})
+
+	It("should surge new pods when the deployment has the server defaults", func() {
+		deployment := getDeployment()
+		defaultSurge := intstr.FromString("25%")
+		defaultUnavailable := intstr.FromString("25%")
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxSurge:       &defaultSurge,
+				MaxUnavailable: &defaultUnavailable,
+			},
+		}
+		_, err := clientSet.AppsV1().Deployments(namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+
+		_, err = k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+		Expect(err).To(BeNil())
+
+		strategy := getDeployment().Spec.Strategy
+		Expect(strategy.Type).To(Equal(appsv1.RollingUpdateDeploymentStrategyType))
+		Expect(strategy.RollingUpdate.MaxSurge.String()).To(Equal("1"))
+		Expect(strategy.RollingUpdate.MaxUnavailable.String()).To(Equal("0"))
+	})
+
+	It("should keep the recreate strategy of the deployment", func() {
+		deployment := getDeployment()
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+		_, err := clientSet.AppsV1().Deployments(namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+
+		_, err = k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+		Expect(err).To(BeNil())
+
+		Expect(getDeployment().Spec.Strategy.Type).To(Equal(appsv1.RecreateDeploymentStrategyType))
+		Expect(getDeployment().Spec.Strategy.RollingUpdate).To(BeNil())
+	})
+
+	It("should report a failed rollout in the status when trying", func() {
+		status := k8sRepo.TryRolloutMicroserviceConfig(applicationID, environment, "unknown")
+		Expect(status.Status).To(Equal(platform.RolloutStatusFailed))
+		Expect(status.Message).ToNot(BeEmpty())
+	})
+
+	It("should not roll out when the config is unchanged", func() {
+		first, err := k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+		Expect(err).To(BeNil())
+
+		second, err := k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+		Expect(err).To(BeNil())
+		Expect(second.Status).To(Equal(platform.RolloutStatusUnchanged))
+		Expect(second.ConfigChecksum).To(Equal(first.ConfigChecksum))
+	})
+
+	It("should change the checksum when a secret changes", func() {
+		first, err := k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+		Expect(err).To(BeNil())
+
+		secret, _ := clientSet.CoreV1().Secrets(namespace).Get(context.TODO(), "dev-order-secret-env-variables", metav1.GetOptions{})
+		secret.Data["PASSWORD"] = []byte("hunter3")
+		clientSet.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+
+		second, err := k8sRepo.RolloutMicroserviceConfig(applicationID, environment, microserviceID)
+		Expect(err).To(BeNil())
+		Expect(second.Status).To(Equal(platform.RolloutStatusProgressing))
+		Expect(second.ConfigChecksum).ToNot(Equal(first.ConfigChecksum))
+	})
+
+	It("should report a complete rollout", func() {
+		replicas := int32(2)
+		deployment := appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Generation: 3},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 3,
+				Replicas:           2,
+				UpdatedReplicas:    2,
+				AvailableReplicas:  2,
+			},
+		}
+		Expect(platformK8s.GetRolloutStatus(deployment).Status).To(Equal(platform.RolloutStatusComplete))
+
+		deployment.Status.AvailableReplicas = 1
+		Expect(platformK8s.GetRolloutStatus(deployment).Status).To(Equal(platform.RolloutStatusProgressing))
+	})
+})
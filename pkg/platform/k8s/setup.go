@@ -8,6 +8,16 @@ import (
 )
 
 func InitKubernetesClient() (kubernetes.Interface, *rest.Config) {
+	client, config, err := NewKubernetesClient()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return client, config
+}
+
+// NewKubernetesClient is InitKubernetesClient returning the error instead of panicking
+func NewKubernetesClient() (kubernetes.Interface, *rest.Config, error) {
 	kubeconfig := viper.GetString("tools.server.kubeConfig")
 
 	if kubeconfig == "incluster" {
@@ -16,13 +26,13 @@ func InitKubernetesClient() (kubernetes.Interface, *rest.Config) {
 
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
-		panic(err.Error())
+		return nil, nil, err
 	}
 
 	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(err.Error())
+		return nil, nil, err
 	}
 
-	return client, config
+	return client, config, nil
 }
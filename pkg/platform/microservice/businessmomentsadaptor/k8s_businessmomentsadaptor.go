@@ -79,6 +79,11 @@ func (r K8sRepo) GetBusinessMomentsConfigmap(applicationID string, environment s
 			continue
 		}
 
+		// The microservice has other configmaps with the same labels and annotations
+		if !strings.HasSuffix(item.GetName(), "-business-moments") {
+			continue
+		}
+
 		found = true
 		foundConfigmap = item
 		break
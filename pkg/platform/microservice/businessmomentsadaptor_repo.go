@@ -8,8 +8,6 @@ package microservice
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
-	"errors"
 	"fmt"
 
 	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
@@ -24,6 +22,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// creationStep creates one resource, the name is used when it already exists or fails
+type creationStep struct {
+	name   string
+	create func() error
+}
+
 type businessMomentsAdaptorRepo struct {
 	k8sClient    kubernetes.Interface
 	kind         platform.MicroserviceKind
@@ -67,41 +71,9 @@ func (r businessMomentsAdaptorRepo) Create(namespace string, tenant dolittleK8s.
 
 	ingresses := customertenant.CreateIngresses(r.isProduction, customerTenants, microservice, service.Name, input.Extra.Ingress)
 
-	token := ""
-
-	connectorBytes, _ := json.Marshal(input.Extra.Connector)
-	var whatKind platform.HttpInputMicroserviceKind
-	json.Unmarshal(connectorBytes, &whatKind)
-	switch whatKind.Kind {
-	case "webhook":
-		var connector platform.HttpInputBusinessMomentAdaptorConnectorWebhook
-		json.Unmarshal(connectorBytes, &connector)
-
-		//connectorConfigBytes, _ := json.Marshal(connector.Config.Config)
-		//json.Unmarshal(connectorConfigBytes, &whatKind)
-		// Super ugly
-		switch connector.Config.Kind {
-		case "basic":
-			var connectorCredentialsConfig platform.HttpInputBusinessMomentAdaptorConnectorWebhookConfigBasic
-			connectorCredentialsConfigBytes, _ := json.Marshal(connector.Config.Config)
-			json.Unmarshal(connectorCredentialsConfigBytes, &connectorCredentialsConfig)
-			token = fmt.Sprintf("Basic %s",
-				basicAuth(connectorCredentialsConfig.Username, connectorCredentialsConfig.Password),
-			)
-		case "bearer":
-			var connectorCredentialsConfig platform.HttpInputBusinessMomentAdaptorConnectorWebhookConfigBearer
-			connectorCredentialsConfigBytes, _ := json.Marshal(connector.Config)
-			json.Unmarshal(connectorCredentialsConfigBytes, &connectorCredentialsConfig)
-
-			token = fmt.Sprintf("Bearer %s", connectorCredentialsConfig.Token)
-		default:
-			return errors.New("Not supported basic / bearer")
-		}
-
-	case "rest":
-		return errors.New("TODO kind: rest")
-	default:
-		return errors.New("Not supported webhook / rest")
+	token, err := GetBusinessMomentsAdaptorAuthorization(input.Extra.Connector)
+	if err != nil {
+		return err
 	}
 
 	configEnvVariables.Data = map[string]string{
@@ -115,54 +87,38 @@ func (r businessMomentsAdaptorRepo) Create(namespace string, tenant dolittleK8s.
 	deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = 3008
 
 	// Assuming the namespace exists
-	var err error
-	client := r.k8sClient
 	ctx := context.TODO()
+	created := microserviceK8s.NewCreatedResources(r.k8sClient, namespace)
 
-	_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, microserviceConfigmap, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("microservice config map") }) != nil {
-		return err
-	}
-
-	_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, configEnvVariables, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("config env variables") }) != nil {
-		return err
-	}
-	_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, configFiles, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("config files") }) != nil {
-		return err
-	}
-	_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, configBusinessMoments, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("config business moments") }) != nil {
-		return err
-	}
-	_, err = client.CoreV1().Secrets(namespace).Create(ctx, configSecrets, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("config secrets") }) != nil {
-		return err
+	steps := []creationStep{
+		{"microservice config map", func() error { return created.ConfigMap(ctx, microserviceConfigmap) }},
+		{"config env variables", func() error { return created.ConfigMap(ctx, configEnvVariables) }},
+		{"config files", func() error { return created.ConfigMap(ctx, configFiles) }},
+		{"config business moments", func() error { return created.ConfigMap(ctx, configBusinessMoments) }},
+		{"config secrets", func() error { return created.Secret(ctx, configSecrets) }},
 	}
-
 	for _, ingress := range ingresses {
-		_, err = client.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, metaV1.CreateOptions{})
-		if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("ingress") }) != nil {
-			return err
+		ingress := ingress
+		steps = append(steps, creationStep{"ingress", func() error { return created.Ingress(ctx, ingress) }})
+	}
+	steps = append(steps, []creationStep{
+		{"service", func() error { return created.Service(ctx, service) }},
+		{"network policy", func() error { return created.NetworkPolicy(ctx, networkPolicy) }},
+		{"deployment", func() error { return created.Deployment(ctx, deployment) }},
+	}...)
+
+	for _, step := range steps {
+		name := step.name
+		err = microserviceK8s.K8sHandleResourceCreationError(step.create(), func() { microserviceK8s.K8sPrintAlreadyExists(name) })
+		if err != nil {
+			// Leave nothing half created behind
+			if rollbackErr := created.Rollback(ctx); rollbackErr != nil {
+				return fmt.Errorf("failed to create %s: %w, and failed to roll back: %s", name, err, rollbackErr.Error())
+			}
+			return fmt.Errorf("failed to create %s: %w", name, err)
 		}
 	}
 
-	_, err = client.CoreV1().Services(namespace).Create(ctx, service, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("service") }) != nil {
-		return err
-	}
-
-	_, err = client.NetworkingV1().NetworkPolicies(namespace).Create(ctx, networkPolicy, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("network policy") }) != nil {
-		return err
-	}
-
-	_, err = client.AppsV1().Deployments(namespace).Create(ctx, deployment, metaV1.CreateOptions{})
-	if microserviceK8s.K8sHandleResourceCreationError(err, func() { microserviceK8s.K8sPrintAlreadyExists("deployment") }) != nil {
-		return err
-	}
-
 	return nil
 }
 
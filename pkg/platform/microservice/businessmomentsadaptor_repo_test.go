@@ -0,0 +1,148 @@
+package microservice_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/microservice"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+)
+
+var _ = Describe("Business moments adaptor repo", func() {
+	var (
+		clientSet       *fake.Clientset
+		customer        dolittleK8s.Tenant
+		application     dolittleK8s.Application
+		namespace       string
+		customerTenants []platform.CustomerTenantInfo
+		input           platform.HttpInputBusinessMomentAdaptorInfo
+		err             error
+	)
+
+	BeforeEach(func() {
+		clientSet = fake.NewSimpleClientset()
+		customer = dolittleK8s.Tenant{
+			Name: "tenant-name",
+			ID:   "67dcf38f-16e4-4b57-bff5-707cff3233ec",
+		}
+		application = dolittleK8s.Application{
+			Name: "application-name",
+			ID:   "c1e08289-be4b-4557-9457-5de90e0ea54a",
+		}
+		namespace = fmt.Sprintf("application-%s", application.ID)
+		customerTenants = []platform.CustomerTenantInfo{
+			{
+				CustomerTenantID: "04b557ed-eb92-476a-b9ef-6c99c1ff9f86",
+				Hosts: []platform.CustomerTenantHost{
+					{
+						Host:       "fake-prefix.fake-host",
+						SecretName: "fake-prefix",
+					},
+				},
+			},
+		}
+		input = platform.HttpInputBusinessMomentAdaptorInfo{
+			MicroserviceBase: platform.MicroserviceBase{
+				Environment: "Dev",
+				Name:        "Adaptor",
+				Kind:        platform.MicroserviceKindBusinessMomentsAdaptor,
+				Dolittle: platform.HttpInputDolittle{
+					ApplicationID:  application.ID,
+					CustomerID:     customer.ID,
+					MicroserviceID: "2f2a9a3b-3f24-4d68-8f3c-3ad0ad9f8c1e",
+				},
+			},
+			Extra: platform.HttpInputBusinessMomentAdaptorExtra{
+				Headimage:    "dolittle/businessmomentsadaptor:latest",
+				Runtimeimage: "dolittle/runtime:latest",
+				Ingress: platform.HttpInputSimpleIngress{
+					Path:     "/adaptor",
+					Pathtype: "Prefix",
+				},
+				Connector: map[string]interface{}{
+					"kind": "webhook",
+					"config": map[string]interface{}{
+						"kind": "bearer",
+						"config": map[string]interface{}{
+							"token": "abc",
+						},
+					},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		repo := microservice.NewBusinessMomentsAdaptorRepo(clientSet, false)
+		err = repo.Create(namespace, customer, application, customerTenants, input)
+	})
+
+	When("creating with a valid connector", func() {
+		It("should not fail", func() {
+			Expect(err).To(BeNil())
+		})
+
+		It("should create the deployment", func() {
+			deployments, _ := clientSet.AppsV1().Deployments(namespace).List(context.TODO(), metaV1.ListOptions{})
+			Expect(deployments.Items).To(HaveLen(1))
+		})
+
+		It("should create the ingress", func() {
+			ingresses, _ := clientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), metaV1.ListOptions{})
+			Expect(ingresses.Items).To(HaveLen(1))
+		})
+
+		It("should set the webhook authorization", func() {
+			configMap, _ := clientSet.CoreV1().ConfigMaps(namespace).Get(context.TODO(), "dev-adaptor-env-variables", metaV1.GetOptions{})
+			Expect(configMap.Data["WH_AUTHORIZATION"]).To(Equal("Bearer abc"))
+		})
+	})
+
+	When("creating with an invalid connector", func() {
+		BeforeEach(func() {
+			input.Extra.Connector = map[string]interface{}{"kind": "webhook"}
+		})
+
+		It("should fail with an invalid connector", func() {
+			Expect(errors.Is(err, microservice.ErrInvalidConnector)).To(BeTrue())
+		})
+
+		It("should not create any resources", func() {
+			Expect(clientSet.Actions()).To(BeEmpty())
+		})
+	})
+
+	When("creating the deployment fails", func() {
+		BeforeEach(func() {
+			clientSet.PrependReactor("create", "deployments", func(action testing.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("deployment failed")
+			})
+		})
+
+		It("should fail with an error", func() {
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should remove the configmaps it created", func() {
+			configMaps, _ := clientSet.CoreV1().ConfigMaps(namespace).List(context.TODO(), metaV1.ListOptions{})
+			Expect(configMaps.Items).To(BeEmpty())
+		})
+
+		It("should remove the secrets, services and ingresses it created", func() {
+			secrets, _ := clientSet.CoreV1().Secrets(namespace).List(context.TODO(), metaV1.ListOptions{})
+			services, _ := clientSet.CoreV1().Services(namespace).List(context.TODO(), metaV1.ListOptions{})
+			ingresses, _ := clientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), metaV1.ListOptions{})
+			Expect(secrets.Items).To(BeEmpty())
+			Expect(services.Items).To(BeEmpty())
+			Expect(ingresses.Items).To(BeEmpty())
+		})
+	})
+})
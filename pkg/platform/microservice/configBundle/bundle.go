@@ -0,0 +1,294 @@
+package configBundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configFiles"
+)
+
+const (
+	EnvironmentVariablesFile       = "environment-variables.env"
+	SecretEnvironmentVariablesFile = "secret-environment-variables.env"
+	ConfigFilesDirectory           = "config-files"
+	// MaxBundleBytes is the most we read when unpacking a bundle
+	MaxBundleBytes = 10 * 1024 * 1024
+)
+
+var (
+	ErrBundleTooLarge = errors.New("config bundle is too large")
+
+	validConfigFileName = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+// Bundle is the whole configuration of a microservice
+type Bundle struct {
+	// EnvironmentVariables is nil when the bundle has no environment variables file
+	EnvironmentVariables map[string]string
+	// SecretEnvironmentVariables is nil when secrets are not part of the bundle
+	SecretEnvironmentVariables map[string]string
+	ConfigFiles                map[string][]byte
+}
+
+// IncludesEnvironmentVariables is true when the bundle carries the environment variables
+func (b Bundle) IncludesEnvironmentVariables() bool {
+	return b.EnvironmentVariables != nil
+}
+
+// IncludesSecrets is true when the bundle carries the secret environment variables
+func (b Bundle) IncludesSecrets() bool {
+	return b.SecretEnvironmentVariables != nil
+}
+
+type ValidationError struct {
+	Errors []string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("invalid config bundle: %s", strings.Join(e.Errors, "; "))
+}
+
+// Validate uses the same rules as updating environment variables and config files one by one
+func (b Bundle) Validate() error {
+	problems := make([]string, 0)
+
+	checkVariables := func(kind string, variables map[string]string) {
+		for name, value := range variables {
+			switch {
+			case name == "":
+				problems = append(problems, fmt.Sprintf("%s: empty name", kind))
+			case strings.TrimSpace(name) != name || strings.ContainsAny(name, " \t"):
+				problems = append(problems, fmt.Sprintf("%s %s: no spaces allowed in the name", kind, name))
+			case value == "":
+				problems = append(problems, fmt.Sprintf("%s %s: empty value", kind, name))
+			case strings.TrimSpace(value) != value:
+				problems = append(problems, fmt.Sprintf("%s %s: leading or trailing whitespace in the value", kind, name))
+			}
+		}
+	}
+
+	checkVariables("environment variable", b.EnvironmentVariables)
+	checkVariables("secret environment variable", b.SecretEnvironmentVariables)
+
+	for name := range b.SecretEnvironmentVariables {
+		if _, ok := b.EnvironmentVariables[name]; ok {
+			problems = append(problems, fmt.Sprintf("%s is both a plain and a secret environment variable", name))
+		}
+	}
+
+	configFilesSize := 0
+	for name, value := range b.ConfigFiles {
+		if !validConfigFileName.MatchString(name) {
+			problems = append(problems, fmt.Sprintf("config file %s: name can only contain letters, numbers, dots, dashes and underscores", name))
+		}
+		configFilesSize += len(value)
+	}
+	if err := configFiles.CheckConfigFilesSize(configFilesSize); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return ValidationError{Errors: problems}
+}
+
+// WriteArchive writes the bundle as a gzipped tar with the same layout as WriteDirectory
+func WriteArchive(w io.Writer, b Bundle) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	modTime := time.Now().UTC()
+
+	writeFile := func(name string, data []byte) error {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tarWriter.Write(data)
+		return err
+	}
+
+	for name, data := range b.files() {
+		if err := writeFile(name, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// ReadArchive reads a bundle written by WriteArchive
+func ReadArchive(r io.Reader) (Bundle, error) {
+	// Read one byte past the limit to tell a bundle that is too large from a broken one
+	archive, err := ioutil.ReadAll(io.LimitReader(r, MaxBundleBytes+1))
+	if err != nil {
+		return Bundle{}, err
+	}
+	if len(archive) > MaxBundleBytes {
+		return Bundle{}, ErrBundleTooLarge
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return Bundle{}, err
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	files := map[string][]byte{}
+	var total int64
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Bundle{}, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Guard against archives that unpack to far more than they weigh
+		total += header.Size
+		if total > MaxBundleBytes {
+			return Bundle{}, ErrBundleTooLarge
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(tarReader, header.Size))
+		if err != nil {
+			return Bundle{}, err
+		}
+		files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = data
+	}
+
+	return fromFiles(files)
+}
+
+// WriteDirectory writes the .env files and the config files next to each other in directory
+func WriteDirectory(directory string, b Bundle) error {
+	err := os.MkdirAll(filepath.Join(directory, ConfigFilesDirectory), 0755)
+	if err != nil {
+		return err
+	}
+
+	for name, data := range b.files() {
+		mode := os.FileMode(0644)
+		if name == SecretEnvironmentVariablesFile {
+			mode = 0600
+		}
+
+		err := ioutil.WriteFile(filepath.Join(directory, filepath.FromSlash(name)), data, mode)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadDirectory reads a bundle written by WriteDirectory
+func ReadDirectory(directory string) (Bundle, error) {
+	files := map[string][]byte{}
+
+	for _, name := range []string{EnvironmentVariablesFile, SecretEnvironmentVariablesFile} {
+		data, err := ioutil.ReadFile(filepath.Join(directory, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return Bundle{}, err
+		}
+		files[name] = data
+	}
+
+	configFiles, err := ioutil.ReadDir(filepath.Join(directory, ConfigFilesDirectory))
+	if err != nil && !os.IsNotExist(err) {
+		return Bundle{}, err
+	}
+
+	for _, configFile := range configFiles {
+		if configFile.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(directory, ConfigFilesDirectory, configFile.Name()))
+		if err != nil {
+			return Bundle{}, err
+		}
+		files[path.Join(ConfigFilesDirectory, configFile.Name())] = data
+	}
+
+	return fromFiles(files)
+}
+
+func (b Bundle) files() map[string][]byte {
+	files := map[string][]byte{}
+
+	if b.IncludesEnvironmentVariables() {
+		files[EnvironmentVariablesFile] = FormatEnv(b.EnvironmentVariables)
+	}
+
+	if b.IncludesSecrets() {
+		files[SecretEnvironmentVariablesFile] = FormatEnv(b.SecretEnvironmentVariables)
+	}
+
+	for name, data := range b.ConfigFiles {
+		files[path.Join(ConfigFilesDirectory, name)] = data
+	}
+	return files
+}
+
+func fromFiles(files map[string][]byte) (Bundle, error) {
+	b := Bundle{
+		ConfigFiles: map[string][]byte{},
+	}
+
+	for name, data := range files {
+		switch {
+		case name == EnvironmentVariablesFile:
+			variables, err := ParseEnv(data)
+			if err != nil {
+				return b, fmt.Errorf("%s: %w", name, err)
+			}
+			b.EnvironmentVariables = variables
+
+		case name == SecretEnvironmentVariablesFile:
+			variables, err := ParseEnv(data)
+			if err != nil {
+				return b, fmt.Errorf("%s: %w", name, err)
+			}
+			b.SecretEnvironmentVariables = variables
+
+		case path.Dir(name) == ConfigFilesDirectory:
+			b.ConfigFiles[path.Base(name)] = data
+
+		default:
+			return b, fmt.Errorf("unexpected file %s in config bundle", name)
+		}
+	}
+
+	return b, nil
+}
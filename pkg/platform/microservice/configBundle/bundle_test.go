@@ -0,0 +1,128 @@
+package configBundle_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configBundle"
+)
+
+var _ = Describe("Bundle", func() {
+	var bundle configBundle.Bundle
+
+	BeforeEach(func() {
+		bundle = configBundle.Bundle{
+			EnvironmentVariables: map[string]string{
+				"LOG_LEVEL": "info",
+				"GREETING":  "hello \"world\"\n# not a comment",
+			},
+			SecretEnvironmentVariables: map[string]string{
+				"PASSWORD": "hunter2",
+			},
+			ConfigFiles: map[string][]byte{
+				"appsettings.json": []byte(`{"a": 1}`),
+				"blob.bin":         {0xff, 0xfe},
+			},
+		}
+	})
+
+	It("should parse what it formats", func() {
+		variables, err := configBundle.ParseEnv(configBundle.FormatEnv(bundle.EnvironmentVariables))
+		Expect(err).To(BeNil())
+		Expect(variables).To(Equal(bundle.EnvironmentVariables))
+	})
+
+	It("should parse comments, exports and single quotes", func() {
+		variables, err := configBundle.ParseEnv([]byte("# comment\n\nexport A=1\nB='two words'\n"))
+		Expect(err).To(BeNil())
+		Expect(variables).To(Equal(map[string]string{"A": "1", "B": "two words"}))
+
+		_, err = configBundle.ParseEnv([]byte("A=1\nA=2\n"))
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should round trip through an archive", func() {
+		var archive bytes.Buffer
+		Expect(configBundle.WriteArchive(&archive, bundle)).To(Succeed())
+
+		read, err := configBundle.ReadArchive(&archive)
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(bundle))
+	})
+
+	It("should tell an archive that is too large from a broken one", func() {
+		_, err := configBundle.ReadArchive(bytes.NewReader(make([]byte, configBundle.MaxBundleBytes+1)))
+		Expect(err).To(Equal(configBundle.ErrBundleTooLarge))
+
+		_, err = configBundle.ReadArchive(bytes.NewReader(make([]byte, 16)))
+		Expect(err).ToNot(BeNil())
+		Expect(err).ToNot(Equal(configBundle.ErrBundleTooLarge))
+	})
+
+	It("should round trip through a directory without secrets", func() {
+		directory, err := ioutil.TempDir("", "config-bundle")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(directory)
+
+		bundle.SecretEnvironmentVariables = nil
+		Expect(configBundle.WriteDirectory(directory, bundle)).To(Succeed())
+
+		read, err := configBundle.ReadDirectory(directory)
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(bundle))
+		Expect(read.IncludesSecrets()).To(BeFalse())
+	})
+
+	It("should list every problem when validating", func() {
+		bundle.EnvironmentVariables["EMPTY"] = ""
+		bundle.EnvironmentVariables["PASSWORD"] = "plain"
+		bundle.ConfigFiles["has space.txt"] = []byte("x")
+
+		err := bundle.Validate()
+		Expect(err).To(BeAssignableToTypeOf(configBundle.ValidationError{}))
+		Expect(err.(configBundle.ValidationError).Errors).To(HaveLen(3))
+	})
+
+	It("should plan the changes, leaving secrets alone when they are not included", func() {
+		incoming := configBundle.Bundle{
+			EnvironmentVariables: map[string]string{"LOG_LEVEL": "debug"},
+			ConfigFiles: map[string][]byte{
+				"appsettings.json": []byte(`{"a": 2}`),
+				"blob.bin":         {0xff, 0xfe},
+			},
+		}
+
+		Expect(configBundle.Plan(bundle, incoming)).To(Equal([]platform.ConfigRevisionChange{
+			{Kind: platform.ConfigRevisionKindEnvironmentVariable, Name: "GREETING", Change: platform.ConfigRevisionChangeRemoved, From: bundle.EnvironmentVariables["GREETING"]},
+			{Kind: platform.ConfigRevisionKindEnvironmentVariable, Name: "LOG_LEVEL", Change: platform.ConfigRevisionChangeChanged, From: "info", To: "debug"},
+			{Kind: platform.ConfigRevisionKindConfigFile, Name: "appsettings.json", Change: platform.ConfigRevisionChangeChanged},
+		}))
+
+		incoming.SecretEnvironmentVariables = map[string]string{}
+		Expect(configBundle.Plan(bundle, incoming)).To(ContainElement(platform.ConfigRevisionChange{
+			Kind: platform.ConfigRevisionKindSecretEnvironmentVariable, Name: "PASSWORD", Change: platform.ConfigRevisionChangeRemoved,
+		}))
+	})
+
+	It("should leave the environment variables alone when the bundle has no environment variables file", func() {
+		directory, err := ioutil.TempDir("", "config-bundle")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(directory)
+
+		Expect(os.Mkdir(filepath.Join(directory, configBundle.ConfigFilesDirectory), 0755)).To(Succeed())
+		for name, data := range bundle.ConfigFiles {
+			Expect(ioutil.WriteFile(filepath.Join(directory, configBundle.ConfigFilesDirectory, name), data, 0644)).To(Succeed())
+		}
+
+		incoming, err := configBundle.ReadDirectory(directory)
+		Expect(err).To(BeNil())
+		Expect(incoming.IncludesEnvironmentVariables()).To(BeFalse())
+		Expect(configBundle.Plan(bundle, incoming)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,13 @@
+package configBundle_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfigBundle(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ConfigBundle Suite")
+}
@@ -0,0 +1,78 @@
+package configBundle
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseEnv reads KEY=value lines, as written by FormatEnv
+// Blank lines, comments and an "export " prefix are allowed, values can be single or double quoted
+func ParseEnv(data []byte) (map[string]string, error) {
+	variables := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNumber)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid double quoted value", lineNumber)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			if len(value) < 2 || !strings.HasSuffix(value, "'") {
+				return nil, fmt.Errorf("line %d: invalid single quoted value", lineNumber)
+			}
+			value = value[1 : len(value)-1]
+		}
+
+		if _, ok := variables[name]; ok {
+			return nil, fmt.Errorf("line %d: %s is defined more than once", lineNumber, name)
+		}
+		variables[name] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+// FormatEnv writes the variables sorted by name, quoting values that need it
+func FormatEnv(variables map[string]string) []byte {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		value := variables[name]
+		if strings.ContainsAny(value, " \t\r\n\"'#\\") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", name, value)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,225 @@
+package configBundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configFiles"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configHistory"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type ConfigBundleRepo interface {
+	// GetBundle returns the current configuration, secrets are only included when asked for
+	GetBundle(applicationID string, environment string, microserviceID string, includeSecrets bool) (Bundle, error)
+	// ApplyBundle replaces the configuration, secrets are left alone when the bundle does not include them
+	ApplyBundle(applicationID string, environment string, microserviceID string, bundle Bundle) error
+}
+
+type k8sRepo struct {
+	k8sDolittleRepo platformK8s.K8sRepo
+	logContext      logrus.FieldLogger
+}
+
+func NewConfigBundleK8sRepo(k8sDolittleRepo platformK8s.K8sRepo, logContext logrus.FieldLogger) k8sRepo {
+	return k8sRepo{
+		k8sDolittleRepo: k8sDolittleRepo,
+		logContext:      logContext,
+	}
+}
+
+type microserviceConfig struct {
+	environmentVariables *corev1.ConfigMap
+	secret               *corev1.Secret
+	configFiles          *corev1.ConfigMap
+}
+
+func (r k8sRepo) GetBundle(applicationID string, environment string, microserviceID string, includeSecrets bool) (Bundle, error) {
+	config, err := r.getConfig(applicationID, environment, microserviceID)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	bundle := Bundle{
+		EnvironmentVariables: map[string]string{},
+		ConfigFiles:          map[string][]byte{},
+	}
+
+	for name, value := range config.environmentVariables.Data {
+		bundle.EnvironmentVariables[name] = value
+	}
+
+	if includeSecrets {
+		references, err := secretstore.GetReferences(config.secret)
+		if err != nil {
+			return bundle, err
+		}
+
+		bundle.SecretEnvironmentVariables = map[string]string{}
+		for name, value := range config.secret.Data {
+			// Values from external secret stores stay there
+			if _, ok := references[name]; ok {
+				continue
+			}
+			bundle.SecretEnvironmentVariables[name] = string(value)
+		}
+	}
+
+	for name, value := range config.configFiles.Data {
+		bundle.ConfigFiles[name] = []byte(value)
+	}
+	for name, value := range config.configFiles.BinaryData {
+		bundle.ConfigFiles[name] = value
+	}
+
+	return bundle, nil
+}
+
+func (r k8sRepo) ApplyBundle(applicationID string, environment string, microserviceID string, bundle Bundle) error {
+	config, err := r.getConfig(applicationID, environment, microserviceID)
+	if err != nil {
+		return err
+	}
+
+	// Environment variables that are not part of the bundle are left as they are
+	if bundle.IncludesEnvironmentVariables() {
+		config.environmentVariables.Data = make(map[string]string, len(bundle.EnvironmentVariables))
+		for name, value := range bundle.EnvironmentVariables {
+			config.environmentVariables.Data[name] = value
+		}
+	}
+
+	previousFiles := map[string][]byte{}
+	for name, value := range config.configFiles.Data {
+		previousFiles[name] = []byte(value)
+	}
+	for name, value := range config.configFiles.BinaryData {
+		previousFiles[name] = value
+	}
+
+	config.configFiles.Data = map[string]string{}
+	config.configFiles.BinaryData = map[string][]byte{}
+	for name, value := range bundle.ConfigFiles {
+		if utf8.Valid(value) {
+			config.configFiles.Data[name] = string(value)
+		} else {
+			config.configFiles.BinaryData[name] = value
+		}
+	}
+
+	err = configFiles.ReplaceMetadata(config.configFiles, previousFiles)
+	if err != nil {
+		return fmt.Errorf("failed to store config files metadata: %w", err)
+	}
+
+	// Validate only counts the files, the metadata is part of the configmap as well
+	err = configFiles.CheckConfigMapSize(config.configFiles)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.k8sDolittleRepo.WriteConfigMap(config.environmentVariables)
+	if err != nil {
+		r.logContext.WithField("error", err).Error("failed to update configmap")
+		return errors.New("failed to update configmap")
+	}
+
+	if bundle.IncludesSecrets() {
+		references, err := secretstore.GetReferences(config.secret)
+		if err != nil {
+			return err
+		}
+
+		data := make(map[string][]byte, len(bundle.SecretEnvironmentVariables)+len(references))
+		for name, value := range bundle.SecretEnvironmentVariables {
+			data[name] = []byte(value)
+		}
+		// References are kept, they are managed through the environment variables
+		for name := range references {
+			if value, ok := config.secret.Data[name]; ok {
+				data[name] = value
+			}
+		}
+		config.secret.Data = data
+		config.secret.StringData = nil
+
+		_, err = r.k8sDolittleRepo.WriteSecret(config.secret)
+		if err != nil {
+			r.logContext.WithField("error", err).Error("failed to update secret")
+			return errors.New("failed to update secret")
+		}
+	}
+
+	_, err = r.k8sDolittleRepo.WriteConfigMap(config.configFiles)
+	if err != nil {
+		r.logContext.WithField("error", err).Error("failed to update config files configmap")
+		return errors.New("failed to update config files configmap")
+	}
+	return nil
+}
+
+func (r k8sRepo) getConfig(applicationID string, environment string, microserviceID string) (microserviceConfig, error) {
+	config := microserviceConfig{}
+
+	name, err := r.k8sDolittleRepo.GetMicroserviceName(applicationID, environment, microserviceID)
+	if err != nil {
+		return config, errors.New("unable to find microservice")
+	}
+
+	config.environmentVariables, err = r.k8sDolittleRepo.GetConfigMap(applicationID, platformK8s.GetMicroserviceEnvironmentVariableConfigmapName(name))
+	if err != nil {
+		return config, errors.New("unable to load data from configmap")
+	}
+
+	config.secret, err = r.k8sDolittleRepo.GetSecret(r.logContext, applicationID, platformK8s.GetMicroserviceEnvironmentVariableSecretName(name))
+	if err != nil {
+		return config, errors.New("unable to load data from secret")
+	}
+
+	config.configFiles, err = r.k8sDolittleRepo.GetConfigMap(applicationID, platformK8s.GetMicroserviceConfigFilesConfigmapName(name))
+	if err != nil {
+		return config, errors.New("unable to load data from config files configmap")
+	}
+	return config, nil
+}
+
+// Plan lists what applying incoming on top of current would change
+// current must include secrets when incoming does
+func Plan(current Bundle, incoming Bundle) []platform.ConfigRevisionChange {
+	from := configHistory.Snapshot{
+		EnvironmentVariables: current.EnvironmentVariables,
+		SecretHashes:         hashValues(current.SecretEnvironmentVariables),
+		ConfigFiles:          current.ConfigFiles,
+	}
+
+	// Environment variables and secrets that are not part of the bundle are left as they are
+	to := configHistory.Snapshot{
+		EnvironmentVariables: from.EnvironmentVariables,
+		SecretHashes:         from.SecretHashes,
+		ConfigFiles:          incoming.ConfigFiles,
+	}
+	if incoming.IncludesEnvironmentVariables() {
+		to.EnvironmentVariables = incoming.EnvironmentVariables
+	}
+	if incoming.IncludesSecrets() {
+		to.SecretHashes = hashValues(incoming.SecretEnvironmentVariables)
+	}
+
+	return configHistory.Diff(from, to)
+}
+
+func hashValues(values map[string]string) map[string]string {
+	hashes := make(map[string]string, len(values))
+	for name, value := range values {
+		sum := sha256.Sum256([]byte(value))
+		hashes[name] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
@@ -0,0 +1,188 @@
+package configBundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configFiles"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configHistory"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type service struct {
+	configBundleRepo ConfigBundleRepo
+	k8sDolittleRepo  platformK8s.K8sRepo
+	configHistory    configHistory.Recorder
+	logContext       logrus.FieldLogger
+}
+
+func NewService(configBundleRepo ConfigBundleRepo, k8sDolittleRepo platformK8s.K8sRepo, configHistory configHistory.Recorder, logContext logrus.FieldLogger) service {
+	return service{
+		configBundleRepo: configBundleRepo,
+		k8sDolittleRepo:  k8sDolittleRepo,
+		configHistory:    configHistory,
+		logContext:       logContext,
+	}
+}
+
+// Export responds with the configuration as a gzipped tar
+// Secret environment variables are only included with the query parameter includeSecrets=true
+func (s *service) Export(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	microserviceID := vars["microserviceID"]
+	environment := vars["environment"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	includeSecrets := r.URL.Query().Get("includeSecrets") == "true"
+	s.logContext.WithFields(logrus.Fields{
+		"method":          "Export",
+		"application_id":  applicationID,
+		"microservice_id": microserviceID,
+		"environment":     environment,
+		"user_id":         userID,
+		"include_secrets": includeSecrets,
+	}).Info("Export config bundle")
+
+	bundle, err := s.configBundleRepo.GetBundle(applicationID, environment, microserviceID, includeSecrets)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var archive bytes.Buffer
+	err = WriteArchive(&archive, bundle)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s-config.tar.gz"`, environment, microserviceID))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive.Bytes())
+}
+
+// Import replaces the configuration with the gzipped tar in the body
+// dryRun=true only responds with the changes, includeSecrets=true is required to import secret environment variables
+func (s *service) Import(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	microserviceID := vars["microserviceID"]
+	environment := vars["environment"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := query.Get("dryRun") == "true"
+	includeSecrets := query.Get("includeSecrets") == "true"
+
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":          "Import",
+		"application_id":  applicationID,
+		"microservice_id": microserviceID,
+		"environment":     environment,
+		"user_id":         userID,
+		"dry_run":         dryRun,
+	})
+
+	defer r.Body.Close()
+	bundle, err := ReadArchive(r.Body)
+	if err != nil {
+		if errors.Is(err, ErrBundleTooLarge) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid config bundle: %s", err.Error()))
+		return
+	}
+
+	if bundle.IncludesSecrets() && !includeSecrets {
+		utils.RespondWithError(w, http.StatusBadRequest, "The config bundle includes secret environment variables, import them with includeSecrets=true")
+		return
+	}
+
+	err = bundle.Validate()
+	if err != nil {
+		var validationError ValidationError
+		if errors.As(err, &validationError) {
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, platform.HttpResponseInvalidConfigBundle{
+				Message: "Invalid config bundle",
+				Errors:  validationError.Errors,
+			})
+			return
+		}
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	current, err := s.configBundleRepo.GetBundle(applicationID, environment, microserviceID, bundle.IncludesSecrets())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := platform.HttpResponseConfigBundleImport{
+		ApplicationID:  applicationID,
+		Environment:    environment,
+		MicroserviceID: microserviceID,
+		DryRun:         dryRun,
+		Changes:        Plan(current, bundle),
+	}
+
+	if dryRun || len(response.Changes) == 0 {
+		utils.RespondWithJSON(w, http.StatusOK, response)
+		return
+	}
+
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "before config bundle import")
+	if err != nil {
+		if errors.Is(err, configHistory.ErrRevisionTooLarge) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		logContext.WithField("error", err).Error("failed to record config revision")
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to record config revision")
+		return
+	}
+
+	logContext.WithField("changes", len(response.Changes)).Info("Import config bundle")
+	err = s.configBundleRepo.ApplyBundle(applicationID, environment, microserviceID, bundle)
+	if err != nil {
+		if errors.Is(err, configFiles.ErrConfigFilesTooLarge) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "import config bundle")
+	if err != nil {
+		// The change is made, the next record will pick it up
+		logContext.WithField("error", err).Error("failed to record config revision")
+	}
+
+	if query.Get("rollout") == "true" {
+		response.Rollout = s.k8sDolittleRepo.TryRolloutMicroserviceConfig(applicationID, environment, microserviceID)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
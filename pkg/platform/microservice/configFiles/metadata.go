@@ -0,0 +1,155 @@
+package configFiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MetadataAnnotation holds the metadata of the config files as JSON, keyed by file name
+	MetadataAnnotation = platformK8s.ConfigFilesMetadataAnnotation
+
+	// MaxConfigFilesBytes is the most the configmap holding the config files of a microservice can add up to,
+	// counting the files, their metadata and the template sources.
+	// A configmap is limited to 1MiB, the rest is left for the object itself
+	MaxConfigFilesBytes = 1024*1024 - 64*1024
+
+	// MaxAnnotationsBytes is the most the api server accepts for all the annotations of an object
+	MaxAnnotationsBytes = 256 * 1024
+)
+
+var ErrConfigFilesTooLarge = errors.New("config files are too large")
+
+type fileMetadata struct {
+	ContentType string `json:"contentType"`
+	UpdatedAt   string `json:"updatedAt"`
+	Template    bool   `json:"template,omitempty"`
+	// Source is the template the file was rendered from, so it can be rendered again
+	Source string `json:"source,omitempty"`
+}
+
+// DetectContentType guesses from the file extension first and falls back to sniffing the content
+func DetectContentType(name string, value []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(value)
+}
+
+// ConfigFilesSize adds up the size of every file in the configmap
+func ConfigFilesSize(configMap *corev1.ConfigMap) int {
+	total := 0
+	for _, value := range configMap.Data {
+		total += len(value)
+	}
+	for _, value := range configMap.BinaryData {
+		total += len(value)
+	}
+	return total
+}
+
+// ConfigMapSize adds up everything stored in the configmap, the files, labels and annotations
+func ConfigMapSize(configMap *corev1.ConfigMap) int {
+	total := ConfigFilesSize(configMap)
+	for key, value := range configMap.Labels {
+		total += len(key) + len(value)
+	}
+	total += annotationsSize(configMap)
+	return total
+}
+
+// CheckConfigMapSize returns ErrConfigFilesTooLarge when the configmap, as it would be stored, is over the limits
+func CheckConfigMapSize(configMap *corev1.ConfigMap) error {
+	if size := annotationsSize(configMap); size > MaxAnnotationsBytes {
+		return fmt.Errorf("%w: the metadata and template sources add up to %d bytes, the limit is %d bytes", ErrConfigFilesTooLarge, size, MaxAnnotationsBytes)
+	}
+	return CheckConfigFilesSize(ConfigMapSize(configMap))
+}
+
+func annotationsSize(configMap *corev1.ConfigMap) int {
+	total := 0
+	for key, value := range configMap.Annotations {
+		total += len(key) + len(value)
+	}
+	return total
+}
+
+// CheckConfigFilesSize returns ErrConfigFilesTooLarge when total is over MaxConfigFilesBytes
+func CheckConfigFilesSize(total int) error {
+	if total > MaxConfigFilesBytes {
+		return fmt.Errorf("%w: %d bytes in total, the limit is %d bytes", ErrConfigFilesTooLarge, total, MaxConfigFilesBytes)
+	}
+	return nil
+}
+
+// ReplaceMetadata brings the metadata up to date after all the files of configMap were replaced, previous holds the files from before.
+// Unchanged files keep their metadata, changed and new files are stamped as updated and removed files are dropped.
+// A changed file is no longer what its template rendered, so it stops being a template
+func ReplaceMetadata(configMap *corev1.ConfigMap, previous map[string][]byte) error {
+	existing := getMetadata(configMap)
+	metadata := map[string]fileMetadata{}
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	update := func(name string, value []byte) {
+		file, ok := existing[name]
+		if before, found := previous[name]; ok && found && bytes.Equal(before, value) {
+			metadata[name] = file
+			return
+		}
+
+		metadata[name] = fileMetadata{
+			ContentType: DetectContentType(name, value),
+			UpdatedAt:   now,
+		}
+	}
+
+	for name, value := range configMap.Data {
+		update(name, []byte(value))
+	}
+	for name, value := range configMap.BinaryData {
+		update(name, value)
+	}
+	return setMetadata(configMap, metadata)
+}
+
+func getMetadata(configMap *corev1.ConfigMap) map[string]fileMetadata {
+	metadata := map[string]fileMetadata{}
+	data, ok := configMap.Annotations[MetadataAnnotation]
+	if !ok || data == "" {
+		return metadata
+	}
+
+	// Metadata is only informational, so a broken annotation is started over
+	err := json.Unmarshal([]byte(data), &metadata)
+	if err != nil {
+		return map[string]fileMetadata{}
+	}
+	return metadata
+}
+
+func setMetadata(configMap *corev1.ConfigMap, metadata map[string]fileMetadata) error {
+	if len(metadata) == 0 {
+		delete(configMap.Annotations, MetadataAnnotation)
+		return nil
+	}
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[MetadataAnnotation] = string(b)
+	return nil
+}
@@ -0,0 +1,51 @@
+package configFiles_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configFiles"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Metadata", func() {
+	It("should keep unchanged files and update the rest, no longer as templates, when replacing", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					configFiles.MetadataAnnotation: `{
+						"same.json": {"contentType": "application/json", "updatedAt": "2021-10-01T00:00:00Z"},
+						"changed.txt": {"contentType": "text/plain", "updatedAt": "2021-10-01T00:00:00Z", "template": true, "source": "{{ .Environment }}"},
+						"removed.txt": {"contentType": "text/plain", "updatedAt": "2021-10-01T00:00:00Z"}
+					}`,
+				},
+			},
+			Data: map[string]string{
+				"same.json":   "{}",
+				"changed.txt": "after",
+			},
+			BinaryData: map[string][]byte{
+				"new.bin": {0xff, 0xfe},
+			},
+		}
+		previous := map[string][]byte{
+			"same.json":   []byte("{}"),
+			"changed.txt": []byte("before"),
+			"removed.txt": []byte("gone"),
+		}
+
+		Expect(configFiles.ReplaceMetadata(configMap, previous)).To(Succeed())
+
+		var metadata map[string]map[string]interface{}
+		Expect(json.Unmarshal([]byte(configMap.Annotations[configFiles.MetadataAnnotation]), &metadata)).To(Succeed())
+		Expect(metadata).To(HaveLen(3))
+		Expect(metadata["same.json"]["updatedAt"]).To(Equal("2021-10-01T00:00:00Z"))
+		Expect(metadata["changed.txt"]["updatedAt"]).ToNot(Equal("2021-10-01T00:00:00Z"))
+		Expect(metadata["changed.txt"]).ToNot(HaveKey("template"))
+		Expect(metadata["changed.txt"]).ToNot(HaveKey("source"))
+		Expect(metadata["new.bin"]["contentType"]).To(Equal("application/octet-stream"))
+	})
+})
@@ -2,16 +2,21 @@ package configFiles
 
 import (
 	"errors"
-
+	"fmt"
+	"sort"
+	"time"
 	"unicode/utf8"
 
+	"github.com/dolittle/platform-api/pkg/platform"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 )
 
 type ConfigFilesRepo interface {
-	GetConfigFilesNamesList(applicationID string, environment string, microserviceID string) ([]string, error)
+	// GetConfigFilesNamesList lists the config files sorted by name, with their size and content type
+	GetConfigFilesNamesList(applicationID string, environment string, microserviceID string) ([]platform.ConfigFileInfo, error)
+	// AddEntryToConfigFiles returns ErrConfigFilesTooLarge when the file, its metadata and template source would no longer fit in the configmap
 	AddEntryToConfigFiles(applicationID string, environment string, microserviceID string, data MicroserviceConfigFile) error
 	RemoveEntryFromConfigFiles(applicationID string, environment string, microserviceID string, key string) error
 }
@@ -24,6 +29,10 @@ type k8sRepo struct {
 type MicroserviceConfigFile struct {
 	Name  string `json:"name"`
 	Value []byte `json:"value"`
+	// Template marks the value as rendered from a template
+	Template bool `json:"template"`
+	// Source is the template the value was rendered from
+	Source []byte `json:"source,omitempty"`
 }
 
 func NewConfigFilesK8sRepo(k8sDolittleRepo platformK8s.K8sRepo, k8sClient kubernetes.Interface, logContext logrus.FieldLogger) k8sRepo {
@@ -34,8 +43,8 @@ func NewConfigFilesK8sRepo(k8sDolittleRepo platformK8s.K8sRepo, k8sClient kubern
 	}
 }
 
-func (r k8sRepo) GetConfigFilesNamesList(applicationID string, environment string, microserviceID string) ([]string, error) {
-	data := []string{}
+func (r k8sRepo) GetConfigFilesNamesList(applicationID string, environment string, microserviceID string) ([]platform.ConfigFileInfo, error) {
+	data := []platform.ConfigFileInfo{}
 
 	logContext := r.logContext.WithFields(logrus.Fields{
 		"method":          "GetConfigFilesNamesList",
@@ -59,14 +68,35 @@ func (r k8sRepo) GetConfigFilesNamesList(applicationID string, environment strin
 		return data, err
 	}
 
-	for name := range configMap.BinaryData {
-		data = append(data, name)
+	metadata := getMetadata(configMap)
+	describe := func(name string, value []byte) platform.ConfigFileInfo {
+		info := platform.ConfigFileInfo{
+			Name: name,
+			Size: len(value),
+		}
+		if fileMetadata, ok := metadata[name]; ok {
+			info.ContentType = fileMetadata.ContentType
+			info.UpdatedAt = fileMetadata.UpdatedAt
+			info.Template = fileMetadata.Template
+		}
+		if info.ContentType == "" {
+			info.ContentType = DetectContentType(name, value)
+		}
+		return info
+	}
+
+	for name, value := range configMap.BinaryData {
+		data = append(data, describe(name, value))
 	}
 
-	for name := range configMap.Data {
-		data = append(data, name)
+	for name, value := range configMap.Data {
+		data = append(data, describe(name, []byte(value)))
 	}
 
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Name < data[j].Name
+	})
+
 	logContext.Infof("found %d config files", len(data))
 
 	return data, nil
@@ -101,6 +131,10 @@ func (r k8sRepo) AddEntryToConfigFiles(applicationID string, environment string,
 		configMap.BinaryData = map[string][]byte{}
 	}
 
+	// Replacing a file frees up its current size
+	delete(configMap.Data, data.Name)
+	delete(configMap.BinaryData, data.Name)
+
 	if utf8.Valid(data.Value) {
 		configMap.Data[data.Name] = string(data.Value)
 	} else {
@@ -108,6 +142,24 @@ func (r k8sRepo) AddEntryToConfigFiles(applicationID string, environment string,
 
 	}
 
+	metadata := getMetadata(configMap)
+	metadata[data.Name] = fileMetadata{
+		ContentType: DetectContentType(data.Name, data.Value),
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+		Template:    data.Template,
+		Source:      string(data.Source),
+	}
+	err = setMetadata(configMap, metadata)
+	if err != nil {
+		return fmt.Errorf("failed to store config files metadata: %w", err)
+	}
+
+	err = CheckConfigMapSize(configMap)
+	if err != nil {
+		logContext.WithField("error", err).Info("config files too large")
+		return err
+	}
+
 	// Write configmap and secret
 	_, err = r.k8sDolittleRepo.WriteConfigMap(configMap)
 	if err != nil {
@@ -144,6 +196,14 @@ func (r k8sRepo) RemoveEntryFromConfigFiles(applicationID string, environment st
 	delete(configMap.BinaryData, key)
 	delete(configMap.Data, key)
 
+	metadata := getMetadata(configMap)
+	delete(metadata, key)
+	err = setMetadata(configMap, metadata)
+	if err != nil {
+		logContext.WithField("error", err).Error("failed to store config files metadata")
+		return err
+	}
+
 	// Write configmap and secret
 	_, err = r.k8sDolittleRepo.WriteConfigMap(configMap)
 
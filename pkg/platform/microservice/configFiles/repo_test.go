@@ -1,9 +1,13 @@
 package configFiles_test
 
 import (
+	"errors"
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/dolittle/platform-api/pkg/platform"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/sirupsen/logrus"
 	logrusTest "github.com/sirupsen/logrus/hooks/test"
@@ -133,6 +137,101 @@ var _ = Describe("Repo", func() {
 			})
 		})
 
+		It("should store the content type, when it was updated and the template source", func() {
+			configFile := configFiles.MicroserviceConfigFile{
+				Name:     "appsettings.json",
+				Value:    []byte("{}"),
+				Template: true,
+				Source:   []byte("{{ .ApplicationID }}"),
+			}
+
+			clientSet.AddReactor("update", "configmaps", func(action testing.Action) (handled bool, ret runtime.Object, err error) {
+				configMap := action.(testing.UpdateAction).GetObject().(*corev1.ConfigMap)
+
+				Expect(configMap.Annotations[configFiles.MetadataAnnotation]).To(ContainSubstring(`"contentType":"application/json"`))
+				Expect(configMap.Annotations[configFiles.MetadataAnnotation]).To(ContainSubstring(`"updatedAt":`))
+				Expect(configMap.Annotations[configFiles.MetadataAnnotation]).To(ContainSubstring(`"template":true`))
+				Expect(configMap.Annotations[configFiles.MetadataAnnotation]).To(ContainSubstring(`"source":"{{ .ApplicationID }}"`))
+
+				return true, configMap, nil
+			})
+
+			err := repo.AddEntryToConfigFiles(applicationID, environment, microserviceID, configFile)
+
+			Expect(err).To(BeNil())
+		})
+
+		When("the config files would add up to more than the limit", func() {
+			var (
+				updated bool
+			)
+
+			BeforeEach(func() {
+				updated = false
+
+				clientSet.AddReactor("get", "configmaps", func(action testing.Action) (handled bool, ret runtime.Object, err error) {
+					getAction := action.(testing.GetAction)
+					configMap := &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: getAction.GetName(),
+						},
+						Data: map[string]string{
+							"existing.txt": strings.Repeat("a", configFiles.MaxConfigFilesBytes-10),
+						},
+					}
+					return true, configMap, nil
+				})
+
+				clientSet.AddReactor("update", "configmaps", func(action testing.Action) (handled bool, ret runtime.Object, err error) {
+					updated = true
+					return true, action.(testing.UpdateAction).GetObject(), nil
+				})
+			})
+
+			It("should not save a new file", func() {
+				err := repo.AddEntryToConfigFiles(applicationID, environment, microserviceID, configFiles.MicroserviceConfigFile{
+					Name:  "new.txt",
+					Value: []byte(strings.Repeat("b", 11)),
+				})
+
+				Expect(errors.Is(err, configFiles.ErrConfigFilesTooLarge)).To(BeTrue())
+				Expect(updated).To(BeFalse())
+			})
+
+			It("should not count the file being replaced", func() {
+				err := repo.AddEntryToConfigFiles(applicationID, environment, microserviceID, configFiles.MicroserviceConfigFile{
+					Name:  "existing.txt",
+					Value: []byte(strings.Repeat("b", configFiles.MaxConfigFilesBytes-1024)),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(updated).To(BeTrue())
+			})
+
+			It("should count the metadata and template source", func() {
+				err := repo.AddEntryToConfigFiles(applicationID, environment, microserviceID, configFiles.MicroserviceConfigFile{
+					Name:     "existing.txt",
+					Value:    []byte(strings.Repeat("b", configFiles.MaxConfigFilesBytes-1024)),
+					Template: true,
+					Source:   []byte(strings.Repeat("c", 1024)),
+				})
+
+				Expect(errors.Is(err, configFiles.ErrConfigFilesTooLarge)).To(BeTrue())
+				Expect(updated).To(BeFalse())
+			})
+
+			It("should refuse template sources over the annotation limit", func() {
+				err := repo.AddEntryToConfigFiles(applicationID, environment, microserviceID, configFiles.MicroserviceConfigFile{
+					Name:     "existing.txt",
+					Value:    []byte("b"),
+					Template: true,
+					Source:   []byte(strings.Repeat("c", configFiles.MaxAnnotationsBytes)),
+				})
+
+				Expect(errors.Is(err, configFiles.ErrConfigFilesTooLarge)).To(BeTrue())
+				Expect(updated).To(BeFalse())
+			})
+		})
 	})
 
 	Describe("getting config files names", func() {
@@ -160,7 +259,36 @@ var _ = Describe("Repo", func() {
 				files, err := repo.GetConfigFilesNamesList(applicationID, environment, microserviceID)
 				Expect(err).To(BeNil())
 				Expect(files).ToNot(BeEmpty())
-				Expect(files).To(ConsistOf(binaryFile, dataFile))
+				Expect(files).To(ConsistOf(
+					platform.ConfigFileInfo{Name: binaryFile, Size: 3, ContentType: "application/octet-stream"},
+					platform.ConfigFileInfo{Name: dataFile, Size: 14, ContentType: "text/plain; charset=utf-8"},
+				))
+			})
+		})
+
+		When("the config map has metadata for a file", func() {
+			It("should use it", func() {
+				clientSet.AddReactor("get", "configmaps", func(action testing.Action) (handled bool, ret runtime.Object, err error) {
+					getAction := action.(testing.GetAction)
+					configMap := &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: getAction.GetName(),
+							Annotations: map[string]string{
+								configFiles.MetadataAnnotation: `{"appsettings.json":{"contentType":"application/json","updatedAt":"2021-11-01T10:00:00Z","template":true}}`,
+							},
+						},
+						Data: map[string]string{
+							"appsettings.json": "{}",
+						},
+					}
+					return true, configMap, nil
+				})
+
+				files, err := repo.GetConfigFilesNamesList(applicationID, environment, microserviceID)
+				Expect(err).To(BeNil())
+				Expect(files).To(Equal([]platform.ConfigFileInfo{
+					{Name: "appsettings.json", Size: 2, ContentType: "application/json", UpdatedAt: "2021-11-01T10:00:00Z", Template: true},
+				}))
 			})
 		})
 	})
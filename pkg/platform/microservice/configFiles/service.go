@@ -2,12 +2,17 @@ package configFiles
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/dolittle/platform-api/pkg/platform"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configHistory"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
 	"github.com/dolittle/platform-api/pkg/utils"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -16,13 +21,17 @@ import (
 type service struct {
 	configFilesRepo ConfigFilesRepo
 	k8sDolittleRepo platformK8s.K8sRepo
+	configHistory   configHistory.Recorder
+	gitRepo         storage.Repo
 	logContext      logrus.FieldLogger
 }
 
-func NewService(configFilesRepo ConfigFilesRepo, k8sDolittleRepo platformK8s.K8sRepo, logContext logrus.FieldLogger) service {
+func NewService(configFilesRepo ConfigFilesRepo, k8sDolittleRepo platformK8s.K8sRepo, configHistory configHistory.Recorder, gitRepo storage.Repo, logContext logrus.FieldLogger) service {
 	return service{
 		configFilesRepo: configFilesRepo,
 		k8sDolittleRepo: k8sDolittleRepo,
+		configHistory:   configHistory,
+		gitRepo:         gitRepo,
 		logContext:      logContext,
 	}
 }
@@ -50,7 +59,7 @@ func (s *service) GetConfigFilesNamesList(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	data, err := s.configFilesRepo.GetConfigFilesNamesList(applicationID, environment, microserviceID)
+	files, err := s.configFilesRepo.GetConfigFilesNamesList(applicationID, environment, microserviceID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -60,7 +69,13 @@ func (s *service) GetConfigFilesNamesList(w http.ResponseWriter, r *http.Request
 		ApplicationID:  applicationID,
 		Environment:    environment,
 		MicroserviceID: microserviceID,
-		Data:           data,
+		Data:           make([]string, 0, len(files)),
+		Files:          files,
+		MaxTotalSize:   MaxConfigFilesBytes,
+	}
+	for _, file := range files {
+		response.Data = append(response.Data, file.Name)
+		response.TotalSize += file.Size
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, response)
@@ -83,6 +98,16 @@ func (s *service) UpdateConfigFiles(w http.ResponseWriter, r *http.Request) {
 		"environment":     environment,
 	})
 
+	// No point in reading a body that can never fit, with room to spare for the multipart encoding
+	if r.ContentLength > MaxConfigFilesBytes*2 {
+		msg := fmt.Sprintf("UpdateConfigFiles ERROR: Request too large, config files can add up to %d bytes", MaxConfigFilesBytes)
+
+		logContext.Info(msg)
+
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, msg)
+		return
+	}
+
 	r.ParseForm()
 
 	file, handler, err := r.FormFile("file")
@@ -114,12 +139,12 @@ func (s *service) UpdateConfigFiles(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// file size limit from header.Size()
-	if handler.Size > 3145728 {
-		msg := "UpdateConfigFiles ERROR: File size too large"
+	if handler.Size > MaxConfigFilesBytes {
+		msg := fmt.Sprintf("UpdateConfigFiles ERROR: File size too large, config files can add up to %d bytes", MaxConfigFilesBytes)
 
 		logContext.Info(msg)
 
-		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, msg)
 		return
 	}
 
@@ -141,18 +166,70 @@ func (s *service) UpdateConfigFiles(w http.ResponseWriter, r *http.Request) {
 	input.Value = body
 	input.Name = handler.Filename
 
+	// Opt in to rendering the file with platform values, as template=true in the form or query
+	if r.FormValue("template") == "true" {
+		values, err := s.getTemplateValues(customerID, applicationID, environment, microserviceID)
+		if err != nil {
+			logContext.WithField("error", err).Error("failed to get template values")
+			utils.RespondWithError(w, http.StatusInternalServerError, "failed to get template values")
+			return
+		}
+
+		if !utf8.Valid(body) {
+			logContext.Info("UpdateConfigFiles ERROR: Template is not text")
+			utils.RespondWithError(w, http.StatusUnprocessableEntity, "UpdateConfigFiles ERROR: A template must be UTF-8 text")
+			return
+		}
+
+		input.Value, err = RenderTemplate(input.Name, body, values)
+		if err != nil {
+			logContext.WithField("error", err).Info("UpdateConfigFiles ERROR: Invalid template")
+			utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		input.Template = true
+		input.Source = body
+	}
+
+	// Snapshot first, so the configuration being replaced can always be rolled back to
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "before config file update")
+	if err != nil {
+		if errors.Is(err, configHistory.ErrRevisionTooLarge) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		logContext.WithField("error", err).Error("failed to record config revision")
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to record config revision")
+		return
+	}
+
 	err = s.configFilesRepo.AddEntryToConfigFiles(applicationID, environment, microserviceID, input)
 	if err != nil {
+		if errors.Is(err, ErrConfigFilesTooLarge) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
 		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "update config file "+input.Name)
+	if err != nil {
+		// The change is made, the next record will pick it up
+		logContext.WithField("error", err).Error("failed to record config revision")
+	}
+
 	response := platform.HttpResponseConfigFilesNamesList{
 		ApplicationID:  applicationID,
 		Environment:    environment,
 		MicroserviceID: microserviceID,
 	}
 
+	// Opt in to rolling out new pods with the changed configuration
+	if r.URL.Query().Get("rollout") == "true" {
+		response.Rollout = s.k8sDolittleRepo.TryRolloutMicroserviceConfig(applicationID, environment, microserviceID)
+	}
+
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
 
@@ -202,12 +279,28 @@ func (s *service) DeleteConfigFile(w http.ResponseWriter, r *http.Request) {
 
 	logContext.Info("Update config files")
 
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "before config file delete")
+	if err != nil {
+		if errors.Is(err, configHistory.ErrRevisionTooLarge) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		logContext.WithField("error", err).Error("failed to record config revision")
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to record config revision")
+		return
+	}
+
 	err = s.configFilesRepo.RemoveEntryFromConfigFiles(applicationID, environment, microserviceID, input.Key)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "delete config file "+input.Key)
+	if err != nil {
+		logContext.WithField("error", err).Error("failed to record config revision")
+	}
+
 	response := platform.HttpResponseDeleteConfigFile{
 		ApplicationID:  applicationID,
 		Environment:    environment,
@@ -215,5 +308,24 @@ func (s *service) DeleteConfigFile(w http.ResponseWriter, r *http.Request) {
 		Success:        true,
 	}
 
+	if r.URL.Query().Get("rollout") == "true" {
+		response.Rollout = s.k8sDolittleRepo.TryRolloutMicroserviceConfig(applicationID, environment, microserviceID)
+	}
+
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
+
+func (s *service) getTemplateValues(customerID string, applicationID string, environment string, microserviceID string) (TemplateValues, error) {
+	application, err := s.gitRepo.GetApplication(customerID, applicationID)
+	if err != nil {
+		return TemplateValues{}, err
+	}
+
+	return TemplateValues{
+		ApplicationID:   applicationID,
+		Environment:     environment,
+		MicroserviceID:  microserviceID,
+		CustomerID:      customerID,
+		CustomerTenants: storage.GetCustomerTenantsByEnvironment(application, environment),
+	}, nil
+}
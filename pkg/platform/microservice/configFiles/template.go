@@ -0,0 +1,45 @@
+package configFiles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+var ErrInvalidTemplate = errors.New("invalid config file template")
+
+// TemplateValues are the platform values a config file template can use, like {{ .ApplicationID }}
+type TemplateValues struct {
+	ApplicationID   string
+	Environment     string
+	MicroserviceID  string
+	CustomerID      string
+	CustomerTenants []platform.CustomerTenantInfo
+}
+
+// CustomerTenantIDs lists the ids of the customer tenants, for {{ range .CustomerTenantIDs }}
+func (v TemplateValues) CustomerTenantIDs() []string {
+	ids := make([]string, 0, len(v.CustomerTenants))
+	for _, customerTenant := range v.CustomerTenants {
+		ids = append(ids, customerTenant.CustomerTenantID)
+	}
+	return ids
+}
+
+// RenderTemplate renders the config file as a Go text/template, unknown values are an error
+func RenderTemplate(name string, value []byte, values TemplateValues) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(value))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err.Error())
+	}
+
+	var rendered bytes.Buffer
+	err = tmpl.Execute(&rendered, values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err.Error())
+	}
+	return rendered.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package configFiles_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configFiles"
+)
+
+var _ = Describe("Template", func() {
+	var (
+		values configFiles.TemplateValues
+	)
+
+	BeforeEach(func() {
+		values = configFiles.TemplateValues{
+			ApplicationID:  "53fd6176-4a6b-4bb0-a32d-b18c69607e78",
+			Environment:    "Dev",
+			MicroserviceID: "963a5d70-8652-494a-a843-d3bebd660acb",
+			CustomerID:     "e4d4b6b4-0a2a-4c4e-8c3a-7f4b7c2f2a61",
+			CustomerTenants: []platform.CustomerTenantInfo{
+				{Alias: "first", CustomerTenantID: "tenant-1"},
+				{Alias: "second", CustomerTenantID: "tenant-2"},
+			},
+		}
+	})
+
+	It("should render the platform values", func() {
+		rendered, err := configFiles.RenderTemplate("appsettings.json", []byte(`{"app":"{{ .ApplicationID }}","env":"{{ .Environment }}","ms":"{{ .MicroserviceID }}","customer":"{{ .CustomerID }}"}`), values)
+
+		Expect(err).To(BeNil())
+		Expect(string(rendered)).To(Equal(`{"app":"53fd6176-4a6b-4bb0-a32d-b18c69607e78","env":"Dev","ms":"963a5d70-8652-494a-a843-d3bebd660acb","customer":"e4d4b6b4-0a2a-4c4e-8c3a-7f4b7c2f2a61"}`))
+	})
+
+	It("should render the customer tenants", func() {
+		rendered, err := configFiles.RenderTemplate("tenants.txt", []byte(`{{ range .CustomerTenants }}{{ .Alias }}={{ .CustomerTenantID }};{{ end }}{{ range .CustomerTenantIDs }}{{ . }},{{ end }}`), values)
+
+		Expect(err).To(BeNil())
+		Expect(string(rendered)).To(Equal("first=tenant-1;second=tenant-2;tenant-1,tenant-2,"))
+	})
+
+	It("should fail on invalid syntax", func() {
+		_, err := configFiles.RenderTemplate("broken.txt", []byte(`{{ .ApplicationID `), values)
+
+		Expect(errors.Is(err, configFiles.ErrInvalidTemplate)).To(BeTrue())
+	})
+
+	It("should fail on unknown values", func() {
+		_, err := configFiles.RenderTemplate("unknown.txt", []byte(`{{ .DoesNotExist }}`), values)
+
+		Expect(errors.Is(err, configFiles.ErrInvalidTemplate)).To(BeTrue())
+	})
+})
@@ -0,0 +1,104 @@
+package configHistory
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+var ErrNoEncryptionKey = errors.New("no encryption key configured, secret values are only stored as hashes")
+
+// SecretCipher protects the secret environment variables stored in a revision
+type SecretCipher interface {
+	// CanDecrypt is false when values are only hashed
+	CanDecrypt() bool
+	Encrypt(value []byte) (string, error)
+	Decrypt(value string) ([]byte, error)
+	// Hash is used to compare secret values without decrypting them
+	Hash(value []byte) string
+}
+
+type aesCipher struct {
+	key []byte
+}
+
+// NewSecretCipher encrypts with AES-256-GCM using a key derived from passphrase
+// Without a passphrase secret values are only hashed, without a key, and can not be rolled back
+func NewSecretCipher(passphrase string) SecretCipher {
+	if passphrase == "" {
+		return aesCipher{}
+	}
+
+	key := sha256.Sum256([]byte(passphrase))
+	return aesCipher{
+		key: key[:],
+	}
+}
+
+func (c aesCipher) CanDecrypt() bool {
+	return len(c.key) != 0
+}
+
+func (c aesCipher) Encrypt(value []byte) (string, error) {
+	if !c.CanDecrypt() {
+		return "", ErrNoEncryptionKey
+	}
+
+	gcm, err := c.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, value, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c aesCipher) Decrypt(value string) ([]byte, error) {
+	if !c.CanDecrypt() {
+		return nil, ErrNoEncryptionKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func (c aesCipher) Hash(value []byte) string {
+	// Keyed with the encryption key so that weak secrets can't be guessed from the hash.
+	// Without an encryption key the key is empty, which is no better than a plain SHA-256
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(value)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c aesCipher) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
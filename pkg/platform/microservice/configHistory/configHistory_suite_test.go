@@ -0,0 +1,13 @@
+package configHistory_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfigHistory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ConfigHistory Suite")
+}
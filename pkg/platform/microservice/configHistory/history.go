@@ -0,0 +1,248 @@
+package configHistory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrSecretNotRecoverable = errors.New("secret value was only stored as a hash and differs from the current value")
+
+// Recorder snapshots the current configuration of a microservice
+type Recorder interface {
+	// Record stores a new revision when the configuration differs from the latest revision
+	Record(applicationID string, environment string, microserviceID string, userID string, reason string) error
+}
+
+type ConfigHistory interface {
+	Recorder
+	// Rollback restores the configuration from revision and records it as a new revision
+	Rollback(applicationID string, environment string, microserviceID string, userID string, revision int) (Revision, error)
+}
+
+type history struct {
+	repo            ConfigHistoryRepo
+	k8sDolittleRepo platformK8s.K8sRepo
+	cipher          SecretCipher
+	logContext      logrus.FieldLogger
+}
+
+func NewConfigHistory(repo ConfigHistoryRepo, k8sDolittleRepo platformK8s.K8sRepo, cipher SecretCipher, logContext logrus.FieldLogger) ConfigHistory {
+	return &history{
+		repo:            repo,
+		k8sDolittleRepo: k8sDolittleRepo,
+		cipher:          cipher,
+		logContext:      logContext,
+	}
+}
+
+func (h *history) Record(applicationID string, environment string, microserviceID string, userID string, reason string) error {
+	_, err := h.record(applicationID, environment, microserviceID, userID, reason)
+	return err
+}
+
+func (h *history) record(applicationID string, environment string, microserviceID string, userID string, reason string) (Revision, error) {
+	snapshot, err := h.takeSnapshot(applicationID, environment, microserviceID)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	revision := Revision{
+		Snapshot: snapshot,
+	}
+	revision.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	revision.CreatedBy = userID
+	revision.Reason = reason
+	revision.ContentHash = snapshot.contentHash()
+
+	revisions, err := h.repo.List(applicationID, environment, microserviceID)
+	if err != nil {
+		return revision, err
+	}
+
+	if len(revisions) != 0 && revisions[0].ContentHash == revision.ContentHash {
+		revision.ConfigRevision = revisions[0]
+		return revision, nil
+	}
+
+	return h.repo.Save(applicationID, environment, microserviceID, revision)
+}
+
+func (h *history) Rollback(applicationID string, environment string, microserviceID string, userID string, revision int) (Revision, error) {
+	logContext := h.logContext.WithFields(logrus.Fields{
+		"method":          "Rollback",
+		"application_id":  applicationID,
+		"microservice_id": microserviceID,
+		"environment":     environment,
+		"revision":        revision,
+	})
+
+	target, err := h.repo.Get(applicationID, environment, microserviceID, revision)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	// Make sure the configuration we are about to replace can be rolled back to
+	_, err = h.record(applicationID, environment, microserviceID, userID, fmt.Sprintf("before rollback to revision %d", revision))
+	if err != nil {
+		return Revision{}, err
+	}
+
+	name, err := h.k8sDolittleRepo.GetMicroserviceName(applicationID, environment, microserviceID)
+	if err != nil {
+		return Revision{}, errors.New("unable to find microservice")
+	}
+
+	configMap, err := h.k8sDolittleRepo.GetConfigMap(applicationID, platformK8s.GetMicroserviceEnvironmentVariableConfigmapName(name))
+	if err != nil {
+		return Revision{}, errors.New("unable to load data from configmap")
+	}
+
+	secret, err := h.k8sDolittleRepo.GetSecret(logContext, applicationID, platformK8s.GetMicroserviceEnvironmentVariableSecretName(name))
+	if err != nil {
+		return Revision{}, errors.New("unable to load data from secret")
+	}
+
+	configFiles, err := h.k8sDolittleRepo.GetConfigMap(applicationID, platformK8s.GetMicroserviceConfigFilesConfigmapName(name))
+	if err != nil {
+		return Revision{}, errors.New("unable to load data from config files configmap")
+	}
+
+	secretData := make(map[string][]byte, len(target.Snapshot.SecretHashes))
+	for key, hash := range target.Snapshot.SecretHashes {
+		if encrypted, ok := target.Snapshot.SecretEnvironmentVariables[key]; ok && h.cipher.CanDecrypt() {
+			value, err := h.cipher.Decrypt(encrypted)
+			if err != nil {
+				return Revision{}, fmt.Errorf("failed to decrypt secret %s: %w", key, err)
+			}
+			secretData[key] = value
+			continue
+		}
+
+		// Without the encrypted value we can only keep the current value, if it is the same
+		current, ok := secret.Data[key]
+		if !ok || h.cipher.Hash(current) != hash {
+			return Revision{}, fmt.Errorf("%w: %s", ErrSecretNotRecoverable, key)
+		}
+		secretData[key] = current
+	}
+
+	configMap.Data = make(map[string]string, len(target.Snapshot.EnvironmentVariables))
+	for key, value := range target.Snapshot.EnvironmentVariables {
+		configMap.Data[key] = value
+	}
+
+	secret.Data = secretData
+	secret.StringData = nil
+	err = secretstore.SetReferences(secret, target.Snapshot.SecretReferences)
+	if err != nil {
+		return Revision{}, fmt.Errorf("failed to restore the secret references: %w", err)
+	}
+
+	configFiles.Data = map[string]string{}
+	configFiles.BinaryData = map[string][]byte{}
+	if target.Snapshot.ConfigFilesMetadata != "" {
+		if configFiles.Annotations == nil {
+			configFiles.Annotations = map[string]string{}
+		}
+		configFiles.Annotations[platformK8s.ConfigFilesMetadataAnnotation] = target.Snapshot.ConfigFilesMetadata
+	} else {
+		delete(configFiles.Annotations, platformK8s.ConfigFilesMetadataAnnotation)
+	}
+	for key, value := range target.Snapshot.ConfigFiles {
+		if utf8.Valid(value) {
+			configFiles.Data[key] = string(value)
+		} else {
+			configFiles.BinaryData[key] = value
+		}
+	}
+
+	_, err = h.k8sDolittleRepo.WriteConfigMap(configMap)
+	if err != nil {
+		logContext.WithField("error", err).Error("failed to update configmap")
+		return Revision{}, errors.New("failed to update configmap")
+	}
+
+	_, err = h.k8sDolittleRepo.WriteSecret(secret)
+	if err != nil {
+		logContext.WithField("error", err).Error("failed to update secret")
+		return Revision{}, errors.New("failed to update secret")
+	}
+
+	_, err = h.k8sDolittleRepo.WriteConfigMap(configFiles)
+	if err != nil {
+		logContext.WithField("error", err).Error("failed to update config files configmap")
+		return Revision{}, errors.New("failed to update config files configmap")
+	}
+
+	return h.record(applicationID, environment, microserviceID, userID, fmt.Sprintf("rollback to revision %d", revision))
+}
+
+func (h *history) takeSnapshot(applicationID string, environment string, microserviceID string) (Snapshot, error) {
+	snapshot := Snapshot{
+		EnvironmentVariables:       map[string]string{},
+		SecretEnvironmentVariables: map[string]string{},
+		SecretHashes:               map[string]string{},
+		ConfigFiles:                map[string][]byte{},
+	}
+
+	name, err := h.k8sDolittleRepo.GetMicroserviceName(applicationID, environment, microserviceID)
+	if err != nil {
+		return snapshot, errors.New("unable to find microservice")
+	}
+
+	configMap, err := h.k8sDolittleRepo.GetConfigMap(applicationID, platformK8s.GetMicroserviceEnvironmentVariableConfigmapName(name))
+	if err != nil {
+		return snapshot, errors.New("unable to load data from configmap")
+	}
+
+	secret, err := h.k8sDolittleRepo.GetSecret(h.logContext, applicationID, platformK8s.GetMicroserviceEnvironmentVariableSecretName(name))
+	if err != nil {
+		return snapshot, errors.New("unable to load data from secret")
+	}
+
+	configFiles, err := h.k8sDolittleRepo.GetConfigMap(applicationID, platformK8s.GetMicroserviceConfigFilesConfigmapName(name))
+	if err != nil {
+		return snapshot, errors.New("unable to load data from config files configmap")
+	}
+
+	for key, value := range configMap.Data {
+		snapshot.EnvironmentVariables[key] = value
+	}
+
+	for key, value := range secret.Data {
+		snapshot.SecretHashes[key] = h.cipher.Hash(value)
+		if !h.cipher.CanDecrypt() {
+			continue
+		}
+
+		encrypted, err := h.cipher.Encrypt(value)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to encrypt secret %s: %w", key, err)
+		}
+		snapshot.SecretEnvironmentVariables[key] = encrypted
+	}
+
+	references, err := secretstore.GetReferences(secret)
+	if err != nil {
+		return snapshot, err
+	}
+	if len(references) != 0 {
+		snapshot.SecretReferences = references
+	}
+
+	for key, value := range configFiles.Data {
+		snapshot.ConfigFiles[key] = []byte(value)
+	}
+	for key, value := range configFiles.BinaryData {
+		snapshot.ConfigFiles[key] = value
+	}
+	snapshot.ConfigFilesMetadata = configFiles.Annotations[platformK8s.ConfigFilesMetadataAnnotation]
+
+	return snapshot, nil
+}
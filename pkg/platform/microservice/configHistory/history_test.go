@@ -0,0 +1,270 @@
+package configHistory_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configHistory"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("History", func() {
+	var (
+		clientSet      *fake.Clientset
+		repo           configHistory.ConfigHistoryRepo
+		history        configHistory.ConfigHistory
+		applicationID  string
+		environment    string
+		microserviceID string
+		namespace      string
+		encryptionKey  string
+	)
+
+	getConfigMap := func(name string) *corev1.ConfigMap {
+		configMap, err := clientSet.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		return configMap
+	}
+
+	getSecret := func() *corev1.Secret {
+		secret, err := clientSet.CoreV1().Secrets(namespace).Get(context.TODO(), "test-order-secret-env-variables", metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		return secret
+	}
+
+	setEnvironmentVariable := func(key string, value string) {
+		configMap := getConfigMap("test-order-env-variables")
+		configMap.Data[key] = value
+		_, err := clientSet.CoreV1().ConfigMaps(namespace).Update(context.TODO(), configMap, metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+	}
+
+	setSecret := func(key string, value string) {
+		secret := getSecret()
+		secret.Data[key] = []byte(value)
+		_, err := clientSet.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+	}
+
+	JustBeforeEach(func() {
+		logger, _ := logrusTest.NewNullLogger()
+		k8sRepo := platformK8s.NewK8sRepo(clientSet, &rest.Config{}, logger.WithField("context", "k8s-repo"))
+		repo = configHistory.NewConfigHistoryK8sRepo(clientSet, 3, logger)
+		history = configHistory.NewConfigHistory(repo, k8sRepo, configHistory.NewSecretCipher(encryptionKey), logger)
+	})
+
+	BeforeEach(func() {
+		applicationID = "53fd6176-4a6b-4bb0-a32d-b18c69607e78"
+		environment = "test"
+		microserviceID = "963a5d70-8652-494a-a843-d3bebd660acb"
+		namespace = "application-" + applicationID
+		encryptionKey = "a-key"
+
+		clientSet = fake.NewSimpleClientset(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-order",
+					Namespace: namespace,
+					Labels: map[string]string{
+						"tenant":       "fake-tenant",
+						"application":  "fake-application",
+						"environment":  environment,
+						"microservice": "order",
+					},
+					Annotations: map[string]string{
+						"dolittle.io/microservice-id": microserviceID,
+					},
+				},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-order-env-variables", Namespace: namespace},
+				Data:       map[string]string{"LOG_LEVEL": "info"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-order-secret-env-variables", Namespace: namespace},
+				Data:       map[string][]byte{"PASSWORD": []byte("hunter2")},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-order-config-files", Namespace: namespace},
+				Data:       map[string]string{"config.json": "{}"},
+				BinaryData: map[string][]byte{"blob.bin": {0xff, 0xfe}},
+			},
+		)
+	})
+
+	Describe("recording", func() {
+		It("should only store a revision when the configuration changed", func() {
+			Expect(history.Record(applicationID, environment, microserviceID, "user-1", "first")).To(Succeed())
+			Expect(history.Record(applicationID, environment, microserviceID, "user-1", "nothing changed")).To(Succeed())
+
+			setEnvironmentVariable("LOG_LEVEL", "debug")
+			Expect(history.Record(applicationID, environment, microserviceID, "user-2", "second")).To(Succeed())
+
+			revisions, err := repo.List(applicationID, environment, microserviceID)
+			Expect(err).To(BeNil())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[0].Revision).To(Equal(2))
+			Expect(revisions[0].CreatedBy).To(Equal("user-2"))
+			Expect(revisions[0].Reason).To(Equal("second"))
+			Expect(revisions[0].ContentHash).ToNot(Equal(revisions[1].ContentHash))
+		})
+
+		It("should not store secret values in plain text", func() {
+			Expect(history.Record(applicationID, environment, microserviceID, "user-1", "first")).To(Succeed())
+
+			revision, err := repo.Get(applicationID, environment, microserviceID, 1)
+			Expect(err).To(BeNil())
+			Expect(revision.Snapshot.SecretEnvironmentVariables["PASSWORD"]).ToNot(BeEmpty())
+			Expect(revision.Snapshot.SecretEnvironmentVariables["PASSWORD"]).ToNot(ContainSubstring("hunter2"))
+			Expect(revision.Snapshot.SecretHashes["PASSWORD"]).ToNot(ContainSubstring("hunter2"))
+			Expect(revision.Snapshot.ConfigFiles["blob.bin"]).To(Equal([]byte{0xff, 0xfe}))
+		})
+
+		It("should only keep the newest revisions", func() {
+			for _, level := range []string{"1", "2", "3", "4", "5"} {
+				setEnvironmentVariable("LOG_LEVEL", level)
+				Expect(history.Record(applicationID, environment, microserviceID, "user-1", level)).To(Succeed())
+			}
+
+			revisions, err := repo.List(applicationID, environment, microserviceID)
+			Expect(err).To(BeNil())
+			Expect(revisions).To(HaveLen(3))
+			Expect(revisions[0].Revision).To(Equal(5))
+			Expect(revisions[2].Revision).To(Equal(3))
+		})
+	})
+
+	Describe("saving", func() {
+		It("should find the revisions whatever the case of the environment", func() {
+			_, err := repo.Save(applicationID, "Test", microserviceID, configHistory.Revision{})
+			Expect(err).To(BeNil())
+
+			revisions, err := repo.List(applicationID, "test", microserviceID)
+			Expect(err).To(BeNil())
+			Expect(revisions).To(HaveLen(1))
+
+			_, err = repo.Get(applicationID, "TEST", microserviceID, revisions[0].Revision)
+			Expect(err).To(BeNil())
+		})
+
+		It("should refuse a revision that does not fit in a secret", func() {
+			// Random bytes do not compress
+			blob := make([]byte, configHistory.MaxRevisionBytes)
+			rand.New(rand.NewSource(1)).Read(blob)
+			revision := configHistory.Revision{}
+			revision.Snapshot.ConfigFiles = map[string][]byte{"blob.bin": blob}
+
+			_, err := repo.Save(applicationID, environment, microserviceID, revision)
+			Expect(errors.Is(err, configHistory.ErrRevisionTooLarge)).To(BeTrue())
+
+			revisions, err := repo.List(applicationID, environment, microserviceID)
+			Expect(err).To(BeNil())
+			Expect(revisions).To(BeEmpty())
+		})
+	})
+
+	Describe("diffing", func() {
+		It("should list the changes without secret values", func() {
+			Expect(history.Record(applicationID, environment, microserviceID, "user-1", "first")).To(Succeed())
+			setEnvironmentVariable("LOG_LEVEL", "debug")
+			setEnvironmentVariable("NEW", "value")
+			setSecret("PASSWORD", "hunter3")
+			Expect(history.Record(applicationID, environment, microserviceID, "user-1", "second")).To(Succeed())
+
+			from, _ := repo.Get(applicationID, environment, microserviceID, 1)
+			to, _ := repo.Get(applicationID, environment, microserviceID, 2)
+
+			Expect(configHistory.Diff(from.Snapshot, to.Snapshot)).To(Equal([]platform.ConfigRevisionChange{
+				{Kind: platform.ConfigRevisionKindEnvironmentVariable, Name: "LOG_LEVEL", Change: platform.ConfigRevisionChangeChanged, From: "info", To: "debug"},
+				{Kind: platform.ConfigRevisionKindEnvironmentVariable, Name: "NEW", Change: platform.ConfigRevisionChangeAdded, To: "value"},
+				{Kind: platform.ConfigRevisionKindSecretEnvironmentVariable, Name: "PASSWORD", Change: platform.ConfigRevisionChangeChanged},
+			}))
+		})
+	})
+
+	Describe("rolling back", func() {
+		It("should restore the configuration and record it", func() {
+			Expect(history.Record(applicationID, environment, microserviceID, "user-1", "first")).To(Succeed())
+			setEnvironmentVariable("LOG_LEVEL", "debug")
+			setSecret("PASSWORD", "hunter3")
+
+			revision, err := history.Rollback(applicationID, environment, microserviceID, "user-2", 1)
+			Expect(err).To(BeNil())
+			// 2 is the configuration before the rollback
+			Expect(revision.Revision).To(Equal(3))
+			Expect(revision.CreatedBy).To(Equal("user-2"))
+
+			Expect(getConfigMap("test-order-env-variables").Data).To(Equal(map[string]string{"LOG_LEVEL": "info"}))
+			Expect(getSecret().Data["PASSWORD"]).To(Equal([]byte("hunter2")))
+			Expect(getConfigMap("test-order-config-files").BinaryData["blob.bin"]).To(Equal([]byte{0xff, 0xfe}))
+
+			_, err = history.Rollback(applicationID, environment, microserviceID, "user-2", 42)
+			Expect(err).To(Equal(configHistory.ErrRevisionNotFound))
+		})
+
+		It("should restore the secret references and the config files metadata", func() {
+			secret := getSecret()
+			secret.Annotations = map[string]string{
+				secretstore.ReferencesAnnotation: `{"PASSWORD":{"store":"vault","path":"secret/data/order","key":"password"}}`,
+			}
+			_, err := clientSet.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			configFiles := getConfigMap("test-order-config-files")
+			configFiles.Annotations = map[string]string{
+				platformK8s.ConfigFilesMetadataAnnotation: `{"config.json":{"contentType":"application/json","updatedAt":"2021-10-01T00:00:00Z"}}`,
+			}
+			_, err = clientSet.CoreV1().ConfigMaps(namespace).Update(context.TODO(), configFiles, metav1.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			Expect(history.Record(applicationID, environment, microserviceID, "user-1", "first")).To(Succeed())
+
+			secret = getSecret()
+			Expect(secretstore.SetReferences(secret, nil)).To(Succeed())
+			_, err = clientSet.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			configFiles = getConfigMap("test-order-config-files")
+			delete(configFiles.Annotations, platformK8s.ConfigFilesMetadataAnnotation)
+			_, err = clientSet.CoreV1().ConfigMaps(namespace).Update(context.TODO(), configFiles, metav1.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			_, err = history.Rollback(applicationID, environment, microserviceID, "user-2", 1)
+			Expect(err).To(BeNil())
+
+			references, err := secretstore.GetReferences(getSecret())
+			Expect(err).To(BeNil())
+			Expect(references["PASSWORD"].Path).To(Equal("secret/data/order"))
+			Expect(getSecret().Labels[secretstore.HasReferencesLabel]).To(Equal("true"))
+			Expect(getConfigMap("test-order-config-files").Annotations[platformK8s.ConfigFilesMetadataAnnotation]).To(ContainSubstring("application/json"))
+		})
+
+		When("no encryption key is configured", func() {
+			BeforeEach(func() {
+				encryptionKey = ""
+			})
+
+			It("should refuse to roll back changed secrets", func() {
+				Expect(history.Record(applicationID, environment, microserviceID, "user-1", "first")).To(Succeed())
+				setSecret("PASSWORD", "hunter3")
+
+				_, err := history.Rollback(applicationID, environment, microserviceID, "user-1", 1)
+				Expect(err).To(MatchError(ContainSubstring(configHistory.ErrSecretNotRecoverable.Error())))
+				Expect(getSecret().Data["PASSWORD"]).To(Equal([]byte("hunter3")))
+			})
+		})
+	})
+})
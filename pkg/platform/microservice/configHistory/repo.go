@@ -0,0 +1,258 @@
+package configHistory
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	labelConfigRevisionOf = "dolittle.io/config-revision-of"
+	labelRevision         = "dolittle.io/config-revision"
+
+	annotationCreatedAt   = "dolittle.io/created-at"
+	annotationCreatedBy   = "dolittle.io/created-by"
+	annotationReason      = "dolittle.io/reason"
+	annotationContentHash = "dolittle.io/content-hash"
+
+	snapshotKey = "snapshot.json.gz"
+
+	// MaxRevisionBytes is the most a revision secret can hold, the api server refuses secrets over 1MiB
+	MaxRevisionBytes = 1024 * 1024
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrRevisionTooLarge is returned when the configuration does not fit in a revision secret
+	ErrRevisionTooLarge = errors.New("configuration is too large to keep a revision of")
+)
+
+type ConfigHistoryRepo interface {
+	// Save stores the revision as the next revision number
+	Save(applicationID string, environment string, microserviceID string, revision Revision) (Revision, error)
+	// List returns the revisions without their snapshots, newest first
+	List(applicationID string, environment string, microserviceID string) ([]platform.ConfigRevision, error)
+	Get(applicationID string, environment string, microserviceID string, revision int) (Revision, error)
+}
+
+type k8sRepo struct {
+	k8sClient    kubernetes.Interface
+	maxRevisions int
+	logContext   logrus.FieldLogger
+}
+
+// NewConfigHistoryK8sRepo stores each revision as a secret in the application namespace
+// Only the newest maxRevisions are kept, 0 keeps them all
+func NewConfigHistoryK8sRepo(k8sClient kubernetes.Interface, maxRevisions int, logContext logrus.FieldLogger) k8sRepo {
+	return k8sRepo{
+		k8sClient:    k8sClient,
+		maxRevisions: maxRevisions,
+		logContext:   logContext,
+	}
+}
+
+func (r k8sRepo) Save(applicationID string, environment string, microserviceID string, revision Revision) (Revision, error) {
+	ctx := context.TODO()
+	namespace := platformK8s.GetApplicationNamespace(applicationID)
+
+	existing, err := r.listSecrets(applicationID, environment, microserviceID)
+	if err != nil {
+		return revision, err
+	}
+
+	revision.Revision = 1
+	if len(existing) != 0 {
+		revision.Revision = revisionOf(existing[0]) + 1
+	}
+
+	b, err := json.Marshal(revision.Snapshot)
+	if err != nil {
+		return revision, err
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(b); err != nil {
+		return revision, err
+	}
+	if err := writer.Close(); err != nil {
+		return revision, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getRevisionSecretName(environment, microserviceID, revision.Revision),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"environment":         strings.ToLower(environment),
+				labelConfigRevisionOf: microserviceID,
+				labelRevision:         strconv.Itoa(revision.Revision),
+			},
+			Annotations: map[string]string{
+				"dolittle.io/microservice-id": microserviceID,
+				"dolittle.io/application-id":  applicationID,
+				annotationCreatedAt:           revision.CreatedAt,
+				annotationCreatedBy:           revision.CreatedBy,
+				annotationReason:              revision.Reason,
+				annotationContentHash:         revision.ContentHash,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			snapshotKey: compressed.Bytes(),
+		},
+	}
+
+	size := getSecretSize(secret)
+	if size > MaxRevisionBytes {
+		return revision, fmt.Errorf("%w: %d bytes, the limit is %d bytes", ErrRevisionTooLarge, size, MaxRevisionBytes)
+	}
+
+	_, err = r.k8sClient.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil {
+		return revision, err
+	}
+
+	if r.maxRevisions > 0 && len(existing)+1 > r.maxRevisions {
+		for _, old := range existing[r.maxRevisions-1:] {
+			err := r.k8sClient.CoreV1().Secrets(namespace).Delete(ctx, old.Name, metav1.DeleteOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				// The revision is saved, a later save will try again
+				r.logContext.WithFields(logrus.Fields{
+					"error":  err,
+					"secret": old.Name,
+				}).Error("failed to prune old config revision")
+			}
+		}
+	}
+
+	return revision, nil
+}
+
+func (r k8sRepo) List(applicationID string, environment string, microserviceID string) ([]platform.ConfigRevision, error) {
+	secrets, err := r.listSecrets(applicationID, environment, microserviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]platform.ConfigRevision, 0, len(secrets))
+	for _, secret := range secrets {
+		revisions = append(revisions, toConfigRevision(secret))
+	}
+	return revisions, nil
+}
+
+func (r k8sRepo) Get(applicationID string, environment string, microserviceID string, revision int) (Revision, error) {
+	namespace := platformK8s.GetApplicationNamespace(applicationID)
+	secret, err := r.k8sClient.CoreV1().Secrets(namespace).Get(context.TODO(), getRevisionSecretName(environment, microserviceID, revision), metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return Revision{}, ErrRevisionNotFound
+		}
+		return Revision{}, err
+	}
+
+	stored := Revision{
+		ConfigRevision: toConfigRevision(*secret),
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(secret.Data[snapshotKey]))
+	if err != nil {
+		return stored, fmt.Errorf("revision %d is corrupt: %w", revision, err)
+	}
+	defer reader.Close()
+
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return stored, fmt.Errorf("revision %d is corrupt: %w", revision, err)
+	}
+
+	err = json.Unmarshal(b, &stored.Snapshot)
+	if err != nil {
+		return stored, fmt.Errorf("revision %d is corrupt: %w", revision, err)
+	}
+	return stored, nil
+}
+
+// listSecrets returns the revision secrets, newest first
+// The environment is matched ignoring case, like the secret names Get looks revisions up by
+func (r k8sRepo) listSecrets(applicationID string, environment string, microserviceID string) ([]corev1.Secret, error) {
+	namespace := platformK8s.GetApplicationNamespace(applicationID)
+	selector := labels.SelectorFromSet(labels.Set{
+		labelConfigRevisionOf: microserviceID,
+	})
+
+	secrets, err := r.k8sClient.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]corev1.Secret, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		if strings.EqualFold(secret.Labels["environment"], environment) {
+			items = append(items, secret)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return revisionOf(items[i]) > revisionOf(items[j])
+	})
+	return items, nil
+}
+
+func revisionOf(secret corev1.Secret) int {
+	revision, _ := strconv.Atoi(secret.Labels[labelRevision])
+	return revision
+}
+
+func toConfigRevision(secret corev1.Secret) platform.ConfigRevision {
+	return platform.ConfigRevision{
+		Revision:    revisionOf(secret),
+		CreatedAt:   secret.Annotations[annotationCreatedAt],
+		CreatedBy:   secret.Annotations[annotationCreatedBy],
+		Reason:      secret.Annotations[annotationReason],
+		ContentHash: secret.Annotations[annotationContentHash],
+	}
+}
+
+// getSecretSize counts the data, labels and annotations of the secret
+func getSecretSize(secret *corev1.Secret) int {
+	size := 0
+	for key, value := range secret.Data {
+		size += len(key) + len(value)
+	}
+	for key, value := range secret.Labels {
+		size += len(key) + len(value)
+	}
+	for key, value := range secret.Annotations {
+		size += len(key) + len(value)
+	}
+	return size
+}
+
+func getRevisionSecretName(environment string, microserviceID string, revision int) string {
+	return strings.ToLower(
+		fmt.Sprintf("%s-%s-config-revision-%d",
+			environment,
+			microserviceID,
+			revision,
+		),
+	)
+}
@@ -0,0 +1,161 @@
+package configHistory
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type service struct {
+	history         ConfigHistory
+	repo            ConfigHistoryRepo
+	k8sDolittleRepo platformK8s.K8sRepo
+	logContext      logrus.FieldLogger
+}
+
+func NewService(history ConfigHistory, repo ConfigHistoryRepo, k8sDolittleRepo platformK8s.K8sRepo, logContext logrus.FieldLogger) service {
+	return service{
+		history:         history,
+		repo:            repo,
+		k8sDolittleRepo: k8sDolittleRepo,
+		logContext:      logContext,
+	}
+}
+
+func (s *service) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	microserviceID := vars["microserviceID"]
+	environment := vars["environment"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	revisions, err := s.repo.List(applicationID, environment, microserviceID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, platform.HttpResponseConfigRevisions{
+		ApplicationID:  applicationID,
+		Environment:    environment,
+		MicroserviceID: microserviceID,
+		Revisions:      revisions,
+	})
+}
+
+// DiffRevisions compares the revisions in the query parameters "from" and "to"
+func (s *service) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	microserviceID := vars["microserviceID"]
+	environment := vars["environment"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "from must be a revision number")
+		return
+	}
+
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "to must be a revision number")
+		return
+	}
+
+	fromRevision, err := s.repo.Get(applicationID, environment, microserviceID, from)
+	if err != nil {
+		s.respondWithRevisionError(w, err)
+		return
+	}
+
+	toRevision, err := s.repo.Get(applicationID, environment, microserviceID, to)
+	if err != nil {
+		s.respondWithRevisionError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, platform.HttpResponseConfigRevisionDiff{
+		ApplicationID:  applicationID,
+		Environment:    environment,
+		MicroserviceID: microserviceID,
+		From:           from,
+		To:             to,
+		Changes:        Diff(fromRevision.Snapshot, toRevision.Snapshot),
+	})
+}
+
+func (s *service) Rollback(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	microserviceID := vars["microserviceID"]
+	environment := vars["environment"]
+
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "revision must be a number")
+		return
+	}
+
+	s.logContext.WithFields(logrus.Fields{
+		"application_id":  applicationID,
+		"microservice_id": microserviceID,
+		"environment":     environment,
+		"revision":        revision,
+		"user_id":         userID,
+	}).Info("Rollback configuration")
+
+	rolledBack, err := s.history.Rollback(applicationID, environment, microserviceID, userID, revision)
+	if err != nil {
+		s.respondWithRevisionError(w, err)
+		return
+	}
+
+	response := platform.HttpResponseConfigRevisionRollback{
+		Revision: rolledBack.ConfigRevision,
+	}
+
+	if r.URL.Query().Get("rollout") == "true" {
+		response.Rollout = s.k8sDolittleRepo.TryRolloutMicroserviceConfig(applicationID, environment, microserviceID)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *service) respondWithRevisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrRevisionNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrSecretNotRecoverable):
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, ErrRevisionTooLarge):
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
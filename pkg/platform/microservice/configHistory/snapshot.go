@@ -0,0 +1,119 @@
+package configHistory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+// Snapshot is the configuration of a microservice at a point in time
+type Snapshot struct {
+	EnvironmentVariables map[string]string `json:"environmentVariables"`
+	// SecretEnvironmentVariables holds the encrypted values, empty when no encryption key is configured
+	SecretEnvironmentVariables map[string]string `json:"secretEnvironmentVariables"`
+	SecretHashes               map[string]string `json:"secretHashes"`
+	// SecretReferences are the secret environment variables resolved from a secret store
+	SecretReferences map[string]platform.SecretReference `json:"secretReferences,omitempty"`
+	ConfigFiles      map[string][]byte                   `json:"configFiles"`
+	// ConfigFilesMetadata is the metadata annotation of the config files, as it was stored
+	ConfigFilesMetadata string `json:"configFilesMetadata,omitempty"`
+}
+
+// Revision is a stored snapshot
+type Revision struct {
+	platform.ConfigRevision
+	Snapshot Snapshot `json:"snapshot"`
+}
+
+// contentHash covers secrets by their hashes, so it is the same across encryptions of the same values
+func (s Snapshot) contentHash() string {
+	configFileHashes := make(map[string]string, len(s.ConfigFiles))
+	for name, value := range s.ConfigFiles {
+		sum := sha256.Sum256(value)
+		configFileHashes[name] = hex.EncodeToString(sum[:])
+	}
+
+	// json.Marshal sorts map keys, which keeps the hash stable
+	b, _ := json.Marshal(struct {
+		EnvironmentVariables map[string]string                   `json:"environmentVariables"`
+		SecretHashes         map[string]string                   `json:"secretHashes"`
+		SecretReferences     map[string]platform.SecretReference `json:"secretReferences,omitempty"`
+		ConfigFiles          map[string]string                   `json:"configFiles"`
+		ConfigFilesMetadata  string                              `json:"configFilesMetadata,omitempty"`
+	}{
+		EnvironmentVariables: s.EnvironmentVariables,
+		SecretHashes:         s.SecretHashes,
+		SecretReferences:     s.SecretReferences,
+		ConfigFiles:          configFileHashes,
+		ConfigFilesMetadata:  s.ConfigFilesMetadata,
+	})
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Diff lists what changed going from one snapshot to another
+// Secret values and config file contents are never included
+func Diff(from Snapshot, to Snapshot) []platform.ConfigRevisionChange {
+	changes := make([]platform.ConfigRevisionChange, 0)
+
+	changes = append(changes, diffValues(platform.ConfigRevisionKindEnvironmentVariable, from.EnvironmentVariables, to.EnvironmentVariables, true)...)
+	changes = append(changes, diffValues(platform.ConfigRevisionKindSecretEnvironmentVariable, from.SecretHashes, to.SecretHashes, false)...)
+
+	fromFiles := make(map[string]string, len(from.ConfigFiles))
+	for name, value := range from.ConfigFiles {
+		fromFiles[name] = string(value)
+	}
+	toFiles := make(map[string]string, len(to.ConfigFiles))
+	for name, value := range to.ConfigFiles {
+		toFiles[name] = string(value)
+	}
+	changes = append(changes, diffValues(platform.ConfigRevisionKindConfigFile, fromFiles, toFiles, false)...)
+
+	return changes
+}
+
+func diffValues(kind string, from map[string]string, to map[string]string, includeValues bool) []platform.ConfigRevisionChange {
+	names := make([]string, 0, len(from)+len(to))
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]platform.ConfigRevisionChange, 0)
+	for _, name := range names {
+		fromValue, inFrom := from[name]
+		toValue, inTo := to[name]
+
+		change := platform.ConfigRevisionChange{
+			Kind: kind,
+			Name: name,
+		}
+
+		switch {
+		case !inFrom:
+			change.Change = platform.ConfigRevisionChangeAdded
+		case !inTo:
+			change.Change = platform.ConfigRevisionChangeRemoved
+		case fromValue != toValue:
+			change.Change = platform.ConfigRevisionChangeChanged
+		default:
+			continue
+		}
+
+		if includeValues {
+			change.From = fromValue
+			change.To = toValue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
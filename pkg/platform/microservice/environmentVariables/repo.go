@@ -2,10 +2,12 @@ package environmentVariables
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/dolittle/platform-api/pkg/platform"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
 	"github.com/sirupsen/logrus"
 	"github.com/thoas/go-funk"
 	"k8s.io/client-go/kubernetes"
@@ -59,8 +61,16 @@ func (r k8sRepo) GetEnvironmentVariables(applicationID string, environment strin
 		})
 	}
 
+	references, err := secretstore.GetReferences(secret)
+	if err != nil {
+		return data, errors.New("GetEnvironmentVariables ERROR: unable to load secret references")
+	}
+
 	// When using StringData, it does not appear I need to handle this
 	for name, value := range secret.Data {
+		if _, ok := references[name]; ok {
+			continue
+		}
 		data = append(data, platform.StudioEnvironmentVariable{
 			Name:     name,
 			Value:    string(value),
@@ -68,6 +78,16 @@ func (r k8sRepo) GetEnvironmentVariables(applicationID string, environment strin
 		})
 	}
 
+	// The values of references are never handed out, also listed before they are synced
+	for name, reference := range references {
+		reference := reference
+		data = append(data, platform.StudioEnvironmentVariable{
+			Name:      name,
+			IsSecret:  true,
+			Reference: &reference,
+		})
+	}
+
 	return data, nil
 }
 
@@ -83,7 +103,19 @@ func (r k8sRepo) UpdateEnvironmentVariables(applicationID string, environment st
 			return errors.New("UpdateEnvironmentVariables ERROR: No spaces allowed in environment variable name in existing configmap")
 		}
 
-		if item.Value == "" {
+		if item.Reference != nil {
+			if !item.IsSecret {
+				return errors.New("UpdateEnvironmentVariables ERROR: Only secret environment variables can reference a secret store")
+			}
+
+			if item.Value != "" {
+				return errors.New("UpdateEnvironmentVariables ERROR: No value allowed when referencing a secret store")
+			}
+
+			if err := secretstore.ValidateReference(*item.Reference); err != nil {
+				return fmt.Errorf("UpdateEnvironmentVariables ERROR: %s: %w", item.Name, err)
+			}
+		} else if item.Value == "" {
 			return errors.New("UpdateEnvironmentVariables ERROR: No empty value allowed in environment variable value in existing configmap")
 		}
 
@@ -129,19 +161,36 @@ func (r k8sRepo) UpdateEnvironmentVariables(applicationID string, environment st
 		configMap.Data[item.Name] = item.Value
 	}
 
+	previous := secret.Data
+
 	// Because I am overriding all, this is required to make sure
 	// Current data is cleared, as I suspect StringData has some magic under the hood on
 	// saving and on reading
 	secret.Data = make(map[string][]byte)
 	secret.StringData = make(map[string]string)
+	references := make(map[string]platform.SecretReference)
 
 	for _, item := range data {
 		if !item.IsSecret {
 			continue
 		}
+
+		if item.Reference != nil {
+			references[item.Name] = *item.Reference
+			// Keep the synced value until the next sync, so the microservice is not left without it
+			if value, ok := previous[item.Name]; ok {
+				secret.StringData[item.Name] = string(value)
+			}
+			continue
+		}
 		secret.StringData[item.Name] = item.Value
 	}
 
+	err = secretstore.SetReferences(secret, references)
+	if err != nil {
+		return errors.New("UpdateEnvironmentVariables ERROR: failed to store secret references")
+	}
+
 	// Write configmap and secret
 	_, err = r.k8sDolittleRepo.WriteConfigMap(configMap)
 	if err != nil {
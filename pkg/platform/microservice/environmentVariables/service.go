@@ -1,12 +1,17 @@
 package environmentVariables
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/dolittle/platform-api/pkg/platform"
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/configHistory"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
 	"github.com/dolittle/platform-api/pkg/utils"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -15,13 +20,17 @@ import (
 type service struct {
 	environmentVariablesRepo EnvironmentVariablesRepo
 	k8sDolittleRepo          platformK8s.K8sRepo
+	configHistory            configHistory.Recorder
+	secretSyncer             secretstore.Syncer
 	logContext               logrus.FieldLogger
 }
 
-func NewService(environmentVariablesRepo EnvironmentVariablesRepo, k8sDolittleRepo platformK8s.K8sRepo, logContext logrus.FieldLogger) service {
+func NewService(environmentVariablesRepo EnvironmentVariablesRepo, k8sDolittleRepo platformK8s.K8sRepo, configHistory configHistory.Recorder, secretSyncer secretstore.Syncer, logContext logrus.FieldLogger) service {
 	return service{
 		environmentVariablesRepo: environmentVariablesRepo,
 		k8sDolittleRepo:          k8sDolittleRepo,
+		configHistory:            configHistory,
+		secretSyncer:             secretSyncer,
 		logContext:               logContext,
 	}
 }
@@ -94,17 +103,58 @@ func (s *service) UpdateEnvironmentVariables(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	for _, item := range input.Data {
+		if item.Reference == nil {
+			continue
+		}
+
+		err := s.secretSyncer.Validate(secretstore.Scope{CustomerID: customerID, ApplicationID: applicationID}, *item.Reference)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("UpdateEnvironmentVariables ERROR: %s: %s", item.Name, err.Error()))
+			return
+		}
+	}
+
+	// Snapshot first, so the configuration being replaced can always be rolled back to
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "before environment variables update")
+	if err != nil {
+		if errors.Is(err, configHistory.ErrRevisionTooLarge) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		s.logContext.WithField("error", err).Error("failed to record config revision")
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to record config revision")
+		return
+	}
+
 	err = s.environmentVariablesRepo.UpdateEnvironmentVariables(applicationID, environment, microserviceID, input.Data)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
+	// Resolve new references right away, instead of waiting for the scheduled sync
+	err = s.secretSyncer.SyncMicroservice(context.TODO(), applicationID, environment, microserviceID)
+	if err != nil {
+		s.logContext.WithField("error", err).Warn("failed to sync secret references, they will be retried on the next sync")
+	}
+
+	err = s.configHistory.Record(applicationID, environment, microserviceID, userID, "update environment variables")
+	if err != nil {
+		// The change is made, the next record will pick it up
+		s.logContext.WithField("error", err).Error("failed to record config revision")
+	}
+
 	data, err := s.environmentVariablesRepo.GetEnvironmentVariables(applicationID, environment, microserviceID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	response.Data = data
+
+	// Opt in to rolling out new pods with the changed configuration
+	if r.URL.Query().Get("rollout") == "true" {
+		response.Rollout = s.k8sDolittleRepo.TryRolloutMicroserviceConfig(applicationID, environment, microserviceID)
+	}
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
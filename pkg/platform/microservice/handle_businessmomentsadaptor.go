@@ -19,10 +19,19 @@ func (s *service) handleBusinessMomentsAdaptor(responseWriter http.ResponseWrite
 		return
 	}
 
+	if _, err := GetBusinessMomentsAdaptorAuthorization(ms.Extra.Connector); err != nil {
+		utils.RespondWithError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if CheckIfIngressPathInUseInEnvironment(applicationInfo.Ingresses, ms.Environment, ms.Extra.Ingress.Path) {
+		utils.RespondWithError(responseWriter, http.StatusBadRequest, "ms.Extra.Ingress.Path The path is already in use")
+		return
+	}
+
 	err := s.businessMomentsAdaptorRepo.Create(msK8sInfo.Namespace, msK8sInfo.Customer, msK8sInfo.Application, customerTenants, ms)
-	if statusErr != nil {
-		// TODO change
-		utils.RespondWithError(responseWriter, http.StatusInternalServerError, statusErr.Error())
+	if err != nil {
+		utils.RespondWithError(responseWriter, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -36,6 +45,10 @@ func (s *service) handleBusinessMomentsAdaptor(responseWriter http.ResponseWrite
 		ms,
 	)
 	if err != nil {
+		// Without it in storage the microservice can't be managed, so don't leave it running
+		if deleteErr := s.businessMomentsAdaptorRepo.Delete(ms.Dolittle.ApplicationID, ms.Environment, ms.Dolittle.MicroserviceID); deleteErr != nil {
+			s.logContext.WithError(deleteErr).Error("Could not remove the business moments adaptor after failing to save it")
+		}
 		utils.RespondWithError(responseWriter, http.StatusInternalServerError, err.Error())
 		return
 	}
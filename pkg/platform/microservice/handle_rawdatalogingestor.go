@@ -40,7 +40,7 @@ func (s *service) handleRawDataLogIngestor(
 	})
 
 	if !writeToCheck {
-		utils.RespondWithError(responseWriter, http.StatusForbidden, "writeTo is not valid, leave empty or set to stdout")
+		utils.RespondWithError(responseWriter, http.StatusBadRequest, "writeTo is not valid, leave empty or set to stdout")
 		return
 	}
 
@@ -50,6 +50,10 @@ func (s *service) handleRawDataLogIngestor(
 		return
 	}
 	if !exists {
+		if CheckIfIngressPathInUseInEnvironment(applicationInfo.Ingresses, ms.Environment, ms.Extra.Ingress.Path) {
+			utils.RespondWithError(responseWriter, http.StatusBadRequest, "ms.Extra.Ingress.Path The path is already in use")
+			return
+		}
 		// Create in Kubernetes
 		err = s.rawDataLogIngestorRepo.Create(msK8sInfo.Namespace, msK8sInfo.Customer, msK8sInfo.Application, customerTenants, ms) //TODO:
 	} else {
@@ -72,6 +76,12 @@ func (s *service) handleRawDataLogIngestor(
 		ms,
 	)
 	if err != nil {
+		// Only remove what this request created, an update leaves the running ingestor alone
+		if !exists {
+			if deleteErr := s.rawDataLogIngestorRepo.Delete(msK8sInfo.Namespace, ms.Dolittle.MicroserviceID); deleteErr != nil {
+				s.logContext.WithError(deleteErr).Error("Could not remove the raw data log ingestor after failing to save it")
+			}
+		}
 		utils.RespondWithError(responseWriter, http.StatusInternalServerError, err.Error())
 		return
 	}
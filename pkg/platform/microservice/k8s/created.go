@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreatedResources creates resources and keeps track of them, so a microservice that fails half way
+// can be rolled back. Resources that already existed are not tracked, as they are not ours to remove
+type CreatedResources struct {
+	client    kubernetes.Interface
+	namespace string
+	deletes   []func(ctx context.Context) error
+}
+
+func NewCreatedResources(client kubernetes.Interface, namespace string) *CreatedResources {
+	return &CreatedResources{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+func (c *CreatedResources) ConfigMap(ctx context.Context, configMap *corev1.ConfigMap) error {
+	_, err := c.client.CoreV1().ConfigMaps(c.namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	return c.track(err, func(ctx context.Context) error {
+		return c.client.CoreV1().ConfigMaps(c.namespace).Delete(ctx, configMap.Name, metav1.DeleteOptions{})
+	})
+}
+
+func (c *CreatedResources) Secret(ctx context.Context, secret *corev1.Secret) error {
+	_, err := c.client.CoreV1().Secrets(c.namespace).Create(ctx, secret, metav1.CreateOptions{})
+	return c.track(err, func(ctx context.Context) error {
+		return c.client.CoreV1().Secrets(c.namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+	})
+}
+
+func (c *CreatedResources) Service(ctx context.Context, service *corev1.Service) error {
+	_, err := c.client.CoreV1().Services(c.namespace).Create(ctx, service, metav1.CreateOptions{})
+	return c.track(err, func(ctx context.Context) error {
+		return c.client.CoreV1().Services(c.namespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
+	})
+}
+
+func (c *CreatedResources) Ingress(ctx context.Context, ingress *networkingv1.Ingress) error {
+	_, err := c.client.NetworkingV1().Ingresses(c.namespace).Create(ctx, ingress, metav1.CreateOptions{})
+	return c.track(err, func(ctx context.Context) error {
+		return c.client.NetworkingV1().Ingresses(c.namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{})
+	})
+}
+
+func (c *CreatedResources) NetworkPolicy(ctx context.Context, networkPolicy *networkingv1.NetworkPolicy) error {
+	_, err := c.client.NetworkingV1().NetworkPolicies(c.namespace).Create(ctx, networkPolicy, metav1.CreateOptions{})
+	return c.track(err, func(ctx context.Context) error {
+		return c.client.NetworkingV1().NetworkPolicies(c.namespace).Delete(ctx, networkPolicy.Name, metav1.DeleteOptions{})
+	})
+}
+
+func (c *CreatedResources) Deployment(ctx context.Context, deployment *appsv1.Deployment) error {
+	_, err := c.client.AppsV1().Deployments(c.namespace).Create(ctx, deployment, metav1.CreateOptions{})
+	return c.track(err, func(ctx context.Context) error {
+		return c.client.AppsV1().Deployments(c.namespace).Delete(ctx, deployment.Name, metav1.DeleteOptions{})
+	})
+}
+
+func (c *CreatedResources) StatefulSet(ctx context.Context, statefulSet *appsv1.StatefulSet) error {
+	_, err := c.client.AppsV1().StatefulSets(c.namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+	return c.track(err, func(ctx context.Context) error {
+		return c.client.AppsV1().StatefulSets(c.namespace).Delete(ctx, statefulSet.Name, metav1.DeleteOptions{})
+	})
+}
+
+// Rollback deletes the tracked resources, newest first, carrying on past errors and returning the first one
+func (c *CreatedResources) Rollback(ctx context.Context) error {
+	var firstErr error
+	for i := len(c.deletes) - 1; i >= 0; i-- {
+		err := c.deletes[i](ctx)
+		if err != nil && !k8serrors.IsNotFound(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.deletes = nil
+	return firstErr
+}
+
+func (c *CreatedResources) track(err error, delete func(ctx context.Context) error) error {
+	if err == nil {
+		c.deletes = append(c.deletes, delete)
+	}
+	return err
+}
@@ -70,8 +70,7 @@ func K8sStopDeployment(client kubernetes.Interface, context context.Context, nam
 func K8sHandleResourceCreationError(creationError error, onExists func()) error {
 	if creationError != nil {
 		if !k8serrors.IsAlreadyExists(creationError) {
-			log.Fatal(creationError)
-			return errors.New("issue")
+			return creationError
 		}
 		onExists()
 	}
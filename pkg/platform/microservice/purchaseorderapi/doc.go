@@ -18,6 +18,10 @@ type Repo interface {
 	Exists(namespace string, customer k8s.Tenant, application k8s.Application, customerTenants []platform.CustomerTenantInfo, input platform.HttpInputPurchaseOrderInfo) (bool, error)
 	// EnvironmentHasPurchaseOrderAPI checks whether the given environment has a purchase order api deployed
 	EnvironmentHasPurchaseOrderAPI(namespace string, input platform.HttpInputPurchaseOrderInfo) (bool, error)
+	// UpdateImages changes the images of the running microservice, an empty image is left as it is
+	UpdateImages(applicationID, environment, microserviceID, headImage, runtimeImage string) error
+	// GetStatus gets the images and replicas of the running microservice
+	GetStatus(applicationID, environment, microserviceID string) (platform.PurchaseOrderAPIStatus, error)
 }
 
 type K8sResource interface {
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/k8s"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/parser"
 	"github.com/dolittle/platform-api/pkg/platform/microservice/rawdatalog"
@@ -34,6 +35,9 @@ func newForbidden(err error) *Error {
 func newConflict(err error) *Error {
 	return &Error{http.StatusConflict, err}
 }
+func newNotFound(err error) *Error {
+	return &Error{http.StatusNotFound, err}
+}
 
 func (e *Error) Error() string {
 	return fmt.Sprintf("status %d: Err %v", e.StatusCode, e.Err)
@@ -84,20 +88,23 @@ func (s *Handler) Create(inputBytes []byte, applicationInfo platform.Application
 		return ms, newConflict(fmt.Errorf("a Purchase Order API Microservice with the same name already exists in kubernetes or git storage"))
 	}
 
-	if statusErr := s.ensureRawDataLogExists(msK8sInfo, ms, customerTenants, logger); statusErr != nil {
+	rawDataLogMicroserviceID, statusErr := s.ensureRawDataLogExists(msK8sInfo, ms, customerTenants, logger)
+	if statusErr != nil {
 		return ms, statusErr
 	}
+	// Only a raw data log created here is ours to clean up when deleting
+	ms.Extra.RawDataLogMicroserviceID = rawDataLogMicroserviceID
 
 	return ms, s.createPurchaseOrderAPI(msK8sInfo, ms, customerTenants, logger)
 }
 
-// Update updates an existing PurchaseOrderAPI microservice and creates a RawDataLog microservice too if it didn't already exist
-func (s *Handler) UpdateWebhooks(inputBytes []byte, applicationInfo platform.Application, customerTenants []platform.CustomerTenantInfo) (platform.HttpInputPurchaseOrderInfo, *Error) {
+// Update updates the images, webhooks and raw data log name of an existing PurchaseOrderAPI microservice and creates a RawDataLog microservice too if it didn't already exist
+func (s *Handler) Update(inputBytes []byte, applicationInfo platform.Application, customerTenants []platform.CustomerTenantInfo) (platform.HttpInputPurchaseOrderInfo, *Error) {
 	// Function assumes access check has taken place
 	var ms platform.HttpInputPurchaseOrderInfo
 	logger := s.logContext.WithFields(logrus.Fields{
 		"handler": "PurchaseOrderAPI",
-		"method":  "UpdateWebhooks",
+		"method":  "Update",
 	})
 
 	msK8sInfo, parserError := s.parser.Parse(inputBytes, &ms, applicationInfo)
@@ -107,9 +114,10 @@ func (s *Handler) UpdateWebhooks(inputBytes []byte, applicationInfo platform.App
 	}
 
 	logger = logger.WithFields(logrus.Fields{
-		"customer_id":    applicationInfo.Customer.ID,
-		"application_id": applicationInfo.ID,
-		"environment":    ms.Environment,
+		"customer_id":     applicationInfo.Customer.ID,
+		"application_id":  applicationInfo.ID,
+		"environment":     ms.Environment,
+		"microservice_id": ms.Dolittle.MicroserviceID,
 	})
 	logger.Debug("Starting to update PurchaseOrderAPI microservice")
 
@@ -123,19 +131,147 @@ func (s *Handler) UpdateWebhooks(inputBytes []byte, applicationInfo platform.App
 		return ms, newConflict(fmt.Errorf("a Purchase Order API Microservice does not exist in kubernetes or git storage"))
 	}
 
-	if statusErr := s.ensureRawDataLogExists(msK8sInfo, ms, customerTenants, logger); statusErr != nil {
+	storedMicroservice, statusErr := s.getStoredPurchaseOrderAPI(msK8sInfo.Customer.ID, msK8sInfo.Application.ID, ms.Environment, ms.Dolittle.MicroserviceID, logger)
+	if statusErr != nil {
+		return ms, statusErr
+	}
+
+	renamingRawDataLog := ms.Extra.RawDataLogName != "" && ms.Extra.RawDataLogName != storedMicroservice.Extra.RawDataLogName
+	if renamingRawDataLog && storedMicroservice.Extra.RawDataLogMicroserviceID == "" {
+		rawDataLogExists, _, err := s.rawdatalogRepo.Exists(msK8sInfo.Namespace, ms.Environment)
+		if err != nil {
+			logger.WithError(err).Error("Failed to check if Raw Data Log exists")
+			return ms, newInternalError(fmt.Errorf("failed to check if Raw Data Log exists: %w", err))
+		}
+		if rawDataLogExists {
+			logger.Warn("The Raw Data Log was not created by the Purchase Order API, refusing to rename it")
+			return ms, newConflict(errors.New("the Raw Data Log was not created by the Purchase Order API and can not be renamed"))
+		}
+	}
+
+	rawDataLogMicroserviceID, statusErr := s.ensureRawDataLogExists(msK8sInfo, ms, customerTenants, logger)
+	if statusErr != nil {
 		return ms, statusErr
 	}
-	return ms, s.updatePurchaseOrderAPIWebhooks(msK8sInfo, ms.Extra.Webhooks, ms.Environment, ms.Dolittle.MicroserviceID, logger)
+	if rawDataLogMicroserviceID != "" {
+		// The Raw Data Log was created with the requested name
+		storedMicroservice.Extra.RawDataLogMicroserviceID = rawDataLogMicroserviceID
+	} else if renamingRawDataLog {
+		if statusErr := s.renameRawDataLog(msK8sInfo, storedMicroservice, ms.Extra.RawDataLogName, logger); statusErr != nil {
+			return ms, statusErr
+		}
+	}
+	if ms.Extra.RawDataLogName != "" {
+		storedMicroservice.Extra.RawDataLogName = ms.Extra.RawDataLogName
+	}
+	storedMicroservice.Extra.Webhooks = ms.Extra.Webhooks
+
+	if ms.Extra.Headimage != "" {
+		storedMicroservice.Extra.Headimage = ms.Extra.Headimage
+	}
+	if ms.Extra.Runtimeimage != "" {
+		storedMicroservice.Extra.Runtimeimage = ms.Extra.Runtimeimage
+	}
+	if err := s.gitRepo.SaveMicroservice(storedMicroservice.Dolittle.CustomerID, storedMicroservice.Dolittle.ApplicationID, storedMicroservice.Environment, storedMicroservice.Dolittle.MicroserviceID, storedMicroservice); err != nil {
+		logger.WithError(err).Error("Failed to save Purchase Order API in GitRepo")
+		return ms, newInternalError(fmt.Errorf("failed to save Purchase Order API in GitRepo: %w", err))
+	}
+
+	// The images are changed in the cluster last, so that a failure before leaves the running Purchase Order API untouched
+	if err := s.repo.UpdateImages(msK8sInfo.Application.ID, ms.Environment, ms.Dolittle.MicroserviceID, ms.Extra.Headimage, ms.Extra.Runtimeimage); err != nil {
+		logger.WithError(err).Error("Failed to update Purchase Order API images")
+		return ms, newInternalError(fmt.Errorf("failed to update Purchase Order API images: %w", err))
+	}
+	return storedMicroservice, nil
 }
 
-func (s *Handler) Delete(applicationID, environment, microserviceID string) error {
+// Delete deletes the PurchaseOrderAPI microservice, and the RawDataLog microservice when it was created by it and deleteRawDataLog is set
+func (s *Handler) Delete(customerID, applicationID, environment, microserviceID string, deleteRawDataLog bool) error {
+	logger := s.logContext.WithFields(logrus.Fields{
+		"handler":         "PurchaseOrderAPI",
+		"method":          "Delete",
+		"customer_id":     customerID,
+		"application_id":  applicationID,
+		"environment":     environment,
+		"microservice_id": microserviceID,
+	})
+
+	var storedMicroservice platform.HttpInputPurchaseOrderInfo
+	if deleteRawDataLog {
+		stored, statusErr := s.getStoredPurchaseOrderAPI(customerID, applicationID, environment, microserviceID, logger)
+		if statusErr != nil {
+			return statusErr
+		}
+		storedMicroservice = stored
+
+		if statusErr := s.ensureRawDataLogIsUnused(customerID, applicationID, environment, microserviceID, storedMicroservice.Extra.RawDataLogMicroserviceID, logger); statusErr != nil {
+			return statusErr
+		}
+	}
+
 	if err := s.repo.Delete(applicationID, environment, microserviceID); err != nil {
 		return fmt.Errorf("failed to delete Purchase Order API: %w", err)
 	}
+
+	if !deleteRawDataLog {
+		return nil
+	}
+
+	rawDataLogMicroserviceID := storedMicroservice.Extra.RawDataLogMicroserviceID
+	if rawDataLogMicroserviceID == "" {
+		logger.Info("The Raw Data Log was not created by the Purchase Order API, leaving it")
+		return nil
+	}
+
+	namespace := fmt.Sprintf("application-%s", applicationID)
+	if err := s.rawdatalogRepo.Delete(namespace, rawDataLogMicroserviceID); err != nil {
+		logger.WithError(err).Error("Failed to delete Raw Data Log")
+		return fmt.Errorf("failed to delete Raw Data Log: %w", err)
+	}
+
+	if err := s.gitRepo.DeleteMicroservice(customerID, applicationID, environment, rawDataLogMicroserviceID); err != nil {
+		logger.WithError(err).Error("Failed to delete Raw Data Log from GitRepo")
+		return fmt.Errorf("failed to delete Raw Data Log from GitRepo: %w", err)
+	}
 	return nil
 }
 
+// GetStatus gets the running state of the PurchaseOrderAPI microservice and whether its RawDataLog exists
+func (s *Handler) GetStatus(customerID, applicationID, environment, microserviceID string) (platform.PurchaseOrderAPIStatus, *Error) {
+	logger := s.logContext.WithFields(logrus.Fields{
+		"handler":         "PurchaseOrderAPI",
+		"method":          "GetStatus",
+		"customer_id":     customerID,
+		"application_id":  applicationID,
+		"environment":     environment,
+		"microservice_id": microserviceID,
+	})
+
+	status, err := s.repo.GetStatus(applicationID, environment, microserviceID)
+	if err != nil {
+		if errors.Is(err, platformK8s.ErrNotFound) {
+			return status, newNotFound(fmt.Errorf("purchase Order API %s not found in environment %s", microserviceID, environment))
+		}
+		logger.WithError(err).Error("Failed to get Purchase Order API status")
+		return status, newInternalError(fmt.Errorf("failed to get Purchase Order API status: %w", err))
+	}
+
+	storedMicroservice, statusErr := s.getStoredPurchaseOrderAPI(customerID, applicationID, environment, microserviceID, logger)
+	if statusErr != nil {
+		return status, statusErr
+	}
+	status.RawDataLogName = storedMicroservice.Extra.RawDataLogName
+	status.RawDataLogMicroserviceID = storedMicroservice.Extra.RawDataLogMicroserviceID
+
+	namespace := fmt.Sprintf("application-%s", applicationID)
+	status.RawDataLogExists, _, err = s.rawdatalogRepo.Exists(namespace, environment)
+	if err != nil {
+		logger.WithError(err).Error("Failed to check if Raw Data Log exists")
+		return status, newInternalError(fmt.Errorf("failed to check if Raw Data Log exists: %w", err))
+	}
+	return status, nil
+}
+
 func (s *Handler) GetDataStatus(dns, customerID, applicationID, environment, microserviceID string) (platform.PurchaseOrderStatus, *Error) {
 	logger := s.logContext.WithFields(logrus.Fields{
 		"handler":         "PurchaseOrderAPI",
@@ -216,50 +352,108 @@ func (s *Handler) createPurchaseOrderAPI(msK8sInfo k8s.MicroserviceK8sInfo, ms p
 	return nil
 }
 
-func (s *Handler) ensureRawDataLogExists(msK8sInfo k8s.MicroserviceK8sInfo, ms platform.HttpInputPurchaseOrderInfo, customerTenants []platform.CustomerTenantInfo, logger *logrus.Entry) *Error {
+// ensureRawDataLogExists returns the id of the Raw Data Log when it had to be created
+func (s *Handler) ensureRawDataLogExists(msK8sInfo k8s.MicroserviceK8sInfo, ms platform.HttpInputPurchaseOrderInfo, customerTenants []platform.CustomerTenantInfo, logger *logrus.Entry) (string, *Error) {
 	rawDataLogExists, microserviceID, err := s.rawdatalogRepo.Exists(msK8sInfo.Namespace, ms.Environment)
 	if err != nil {
 		logger.WithError(err).Error("Failed to check if Raw Data Log exists")
-		return newInternalError(fmt.Errorf("failed to check if Raw Data Log exists: %w", err))
+		return "", newInternalError(fmt.Errorf("failed to check if Raw Data Log exists: %w", err))
 	}
 	if !rawDataLogExists {
 		logger.Debug("Raw Data Log does not exist, creating a new one")
 		return s.createRawDataLog(msK8sInfo, ms, customerTenants, logger)
 	} else {
-		return s.updateRawDataLogWebhooks(msK8sInfo, ms.Extra.Webhooks, ms.Environment, microserviceID, logger)
+		return "", s.updateRawDataLogWebhooks(msK8sInfo, ms.Extra.Webhooks, ms.Environment, microserviceID, logger)
 	}
 }
 
-func (s *Handler) updatePurchaseOrderAPIWebhooks(msK8sInfo k8s.MicroserviceK8sInfo, webhooks []platform.RawDataLogIngestorWebhookConfig, environment, microserviceID string, logger *logrus.Entry) *Error {
+func (s *Handler) getStoredPurchaseOrderAPI(customerID, applicationID, environment, microserviceID string, logger *logrus.Entry) (platform.HttpInputPurchaseOrderInfo, *Error) {
 	var storedMicroservice platform.HttpInputPurchaseOrderInfo
-	bytes, err := s.gitRepo.GetMicroservice(msK8sInfo.Customer.ID, msK8sInfo.Application.ID, environment, microserviceID)
+	bytes, err := s.gitRepo.GetMicroservice(customerID, applicationID, environment, microserviceID)
 	if err != nil {
 		logger.WithError(err).Error("Failed to get Purchase Order API microservice from GitRepo")
-		return newInternalError(fmt.Errorf("failed to get Purchase Order API microservice from GitRepo: %w", err))
+		return storedMicroservice, newNotFound(fmt.Errorf("failed to get Purchase Order API microservice from GitRepo: %w", err))
 	}
 
-	json.Unmarshal(bytes, &storedMicroservice)
-	storedMicroservice.Extra.Webhooks = webhooks
+	if err := json.Unmarshal(bytes, &storedMicroservice); err != nil {
+		logger.WithError(err).Error("Failed to parse Purchase Order API microservice from GitRepo")
+		return storedMicroservice, newInternalError(fmt.Errorf("failed to parse Purchase Order API microservice from GitRepo: %w", err))
+	}
+	return storedMicroservice, nil
+}
 
-	if err := s.gitRepo.SaveMicroservice(storedMicroservice.Dolittle.CustomerID, storedMicroservice.Dolittle.ApplicationID, storedMicroservice.Environment, storedMicroservice.Dolittle.MicroserviceID, storedMicroservice); err != nil {
-		logger.WithError(err).Error("Failed to save Purchase Order API in GitRepo")
-		return newInternalError(fmt.Errorf("failed to save Purchase Order API in GitRepo: %w", err))
+// renameRawDataLog only changes the stored name of a Raw Data Log created by the Purchase Order API, the kubernetes resources keep their names
+func (s *Handler) renameRawDataLog(msK8sInfo k8s.MicroserviceK8sInfo, storedMicroservice platform.HttpInputPurchaseOrderInfo, name string, logger *logrus.Entry) *Error {
+	rawDataLogMicroserviceID := storedMicroservice.Extra.RawDataLogMicroserviceID
+	if rawDataLogMicroserviceID == "" {
+		logger.Warn("The Raw Data Log was not created by the Purchase Order API, refusing to rename it")
+		return newConflict(errors.New("the Raw Data Log was not created by the Purchase Order API and can not be renamed"))
+	}
+
+	var rawDataLog platform.HttpInputRawDataLogIngestorInfo
+	bytes, err := s.gitRepo.GetMicroservice(msK8sInfo.Customer.ID, msK8sInfo.Application.ID, storedMicroservice.Environment, rawDataLogMicroserviceID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get Raw Data Log microservice from GitRepo")
+		return newInternalError(fmt.Errorf("failed to get Raw Data Log microservice from GitRepo: %w", err))
+	}
+
+	if err := json.Unmarshal(bytes, &rawDataLog); err != nil {
+		logger.WithError(err).Error("Failed to parse Raw Data Log microservice from GitRepo")
+		return newInternalError(fmt.Errorf("failed to parse Raw Data Log microservice from GitRepo: %w", err))
+	}
+	rawDataLog.Name = name
+	if err := s.gitRepo.SaveMicroservice(rawDataLog.Dolittle.CustomerID, rawDataLog.Dolittle.ApplicationID, rawDataLog.Environment, rawDataLog.Dolittle.MicroserviceID, rawDataLog); err != nil {
+		logger.WithError(err).Error("Failed to save Raw Data Log in GitRepo")
+		return newInternalError(fmt.Errorf("failed to save Raw Data Log in GitRepo: %w", err))
 	}
 	return nil
 }
 
-func (s *Handler) createRawDataLog(msK8sInfo k8s.MicroserviceK8sInfo, ms platform.HttpInputPurchaseOrderInfo, customerTenants []platform.CustomerTenantInfo, logger *logrus.Entry) *Error {
+// ensureRawDataLogIsUnused returns a conflict when a microservice other than the Purchase Order API still uses the Raw Data Log in the environment
+func (s *Handler) ensureRawDataLogIsUnused(customerID, applicationID, environment, microserviceID, rawDataLogMicroserviceID string, logger *logrus.Entry) *Error {
+	if rawDataLogMicroserviceID == "" {
+		return nil
+	}
+
+	microservices, err := s.gitRepo.GetMicroservices(customerID, applicationID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get microservices from GitRepo")
+		return newInternalError(fmt.Errorf("failed to get microservices from GitRepo: %w", err))
+	}
+
+	for _, microservice := range microservices {
+		id := microservice.Dolittle.MicroserviceID
+		if id == microserviceID || id == rawDataLogMicroserviceID || !strings.EqualFold(microservice.Environment, environment) {
+			continue
+		}
+
+		var extra struct {
+			RawDataLogMicroserviceID string `json:"rawDataLogMicroserviceId"`
+		}
+		// An extra without the reference does not use the Raw Data Log, so decoding errors are not fatal here
+		bytes, _ := json.Marshal(microservice.Extra)
+		_ = json.Unmarshal(bytes, &extra)
+
+		if microservice.Kind == platform.MicroserviceKindPurchaseOrderAPI || extra.RawDataLogMicroserviceID == rawDataLogMicroserviceID {
+			logger.WithField("used_by", id).Warn("The Raw Data Log is still used by another microservice, refusing to delete it")
+			return newConflict(fmt.Errorf("the Raw Data Log is still used by the microservice %s", microservice.Name))
+		}
+	}
+	return nil
+}
+
+func (s *Handler) createRawDataLog(msK8sInfo k8s.MicroserviceK8sInfo, ms platform.HttpInputPurchaseOrderInfo, customerTenants []platform.CustomerTenantInfo, logger *logrus.Entry) (string, *Error) {
 	rawDataLogMicroservice := s.extractRawDataLogInfo(ms)
 	if err := s.rawdatalogRepo.Create(msK8sInfo.Namespace, msK8sInfo.Customer, msK8sInfo.Application, customerTenants, rawDataLogMicroservice); err != nil {
 		logger.WithError(err).Error("Failed to create Raw Data Log")
-		return newInternalError(fmt.Errorf("failed to create Raw Data Log: %w", err))
+		return "", newInternalError(fmt.Errorf("failed to create Raw Data Log: %w", err))
 	}
 
 	if err := s.gitRepo.SaveMicroservice(rawDataLogMicroservice.Dolittle.CustomerID, rawDataLogMicroservice.Dolittle.ApplicationID, rawDataLogMicroservice.Environment, rawDataLogMicroservice.Dolittle.MicroserviceID, rawDataLogMicroservice); err != nil {
 		logger.WithError(err).Error("Failed to save Raw Data Log in GitRepo")
-		return newInternalError(fmt.Errorf("failed to save Raw Data Log in GitRepo: %w", err))
+		return "", newInternalError(fmt.Errorf("failed to save Raw Data Log in GitRepo: %w", err))
 	}
-	return nil
+	return rawDataLogMicroservice.Dolittle.MicroserviceID, nil
 }
 
 func (s *Handler) updateRawDataLogWebhooks(msK8sInfo k8s.MicroserviceK8sInfo, webhooks []platform.RawDataLogIngestorWebhookConfig, environment, microserviceID string, logger *logrus.Entry) *Error {
@@ -270,7 +464,10 @@ func (s *Handler) updateRawDataLogWebhooks(msK8sInfo k8s.MicroserviceK8sInfo, we
 		return newInternalError(fmt.Errorf("failed to get Raw Data Log microservice from GitRepo: %w", err))
 	}
 
-	json.Unmarshal(bytes, &storedMicroservice)
+	if err := json.Unmarshal(bytes, &storedMicroservice); err != nil {
+		logger.WithError(err).Error("Failed to parse Raw Data Log microservice from GitRepo")
+		return newInternalError(fmt.Errorf("failed to parse Raw Data Log microservice from GitRepo: %w", err))
+	}
 	storedMicroservice.Extra.Webhooks = webhooks
 	if err := s.rawdatalogRepo.Update(msK8sInfo.Namespace, msK8sInfo.Customer, msK8sInfo.Application, storedMicroservice); err != nil {
 		logger.WithError(err).Error("Failed to update Raw Data Log")
@@ -0,0 +1,244 @@
+package purchaseorderapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	mockPurchaseOrderAPI "github.com/dolittle/platform-api/mocks/pkg/platform/microservice/purchaseorderapi"
+	mockStorage "github.com/dolittle/platform-api/mocks/pkg/platform/storage"
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/parser"
+	. "github.com/dolittle/platform-api/pkg/platform/microservice/purchaseorderapi"
+	"github.com/dolittle/platform-api/pkg/platform/microservice/rawdatalog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("For handler", func() {
+	var (
+		customerID         string
+		applicationID      string
+		microserviceID     string
+		rawDataLogID       string
+		namespace          string
+		applicationInfo    platform.Application
+		stored             platform.HttpInputPurchaseOrderInfo
+		storedRawDataLog   platform.HttpInputRawDataLogIngestorInfo
+		otherMicroservices []platform.HttpMicroserviceBase
+		objects            []runtime.Object
+		repo               *mockPurchaseOrderAPI.Repo
+		gitRepo            *mockStorage.Repo
+		handler            *Handler
+	)
+
+	input := func(rawDataLogName string) []byte {
+		ms := stored
+		ms.Extra.RawDataLogName = rawDataLogName
+		ms.Extra.RawDataLogMicroserviceID = ""
+		ms.Extra.Headimage = "dolittle/purchaseorderapi:2.0.0"
+		bytes, _ := json.Marshal(ms)
+		return bytes
+	}
+
+	BeforeEach(func() {
+		customerID = "453e04a7-4f9d-42f2-b36c-d51fa2c83fa3"
+		applicationID = "11b6cf47-5d9f-438f-8116-0d9828654657"
+		microserviceID = "0d1cf5f2-3d1b-4a47-9d2a-7f7a0c1b2a11"
+		rawDataLogID = "9f6a6d2e-4c0b-4a3e-8a55-1b0c6b7c8d22"
+		namespace = "application-" + applicationID
+		applicationInfo = platform.Application{
+			ID:       applicationID,
+			Name:     "Taco",
+			Customer: platform.Tenant{ID: customerID, Name: "Customer-Chris"},
+		}
+		stored = platform.HttpInputPurchaseOrderInfo{
+			MicroserviceBase: platform.MicroserviceBase{
+				Name:        "PurchaseOrderAPI",
+				Kind:        platform.MicroserviceKindPurchaseOrderAPI,
+				Environment: "Dev",
+				Dolittle: platform.HttpInputDolittle{
+					CustomerID:     customerID,
+					ApplicationID:  applicationID,
+					MicroserviceID: microserviceID,
+				},
+			},
+			Extra: platform.HttpInputPurchaseOrderExtra{
+				Headimage:                "dolittle/purchaseorderapi:1.0.0",
+				RawDataLogName:           "raw-data-log",
+				RawDataLogMicroserviceID: rawDataLogID,
+			},
+		}
+		storedRawDataLog = platform.HttpInputRawDataLogIngestorInfo{
+			MicroserviceBase: platform.MicroserviceBase{
+				Name:        "raw-data-log",
+				Kind:        platform.MicroserviceKindRawDataLogIngestor,
+				Environment: "Dev",
+				Dolittle: platform.HttpInputDolittle{
+					CustomerID:     customerID,
+					ApplicationID:  applicationID,
+					MicroserviceID: rawDataLogID,
+				},
+			},
+		}
+		otherMicroservices = []platform.HttpMicroserviceBase{}
+		objects = []runtime.Object{
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dev-raw-data-log",
+					Namespace: namespace,
+					Labels:    map[string]string{"microservice": "raw-data-log", "environment": "Dev"},
+					Annotations: map[string]string{
+						"dolittle.io/microservice-kind": string(platform.MicroserviceKindRawDataLogIngestor),
+						"dolittle.io/microservice-id":   rawDataLogID,
+					},
+				},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-raw-data-log-config-files", Namespace: namespace},
+			},
+		}
+
+		repo = new(mockPurchaseOrderAPI.Repo)
+		repo.On("EnvironmentHasPurchaseOrderAPI", mock.Anything, mock.Anything).Return(true, nil)
+		gitRepo = new(mockStorage.Repo)
+	})
+
+	JustBeforeEach(func() {
+		storedBytes, _ := json.Marshal(stored)
+		rawDataLogBytes, _ := json.Marshal(storedRawDataLog)
+		gitRepo.On("GetMicroservice", customerID, applicationID, "Dev", microserviceID).Return(storedBytes, nil)
+		gitRepo.On("GetMicroservice", customerID, applicationID, "Dev", rawDataLogID).Return(rawDataLogBytes, nil)
+		gitRepo.On("GetMicroservices", customerID, applicationID).Return(append([]platform.HttpMicroserviceBase{
+			{MicroserviceBase: stored.MicroserviceBase},
+			{MicroserviceBase: storedRawDataLog.MicroserviceBase},
+		}, otherMicroservices...), nil)
+
+		logger, _ := logrusTest.NewNullLogger()
+		clientSet := fake.NewSimpleClientset(objects...)
+		// The fake clientset does not serve the scale subresource
+		clientSet.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "scale" {
+				return false, nil, nil
+			}
+			return true, &autoscalingv1.Scale{}, nil
+		})
+		rawDataLogRepo := rawdatalog.NewRawDataLogIngestorRepo(false, platformK8s.NewK8sRepo(clientSet, nil, logger), clientSet, logger)
+		handler = NewHandler(parser.NewJsonParser(), repo, gitRepo, rawDataLogRepo, logger)
+	})
+
+	Describe("updating a Purchase Order API", func() {
+		It("should rename the Raw Data Log it created and update the images last", func() {
+			var order []string
+			gitRepo.On("SaveMicroservice", customerID, applicationID, "Dev", rawDataLogID, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				order = append(order, "raw data log "+args.Get(4).(platform.HttpInputRawDataLogIngestorInfo).Name)
+			})
+			gitRepo.On("SaveMicroservice", customerID, applicationID, "Dev", microserviceID, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				order = append(order, "save")
+			})
+			repo.On("UpdateImages", applicationID, "Dev", microserviceID, "dolittle/purchaseorderapi:2.0.0", "").Return(nil).Run(func(args mock.Arguments) {
+				order = append(order, "images")
+			})
+
+			updated, err := handler.Update(input("renamed"), applicationInfo, []platform.CustomerTenantInfo{})
+			Expect(err).To(BeNil())
+			Expect(updated.Extra.RawDataLogName).To(Equal("renamed"))
+			Expect(updated.Extra.Headimage).To(Equal("dolittle/purchaseorderapi:2.0.0"))
+			Expect(updated.Extra.RawDataLogMicroserviceID).To(Equal(rawDataLogID))
+			Expect(order).To(Equal([]string{"raw data log raw-data-log", "raw data log renamed", "save", "images"}))
+		})
+
+		Describe("when the Raw Data Log was not created by it", func() {
+			BeforeEach(func() {
+				stored.Extra.RawDataLogMicroserviceID = ""
+			})
+
+			It("should refuse to rename it", func() {
+				_, err := handler.Update(input("renamed"), applicationInfo, []platform.CustomerTenantInfo{})
+				Expect(err).NotTo(BeNil())
+				Expect(err.StatusCode).To(Equal(http.StatusConflict))
+				gitRepo.AssertNotCalled(GinkgoT(), "SaveMicroservice", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				repo.AssertNotCalled(GinkgoT(), "UpdateImages", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+		})
+
+		It("should not update the images when saving fails", func() {
+			gitRepo.On("SaveMicroservice", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("git is down"))
+
+			_, err := handler.Update(input("raw-data-log"), applicationInfo, []platform.CustomerTenantInfo{})
+			Expect(err).NotTo(BeNil())
+			Expect(err.StatusCode).To(Equal(http.StatusInternalServerError))
+			repo.AssertNotCalled(GinkgoT(), "UpdateImages", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("deleting a Purchase Order API", func() {
+		BeforeEach(func() {
+			repo.On("Delete", applicationID, "Dev", microserviceID).Return(nil)
+		})
+
+		It("should delete the Raw Data Log it created when asked to", func() {
+			gitRepo.On("DeleteMicroservice", customerID, applicationID, "Dev", rawDataLogID).Return(nil)
+
+			Expect(handler.Delete(customerID, applicationID, "Dev", microserviceID, true)).To(Succeed())
+			repo.AssertCalled(GinkgoT(), "Delete", applicationID, "Dev", microserviceID)
+			gitRepo.AssertCalled(GinkgoT(), "DeleteMicroservice", customerID, applicationID, "Dev", rawDataLogID)
+		})
+
+		It("should leave the Raw Data Log when not asked to delete it", func() {
+			Expect(handler.Delete(customerID, applicationID, "Dev", microserviceID, false)).To(Succeed())
+			gitRepo.AssertNotCalled(GinkgoT(), "DeleteMicroservice", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		Describe("when another microservice uses the Raw Data Log", func() {
+			BeforeEach(func() {
+				otherMicroservices = append(otherMicroservices, platform.HttpMicroserviceBase{
+					MicroserviceBase: platform.MicroserviceBase{
+						Name:        "Reader",
+						Kind:        platform.MicroserviceKindSimple,
+						Environment: "Dev",
+						Dolittle:    platform.HttpInputDolittle{MicroserviceID: "5c3b8c8e-0a0f-4b8e-9a3d-2f1e0d9c8b33"},
+					},
+					Extra: map[string]interface{}{"rawDataLogMicroserviceId": rawDataLogID},
+				})
+			})
+
+			It("should refuse to delete anything", func() {
+				err := handler.Delete(customerID, applicationID, "Dev", microserviceID, true)
+				var handlerErr *Error
+				Expect(errors.As(err, &handlerErr)).To(BeTrue())
+				Expect(handlerErr.StatusCode).To(Equal(http.StatusConflict))
+				repo.AssertNotCalled(GinkgoT(), "Delete", mock.Anything, mock.Anything, mock.Anything)
+			})
+		})
+
+		Describe("when another Purchase Order API is in the environment", func() {
+			BeforeEach(func() {
+				otherMicroservices = append(otherMicroservices, platform.HttpMicroserviceBase{
+					MicroserviceBase: platform.MicroserviceBase{
+						Name:        "OtherPurchaseOrderAPI",
+						Kind:        platform.MicroserviceKindPurchaseOrderAPI,
+						Environment: "Dev",
+						Dolittle:    platform.HttpInputDolittle{MicroserviceID: "7e2d1c0b-9a8f-4e7d-8c6b-5a4f3e2d1c44"},
+					},
+				})
+			})
+
+			It("should refuse to delete the Raw Data Log", func() {
+				err := handler.Delete(customerID, applicationID, "Dev", microserviceID, true)
+				Expect(err).NotTo(BeNil())
+				repo.AssertNotCalled(GinkgoT(), "Delete", mock.Anything, mock.Anything, mock.Anything)
+			})
+		})
+	})
+})
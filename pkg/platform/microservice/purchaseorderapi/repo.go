@@ -7,7 +7,9 @@ import (
 	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
 	"github.com/dolittle/platform-api/pkg/k8s"
 	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/automate"
 	microserviceK8s "github.com/dolittle/platform-api/pkg/platform/microservice/k8s"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -104,3 +106,65 @@ func (r *repo) Delete(applicationID, environment, microserviceID string) error {
 	ctx := context.TODO()
 	return r.k8sResource.Delete(ctx, applicationID, environment, microserviceID)
 }
+
+// UpdateImages changes the images of the head and runtime containers of the deployment
+func (r *repo) UpdateImages(applicationID, environment, microserviceID, headImage, runtimeImage string) error {
+	ctx := context.TODO()
+	deployment, err := automate.GetDeployment(ctx, r.k8sClient, applicationID, environment, microserviceID)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for containerName, image := range map[string]string{"head": headImage, "runtime": runtimeImage} {
+		if image == "" {
+			continue
+		}
+
+		index := automate.GetContainerIndex(deployment, containerName)
+		if index == -1 {
+			return fmt.Errorf("purchase order api deployment %s has no %s container", deployment.Name, containerName)
+		}
+
+		container := &deployment.Spec.Template.Spec.Containers[index]
+		if container.Image == image {
+			continue
+		}
+		container.Image = image
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	_, err = r.k8sClient.AppsV1().Deployments(deployment.Namespace).Update(ctx, &deployment, metaV1.UpdateOptions{})
+	return err
+}
+
+// GetStatus gets the images and replicas from the deployment
+func (r *repo) GetStatus(applicationID, environment, microserviceID string) (platform.PurchaseOrderAPIStatus, error) {
+	status := platform.PurchaseOrderAPIStatus{
+		MicroserviceID: microserviceID,
+		Environment:    environment,
+	}
+
+	ctx := context.TODO()
+	deployment, err := automate.GetDeployment(ctx, r.k8sClient, applicationID, environment, microserviceID)
+	if err != nil {
+		return status, err
+	}
+
+	if index := automate.GetContainerIndex(deployment, "head"); index != -1 {
+		status.HeadImage = deployment.Spec.Template.Spec.Containers[index].Image
+	}
+	if index := automate.GetContainerIndex(deployment, "runtime"); index != -1 {
+		status.RuntimeImage = deployment.Spec.Template.Spec.Containers[index].Image
+	}
+
+	if deployment.Spec.Replicas != nil {
+		status.Replicas = *deployment.Spec.Replicas
+	}
+	status.AvailableReplicas = deployment.Status.AvailableReplicas
+	return status, nil
+}
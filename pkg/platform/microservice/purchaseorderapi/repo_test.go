@@ -1,17 +1,20 @@
 package purchaseorderapi_test
 
 import (
+	"context"
 	"fmt"
 
 	dolittleK8s "github.com/dolittle/platform-api/pkg/dolittle/k8s"
 	"github.com/dolittle/platform-api/pkg/k8s"
 	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
 	. "github.com/dolittle/platform-api/pkg/platform/microservice/purchaseorderapi"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
 	logrusTest "github.com/sirupsen/logrus/hooks/test"
 	v1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -977,6 +980,113 @@ var _ = Describe("For repo", func() {
 	})
 })
 
+var _ = Describe("For repo with a running purchase order api", func() {
+	var (
+		repo           Repo
+		client         *fake.Clientset
+		customer       dolittleK8s.Tenant
+		application    dolittleK8s.Application
+		namespace      string
+		environment    string
+		microserviceID string
+		deployment     *v1.Deployment
+		err            error
+	)
+
+	BeforeEach(func() {
+		logger, _ := logrusTest.NewNullLogger()
+		customer = dolittleK8s.Tenant{
+			Name: "tenant-name",
+			ID:   "67dcf38f-16e4-4b57-bff5-707cff3233ec",
+		}
+		application = dolittleK8s.Application{
+			Name: "application-name",
+			ID:   "c1e08289-be4b-4557-9457-5de90e0ea54a",
+		}
+		namespace = fmt.Sprintf("application-%s", application.ID)
+		environment = "Dev"
+		microserviceID = "ef97a13b-2597-42a3-9fcb-161add2264c7"
+
+		deployment = newDeploymentFrom(customer, application, environment, "purchase-order-api", microserviceID, platform.MicroserviceKindPurchaseOrderAPI)
+		deployment.Status.AvailableReplicas = 1
+
+		client = fake.NewSimpleClientset(deployment)
+		k8sResourceSpecFactory := NewK8sResourceSpecFactory()
+		k8sResource := NewK8sResource(client, k8sResourceSpecFactory)
+		k8sRepoV2 := k8s.NewRepo(client, logger.WithField("context", "k8s-repo-v2"))
+		repo = NewRepo(k8sResource, k8sResourceSpecFactory, client, k8sRepoV2)
+	})
+
+	getImages := func() (string, string) {
+		updated, getErr := client.AppsV1().Deployments(namespace).Get(context.TODO(), deployment.Name, metaV1.GetOptions{})
+		Expect(getErr).To(BeNil())
+		containers := updated.Spec.Template.Spec.Containers
+		return containers[0].Image, containers[1].Image
+	}
+
+	Describe("when updating the images", func() {
+		Describe("and both are given", func() {
+			BeforeEach(func() {
+				err = repo.UpdateImages(application.ID, environment, microserviceID, "head-image:2.0.0", "runtime-image:7.0.0")
+			})
+
+			It("should not fail", func() {
+				Expect(err).To(BeNil())
+			})
+			It("should update both containers", func() {
+				head, runtime := getImages()
+				Expect(head).To(Equal("head-image:2.0.0"))
+				Expect(runtime).To(Equal("runtime-image:7.0.0"))
+			})
+		})
+
+		Describe("and only the head image is given", func() {
+			BeforeEach(func() {
+				err = repo.UpdateImages(application.ID, environment, microserviceID, "head-image:2.0.0", "")
+			})
+
+			It("should not fail", func() {
+				Expect(err).To(BeNil())
+			})
+			It("should leave the runtime image", func() {
+				head, runtime := getImages()
+				Expect(head).To(Equal("head-image:2.0.0"))
+				Expect(runtime).To(Equal("runtime-image:shouldnt-matter"))
+			})
+		})
+
+		Describe("and the microservice is in another environment", func() {
+			BeforeEach(func() {
+				err = repo.UpdateImages(application.ID, "Prod", microserviceID, "head-image:2.0.0", "")
+			})
+
+			It("should not be found", func() {
+				Expect(err).To(Equal(platformK8s.ErrNotFound))
+			})
+		})
+	})
+
+	Describe("when getting the status", func() {
+		var status platform.PurchaseOrderAPIStatus
+
+		BeforeEach(func() {
+			status, err = repo.GetStatus(application.ID, environment, microserviceID)
+		})
+
+		It("should not fail", func() {
+			Expect(err).To(BeNil())
+		})
+		It("should have the images", func() {
+			Expect(status.HeadImage).To(Equal("head-image:shouldnt-matter"))
+			Expect(status.RuntimeImage).To(Equal("runtime-image:shouldnt-matter"))
+		})
+		It("should have the replicas", func() {
+			Expect(status.Replicas).To(Equal(int32(1)))
+			Expect(status.AvailableReplicas).To(Equal(int32(1)))
+		})
+	})
+})
+
 func newPurchaseOrderAPICreateInput(customer dolittleK8s.Tenant, application dolittleK8s.Application, environment, name string) platform.HttpInputPurchaseOrderInfo {
 	return platform.HttpInputPurchaseOrderInfo{
 		MicroserviceBase: platform.MicroserviceBase{
@@ -72,3 +72,35 @@ func (s *service) GetDataStatus(responseWriter http.ResponseWriter, request *htt
 	}
 	utils.RespondWithJSON(responseWriter, http.StatusAccepted, status)
 }
+
+func (s *service) GetStatus(responseWriter http.ResponseWriter, request *http.Request) {
+	userID := request.Header.Get("User-ID")
+	customerID := request.Header.Get("Tenant-ID")
+
+	vars := mux.Vars(request)
+	applicationID := vars["applicationID"]
+	environment := vars["environment"]
+	microserviceID := vars["microserviceID"]
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"service":         "PurchaseOrderAPI",
+		"method":          "GetStatus",
+		"customer_id":     customerID,
+		"application_id":  applicationID,
+		"environment":     environment,
+		"microservice_id": microserviceID,
+	})
+
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(responseWriter, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	status, getError := s.handler.GetStatus(customerID, applicationID, environment, microserviceID)
+	if getError != nil {
+		logger.WithError(getError).Error("Failed to get the microservices status")
+		utils.RespondWithError(responseWriter, getError.StatusCode, getError.Error())
+		return
+	}
+	utils.RespondWithJSON(responseWriter, http.StatusOK, status)
+}
@@ -2,7 +2,6 @@ package rawdatalog
 
 import (
 	"errors"
-	"log"
 
 	"github.com/dolittle/platform-api/pkg/dolittle/k8s"
 	"github.com/dolittle/platform-api/pkg/platform"
@@ -17,6 +16,7 @@ import (
 	"strings"
 
 	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	microserviceK8s "github.com/dolittle/platform-api/pkg/platform/microservice/k8s"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -159,19 +159,28 @@ func (r RawDataLogIngestorRepo) Create(namespace string, customer k8s.Tenant, ap
 		return errors.New("no-customer-tenants")
 	}
 
+	// Only what is created here is removed again if a later step fails
+	created := microserviceK8s.NewCreatedResources(r.k8sClient, namespace)
+	rollback := func(err error) error {
+		if rollbackErr := created.Rollback(context.TODO()); rollbackErr != nil {
+			logger.WithError(rollbackErr).Error("Could not roll back the RawDataLog microservice")
+		}
+		return err
+	}
+
 	// TODO changing writeTo will break this.
 	if input.Extra.WriteTo != "stdout" {
 
 		action := "upsert"
-		if err := r.doNats(namespace, labels, annotations, input, action); err != nil {
+		if err := r.doNats(namespace, labels, annotations, input, action, created); err != nil {
 			logger.WithError(err).Error("Could not doNats")
-			return err
+			return rollback(err)
 		}
 	}
 
-	if err := r.doDolittle(namespace, customer, application, customerTenants, input); err != nil {
+	if err := r.doDolittle(namespace, customer, application, customerTenants, input, created); err != nil {
 		logger.WithError(err).Error("Could not doDolittle")
-		return err
+		return rollback(err)
 	}
 	return nil
 }
@@ -223,8 +232,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 		Deployments(namespace).
 		GetScale(ctx, foundDeployment.Name, metaV1.GetOptions{})
 	if err != nil {
-		log.Fatal(err)
-		return errors.New("issue")
+		return err
 	}
 
 	sc := *s
@@ -234,8 +242,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 			Deployments(namespace).
 			UpdateScale(ctx, foundDeployment.Name, &sc, metaV1.UpdateOptions{})
 		if err != nil {
-			log.Fatal(err)
-			return errors.New("todo")
+			return err
 		}
 	}
 
@@ -250,8 +257,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 	for _, config := range configs.Items {
 		err = client.CoreV1().ConfigMaps(namespace).Delete(ctx, config.Name, metaV1.DeleteOptions{})
 		if err != nil {
-			log.Fatal(err)
-			return errors.New("todo")
+			return err
 		}
 	}
 
@@ -260,8 +266,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 	for _, secret := range secrets.Items {
 		err = client.CoreV1().Secrets(namespace).Delete(ctx, secret.Name, metaV1.DeleteOptions{})
 		if err != nil {
-			log.Fatal(err)
-			return errors.New("todo")
+			return err
 		}
 	}
 
@@ -270,8 +275,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 	for _, ingress := range ingresses.Items {
 		err = client.NetworkingV1().Ingresses(namespace).Delete(ctx, ingress.Name, metaV1.DeleteOptions{})
 		if err != nil {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 	}
 
@@ -280,8 +284,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 	for _, policy := range policies.Items {
 		err = client.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, policy.Name, metaV1.DeleteOptions{})
 		if err != nil {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 	}
 
@@ -290,8 +293,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 	for _, service := range services.Items {
 		err = client.CoreV1().Services(namespace).Delete(ctx, service.Name, metaV1.DeleteOptions{})
 		if err != nil {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 	}
 
@@ -300,8 +302,7 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 	for _, stateful := range statefulSets.Items {
 		err = client.AppsV1().StatefulSets(namespace).Delete(ctx, stateful.Name, metaV1.DeleteOptions{})
 		if err != nil {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 	}
 
@@ -310,15 +311,14 @@ func (r RawDataLogIngestorRepo) Delete(namespace string, microserviceID string)
 		Deployments(namespace).
 		Delete(ctx, foundDeployment.Name, metaV1.DeleteOptions{})
 	if err != nil {
-		log.Fatal(err)
-		return errors.New("todo")
+		return err
 	}
 
 	return nil
 }
 
 // Creates or deletes the statefulset, service and configmap of the given statefulset, service and configmap
-func (r RawDataLogIngestorRepo) doStatefulService(namespace string, configMap *corev1.ConfigMap, service *corev1.Service, statfulset *appsv1.StatefulSet, action string) error {
+func (r RawDataLogIngestorRepo) doStatefulService(namespace string, configMap *corev1.ConfigMap, service *corev1.Service, statfulset *appsv1.StatefulSet, action string, created *microserviceK8s.CreatedResources) error {
 	ctx := context.TODO()
 
 	if action == "delete" {
@@ -340,7 +340,7 @@ func (r RawDataLogIngestorRepo) doStatefulService(namespace string, configMap *c
 
 	if existing, err := r.k8sClient.CoreV1().ConfigMaps(namespace).Get(ctx, configMap.GetName(), metaV1.GetOptions{}); err != nil {
 		if k8serrors.IsNotFound(err) {
-			if _, err := r.k8sClient.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metaV1.CreateOptions{}); err != nil {
+			if err := created.ConfigMap(ctx, configMap); err != nil {
 				return err
 			}
 		} else {
@@ -355,7 +355,7 @@ func (r RawDataLogIngestorRepo) doStatefulService(namespace string, configMap *c
 
 	if existing, err := r.k8sClient.CoreV1().Services(namespace).Get(ctx, service.GetName(), metaV1.GetOptions{}); err != nil {
 		if k8serrors.IsNotFound(err) {
-			if _, err := r.k8sClient.CoreV1().Services(namespace).Create(ctx, service, metaV1.CreateOptions{}); err != nil {
+			if err := created.Service(ctx, service); err != nil {
 				return err
 			}
 		} else {
@@ -370,7 +370,7 @@ func (r RawDataLogIngestorRepo) doStatefulService(namespace string, configMap *c
 
 	if existing, err := r.k8sClient.AppsV1().StatefulSets(namespace).Get(ctx, statfulset.GetName(), metaV1.GetOptions{}); err != nil {
 		if k8serrors.IsNotFound(err) {
-			if _, err := r.k8sClient.AppsV1().StatefulSets(namespace).Create(ctx, statfulset, metaV1.CreateOptions{}); err != nil {
+			if err := created.StatefulSet(ctx, statfulset); err != nil {
 				return err
 			}
 		} else {
@@ -391,7 +391,7 @@ func (r RawDataLogIngestorRepo) doStatefulService(namespace string, configMap *c
 	return nil
 }
 
-func (r RawDataLogIngestorRepo) doNats(namespace string, labels, annotations k8slabels.Set, input platform.HttpInputRawDataLogIngestorInfo, action string) error {
+func (r RawDataLogIngestorRepo) doNats(namespace string, labels, annotations k8slabels.Set, input platform.HttpInputRawDataLogIngestorInfo, action string, created *microserviceK8s.CreatedResources) error {
 	r.logContext.WithFields(logrus.Fields{
 		"namespace": namespace,
 		"method":    "RawDataLogIngestorRepo.doNats",
@@ -407,10 +407,10 @@ func (r RawDataLogIngestorRepo) doNats(namespace string, labels, annotations k8s
 	nats := createNatsResources(namespace, environment, natsLabels, annotations)
 	stan := createStanResources(namespace, environment, stanLabels, annotations)
 
-	if err := r.doStatefulService(namespace, nats.configMap, nats.service, nats.statfulset, action); err != nil {
+	if err := r.doStatefulService(namespace, nats.configMap, nats.service, nats.statfulset, action, created); err != nil {
 		return err
 	}
-	if err := r.doStatefulService(namespace, stan.configMap, stan.service, stan.statfulset, action); err != nil {
+	if err := r.doStatefulService(namespace, stan.configMap, stan.service, stan.statfulset, action, created); err != nil {
 		return err
 	}
 
@@ -419,7 +419,7 @@ func (r RawDataLogIngestorRepo) doNats(namespace string, labels, annotations k8s
 
 // Creates the RawDataLog microservice in k8s
 // TODO this tenant is wrong
-func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant, application k8s.Application, customerTenants []platform.CustomerTenantInfo, input platform.HttpInputRawDataLogIngestorInfo) error {
+func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant, application k8s.Application, customerTenants []platform.CustomerTenantInfo, input platform.HttpInputRawDataLogIngestorInfo, created *microserviceK8s.CreatedResources) error {
 	isProduction := r.isProduction
 	r.logContext.WithFields(logrus.Fields{
 		"namespace": namespace,
@@ -504,12 +504,11 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 	ctx := context.TODO()
 
 	// ConfigMaps
-	_, err := client.CoreV1().ConfigMaps(namespace).Create(ctx, microserviceConfigmap, metaV1.CreateOptions{})
+	err := created.ConfigMap(ctx, microserviceConfigmap)
 
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 
 		_, err = client.CoreV1().ConfigMaps(namespace).Update(ctx, microserviceConfigmap, metaV1.UpdateOptions{})
@@ -521,11 +520,10 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 		}
 	}
 
-	_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, configEnvVariables, metaV1.CreateOptions{})
+	err = created.ConfigMap(ctx, configEnvVariables)
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 
 		_, err = client.CoreV1().ConfigMaps(namespace).Update(ctx, configEnvVariables, metaV1.UpdateOptions{})
@@ -536,11 +534,10 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 		}
 	}
 
-	_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, configFiles, metaV1.CreateOptions{})
+	err = created.ConfigMap(ctx, configFiles)
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 
 		_, err = client.CoreV1().ConfigMaps(namespace).Update(ctx, configFiles, metaV1.UpdateOptions{})
@@ -552,11 +549,10 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 	}
 
 	// Secrets
-	_, err = client.CoreV1().Secrets(namespace).Create(ctx, configSecrets, metaV1.CreateOptions{})
+	err = created.Secret(ctx, configSecrets)
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 
 		_, err = client.CoreV1().Secrets(namespace).Update(ctx, configSecrets, metaV1.UpdateOptions{})
@@ -568,11 +564,10 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 	}
 
 	// Ingress
-	_, err = client.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, metaV1.CreateOptions{})
+	err = created.Ingress(ctx, ingress)
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 
 		_, err = client.NetworkingV1().Ingresses(namespace).Update(ctx, ingress, metaV1.UpdateOptions{})
@@ -584,11 +579,10 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 	}
 
 	// Service
-	_, err = client.CoreV1().Services(namespace).Create(ctx, service, metaV1.CreateOptions{})
+	err = created.Service(ctx, service)
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 		// TODO this breaks
 		// I think I need to be strict about what is changeable
@@ -613,11 +607,10 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 	}
 
 	// NetworkPolicy
-	_, err = client.NetworkingV1().NetworkPolicies(namespace).Create(ctx, networkPolicy, metaV1.CreateOptions{})
+	err = created.NetworkPolicy(ctx, networkPolicy)
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 
 		fmt.Println("Network Policy already exists")
@@ -628,11 +621,10 @@ func (r RawDataLogIngestorRepo) doDolittle(namespace string, customer k8s.Tenant
 		}
 	}
 
-	_, err = client.AppsV1().Deployments(namespace).Create(ctx, deployment, metaV1.CreateOptions{})
+	err = created.Deployment(ctx, deployment)
 	if err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
-			log.Fatal(err)
-			return errors.New("issue")
+			return err
 		}
 
 		_, err = client.AppsV1().Deployments(namespace).Update(ctx, deployment, metaV1.UpdateOptions{})
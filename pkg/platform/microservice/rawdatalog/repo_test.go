@@ -1,6 +1,8 @@
 package rawdatalog_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	. "github.com/onsi/ginkgo"
@@ -13,6 +15,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -94,6 +97,42 @@ var _ = Describe("Repo", func() {
 			})
 		})
 
+		Context("and creating the deployment fails", func() {
+			BeforeEach(func() {
+				clientSet.PrependReactor("create", "deployments", func(action testing.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("deployment failed")
+				})
+				customerTenants := []platform.CustomerTenantInfo{
+					{
+						CustomerTenantID: "f4679b71-1215-4a60-8483-53b0d5f2bb47",
+						Hosts: []platform.CustomerTenantHost{
+							{
+								Host:       "some-fancy.domain.name",
+								SecretName: "some-fancy-certificate",
+							},
+						},
+					},
+				}
+				err = rawDataLogRepo.Create(namespace, customer, application, customerTenants, input)
+			})
+
+			It("should fail with an error", func() {
+				Expect(err).ToNot(BeNil())
+			})
+			It("should remove the statefulsets it created", func() {
+				statefulSets, _ := clientSet.AppsV1().StatefulSets(namespace).List(context.TODO(), metaV1.ListOptions{})
+				Expect(statefulSets.Items).To(BeEmpty())
+			})
+			It("should remove the configmaps it created", func() {
+				configMaps, _ := clientSet.CoreV1().ConfigMaps(namespace).List(context.TODO(), metaV1.ListOptions{})
+				Expect(configMaps.Items).To(BeEmpty())
+			})
+			It("should remove the ingress it created", func() {
+				ingresses, _ := clientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), metaV1.ListOptions{})
+				Expect(ingresses.Items).To(BeEmpty())
+			})
+		})
+
 		Context("and an application exists but no other resources", func() {
 			var (
 				natsConfigMap             *corev1.ConfigMap
@@ -0,0 +1,140 @@
+package rawdatalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/storage"
+	ingestor "github.com/dolittle/platform-api/pkg/rawdatalog"
+	"github.com/dolittle/platform-api/pkg/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookStatsPath is where the raw data log ingestor exposes its webhook stats
+const WebhookStatsPath = ingestor.WebhookStatsPath
+
+var (
+	ErrWebhookStatsNotEnabled = errors.New("webhook stats are not enabled for this raw data log ingestor, set webhookStatsAuthorization")
+)
+
+type service struct {
+	gitRepo         storage.Repo
+	k8sDolittleRepo platformK8s.K8sRepo
+	client          *http.Client
+	logContext      logrus.FieldLogger
+}
+
+func NewService(gitRepo storage.Repo, k8sDolittleRepo platformK8s.K8sRepo, logContext logrus.FieldLogger) service {
+	return service{
+		gitRepo:         gitRepo,
+		k8sDolittleRepo: k8sDolittleRepo,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logContext: logContext,
+	}
+}
+
+// GetWebhookStats proxies the webhook stats of the raw data log ingestor, for Studio
+func (s *service) GetWebhookStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("User-ID")
+	customerID := r.Header.Get("Tenant-ID")
+
+	vars := mux.Vars(r)
+	applicationID := vars["applicationID"]
+	environment := strings.ToLower(vars["environment"])
+	microserviceID := vars["microserviceID"]
+
+	logger := s.logContext.WithFields(logrus.Fields{
+		"service":         "RawDataLogIngestor",
+		"method":          "GetWebhookStats",
+		"customer_id":     customerID,
+		"application_id":  applicationID,
+		"environment":     environment,
+		"microservice_id": microserviceID,
+	})
+
+	allowed := s.k8sDolittleRepo.CanModifyApplicationWithResponse(w, customerID, applicationID, userID)
+	if !allowed {
+		return
+	}
+
+	data, err := s.gitRepo.GetMicroservice(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get the microservice from storage")
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var ms platform.HttpInputRawDataLogIngestorInfo
+	err = json.Unmarshal(data, &ms)
+	if err != nil || ms.Kind != platform.MicroserviceKindRawDataLogIngestor {
+		logger.Error("Microservice is not a raw data log ingestor")
+		utils.RespondWithError(w, http.StatusBadRequest, "microservice is not a raw data log ingestor")
+		return
+	}
+
+	if ms.Extra.WebhookStatsAuthorization == "" {
+		utils.RespondWithError(w, http.StatusNotFound, ErrWebhookStatsNotEnabled.Error())
+		return
+	}
+
+	dns, err := s.k8sDolittleRepo.GetMicroserviceDNS(applicationID, microserviceID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get the microservices DNS")
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	stats, err := GetWebhookStats(s.client, dns, ms.Extra.WebhookStatsAuthorization)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get the webhook stats")
+		utils.RespondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	stats.MicroserviceID = microserviceID
+	utils.RespondWithJSON(w, http.StatusOK, stats)
+}
+
+// GetWebhookStats requests the webhook stats from the raw data log ingestor running at dns
+func GetWebhookStats(client *http.Client, dns string, authorization string) (platform.HttpResponseRawDataLogIngestorWebhookStats, error) {
+	var stats platform.HttpResponseRawDataLogIngestorWebhookStats
+
+	url := fmt.Sprintf("http://%s%s", dns, WebhookStatsPath)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return stats, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return stats, fmt.Errorf("failed to request raw data log ingestor webhook stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return stats, fmt.Errorf("raw data log ingestor webhook stats returned %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read response for raw data log ingestor webhook stats: %w", err)
+	}
+
+	err = json.Unmarshal(body, &stats)
+	if err != nil {
+		return stats, fmt.Errorf("failed to parse response for raw data log ingestor webhook stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,57 @@
+package rawdatalog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	. "github.com/dolittle/platform-api/pkg/platform/microservice/rawdatalog"
+	"github.com/dolittle/platform-api/pkg/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service", func() {
+	Describe("when getting webhook stats", func() {
+		var (
+			server *httptest.Server
+			dns    string
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != WebhookStatsPath || r.Header.Get("Authorization") != "Bearer stats" {
+					utils.RespondWithError(w, http.StatusForbidden, "no")
+					return
+				}
+				utils.RespondWithJSON(w, http.StatusOK, platform.HttpResponseRawDataLogIngestorWebhookStats{
+					ApplicationID: "application",
+					Environment:   "dev",
+					Webhooks: []platform.RawDataLogIngestorWebhookStats{
+						{UriSuffix: "m3/item", Accepted: 3},
+					},
+				})
+			}))
+			dns = strings.TrimPrefix(server.URL, "http://")
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should return the stats from the ingestor", func() {
+			stats, err := GetWebhookStats(server.Client(), dns, "Bearer stats")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.ApplicationID).To(Equal("application"))
+			Expect(stats.Webhooks).To(Equal([]platform.RawDataLogIngestorWebhookStats{
+				{UriSuffix: "m3/item", Accepted: 3},
+			}))
+		})
+
+		It("should fail when the ingestor rejects the authorization", func() {
+			_, err := GetWebhookStats(server.Client(), dns, "Bearer wrong")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -2,6 +2,7 @@ package microservice
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -159,18 +160,17 @@ func (s *service) Create(w http.ResponseWriter, request *http.Request) {
 	case platform.MicroserviceKindSimple:
 		environmentInfo, _ := storage.GetEnvironment(storedApplication.Environments, environment)
 		s.handleSimpleMicroservice(w, request, requestBytes, applicationInfo, environmentInfo, customerTenants)
-	// TODO let us get simple to work and we can come back to these others.
-	//case platform.MicroserviceKindBusinessMomentsAdaptor:
-	//	s.handleBusinessMomentsAdaptor(w, request, requestBytes, applicationInfo, customerTenants)
-	//case platform.MicroserviceKindRawDataLogIngestor:
-	//s.handleRawDataLogIngestor(w, request, requestBytes, applicationInfo, customerTenants)
-	//case platform.MicroserviceKindPurchaseOrderAPI:
-	//	purchaseOrderAPI, err := s.purchaseOrderHandler.Create(requestBytes, applicationInfo, customerTenants)
-	//	if err != nil {
-	//		utils.RespondWithError(w, err.StatusCode, err.Error())
-	//		break
-	//	}
-	//	utils.RespondWithJSON(w, http.StatusAccepted, purchaseOrderAPI)
+	case platform.MicroserviceKindBusinessMomentsAdaptor:
+		s.handleBusinessMomentsAdaptor(w, request, requestBytes, applicationInfo, customerTenants)
+	case platform.MicroserviceKindRawDataLogIngestor:
+		s.handleRawDataLogIngestor(w, request, requestBytes, applicationInfo, customerTenants)
+	case platform.MicroserviceKindPurchaseOrderAPI:
+		purchaseOrderAPI, err := s.purchaseOrderHandler.Create(requestBytes, applicationInfo, customerTenants)
+		if err != nil {
+			utils.RespondWithError(w, err.StatusCode, err.Error())
+			break
+		}
+		utils.RespondWithJSON(w, http.StatusAccepted, purchaseOrderAPI)
 	default:
 		utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Kind %s is not supported", microserviceBase.Kind))
 	}
@@ -248,10 +248,10 @@ func (s *service) Update(w http.ResponseWriter, request *http.Request) {
 
 	switch microserviceBase.Kind {
 	case platform.MicroserviceKindPurchaseOrderAPI:
-		// TODO handle other updation operations too
-		purchaseOrderAPI, err := s.purchaseOrderHandler.UpdateWebhooks(requestBytes, applicationInfo, customerTenants)
+		purchaseOrderAPI, err := s.purchaseOrderHandler.Update(requestBytes, applicationInfo, customerTenants)
 		if err != nil {
 			utils.RespondWithError(w, err.StatusCode, err.Error())
+			break
 		}
 		utils.RespondWithJSON(w, http.StatusOK, purchaseOrderAPI)
 	default:
@@ -398,10 +398,16 @@ func (s *service) Delete(w http.ResponseWriter, r *http.Request) {
 				// TODO add environment
 				err = s.rawDataLogIngestorRepo.Delete(namespace, microserviceID)
 			case platform.MicroserviceKindPurchaseOrderAPI:
-				err = s.purchaseOrderHandler.Delete(applicationID, environment, microserviceID)
+				// The raw data log is only deleted when asked for, as other microservices might use it
+				deleteRawDataLog := r.URL.Query().Get("deleteRawDataLog") == "true"
+				err = s.purchaseOrderHandler.Delete(customerID, applicationID, environment, microserviceID, deleteRawDataLog)
 			}
 			if err != nil {
 				statusCode = http.StatusUnprocessableEntity
+				var handlerErr *purchaseorderapi.Error
+				if errors.As(err, &handlerErr) {
+					statusCode = handlerErr.StatusCode
+				}
 				errStr = err.Error()
 			}
 		}
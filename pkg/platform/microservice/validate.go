@@ -1,6 +1,10 @@
 package microservice
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/dolittle/platform-api/pkg/platform"
 	"github.com/thoas/go-funk"
 )
@@ -15,3 +19,48 @@ func CheckIfIngressPathInUseInEnvironment(ingresses []platform.Ingress, environm
 
 	return pathExists
 }
+
+var ErrInvalidConnector = errors.New("invalid connector")
+
+// GetBusinessMomentsAdaptorAuthorization validates the webhook connector and returns the value for WH_AUTHORIZATION
+func GetBusinessMomentsAdaptorAuthorization(connector interface{}) (string, error) {
+	connectorBytes, err := json.Marshal(connector)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidConnector, err.Error())
+	}
+
+	var whatKind platform.HttpInputMicroserviceKind
+	json.Unmarshal(connectorBytes, &whatKind)
+	switch whatKind.Kind {
+	case "webhook":
+	case "rest":
+		return "", fmt.Errorf("%w: kind rest is not supported yet", ErrInvalidConnector)
+	default:
+		return "", fmt.Errorf("%w: kind must be webhook", ErrInvalidConnector)
+	}
+
+	var webhook platform.HttpInputBusinessMomentAdaptorConnectorWebhook
+	if err := json.Unmarshal(connectorBytes, &webhook); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidConnector, err.Error())
+	}
+
+	credentialsBytes, _ := json.Marshal(webhook.Config.Config)
+	switch webhook.Config.Kind {
+	case "basic":
+		var credentials platform.HttpInputBusinessMomentAdaptorConnectorWebhookConfigBasic
+		json.Unmarshal(credentialsBytes, &credentials)
+		if credentials.Username == "" || credentials.Password == "" {
+			return "", fmt.Errorf("%w: basic needs a username and a password", ErrInvalidConnector)
+		}
+		return fmt.Sprintf("Basic %s", basicAuth(credentials.Username, credentials.Password)), nil
+	case "bearer":
+		var credentials platform.HttpInputBusinessMomentAdaptorConnectorWebhookConfigBearer
+		json.Unmarshal(credentialsBytes, &credentials)
+		if credentials.Token == "" {
+			return "", fmt.Errorf("%w: bearer needs a token", ErrInvalidConnector)
+		}
+		return fmt.Sprintf("Bearer %s", credentials.Token), nil
+	default:
+		return "", fmt.Errorf("%w: config kind must be basic or bearer", ErrInvalidConnector)
+	}
+}
@@ -1,6 +1,8 @@
 package microservice_test
 
 import (
+	"errors"
+
 	"github.com/dolittle/platform-api/pkg/platform"
 	"github.com/dolittle/platform-api/pkg/platform/microservice"
 	. "github.com/onsi/ginkgo"
@@ -41,4 +43,60 @@ var _ = Describe("Validate microservice", func() {
 		})
 	})
 
+	When("Getting the business moments adaptor authorization", func() {
+		connector := func(kind string, config map[string]interface{}) interface{} {
+			return map[string]interface{}{
+				"kind": "webhook",
+				"config": map[string]interface{}{
+					"kind":   kind,
+					"config": config,
+				},
+			}
+		}
+
+		It("Basic with username and password", func() {
+			token, err := microservice.GetBusinessMomentsAdaptorAuthorization(connector("basic", map[string]interface{}{
+				"username": "user",
+				"password": "pass",
+			}))
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("Basic dXNlcjpwYXNz"))
+		})
+
+		It("Basic without a password", func() {
+			_, err := microservice.GetBusinessMomentsAdaptorAuthorization(connector("basic", map[string]interface{}{
+				"username": "user",
+			}))
+			Expect(errors.Is(err, microservice.ErrInvalidConnector)).To(BeTrue())
+		})
+
+		It("Bearer with a token", func() {
+			token, err := microservice.GetBusinessMomentsAdaptorAuthorization(connector("bearer", map[string]interface{}{
+				"token": "abc",
+			}))
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("Bearer abc"))
+		})
+
+		It("Bearer without a token", func() {
+			_, err := microservice.GetBusinessMomentsAdaptorAuthorization(connector("bearer", map[string]interface{}{}))
+			Expect(errors.Is(err, microservice.ErrInvalidConnector)).To(BeTrue())
+		})
+
+		It("Unknown credentials kind", func() {
+			_, err := microservice.GetBusinessMomentsAdaptorAuthorization(connector("oauth", map[string]interface{}{}))
+			Expect(errors.Is(err, microservice.ErrInvalidConnector)).To(BeTrue())
+		})
+
+		It("Rest connector", func() {
+			_, err := microservice.GetBusinessMomentsAdaptorAuthorization(map[string]interface{}{"kind": "rest"})
+			Expect(errors.Is(err, microservice.ErrInvalidConnector)).To(BeTrue())
+		})
+
+		It("Missing connector", func() {
+			_, err := microservice.GetBusinessMomentsAdaptorAuthorization(nil)
+			Expect(errors.Is(err, microservice.ErrInvalidConnector)).To(BeTrue())
+		})
+	})
+
 })
@@ -0,0 +1,64 @@
+package secretstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+type azureKeyVaultStore struct {
+	client     keyvault.BaseClient
+	vaultNames []string
+}
+
+// NewAzureKeyVaultStore reads secrets from the Azure Key Vaults named in vaultNames, any other vault is refused
+// References look like {"store": "azure-key-vault", "path": "{vault name}/{secret name}[/{version}]"}
+func NewAzureKeyVaultStore(authorizer autorest.Authorizer, vaultNames []string) Store {
+	client := keyvault.New()
+	client.Authorizer = authorizer
+	return azureKeyVaultStore{
+		client:     client,
+		vaultNames: vaultNames,
+	}
+}
+
+func (s azureKeyVaultStore) Validate(reference platform.SecretReference) error {
+	vaultName := strings.Split(strings.Trim(reference.Path, "/"), "/")[0]
+	for _, allowed := range s.vaultNames {
+		if strings.EqualFold(vaultName, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: vault %s is not allowed", ErrReferenceOutOfScope, vaultName)
+}
+
+func (s azureKeyVaultStore) GetSecret(ctx context.Context, reference platform.SecretReference) (string, error) {
+	if err := s.Validate(reference); err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(strings.Trim(reference.Path, "/"), "/")
+	version := ""
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+
+	vaultBaseURL := fmt.Sprintf("https://%s.vault.azure.net", parts[0])
+	secret, err := s.client.GetSecret(ctx, vaultBaseURL, parts[1], version)
+	if err != nil {
+		if secret.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s", ErrSecretNotFound, reference.Path)
+		}
+		return "", err
+	}
+
+	if secret.Value == nil {
+		return "", fmt.Errorf("%w: %s has no value", ErrSecretNotFound, reference.Path)
+	}
+	return *secret.Value, nil
+}
@@ -0,0 +1,53 @@
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+type fileStore struct {
+	directory string
+}
+
+// NewFileStore reads secrets from files in directory, a stand-in for a real secret store in development and tests
+// With a key the file is read as a JSON object and the key picks the value
+func NewFileStore(directory string) Store {
+	return fileStore{
+		directory: directory,
+	}
+}
+
+func (s fileStore) GetSecret(ctx context.Context, reference platform.SecretReference) (string, error) {
+	// Cleaning as an absolute path keeps the reference inside the directory
+	path := filepath.Join(s.directory, filepath.Clean("/"+reference.Path))
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: %s", ErrSecretNotFound, reference.Path)
+		}
+		return "", err
+	}
+
+	if reference.Key == "" {
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+
+	var values map[string]string
+	err = json.Unmarshal(b, &values)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a JSON object: %w", reference.Path, err)
+	}
+
+	value, ok := values[reference.Key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s in %s", ErrSecretNotFound, reference.Key, reference.Path)
+	}
+	return value, nil
+}
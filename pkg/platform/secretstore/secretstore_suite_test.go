@@ -0,0 +1,13 @@
+package secretstore_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSecretStore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SecretStore Suite")
+}
@@ -0,0 +1,208 @@
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ReferencesAnnotation holds the references of a secret as JSON, keyed by environment variable name
+	ReferencesAnnotation = "dolittle.io/secret-references"
+	// LastSyncedAnnotation is when the referenced values were last resolved
+	LastSyncedAnnotation = "dolittle.io/secret-references-synced-at"
+	// HasReferencesLabel makes secrets with references easy to find for the syncer
+	HasReferencesLabel = "dolittle.io/has-secret-references"
+)
+
+var (
+	ErrUnknownStore        = errors.New("secret store is not configured")
+	ErrSecretNotFound      = errors.New("secret not found in store")
+	ErrInvalidReference    = errors.New("invalid secret reference")
+	ErrReferenceOutOfScope = errors.New("secret reference is outside of the secrets of the application")
+
+	// validPath keeps %, ?, # and the like out of the paths, which are pasted into urls and file paths
+	validPath = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+)
+
+// Store looks up secret values in an external secret store
+type Store interface {
+	GetSecret(ctx context.Context, reference platform.SecretReference) (string, error)
+}
+
+// referenceValidator is implemented by stores that only allow some of the references of a valid shape
+type referenceValidator interface {
+	Validate(reference platform.SecretReference) error
+}
+
+// Scope is the customer and application a reference is resolved for.
+// The platform reads the stores with its own credentials, so a reference can only point at the secrets of its application:
+// "{mount}/data/{customer id}/{application id}/..." in vault, "{customer id}/{application id}/..." in the file store
+// and secrets named "{application id}-..." in azure key vault
+type Scope struct {
+	CustomerID    string
+	ApplicationID string
+}
+
+// GetScope reads the scope from the annotations of a secret of a microservice
+func GetScope(secret *corev1.Secret) Scope {
+	return Scope{
+		CustomerID:    secret.Annotations["dolittle.io/tenant-id"],
+		ApplicationID: secret.Annotations["dolittle.io/application-id"],
+	}
+}
+
+// Resolver picks the store a reference points at
+type Resolver struct {
+	stores map[string]Store
+}
+
+// NewResolver uses stores keyed by platform.SecretStoreVault, platform.SecretStoreAzureKeyVault or platform.SecretStoreFile
+func NewResolver(stores map[string]Store) Resolver {
+	return Resolver{
+		stores: stores,
+	}
+}
+
+// Validate checks the reference points at a configured store and at the secrets of scope, without looking the value up
+func (r Resolver) Validate(scope Scope, reference platform.SecretReference) error {
+	store, ok := r.stores[reference.Store]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownStore, reference.Store)
+	}
+
+	if err := ValidateReference(reference); err != nil {
+		return err
+	}
+
+	if err := ValidateScope(scope, reference); err != nil {
+		return err
+	}
+
+	if validator, ok := store.(referenceValidator); ok {
+		return validator.Validate(reference)
+	}
+	return nil
+}
+
+func (r Resolver) Resolve(ctx context.Context, scope Scope, reference platform.SecretReference) (string, error) {
+	if err := r.Validate(scope, reference); err != nil {
+		return "", err
+	}
+	return r.stores[reference.Store].GetSecret(ctx, reference)
+}
+
+// ValidateReference checks the shape of the reference, regardless of which stores are configured
+func ValidateReference(reference platform.SecretReference) error {
+	path := strings.Trim(reference.Path, "/")
+	switch {
+	case path == "":
+		return fmt.Errorf("%w: path is required", ErrInvalidReference)
+	case !validPath.MatchString(path):
+		return fmt.Errorf("%w: path can only contain letters, digits, _, -, . and /", ErrInvalidReference)
+	case strings.Contains(path, ".."):
+		return fmt.Errorf("%w: path can not contain ..", ErrInvalidReference)
+	}
+
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." {
+			return fmt.Errorf("%w: path can not contain empty or . segments", ErrInvalidReference)
+		}
+	}
+
+	switch reference.Store {
+	case platform.SecretStoreVault:
+		if reference.Key == "" {
+			return fmt.Errorf("%w: key is required for vault", ErrInvalidReference)
+		}
+	case platform.SecretStoreAzureKeyVault:
+		parts := strings.Split(path, "/")
+		if len(parts) < 2 || len(parts) > 3 {
+			return fmt.Errorf("%w: path must be {vault name}/{secret name}[/{version}]", ErrInvalidReference)
+		}
+	case platform.SecretStoreFile:
+	default:
+		return fmt.Errorf("%w: store %s not supported", ErrInvalidReference, reference.Store)
+	}
+	return nil
+}
+
+// ValidateScope checks the reference points at the secrets of scope, it expects a reference that passed ValidateReference
+func ValidateScope(scope Scope, reference platform.SecretReference) error {
+	if scope.CustomerID == "" || scope.ApplicationID == "" {
+		return fmt.Errorf("%w: the customer and application are required", ErrReferenceOutOfScope)
+	}
+
+	path := strings.Trim(reference.Path, "/")
+	prefix := fmt.Sprintf("%s/%s/", scope.CustomerID, scope.ApplicationID)
+	inScope := false
+
+	switch reference.Store {
+	case platform.SecretStoreVault:
+		parts := strings.SplitN(path, "/", 3)
+		inScope = len(parts) == 3 && parts[1] == "data" && hasNamedPrefix(parts[2], prefix)
+		prefix = "{mount}/data/" + prefix
+	case platform.SecretStoreAzureKeyVault:
+		prefix = scope.ApplicationID + "-"
+		parts := strings.Split(path, "/")
+		inScope = len(parts) > 1 && hasNamedPrefix(parts[1], prefix)
+	case platform.SecretStoreFile:
+		inScope = hasNamedPrefix(path, prefix)
+	}
+
+	if !inScope {
+		return fmt.Errorf("%w: it must start with %s", ErrReferenceOutOfScope, prefix)
+	}
+	return nil
+}
+
+// hasNamedPrefix is true when value starts with prefix and has a name after it
+func hasNamedPrefix(value string, prefix string) bool {
+	return strings.HasPrefix(value, prefix) && len(value) > len(prefix)
+}
+
+// GetReferences reads the references stored on a secret
+func GetReferences(secret *corev1.Secret) (map[string]platform.SecretReference, error) {
+	references := map[string]platform.SecretReference{}
+	data, ok := secret.Annotations[ReferencesAnnotation]
+	if !ok || data == "" {
+		return references, nil
+	}
+
+	err := json.Unmarshal([]byte(data), &references)
+	if err != nil {
+		return references, fmt.Errorf("secret %s has invalid references: %w", secret.Name, err)
+	}
+	return references, nil
+}
+
+// SetReferences stores the references on a secret, removing the annotation and label when there are none
+func SetReferences(secret *corev1.Secret, references map[string]platform.SecretReference) error {
+	if len(references) == 0 {
+		delete(secret.Annotations, ReferencesAnnotation)
+		delete(secret.Annotations, LastSyncedAnnotation)
+		delete(secret.Labels, HasReferencesLabel)
+		return nil
+	}
+
+	b, err := json.Marshal(references)
+	if err != nil {
+		return err
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Annotations[ReferencesAnnotation] = string(b)
+	secret.Labels[HasReferencesLabel] = "true"
+	return nil
+}
@@ -0,0 +1,150 @@
+package secretstore_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
+)
+
+var _ = Describe("Stores", func() {
+	scope := secretstore.Scope{CustomerID: "customer-123", ApplicationID: "application-123"}
+
+	Describe("validating references", func() {
+		It("should require a key for vault", func() {
+			err := secretstore.ValidateReference(platform.SecretReference{Store: platform.SecretStoreVault, Path: "secret/data/order"})
+			Expect(err).To(MatchError(ContainSubstring("key is required")))
+		})
+
+		It("should require a vault and secret name for azure key vault", func() {
+			Expect(secretstore.ValidateReference(platform.SecretReference{Store: platform.SecretStoreAzureKeyVault, Path: "vault/secret"})).To(Succeed())
+			Expect(secretstore.ValidateReference(platform.SecretReference{Store: platform.SecretStoreAzureKeyVault, Path: "secret"})).ToNot(Succeed())
+		})
+
+		It("should not allow walking out of the path", func() {
+			Expect(secretstore.ValidateReference(platform.SecretReference{Store: platform.SecretStoreFile, Path: "../etc/passwd"})).ToNot(Succeed())
+			for _, path := range []string{"secret/data/%2e%2e/other", "secret/data/order?version=1", "secret/data/order#x", "secret//order", "secret/./order", "secret/data/order password"} {
+				Expect(secretstore.ValidateReference(platform.SecretReference{Store: platform.SecretStoreVault, Path: path, Key: "password"})).ToNot(Succeed(), path)
+			}
+		})
+
+		It("should only resolve from configured stores", func() {
+			resolver := secretstore.NewResolver(map[string]secretstore.Store{})
+			_, err := resolver.Resolve(context.TODO(), scope, platform.SecretReference{Store: platform.SecretStoreFile, Path: "password"})
+			Expect(err).To(MatchError(ContainSubstring(secretstore.ErrUnknownStore.Error())))
+		})
+	})
+
+	Describe("scoping references", func() {
+		It("should only allow the secrets of the application", func() {
+			inScope := []platform.SecretReference{
+				{Store: platform.SecretStoreVault, Path: "secret/data/customer-123/application-123/order", Key: "password"},
+				{Store: platform.SecretStoreAzureKeyVault, Path: "platform/application-123-order"},
+				{Store: platform.SecretStoreFile, Path: "customer-123/application-123/password"},
+			}
+			for _, reference := range inScope {
+				Expect(secretstore.ValidateScope(scope, reference)).To(Succeed())
+			}
+
+			outOfScope := []platform.SecretReference{
+				{Store: platform.SecretStoreVault, Path: "secret/data/order", Key: "password"},
+				{Store: platform.SecretStoreVault, Path: "secret/data/customer-123/other-application/order", Key: "password"},
+				{Store: platform.SecretStoreVault, Path: "secret/metadata/customer-123/application-123/order", Key: "password"},
+				{Store: platform.SecretStoreAzureKeyVault, Path: "platform/order"},
+				{Store: platform.SecretStoreFile, Path: "customer-123/application-123/"},
+				{Store: platform.SecretStoreFile, Path: "password"},
+			}
+			for _, reference := range outOfScope {
+				err := secretstore.ValidateScope(scope, reference)
+				Expect(errors.Is(err, secretstore.ErrReferenceOutOfScope)).To(BeTrue(), reference.Path)
+			}
+
+			err := secretstore.ValidateScope(secretstore.Scope{}, inScope[2])
+			Expect(errors.Is(err, secretstore.ErrReferenceOutOfScope)).To(BeTrue())
+		})
+
+		It("should only allow the configured azure key vaults", func() {
+			resolver := secretstore.NewResolver(map[string]secretstore.Store{
+				platform.SecretStoreAzureKeyVault: secretstore.NewAzureKeyVaultStore(nil, []string{"platform"}),
+			})
+			Expect(resolver.Validate(scope, platform.SecretReference{Store: platform.SecretStoreAzureKeyVault, Path: "platform/application-123-order"})).To(Succeed())
+
+			err := resolver.Validate(scope, platform.SecretReference{Store: platform.SecretStoreAzureKeyVault, Path: "customers-vault/application-123-order"})
+			Expect(errors.Is(err, secretstore.ErrReferenceOutOfScope)).To(BeTrue())
+		})
+	})
+
+	Describe("the file store", func() {
+		var (
+			directory string
+			store     secretstore.Store
+		)
+
+		BeforeEach(func() {
+			directory, _ = ioutil.TempDir("", "secret-store")
+			store = secretstore.NewFileStore(directory)
+			ioutil.WriteFile(filepath.Join(directory, "password"), []byte("hunter2\n"), 0600)
+			ioutil.WriteFile(filepath.Join(directory, "order.json"), []byte(`{"user": "order"}`), 0600)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directory)
+		})
+
+		It("should read the whole file or a key from it", func() {
+			value, err := store.GetSecret(context.TODO(), platform.SecretReference{Store: platform.SecretStoreFile, Path: "password"})
+			Expect(err).To(BeNil())
+			Expect(value).To(Equal("hunter2"))
+
+			value, err = store.GetSecret(context.TODO(), platform.SecretReference{Store: platform.SecretStoreFile, Path: "order.json", Key: "user"})
+			Expect(err).To(BeNil())
+			Expect(value).To(Equal("order"))
+		})
+
+		It("should report missing secrets", func() {
+			_, err := store.GetSecret(context.TODO(), platform.SecretReference{Store: platform.SecretStoreFile, Path: "missing"})
+			Expect(err).To(MatchError(ContainSubstring(secretstore.ErrSecretNotFound.Error())))
+		})
+	})
+
+	Describe("the vault store", func() {
+		It("should read a key from the kv engine", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/v1/secret/data/order"))
+				Expect(r.Header.Get("X-Vault-Token")).To(Equal("token"))
+				w.Write([]byte(`{"data": {"data": {"password": "hunter2"}}}`))
+			}))
+			defer server.Close()
+
+			store := secretstore.NewVaultStore(server.Client(), server.URL, "token")
+			value, err := store.GetSecret(context.TODO(), platform.SecretReference{Store: platform.SecretStoreVault, Path: "secret/data/order", Key: "password"})
+			Expect(err).To(BeNil())
+			Expect(value).To(Equal("hunter2"))
+
+			_, err = store.GetSecret(context.TODO(), platform.SecretReference{Store: platform.SecretStoreVault, Path: "secret/data/order", Key: "user"})
+			Expect(err).To(MatchError(ContainSubstring(secretstore.ErrSecretNotFound.Error())))
+		})
+
+		It("should escape the path instead of letting it change the request", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.EscapedPath()).To(Equal("/v1/secret/data/order%3Fversion=1%23x"))
+				Expect(r.URL.RawQuery).To(BeEmpty())
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			store := secretstore.NewVaultStore(server.Client(), server.URL, "token")
+			_, err := store.GetSecret(context.TODO(), platform.SecretReference{Store: platform.SecretStoreVault, Path: "secret/data/order?version=1#x", Key: "password"})
+			Expect(err).To(MatchError(ContainSubstring(secretstore.ErrSecretNotFound.Error())))
+		})
+	})
+})
@@ -0,0 +1,176 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Syncer resolves the references of secret environment variables into their kubernetes secrets
+type Syncer interface {
+	// Validate checks the reference points at a configured store and at the secrets of scope
+	Validate(scope Scope, reference platform.SecretReference) error
+	// SyncMicroservice resolves the references of one microservice right away
+	SyncMicroservice(ctx context.Context, applicationID string, environment string, microserviceID string) error
+}
+
+type syncer struct {
+	k8sClient       kubernetes.Interface
+	k8sDolittleRepo platformK8s.K8sRepo
+	resolver        Resolver
+	logContext      logrus.FieldLogger
+}
+
+func NewSyncer(k8sClient kubernetes.Interface, k8sDolittleRepo platformK8s.K8sRepo, resolver Resolver, logContext logrus.FieldLogger) *syncer {
+	return &syncer{
+		k8sClient:       k8sClient,
+		k8sDolittleRepo: k8sDolittleRepo,
+		resolver:        resolver,
+		logContext:      logContext,
+	}
+}
+
+// Run syncs every secret with references each interval until ctx is done
+func (s *syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.SyncAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll syncs every secret with references in the cluster, errors are logged per secret.
+// Microservices with rotated values are rolled out, as the pods only read their secrets on start
+func (s *syncer) SyncAll(ctx context.Context) {
+	secrets, err := s.k8sClient.CoreV1().Secrets("").List(ctx, metav1.ListOptions{
+		LabelSelector: HasReferencesLabel + "=true",
+	})
+	if err != nil {
+		s.logContext.WithField("error", err).Error("failed to list secrets with references")
+		return
+	}
+
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		changed, err := s.syncSecret(ctx, secret)
+		if err != nil {
+			s.logContext.WithFields(logrus.Fields{
+				"error":     err,
+				"namespace": secret.Namespace,
+				"secret":    secret.Name,
+			}).Error("failed to sync secret references")
+		}
+
+		if changed {
+			s.rollout(secret)
+		}
+	}
+}
+
+func (s *syncer) Validate(scope Scope, reference platform.SecretReference) error {
+	return s.resolver.Validate(scope, reference)
+}
+
+func (s *syncer) SyncMicroservice(ctx context.Context, applicationID string, environment string, microserviceID string) error {
+	name, err := s.k8sDolittleRepo.GetMicroserviceName(applicationID, environment, microserviceID)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.k8sDolittleRepo.GetSecret(s.logContext, applicationID, platformK8s.GetMicroserviceEnvironmentVariableSecretName(name))
+	if err != nil {
+		return err
+	}
+	_, err = s.syncSecret(ctx, secret)
+	return err
+}
+
+// rollout starts new pods of the microservice the secret belongs to
+func (s *syncer) rollout(secret *corev1.Secret) {
+	applicationID := secret.Annotations["dolittle.io/application-id"]
+	microserviceID := secret.Annotations["dolittle.io/microservice-id"]
+	environment := secret.Labels["environment"]
+	if applicationID == "" || microserviceID == "" || environment == "" {
+		s.logContext.WithFields(logrus.Fields{
+			"namespace": secret.Namespace,
+			"secret":    secret.Name,
+		}).Warn("unable to find the microservice of the secret, it is not rolled out")
+		return
+	}
+
+	s.k8sDolittleRepo.TryRolloutMicroserviceConfig(applicationID, environment, microserviceID)
+}
+
+// syncSecret only writes when a value changed, a value that can't be resolved keeps its last synced value
+// Returns true when the secret was updated
+func (s *syncer) syncSecret(ctx context.Context, secret *corev1.Secret) (bool, error) {
+	references, err := GetReferences(secret)
+	if err != nil {
+		return false, err
+	}
+
+	if len(references) == 0 {
+		return false, nil
+	}
+
+	scope := GetScope(secret)
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	changed := false
+	var firstErr error
+	for name, reference := range references {
+		value, err := s.resolver.Resolve(ctx, scope, reference)
+		if err != nil {
+			s.logContext.WithFields(logrus.Fields{
+				"error":     err,
+				"namespace": secret.Namespace,
+				"secret":    secret.Name,
+				"name":      name,
+				"store":     reference.Store,
+			}).Warn("failed to resolve secret reference, keeping the last value")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if current, ok := secret.Data[name]; ok && bytes.Equal(current, []byte(value)) {
+			continue
+		}
+		secret.Data[name] = []byte(value)
+		changed = true
+	}
+
+	if !changed {
+		return false, firstErr
+	}
+
+	secret.Annotations[LastSyncedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	_, err = s.k8sClient.CoreV1().Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	s.logContext.WithFields(logrus.Fields{
+		"namespace": secret.Namespace,
+		"secret":    secret.Name,
+	}).Info("synced secret references")
+	return true, firstErr
+}
@@ -0,0 +1,147 @@
+package secretstore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	platformK8s "github.com/dolittle/platform-api/pkg/platform/k8s"
+	"github.com/dolittle/platform-api/pkg/platform/secretstore"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("Syncer", func() {
+	var (
+		directory     string
+		clientSet     *fake.Clientset
+		namespace     string
+		customerID    string
+		applicationID string
+	)
+
+	getSecret := func() *corev1.Secret {
+		secret, err := clientSet.CoreV1().Secrets(namespace).Get(context.TODO(), "dev-order-secret-env-variables", metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		return secret
+	}
+
+	getDeployment := func() *appsv1.Deployment {
+		deployment, err := clientSet.AppsV1().Deployments(namespace).Get(context.TODO(), "dev-order", metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		return deployment
+	}
+
+	syncAll := func() {
+		logger, _ := logrusTest.NewNullLogger()
+		k8sRepo := platformK8s.NewK8sRepo(clientSet, &rest.Config{}, logger)
+		syncer := secretstore.NewSyncer(clientSet, k8sRepo, secretstore.NewResolver(map[string]secretstore.Store{
+			platform.SecretStoreFile: secretstore.NewFileStore(directory),
+		}), logger)
+
+		syncer.SyncAll(context.TODO())
+	}
+
+	BeforeEach(func() {
+		directory, _ = ioutil.TempDir("", "secret-store")
+		customerID = "fake-customer-123"
+		applicationID = "fake-application-123"
+		namespace = "application-" + applicationID
+		os.MkdirAll(filepath.Join(directory, customerID, applicationID), 0700)
+
+		annotations := map[string]string{
+			"dolittle.io/tenant-id":       customerID,
+			"dolittle.io/application-id":  applicationID,
+			"dolittle.io/microservice-id": "fake-microservice-123",
+		}
+		labels := map[string]string{
+			"tenant":       "fake-tenant",
+			"application":  "fake-application",
+			"environment":  "dev",
+			"microservice": "order",
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "dev-order-secret-env-variables",
+				Namespace:   namespace,
+				Annotations: annotations,
+				Labels:      labels,
+			},
+			Data: map[string][]byte{
+				"PLAIN":    []byte("plain"),
+				"PASSWORD": []byte("old"),
+				"TOKEN":    []byte("last-good"),
+			},
+		}
+		secretstore.SetReferences(secret, map[string]platform.SecretReference{
+			"PASSWORD": {Store: platform.SecretStoreFile, Path: customerID + "/" + applicationID + "/password"},
+			"TOKEN":    {Store: platform.SecretStoreFile, Path: customerID + "/" + applicationID + "/missing"},
+		})
+
+		clientSet = fake.NewSimpleClientset(
+			secret,
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-order", Namespace: namespace, Annotations: annotations, Labels: labels},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-order-env-variables", Namespace: namespace},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-order-config-files", Namespace: namespace},
+			},
+		)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(directory)
+	})
+
+	It("should resolve references and keep the last value of those that fail", func() {
+		ioutil.WriteFile(filepath.Join(directory, customerID, applicationID, "password"), []byte("rotated"), 0600)
+
+		syncAll()
+
+		secret := getSecret()
+		Expect(string(secret.Data["PASSWORD"])).To(Equal("rotated"))
+		Expect(string(secret.Data["TOKEN"])).To(Equal("last-good"))
+		Expect(string(secret.Data["PLAIN"])).To(Equal("plain"))
+		Expect(secret.Annotations[secretstore.LastSyncedAnnotation]).ToNot(BeEmpty())
+	})
+
+	It("should roll out the microservice when a value was rotated", func() {
+		ioutil.WriteFile(filepath.Join(directory, customerID, applicationID, "password"), []byte("rotated"), 0600)
+
+		syncAll()
+
+		Expect(getDeployment().Spec.Template.Annotations[platformK8s.ConfigChecksumAnnotation]).ToNot(BeEmpty())
+	})
+
+	It("should not roll out the microservice when nothing changed", func() {
+		syncAll()
+
+		Expect(getDeployment().Spec.Template.Annotations).ToNot(HaveKey(platformK8s.ConfigChecksumAnnotation))
+	})
+
+	It("should not resolve references outside of the secrets of the application", func() {
+		ioutil.WriteFile(filepath.Join(directory, "password"), []byte("someone else's"), 0600)
+		secret := getSecret()
+		secretstore.SetReferences(secret, map[string]platform.SecretReference{
+			"PASSWORD": {Store: platform.SecretStoreFile, Path: "password"},
+		})
+		clientSet.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+
+		syncAll()
+
+		Expect(string(getSecret().Data["PASSWORD"])).To(Equal("old"))
+	})
+})
@@ -0,0 +1,79 @@
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+type vaultStore struct {
+	client  *http.Client
+	address string
+	token   string
+}
+
+// NewVaultStore reads secrets from the KV version 2 engine of HashiCorp Vault
+// References look like {"store": "vault", "path": "secret/data/order", "key": "password"}
+func NewVaultStore(client *http.Client, address string, token string) Store {
+	return vaultStore{
+		client:  client,
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+	}
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+func (s vaultStore) GetSecret(ctx context.Context, reference platform.SecretReference) (string, error) {
+	// Escape each segment, so the path can never add a query or fragment to the request
+	segments := strings.Split(strings.Trim(reference.Path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	secretURL := fmt.Sprintf("%s/v1/%s", s.address, strings.Join(segments, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, reference.Path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault responded with %s for %s", resp.Status, reference.Path)
+	}
+
+	var body vaultKVResponse
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := body.Data.Data[reference.Key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s in %s", ErrSecretNotFound, reference.Key, reference.Path)
+	}
+
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	b, err := json.Marshal(value)
+	return string(b), err
+}
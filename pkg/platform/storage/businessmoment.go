@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+// MaxBusinessMomentsVersions is how many published versions are kept, older ones are pruned
+const MaxBusinessMomentsVersions = 20
+
+// AddBusinessMomentsVersion adds version to versions and prunes the oldest beyond MaxBusinessMomentsVersions
+// Returns ErrVersionConflict when version is not newer than every existing version
+func AddBusinessMomentsVersion(versions []platform.BusinessMomentsVersion, version platform.BusinessMomentsVersion) ([]platform.BusinessMomentsVersion, error) {
+	for _, existing := range versions {
+		if existing.Version >= version.Version {
+			return versions, ErrVersionConflict
+		}
+	}
+
+	added := make([]platform.BusinessMomentsVersion, 0, len(versions)+1)
+	added = append(added, versions...)
+	added = append(added, version)
+	sort.SliceStable(added, func(i, j int) bool {
+		return added[i].Version < added[j].Version
+	})
+
+	if len(added) > MaxBusinessMomentsVersions {
+		added = added[len(added)-MaxBusinessMomentsVersions:]
+	}
+	return added, nil
+}
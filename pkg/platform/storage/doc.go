@@ -8,7 +8,9 @@ import (
 
 type RepoCustomer interface {
 	GetCustomers() ([]platform.Customer, error)
+	GetCustomer(customerID string) (JSONCustomer, error)
 	SaveCustomer(customer JSONCustomer) error
+	DeleteCustomer(customerID string) error
 }
 type RepoCustomerTenants interface {
 	GetCustomerTenants(application JSONApplication) []platform.CustomerTenantInfo
@@ -26,6 +28,7 @@ type RepoApplication interface {
 	GetApplication(customerID string, applicationID string) (JSONApplication, error)
 	SaveApplication(application JSONApplication) error
 	GetApplications(customerID string) ([]JSONApplication, error)
+	DeleteApplication(customerID string, applicationID string) error
 }
 
 type Repo interface {
@@ -48,11 +51,19 @@ type Repo interface {
 
 	SaveBusinessMomentEntity(customerID string, input platform.HttpInputBusinessMomentEntity) error
 	DeleteBusinessMomentEntity(customerID string, applicationID string, environment string, microserviceID string, entityID string) error
+
+	GetBusinessMomentsVersions(customerID string, applicationID string, environment string, microserviceID string) (platform.HttpResponseBusinessMomentsVersions, error)
+	// SaveBusinessMomentsVersion adds the version and marks it as the published one, only the newest MaxBusinessMomentsVersions are kept
+	// Returns ErrVersionConflict when the version is not newer than the versions already saved
+	SaveBusinessMomentsVersion(customerID string, applicationID string, environment string, microserviceID string, version platform.BusinessMomentsVersion) error
+	SetPublishedBusinessMomentsVersion(customerID string, applicationID string, environment string, microserviceID string, version int) error
 }
 
 var (
 	ErrNotFound                  = errors.New("not-found")
 	ErrNotBusinessMomentsAdaptor = errors.New("not-business-moments-adaptor")
+	ErrVersionNotFound           = errors.New("version-not-found")
+	ErrVersionConflict           = errors.New("version-conflict")
 )
 
 // JSONApplication represents the application.json file
@@ -62,12 +73,17 @@ const (
 	BuildStatusStatePending         = "building"
 	BuildStatusStateFinishedSuccess = "finished:success"
 	BuildStatusStateFinishedFailed  = "finished:failed"
+	BuildStatusStateDeleting        = "deleting"
+	BuildStatusStateDeleteFailed    = "delete:failed"
 )
 
 type JSONBuildStatus struct {
 	State      string `json:"status"`
 	StartedAt  string `json:"startedAt"`
 	FinishedAt string `json:"finishedAt"`
+	// FailedStep is the container of the create job that failed
+	FailedStep string `json:"failedStep,omitempty"`
+	Message    string `json:"message,omitempty"`
 }
 
 type JSONApplication struct {
@@ -83,14 +99,40 @@ type JSONEnvironmentConnections struct {
 	M3Connector bool `json:"m3Connector"`
 }
 
+// JSONEnvironmentMongo is how mongo in the environment is set up, what is empty is left as it is
+type JSONEnvironmentMongo struct {
+	// Version is the tag of the dolittle/mongodb image
+	Version       string `json:"version,omitempty"`
+	VolumeSize    string `json:"volumeSize,omitempty"`
+	CPURequest    string `json:"cpuRequest,omitempty"`
+	MemoryRequest string `json:"memoryRequest,omitempty"`
+	CPULimit      string `json:"cpuLimit,omitempty"`
+	MemoryLimit   string `json:"memoryLimit,omitempty"`
+	// BackupSchedule is the cron schedule of the backup
+	BackupSchedule string `json:"backupSchedule,omitempty"`
+	// BackupRetentionDays is how long backups are kept, 0 keeps them forever
+	BackupRetentionDays int `json:"backupRetentionDays,omitempty"`
+	// BackupRetention is which backups are kept when they are pruned, without it they are never pruned
+	BackupRetention *JSONBackupRetention `json:"backupRetention,omitempty"`
+}
+
+// JSONBackupRetention is how many of the latest days, weeks and months to keep the last backup of
+type JSONBackupRetention struct {
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+}
+
 type JSONEnvironment struct {
 	Name                  string                        `json:"name"`
 	CustomerTenants       []platform.CustomerTenantInfo `json:"customerTenants"`
 	WelcomeMicroserviceID string                        `json:"welcomeMicroserviceID"`
 	Connections           JSONEnvironmentConnections    `json:"connections"`
+	Mongo                 JSONEnvironmentMongo          `json:"mongo"`
 }
 
 type JSONCustomer struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID      string                    `json:"id"`
+	Name    string                    `json:"name"`
+	Contact *platform.CustomerContact `json:"contact,omitempty"`
 }
@@ -51,6 +51,39 @@ func (s *GitStorage) SaveApplication(application storage.JSONApplication) error
 	return nil
 }
 
+// DeleteApplication removes everything stored about the application
+func (s *GitStorage) DeleteApplication(customerID string, applicationID string) error {
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":         "DeleteApplication",
+		"customer_id":    customerID,
+		"application_id": applicationID,
+	})
+
+	if err := s.Pull(); err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("Pull")
+		return err
+	}
+
+	dir := s.GetApplicationDirectory(customerID, applicationID)
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return storage.ErrNotFound
+		}
+		return err
+	}
+
+	err := s.RemovePathAndPush(dir, fmt.Sprintf("delete application %s", applicationID))
+	if err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("RemovePathAndPush")
+		return err
+	}
+	return nil
+}
+
 func (s *GitStorage) GetApplication(customerID string, applicationID string) (storage.JSONApplication, error) {
 	dir := s.GetApplicationDirectory(customerID, applicationID)
 	filename := filepath.Join(dir, "application.json")
@@ -296,3 +296,87 @@ func (s *GitStorage) DeleteBusinessMomentEntity(customerID string, applicationID
 
 	return nil
 }
+
+func (s *GitStorage) GetBusinessMomentsVersions(customerID string, applicationID string, environment string, microserviceID string) (platform.HttpResponseBusinessMomentsVersions, error) {
+	microservice, err := s.getBusinessMomentsAdaptor(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		return platform.HttpResponseBusinessMomentsVersions{}, err
+	}
+
+	data := platform.HttpResponseBusinessMomentsVersions{
+		ApplicationID:    applicationID,
+		Environment:      environment,
+		MicroserviceID:   microserviceID,
+		PublishedVersion: microservice.Extra.PublishedVersion,
+		DraftMoments:     microservice.Extra.Moments,
+		DraftEntities:    microservice.Extra.Entities,
+		Versions:         microservice.Extra.Versions,
+	}
+
+	if data.DraftMoments == nil {
+		data.DraftMoments = make([]platform.BusinessMoment, 0)
+	}
+	if data.DraftEntities == nil {
+		data.DraftEntities = make([]platform.Entity, 0)
+	}
+	if data.Versions == nil {
+		data.Versions = make([]platform.BusinessMomentsVersion, 0)
+	}
+	return data, nil
+}
+
+func (s *GitStorage) SaveBusinessMomentsVersion(customerID string, applicationID string, environment string, microserviceID string, version platform.BusinessMomentsVersion) error {
+	// Read, check and write as one, so two publishes can not both save the same version
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+
+	microservice, err := s.getBusinessMomentsAdaptor(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		return err
+	}
+
+	microservice.Extra.Versions, err = storage.AddBusinessMomentsVersion(microservice.Extra.Versions, version)
+	if err != nil {
+		return err
+	}
+	microservice.Extra.PublishedVersion = version.Version
+	return s.SaveMicroservice(customerID, applicationID, environment, microserviceID, microservice)
+}
+
+func (s *GitStorage) SetPublishedBusinessMomentsVersion(customerID string, applicationID string, environment string, microserviceID string, version int) error {
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+
+	microservice, err := s.getBusinessMomentsAdaptor(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		return err
+	}
+
+	exists := funk.Contains(microservice.Extra.Versions, func(published platform.BusinessMomentsVersion) bool {
+		return published.Version == version
+	})
+	if !exists {
+		return storage.ErrVersionNotFound
+	}
+
+	microservice.Extra.PublishedVersion = version
+	return s.SaveMicroservice(customerID, applicationID, environment, microserviceID, microservice)
+}
+
+func (s *GitStorage) getBusinessMomentsAdaptor(customerID string, applicationID string, environment string, microserviceID string) (platform.HttpInputBusinessMomentAdaptorInfo, error) {
+	var microservice platform.HttpInputBusinessMomentAdaptorInfo
+	rawBytes, err := s.GetMicroservice(customerID, applicationID, environment, microserviceID)
+	if err != nil {
+		return microservice, storage.ErrNotFound
+	}
+
+	err = json.Unmarshal(rawBytes, &microservice)
+	if err != nil {
+		return microservice, err
+	}
+
+	if microservice.Kind != platform.MicroserviceKindBusinessMomentsAdaptor {
+		return microservice, storage.ErrNotBusinessMomentsAdaptor
+	}
+	return microservice, nil
+}
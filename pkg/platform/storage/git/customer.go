@@ -97,6 +97,53 @@ func (s *GitStorage) SaveCustomer(customer storage.JSONCustomer) error {
 	return nil
 }
 
+func (s *GitStorage) GetCustomer(customerID string) (storage.JSONCustomer, error) {
+	var customer storage.JSONCustomer
+	filename := filepath.Join(s.GetCustomerDirectory(customerID), "customer.json")
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return customer, storage.ErrNotFound
+		}
+		return customer, err
+	}
+
+	err = json.Unmarshal(b, &customer)
+	return customer, err
+}
+
+// DeleteCustomer removes everything stored about the customer, including its applications
+func (s *GitStorage) DeleteCustomer(customerID string) error {
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method":      "DeleteCustomer",
+		"customer_id": customerID,
+	})
+
+	if err := s.Pull(); err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("Pull")
+		return err
+	}
+
+	dir := s.GetCustomerDirectory(customerID)
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return storage.ErrNotFound
+		}
+		return err
+	}
+
+	err := s.RemovePathAndPush(dir, fmt.Sprintf("delete customer %s", customerID))
+	if err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("RemovePathAndPush")
+		return err
+	}
+	return nil
+}
+
 func (s *GitStorage) writeToDisk(filename string, data interface{}) error {
 	dir := path.Dir(filename)
 	b, _ := json.MarshalIndent(data, "", " ")
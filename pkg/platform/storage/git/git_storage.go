@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -37,6 +38,8 @@ type GitStorage struct {
 	Directory  string
 	publicKeys *gitSsh.PublicKeys
 	config     GitStorageConfig
+	// versionsLock serialises saving business moments versions
+	versionsLock sync.Mutex
 }
 
 func NewGitStorage(logContext logrus.FieldLogger, gitConfig GitStorageConfig) *GitStorage {
@@ -155,6 +158,40 @@ func (s *GitStorage) CommitPathAndPush(path string, msg string) error {
 		return err
 	}
 
+	return s.commitAndPush(w, msg, logContext)
+}
+
+// RemovePathAndPush removes the path, a file or a directory, from the worktree and the index,
+// creates a commit, and pushes to the remote
+func (s *GitStorage) RemovePathAndPush(path string, msg string) error {
+	path = strings.TrimPrefix(path, s.config.RepoRoot+string(os.PathSeparator))
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"method": "RemovePathAndPush",
+		"msg":    msg,
+		"path":   path,
+	})
+	if s.config.DryRun {
+		logContext.Info("dry-run configured, won't commit and push")
+		return os.RemoveAll(filepath.Join(s.config.RepoRoot, path))
+	}
+
+	w, err := s.Repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Remove(path)
+	if err != nil {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("failed to remove path from index")
+		return err
+	}
+
+	return s.commitAndPush(w, msg, logContext)
+}
+
+func (s *GitStorage) commitAndPush(w *git.Worktree, msg string, logContext logrus.FieldLogger) error {
 	commit, err := w.Commit(msg, &git.CommitOptions{
 		Author: &object.Signature{
 			Name:  "Auto Platform",
@@ -3,6 +3,7 @@ package storage_test
 import (
 	"encoding/json"
 
+	"github.com/dolittle/platform-api/pkg/platform"
 	"github.com/dolittle/platform-api/pkg/platform/storage"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -144,3 +145,32 @@ var _ = Describe("Customer Tenants", func() {
 		Expect(len(customerTenants)).To(Equal(0))
 	})
 })
+
+var _ = Describe("Business moments versions", func() {
+	versionsOf := func(count int) []platform.BusinessMomentsVersion {
+		versions := make([]platform.BusinessMomentsVersion, 0, count)
+		for version := 1; version <= count; version++ {
+			versions = append(versions, platform.BusinessMomentsVersion{Version: version})
+		}
+		return versions
+	}
+
+	It("should add the version", func() {
+		versions, err := storage.AddBusinessMomentsVersion(versionsOf(2), platform.BusinessMomentsVersion{Version: 3})
+		Expect(err).To(BeNil())
+		Expect(versions).To(Equal(versionsOf(3)))
+	})
+
+	It("should refuse a version that is already saved", func() {
+		_, err := storage.AddBusinessMomentsVersion(versionsOf(3), platform.BusinessMomentsVersion{Version: 3})
+		Expect(err).To(Equal(storage.ErrVersionConflict))
+	})
+
+	It("should only keep the newest versions", func() {
+		versions, err := storage.AddBusinessMomentsVersion(versionsOf(storage.MaxBusinessMomentsVersions), platform.BusinessMomentsVersion{Version: storage.MaxBusinessMomentsVersions + 1})
+		Expect(err).To(BeNil())
+		Expect(versions).To(HaveLen(storage.MaxBusinessMomentsVersions))
+		Expect(versions[0].Version).To(Equal(2))
+		Expect(versions[len(versions)-1].Version).To(Equal(storage.MaxBusinessMomentsVersions + 1))
+	})
+})
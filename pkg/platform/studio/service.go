@@ -2,7 +2,9 @@ package studio
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"os"
 
 	"github.com/dolittle/platform-api/pkg/k8s"
 	"github.com/dolittle/platform-api/pkg/platform"
@@ -36,6 +38,11 @@ type HTTPStudioConfig struct {
 	BuildOverwrite       bool     `json:"buildOverwrite"`
 	DisabledEnvironments []string `json:"disabledEnvironments"`
 	CanCreateApplication bool     `json:"canCreateApplication"`
+	// Suspended is only set by suspending the customer, it is ignored when saving
+	Suspended bool `json:"suspended"`
+	// VerifiedDomains are set by platform admins once the customer has shown they own them,
+	// they are kept as they are when left out
+	VerifiedDomains []string `json:"verifiedDomains"`
 }
 
 func (s *service) Get(w http.ResponseWriter, r *http.Request) {
@@ -71,6 +78,8 @@ func (s *service) Get(w http.ResponseWriter, r *http.Request) {
 		BuildOverwrite:       studioConfig.BuildOverwrite,
 		DisabledEnvironments: studioConfig.DisabledEnvironments,
 		CanCreateApplication: studioConfig.CanCreateApplication,
+		Suspended:            studioConfig.Suspended,
+		VerifiedDomains:      studioConfig.VerifiedDomains,
 	}
 	utils.RespondWithJSON(w, http.StatusOK, httpConfig)
 }
@@ -107,10 +116,27 @@ func (s *service) Save(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The backup store and the suspension are not set from Studio, so they are kept as they are
+	existing, err := s.storageRepo.GetStudioConfig(customerID)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logContext.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("failed to get the studio config")
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	studioConfig := platform.StudioConfig{
 		BuildOverwrite:       config.BuildOverwrite,
 		DisabledEnvironments: config.DisabledEnvironments,
 		CanCreateApplication: config.CanCreateApplication,
+		BackupStore:          existing.BackupStore,
+		Suspended:            existing.Suspended,
+		VerifiedDomains:      config.VerifiedDomains,
+	}
+	// Older Studio builds do not send the verified domains, an empty list still clears them
+	if config.VerifiedDomains == nil {
+		studioConfig.VerifiedDomains = existing.VerifiedDomains
 	}
 
 	err = s.storageRepo.SaveStudioConfig(customerID, studioConfig)
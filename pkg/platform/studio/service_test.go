@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 
 	mockK8s "github.com/dolittle/platform-api/mocks/pkg/k8s"
 	mockStorage "github.com/dolittle/platform-api/mocks/pkg/platform/storage"
@@ -139,11 +140,13 @@ var _ = Describe("Studio service", func() {
 				BuildOverwrite:       false,
 				DisabledEnvironments: []string{"*"},
 				CanCreateApplication: false,
+				VerifiedDomains:      []string{"example.com"},
 			}
 			jsonConfig = HTTPStudioConfig{
 				BuildOverwrite:       false,
 				DisabledEnvironments: []string{"*"},
 				CanCreateApplication: false,
+				VerifiedDomains:      []string{"example.com"},
 			}
 			customerID = "4fd6927e-f5cf-44f8-9252-4058f5f24d6d"
 		})
@@ -159,6 +162,134 @@ var _ = Describe("Studio service", func() {
 				userID,
 			).Return(true, nil)
 
+			mockRepo.On(
+				"GetStudioConfig",
+				customerID,
+			).Return(platform.StudioConfig{}, nil)
+
+			mockRepo.On(
+				"SaveStudioConfig",
+				customerID,
+				studioConfig,
+			).Return(nil)
+
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			mock.AssertExpectationsForObjects(GinkgoT(), mockRepo)
+		})
+
+		It("should keep the backup store and the suspension", func() {
+			jsonPayload, _ := json.Marshal(jsonConfig)
+
+			request := httptest.NewRequest("POST", customerUrl, bytes.NewBuffer(jsonPayload))
+			request.Header.Add("User-ID", userID)
+
+			mockRoleBindingRepo.On(
+				"HasUserAdminAccess",
+				userID,
+			).Return(true, nil)
+
+			mockRepo.On(
+				"GetStudioConfig",
+				customerID,
+			).Return(platform.StudioConfig{
+				BackupStore: platform.BackupStoreAzureFiles,
+				Suspended:   true,
+			}, nil)
+
+			studioConfig.BackupStore = platform.BackupStoreAzureFiles
+			studioConfig.Suspended = true
+			mockRepo.On(
+				"SaveStudioConfig",
+				customerID,
+				studioConfig,
+			).Return(nil)
+
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			mock.AssertExpectationsForObjects(GinkgoT(), mockRepo)
+		})
+
+		It("should keep the verified domains when they are left out", func() {
+			jsonPayload := []byte(`{"buildOverwrite": false, "disabledEnvironments": ["*"], "canCreateApplication": false}`)
+
+			request := httptest.NewRequest("POST", customerUrl, bytes.NewBuffer(jsonPayload))
+			request.Header.Add("User-ID", userID)
+
+			mockRoleBindingRepo.On(
+				"HasUserAdminAccess",
+				userID,
+			).Return(true, nil)
+
+			mockRepo.On(
+				"GetStudioConfig",
+				customerID,
+			).Return(platform.StudioConfig{
+				VerifiedDomains: []string{"example.com"},
+			}, nil)
+
+			mockRepo.On(
+				"SaveStudioConfig",
+				customerID,
+				studioConfig,
+			).Return(nil)
+
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			mock.AssertExpectationsForObjects(GinkgoT(), mockRepo)
+		})
+
+		It("should clear the verified domains when given an empty list", func() {
+			jsonConfig.VerifiedDomains = []string{}
+			jsonPayload, _ := json.Marshal(jsonConfig)
+
+			request := httptest.NewRequest("POST", customerUrl, bytes.NewBuffer(jsonPayload))
+			request.Header.Add("User-ID", userID)
+
+			mockRoleBindingRepo.On(
+				"HasUserAdminAccess",
+				userID,
+			).Return(true, nil)
+
+			mockRepo.On(
+				"GetStudioConfig",
+				customerID,
+			).Return(platform.StudioConfig{
+				VerifiedDomains: []string{"example.com"},
+			}, nil)
+
+			studioConfig.VerifiedDomains = []string{}
+			mockRepo.On(
+				"SaveStudioConfig",
+				customerID,
+				studioConfig,
+			).Return(nil)
+
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			mock.AssertExpectationsForObjects(GinkgoT(), mockRepo)
+		})
+
+		It("should save the studio config of a customer without one", func() {
+			jsonPayload, _ := json.Marshal(jsonConfig)
+
+			request := httptest.NewRequest("POST", customerUrl, bytes.NewBuffer(jsonPayload))
+			request.Header.Add("User-ID", userID)
+
+			mockRoleBindingRepo.On(
+				"HasUserAdminAccess",
+				userID,
+			).Return(true, nil)
+
+			mockRepo.On(
+				"GetStudioConfig",
+				customerID,
+			).Return(platform.StudioConfig{}, os.ErrNotExist)
+
 			mockRepo.On(
 				"SaveStudioConfig",
 				customerID,
@@ -205,6 +336,11 @@ var _ = Describe("Studio service", func() {
 				userID,
 			).Return(true, nil)
 
+			mockRepo.On(
+				"GetStudioConfig",
+				customerID,
+			).Return(platform.StudioConfig{}, nil)
+
 			mockRepo.On(
 				"SaveStudioConfig",
 				customerID,
@@ -0,0 +1,187 @@
+package rawdatalog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigSource is where the microservice config of the ingestor comes from
+type ConfigSource interface {
+	// Load returns the microservice config
+	Load() ([]byte, error)
+	// LoadFile returns another file next to the microservice config, used for webhook schemas
+	LoadFile(name string) ([]byte, error)
+	// Watch calls onChange whenever the config might have changed, until ctx is done
+	// Errors whilst watching are logged, not returned
+	Watch(ctx context.Context, onChange func())
+}
+
+type fileConfigSource struct {
+	path       string
+	logContext logrus.FieldLogger
+}
+
+// NewFileConfigSource reads the microservice config from a file, usually the mounted config-files
+func NewFileConfigSource(path string, logContext logrus.FieldLogger) ConfigSource {
+	return &fileConfigSource{
+		path:       path,
+		logContext: logContext,
+	}
+}
+
+func (s *fileConfigSource) Load() ([]byte, error) {
+	return ioutil.ReadFile(s.path)
+}
+
+func (s *fileConfigSource) LoadFile(name string) ([]byte, error) {
+	// Only allow files directly next to the config
+	return ioutil.ReadFile(filepath.Join(filepath.Dir(s.path), filepath.Base(name)))
+}
+
+func (s *fileConfigSource) Watch(ctx context.Context, onChange func()) {
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"pathToMicroserviceConfig": s.path,
+		"context":                  "watching config file",
+	})
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logContext.WithField("error", err).Error("failed to watch config, reload with SIGHUP instead")
+		return
+	}
+	defer watcher.Close()
+
+	// Watching the directory as configmaps are mounted with symlinks that are swapped on change
+	// https://martensson.io/go-fsnotify-and-kubernetes-configmaps/
+	err = watcher.Add(filepath.Dir(s.path))
+	if err != nil {
+		logContext.WithField("error", err).Error("failed to watch config, reload with SIGHUP instead")
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			logContext.WithField("event", event).Debug("Event")
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				onChange()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logContext.WithField("error", err).Error("error whilst watching file change")
+		}
+	}
+}
+
+type configMapConfigSource struct {
+	client     kubernetes.Interface
+	namespace  string
+	name       string
+	key        string
+	logContext logrus.FieldLogger
+}
+
+// NewConfigMapConfigSource reads the microservice config straight from the config-files configmap
+// so changes are picked up without waiting for the kubelet to update the mounted file
+func NewConfigMapConfigSource(client kubernetes.Interface, namespace string, name string, key string, logContext logrus.FieldLogger) ConfigSource {
+	return &configMapConfigSource{
+		client:     client,
+		namespace:  namespace,
+		name:       name,
+		key:        key,
+		logContext: logContext,
+	}
+}
+
+func (s *configMapConfigSource) Load() ([]byte, error) {
+	return s.LoadFile(s.key)
+}
+
+func (s *configMapConfigSource) LoadFile(name string) ([]byte, error) {
+	configMap, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := configMap.Data[name]; ok {
+		return []byte(data), nil
+	}
+
+	if data, ok := configMap.BinaryData[name]; ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s not found in configmap %s/%s", name, s.namespace, s.name)
+}
+
+func (s *configMapConfigSource) Watch(ctx context.Context, onChange func()) {
+	logContext := s.logContext.WithFields(logrus.Fields{
+		"namespace": s.namespace,
+		"configmap": s.name,
+		"context":   "watching config configmap",
+	})
+
+	for {
+		watcher, err := s.client.CoreV1().ConfigMaps(s.namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", s.name).String(),
+		})
+
+		if err != nil {
+			logContext.WithField("error", err).Error("failed to watch configmap, retrying")
+		} else {
+			s.handleEvents(ctx, watcher, onChange, logContext)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// handleEvents returns when the watch is closed by the api server or ctx is done
+func (s *configMapConfigSource) handleEvents(ctx context.Context, watcher watch.Interface, onChange func(), logContext logrus.FieldLogger) {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if _, ok := event.Object.(*corev1.ConfigMap); ok {
+					onChange()
+				}
+			case watch.Deleted:
+				logContext.Warn("configmap deleted, keeping the last good config")
+			case watch.Error:
+				logContext.WithField("event", event.Object).Error("error whilst watching configmap")
+				return
+			}
+		}
+	}
+}
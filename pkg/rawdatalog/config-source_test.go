@@ -0,0 +1,71 @@
+package rawdatalog_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/dolittle/platform-api/pkg/rawdatalog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Config source", func() {
+	Describe("from a configmap", func() {
+		var (
+			clientSet *fake.Clientset
+			source    ConfigSource
+			configMap *corev1.ConfigMap
+		)
+
+		BeforeEach(func() {
+			logger, _ := logrusTest.NewNullLogger()
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dev-ingestor-config-files",
+					Namespace: "application-6db1278e-da39-481a-8474-e0ef6bdc2f6e",
+				},
+				Data: map[string]string{
+					"microservice_data_from_studio.json": `{"extra": {}}`,
+					"item.schema.json":                   `{"type": "object"}`,
+				},
+			}
+			clientSet = fake.NewSimpleClientset(configMap)
+			source = NewConfigMapConfigSource(clientSet, configMap.Namespace, configMap.Name, "microservice_data_from_studio.json", logger)
+		})
+
+		It("should load the config and other files from the configmap", func() {
+			b, err := source.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal(`{"extra": {}}`))
+
+			b, err = source.LoadFile("item.schema.json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal(`{"type": "object"}`))
+
+			_, err = source.LoadFile("missing.json")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should call onChange when the configmap is updated", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			changed := make(chan struct{}, 10)
+			go source.Watch(ctx, func() {
+				changed <- struct{}{}
+			})
+
+			// Give the watch time to start
+			time.Sleep(100 * time.Millisecond)
+			configMap.Data["microservice_data_from_studio.json"] = `{"extra": {"webhooks": []}}`
+			_, err := clientSet.CoreV1().ConfigMaps(configMap.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(changed).Should(Receive())
+		})
+	})
+})
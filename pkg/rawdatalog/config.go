@@ -0,0 +1,62 @@
+package rawdatalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+// activeConfig is a validated microservice config, swapped in as a whole
+type activeConfig struct {
+	version                   int
+	hash                      string
+	loadedAt                  time.Time
+	webhooks                  map[string]webhook
+	webhookStatsAuthorization string
+}
+
+// parseConfig validates the microservice config, including compiling the webhook schemas
+func parseConfig(b []byte, loadFile func(name string) ([]byte, error)) (*activeConfig, error) {
+	var data platform.HttpInputRawDataLogIngestorInfo
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid microservice config: %w", err)
+	}
+
+	webhooks := map[string]webhook{}
+	for _, config := range data.Extra.Webhooks {
+		if config.UriSuffix == "" {
+			return nil, fmt.Errorf("webhook of kind %s is missing uriSuffix", config.Kind)
+		}
+
+		if _, ok := webhooks[config.UriSuffix]; ok {
+			return nil, fmt.Errorf("webhook %s is configured more than once", config.UriSuffix)
+		}
+
+		webhook, err := newWebhook(config, loadFile)
+		if err != nil {
+			return nil, err
+		}
+		webhooks[config.UriSuffix] = webhook
+	}
+
+	sum := sha256.Sum256(b)
+	return &activeConfig{
+		hash:                      hex.EncodeToString(sum[:]),
+		loadedAt:                  time.Now().UTC(),
+		webhooks:                  webhooks,
+		webhookStatsAuthorization: data.Extra.WebhookStatsAuthorization,
+	}, nil
+}
+
+func (c *activeConfig) uriSuffixes() []string {
+	uriSuffixes := make([]string, 0, len(c.webhooks))
+	for uriSuffix := range c.webhooks {
+		uriSuffixes = append(uriSuffixes, uriSuffix)
+	}
+	return uriSuffixes
+}
@@ -1,5 +1,10 @@
 package rawdatalog
 
+import (
+	"context"
+	"time"
+)
+
 type RawMomentMetadata struct {
 	TenantID      string            `json:"tenantId"`
 	ApplicationID string            `json:"applicationId"`
@@ -17,3 +22,28 @@ type RawMoment struct {
 type Repo interface {
 	Write(topic string, moment RawMoment) error
 }
+
+// HttpResponseInvalidPayload is returned when the payload does not match the webhook schema
+type HttpResponseInvalidPayload struct {
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
+// LogEntry is a moment read back from the log, with its position
+type LogEntry struct {
+	Sequence  uint64    `json:"sequence"`
+	Timestamp int64     `json:"timestamp"`
+	Moment    RawMoment `json:"moment"`
+}
+
+// ReadPosition is where to start reading a topic from
+// If Sequence is set it wins over Time, if neither is set we start from the beginning
+type ReadPosition struct {
+	Sequence uint64
+	Time     time.Time
+}
+
+type Reader interface {
+	// Read calls onRead for each entry from position until the end of the topic or ctx is done
+	Read(ctx context.Context, topic string, position ReadPosition, onRead func(entry LogEntry) error) error
+}
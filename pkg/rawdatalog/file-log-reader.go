@@ -0,0 +1,104 @@
+package rawdatalog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type fileLogReader struct {
+	directory string
+}
+
+// NewFileLogReader reads the segments written by the file log repo in directory
+// Reading stops at the end of the last segment
+func NewFileLogReader(directory string) Reader {
+	return &fileLogReader{
+		directory: directory,
+	}
+}
+
+func (r *fileLogReader) Read(ctx context.Context, topic string, position ReadPosition, onRead func(entry LogEntry) error) error {
+	if !validTopic.MatchString(topic) {
+		return ErrInvalidTopic
+	}
+
+	directory := filepath.Join(r.directory, topic)
+	segments, err := listSegments(directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Skip the segments before the one holding the sequence we start at
+	start := 0
+	if position.Sequence != 0 {
+		found := sort.Search(len(segments), func(i int) bool {
+			return segments[i] > position.Sequence
+		})
+		if found > 0 {
+			start = found - 1
+		}
+	}
+
+	// Like stan, the sequence wins over the time
+	from := int64(0)
+	if position.Sequence == 0 && !position.Time.IsZero() {
+		from = position.Time.UTC().UnixNano()
+	}
+
+	for _, firstSequence := range segments[start:] {
+		err := readSegment(ctx, segmentPath(directory, firstSequence), func(entry LogEntry) error {
+			if entry.Sequence < position.Sequence || entry.Timestamp < from {
+				return nil
+			}
+			return onRead(entry)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSegment(ctx context.Context, path string, onRead func(entry LogEntry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A line without a newline is still being written
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry LogEntry
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return fmt.Errorf("failed to parse entry in %s: %w", path, err)
+		}
+
+		err = onRead(entry)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package rawdatalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy decides when the file log repo calls fsync
+type SyncPolicy string
+
+const (
+	// SyncAlways fsyncs after every write
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval fsyncs every FileLogOptions.SyncInterval, a crash can lose the writes since the last sync
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves it to the operating system
+	SyncNever SyncPolicy = "never"
+)
+
+const segmentExtension = ".ndjson"
+
+var (
+	ErrInvalidTopic = errors.New("topic can only contain letters, numbers, dots, dashes and underscores")
+	ErrRepoClosed   = errors.New("file log repo is closed")
+
+	validTopic = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+)
+
+type FileLogOptions struct {
+	// Directory holds a directory per topic with the segments
+	Directory       string
+	SegmentMaxBytes int64
+	Sync            SyncPolicy
+	SyncInterval    time.Duration
+}
+
+// DefaultFileLogOptions returns options suitable for local development
+func DefaultFileLogOptions(directory string) FileLogOptions {
+	return FileLogOptions{
+		Directory:       directory,
+		SegmentMaxBytes: 64 * 1024 * 1024,
+		Sync:            SyncInterval,
+		SyncInterval:    time.Second,
+	}
+}
+
+type fileTopic struct {
+	directory    string
+	file         *os.File
+	size         int64
+	nextSequence uint64
+	dirty        bool
+}
+
+type fileLogRepo struct {
+	mu      sync.Mutex
+	options FileLogOptions
+	topics  map[string]*fileTopic
+	closed  bool
+	done    chan struct{}
+}
+
+// NewFileLogRepo writes each topic to append only NDJSON segments in options.Directory
+// Each line is a LogEntry, segments are named after the first sequence they hold
+func NewFileLogRepo(options FileLogOptions) (*fileLogRepo, error) {
+	switch options.Sync {
+	case SyncAlways, SyncNever:
+	case SyncInterval:
+		if options.SyncInterval <= 0 {
+			return nil, errors.New("sync interval must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("sync policy %s not supported, pick always, interval or never", options.Sync)
+	}
+
+	if options.SegmentMaxBytes <= 0 {
+		return nil, errors.New("segment max bytes must be positive")
+	}
+
+	err := os.MkdirAll(options.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &fileLogRepo{
+		options: options,
+		topics:  map[string]*fileTopic{},
+		done:    make(chan struct{}),
+	}
+
+	if options.Sync == SyncInterval {
+		go r.syncEvery(options.SyncInterval)
+	}
+	return r, nil
+}
+
+func (r *fileLogRepo) Write(topic string, moment RawMoment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrRepoClosed
+	}
+
+	t, err := r.getTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	entry := LogEntry{
+		Sequence:  t.nextSequence,
+		Timestamp: time.Now().UTC().UnixNano(),
+		Moment:    moment,
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if t.size > 0 && t.size+int64(len(line)) > r.options.SegmentMaxBytes {
+		err := t.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := t.file.Write(line)
+	t.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	t.nextSequence++
+	t.dirty = true
+
+	if r.options.Sync == SyncAlways {
+		return t.sync()
+	}
+	return nil
+}
+
+// Close syncs and closes all open segments
+func (r *fileLogRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	close(r.done)
+
+	var firstErr error
+	for _, t := range r.topics {
+		if err := t.sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := t.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (r *fileLogRepo) syncEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			for _, t := range r.topics {
+				// Errors will surface on the next write or close
+				t.sync()
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// getTopic assumes the lock is held
+func (r *fileLogRepo) getTopic(topic string) (*fileTopic, error) {
+	if t, ok := r.topics[topic]; ok {
+		return t, nil
+	}
+
+	if !validTopic.MatchString(topic) {
+		return nil, ErrInvalidTopic
+	}
+
+	t, err := openFileTopic(filepath.Join(r.options.Directory, topic))
+	if err != nil {
+		return nil, err
+	}
+	r.topics[topic] = t
+	return t, nil
+}
+
+// openFileTopic opens the last segment for appending,
+// dropping a partially written last line from a previous crash
+func openFileTopic(directory string) (*fileTopic, error) {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := listSegments(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &fileTopic{
+		directory:    directory,
+		nextSequence: 1,
+	}
+
+	if len(segments) == 0 {
+		return t, t.openSegment(1)
+	}
+
+	last := segments[len(segments)-1]
+	path := segmentPath(directory, last)
+	lastSequence, validSize, err := scanSegment(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = file.Truncate(validSize)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	_, err = file.Seek(validSize, io.SeekStart)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	t.file = file
+	t.size = validSize
+	t.nextSequence = last
+	if lastSequence != 0 {
+		t.nextSequence = lastSequence + 1
+	}
+	return t, nil
+}
+
+func (t *fileTopic) openSegment(firstSequence uint64) error {
+	file, err := os.OpenFile(segmentPath(t.directory, firstSequence), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	t.file = file
+	t.size = 0
+	return nil
+}
+
+func (t *fileTopic) rotate() error {
+	err := t.sync()
+	if err != nil {
+		return err
+	}
+
+	err = t.file.Close()
+	if err != nil {
+		return err
+	}
+	return t.openSegment(t.nextSequence)
+}
+
+func (t *fileTopic) sync() error {
+	if !t.dirty {
+		return nil
+	}
+
+	err := t.file.Sync()
+	if err != nil {
+		return err
+	}
+	t.dirty = false
+	return nil
+}
+
+func segmentPath(directory string, firstSequence uint64) string {
+	return filepath.Join(directory, fmt.Sprintf("%020d%s", firstSequence, segmentExtension))
+}
+
+// listSegments returns the first sequence of each segment in the directory, sorted
+// This is the index used to find where a sequence is stored
+func listSegments(directory string) ([]uint64, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]uint64, 0, len(files))
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, segmentExtension) {
+			continue
+		}
+
+		firstSequence, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, firstSequence)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i] < segments[j]
+	})
+	return segments, nil
+}
+
+// scanSegment returns the last sequence in the segment and the size up to the last complete line
+func scanSegment(path string) (uint64, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	var (
+		lastSequence uint64
+		validSize    int64
+	)
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// Anything left is a partially written line
+			return lastSequence, validSize, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		var entry LogEntry
+		if err := json.Unmarshal(bytes.TrimSpace(line), &entry); err != nil {
+			return 0, 0, fmt.Errorf("segment %s is corrupt at offset %d: %w", path, validSize, err)
+		}
+
+		lastSequence = entry.Sequence
+		validSize += int64(len(line))
+	}
+}
@@ -0,0 +1,134 @@
+package rawdatalog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/dolittle/platform-api/pkg/rawdatalog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File log repo", func() {
+	var (
+		dir     string
+		options FileLogOptions
+	)
+
+	readAll := func(topic string, position ReadPosition) []LogEntry {
+		entries := []LogEntry{}
+		err := NewFileLogReader(dir).Read(context.Background(), topic, position, func(entry LogEntry) error {
+			entries = append(entries, entry)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return entries
+	}
+
+	write := func(repo Repo, topic string, count int) {
+		for i := 0; i < count; i++ {
+			Expect(repo.Write(topic, RawMoment{Kind: "purchaseorder", When: time.Now().Unix(), Data: i})).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "rawdatalog-file")
+		Expect(err).ToNot(HaveOccurred())
+		options = FileLogOptions{
+			Directory:       dir,
+			SegmentMaxBytes: 512,
+			Sync:            SyncAlways,
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should read back what was written with increasing sequences", func() {
+		repo, err := NewFileLogRepo(options)
+		Expect(err).ToNot(HaveOccurred())
+		write(repo, "purchaseorders", 3)
+		Expect(repo.Close()).To(Succeed())
+
+		entries := readAll("purchaseorders", ReadPosition{})
+		Expect(entries).To(HaveLen(3))
+		for i, entry := range entries {
+			Expect(entry.Sequence).To(Equal(uint64(i + 1)))
+			Expect(entry.Moment.Kind).To(Equal("purchaseorder"))
+			Expect(entry.Timestamp).ToNot(BeZero())
+		}
+	})
+
+	It("should rotate segments and read from a sequence", func() {
+		repo, err := NewFileLogRepo(options)
+		Expect(err).ToNot(HaveOccurred())
+		write(repo, "purchaseorders", 20)
+		Expect(repo.Close()).To(Succeed())
+
+		segments, _ := filepath.Glob(filepath.Join(dir, "purchaseorders", "*.ndjson"))
+		Expect(len(segments)).To(BeNumerically(">", 1))
+
+		entries := readAll("purchaseorders", ReadPosition{Sequence: 15})
+		Expect(entries).To(HaveLen(6))
+		Expect(entries[0].Sequence).To(Equal(uint64(15)))
+	})
+
+	It("should let the sequence win over the time", func() {
+		repo, err := NewFileLogRepo(options)
+		Expect(err).ToNot(HaveOccurred())
+		write(repo, "purchaseorders", 3)
+		Expect(repo.Close()).To(Succeed())
+
+		entries := readAll("purchaseorders", ReadPosition{Sequence: 2, Time: time.Now().Add(time.Hour)})
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Sequence).To(Equal(uint64(2)))
+	})
+
+	It("should continue the sequence after reopening and drop a partially written line", func() {
+		repo, err := NewFileLogRepo(options)
+		Expect(err).ToNot(HaveOccurred())
+		write(repo, "purchaseorders", 2)
+		Expect(repo.Close()).To(Succeed())
+
+		segments, _ := filepath.Glob(filepath.Join(dir, "purchaseorders", "*.ndjson"))
+		last := segments[len(segments)-1]
+		file, err := os.OpenFile(last, os.O_APPEND|os.O_WRONLY, 0644)
+		Expect(err).ToNot(HaveOccurred())
+		file.WriteString(`{"sequence":3,"moment":{"ki`)
+		file.Close()
+
+		repo, err = NewFileLogRepo(options)
+		Expect(err).ToNot(HaveOccurred())
+		write(repo, "purchaseorders", 1)
+		Expect(repo.Close()).To(Succeed())
+
+		entries := readAll("purchaseorders", ReadPosition{})
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[2].Sequence).To(Equal(uint64(3)))
+	})
+
+	It("should keep topics apart and reject unsafe topic names", func() {
+		repo, err := NewFileLogRepo(options)
+		Expect(err).ToNot(HaveOccurred())
+		defer repo.Close()
+
+		write(repo, "purchaseorders", 2)
+		write(repo, "items", 1)
+		Expect(repo.Write("../outside", RawMoment{})).To(Equal(ErrInvalidTopic))
+
+		Expect(readAll("purchaseorders", ReadPosition{})).To(HaveLen(2))
+		Expect(readAll("items", ReadPosition{})).To(HaveLen(1))
+		Expect(readAll("unknown", ReadPosition{})).To(BeEmpty())
+	})
+
+	It("should not accept an unknown sync policy", func() {
+		options.Sync = "sometimes"
+		_, err := NewFileLogRepo(options)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,88 @@
+package rawdatalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Filter decides which moments to include when reading the log
+// Empty fields match everything
+type Filter struct {
+	FromSequence uint64
+	ToSequence   uint64
+	From         time.Time
+	To           time.Time
+	Kinds        []string
+	Labels       map[string]string
+}
+
+// Matches returns true if the entry should be included
+func (f Filter) Matches(entry LogEntry) bool {
+	if f.FromSequence != 0 && entry.Sequence < f.FromSequence {
+		return false
+	}
+
+	if f.ToSequence != 0 && entry.Sequence > f.ToSequence {
+		return false
+	}
+
+	if !f.From.IsZero() && entry.Moment.When < f.From.UTC().Unix() {
+		return false
+	}
+
+	if !f.To.IsZero() && entry.Moment.When > f.To.UTC().Unix() {
+		return false
+	}
+
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, kind := range f.Kinds {
+			if kind == entry.Moment.Kind {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	for key, value := range f.Labels {
+		if entry.Moment.Metadata.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPast returns true if the entry is beyond the end of the filter,
+// letting readers stop early instead of reading to the end of the topic
+func (f Filter) IsPast(entry LogEntry) bool {
+	if f.ToSequence != 0 && entry.Sequence > f.ToSequence {
+		return true
+	}
+	return false
+}
+
+// Position returns where a reader should start to satisfy the filter
+func (f Filter) Position() ReadPosition {
+	return ReadPosition{
+		Sequence: f.FromSequence,
+		Time:     f.From,
+	}
+}
+
+// ParseLabels parses "key=value" pairs into a map
+func ParseLabels(pairs []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return labels, fmt.Errorf("label %s is not in the format key=value", pair)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
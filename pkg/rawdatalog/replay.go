@@ -0,0 +1,149 @@
+package rawdatalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// MaxRatePerSecond is the highest rate a HTTPSink can be limited to
+const MaxRatePerSecond = 1000
+
+var (
+	errStopReading = errors.New("stop reading")
+	ErrInvalidRate = fmt.Errorf("rate must be between 0 and %d requests per second", MaxRatePerSecond)
+)
+
+// Sink receives the entries read from the log
+type Sink interface {
+	Write(ctx context.Context, entry LogEntry) error
+}
+
+// Copy reads topic from the start of the filter and writes every matching entry to sink
+// Returns the number of entries written
+func Copy(ctx context.Context, reader Reader, topic string, filter Filter, sink Sink) (int, error) {
+	written := 0
+	err := reader.Read(ctx, topic, filter.Position(), func(entry LogEntry) error {
+		if filter.IsPast(entry) {
+			return errStopReading
+		}
+
+		if !filter.Matches(entry) {
+			return nil
+		}
+
+		err := sink.Write(ctx, entry)
+		if err != nil {
+			return fmt.Errorf("failed to write entry at sequence %d: %w", entry.Sequence, err)
+		}
+		written++
+		return nil
+	})
+
+	if err == errStopReading {
+		return written, nil
+	}
+	return written, err
+}
+
+type ndjsonSink struct {
+	encoder *json.Encoder
+}
+
+// NewNDJSONSink writes one entry per line
+func NewNDJSONSink(w io.Writer) Sink {
+	return &ndjsonSink{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (s *ndjsonSink) Write(ctx context.Context, entry LogEntry) error {
+	return s.encoder.Encode(entry)
+}
+
+// HTTPSink POSTs the entries to a http endpoint
+type HTTPSink struct {
+	client   *http.Client
+	url      string
+	headers  map[string]string
+	dataOnly bool
+	ticker   *time.Ticker
+}
+
+// NewHTTPSink POSTs each moment to url
+// ratePerSecond limits how many requests are made per second, 0 means no limit
+// dataOnly posts the original payload (moment.Data) instead of the whole moment
+// Close the sink when done to stop the rate limiter
+func NewHTTPSink(client *http.Client, url string, headers map[string]string, ratePerSecond int, dataOnly bool) (*HTTPSink, error) {
+	if ratePerSecond < 0 || ratePerSecond > MaxRatePerSecond {
+		return nil, ErrInvalidRate
+	}
+
+	s := &HTTPSink{
+		client:   client,
+		url:      url,
+		headers:  headers,
+		dataOnly: dataOnly,
+	}
+
+	if ratePerSecond > 0 {
+		s.ticker = time.NewTicker(time.Second / time.Duration(ratePerSecond))
+	}
+	return s, nil
+}
+
+// Close stops the rate limiter
+func (s *HTTPSink) Close() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+}
+
+// Write POSTs the entry, the request is cancelled with ctx
+func (s *HTTPSink) Write(ctx context.Context, entry LogEntry) error {
+	if s.ticker != nil {
+		select {
+		case <-s.ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var payload interface{} = entry.Moment
+	if s.dataOnly {
+		payload = entry.Moment.Data
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("target responded with %s", resp.Status)
+	}
+	return nil
+}
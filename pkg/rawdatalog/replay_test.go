@@ -0,0 +1,193 @@
+package rawdatalog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dolittle/platform-api/pkg/rawdatalog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type inMemoryReader struct {
+	entries []LogEntry
+}
+
+func (r *inMemoryReader) Read(ctx context.Context, topic string, position ReadPosition, onRead func(entry LogEntry) error) error {
+	for _, entry := range r.entries {
+		if entry.Sequence < position.Sequence {
+			continue
+		}
+		if err := onRead(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newEntry(sequence uint64, kind string, when time.Time, uriSuffix string) LogEntry {
+	return LogEntry{
+		Sequence: sequence,
+		Moment: RawMoment{
+			Kind: kind,
+			When: when.Unix(),
+			Metadata: RawMomentMetadata{
+				Labels: map[string]string{
+					"uriSuffix": uriSuffix,
+				},
+			},
+			Data: map[string]interface{}{"sequence": sequence},
+		},
+	}
+}
+
+var _ = Describe("Replay", func() {
+	var (
+		start  time.Time
+		reader *inMemoryReader
+	)
+
+	BeforeEach(func() {
+		start = time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+		reader = &inMemoryReader{
+			entries: []LogEntry{
+				newEntry(1, "purchaseorder", start, "m3/purchaseorder"),
+				newEntry(2, "item", start.Add(time.Hour), "m3/item"),
+				newEntry(3, "purchaseorder", start.Add(2*time.Hour), "m3/purchaseorder"),
+				newEntry(4, "purchaseorder", start.Add(3*time.Hour), "other/purchaseorder"),
+			},
+		}
+	})
+
+	Describe("when filtering", func() {
+		It("should match everything with an empty filter", func() {
+			for _, entry := range reader.entries {
+				Expect(Filter{}.Matches(entry)).To(BeTrue())
+			}
+		})
+
+		It("should filter by kind and label", func() {
+			filter := Filter{
+				Kinds:  []string{"purchaseorder"},
+				Labels: map[string]string{"uriSuffix": "m3/purchaseorder"},
+			}
+			Expect(filter.Matches(reader.entries[0])).To(BeTrue())
+			Expect(filter.Matches(reader.entries[1])).To(BeFalse())
+			Expect(filter.Matches(reader.entries[2])).To(BeTrue())
+			Expect(filter.Matches(reader.entries[3])).To(BeFalse())
+		})
+
+		It("should filter by time range", func() {
+			filter := Filter{
+				From: start.Add(30 * time.Minute),
+				To:   start.Add(2 * time.Hour),
+			}
+			Expect(filter.Matches(reader.entries[0])).To(BeFalse())
+			Expect(filter.Matches(reader.entries[1])).To(BeTrue())
+			Expect(filter.Matches(reader.entries[2])).To(BeTrue())
+			Expect(filter.Matches(reader.entries[3])).To(BeFalse())
+		})
+	})
+
+	Describe("when parsing labels", func() {
+		It("should parse key value pairs", func() {
+			labels, err := ParseLabels([]string{"uriSuffix=m3/item", "header=a=b"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(Equal(map[string]string{"uriSuffix": "m3/item", "header": "a=b"}))
+		})
+
+		It("should fail without a value", func() {
+			_, err := ParseLabels([]string{"uriSuffix"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("when exporting", func() {
+		It("should write matching entries as ndjson and stop after the last sequence", func() {
+			var b bytes.Buffer
+			filter := Filter{
+				FromSequence: 2,
+				ToSequence:   3,
+				Kinds:        []string{"purchaseorder"},
+			}
+			written, err := Copy(context.Background(), reader, "topic.todo", filter, NewNDJSONSink(&b))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(written).To(Equal(1))
+
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			Expect(lines).To(HaveLen(1))
+
+			var entry LogEntry
+			Expect(json.Unmarshal([]byte(lines[0]), &entry)).To(Succeed())
+			Expect(entry.Sequence).To(Equal(uint64(3)))
+		})
+	})
+
+	Describe("when replaying", func() {
+		It("should post each moment to the target with the headers", func() {
+			var received []map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Authorization")).To(Equal("Bearer fake"))
+				var data map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&data)
+				received = append(received, data)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			sink, err := NewHTTPSink(server.Client(), server.URL, map[string]string{"Authorization": "Bearer fake"}, 0, true)
+			Expect(err).ToNot(HaveOccurred())
+			defer sink.Close()
+			written, err := Copy(context.Background(), reader, "topic.todo", Filter{Kinds: []string{"item"}}, sink)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(written).To(Equal(1))
+			Expect(received).To(Equal([]map[string]interface{}{{"sequence": float64(2)}}))
+		})
+
+		It("should stop when the target fails", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			sink, err := NewHTTPSink(server.Client(), server.URL, map[string]string{}, 0, false)
+			Expect(err).ToNot(HaveOccurred())
+			defer sink.Close()
+			written, err := Copy(context.Background(), reader, "topic.todo", Filter{}, sink)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("sequence 1"))
+			Expect(written).To(Equal(0))
+		})
+
+		It("should not post when the context is cancelled", func() {
+			called := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			sink, err := NewHTTPSink(server.Client(), server.URL, map[string]string{}, 0, false)
+			Expect(err).ToNot(HaveOccurred())
+			defer sink.Close()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err = sink.Write(ctx, LogEntry{Sequence: 1})
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(called).To(BeFalse())
+		})
+
+		It("should not accept a rate it can not limit to", func() {
+			for _, rate := range []int{-1, MaxRatePerSecond + 1, 2000000000} {
+				_, err := NewHTTPSink(http.DefaultClient, "http://localhost", map[string]string{}, rate, false)
+				Expect(err).To(Equal(ErrInvalidRate))
+			}
+		})
+	})
+})
@@ -1,130 +1,116 @@
 package rawdatalog
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dolittle/platform-api/pkg/platform"
 	"github.com/dolittle/platform-api/pkg/utils"
-	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 )
 
 type service struct {
-	logContext               logrus.FieldLogger
-	repo                     Repo
-	uriPrefix                string
-	pathToMicroserviceConfig string
-	topic                    string
-	tenantID                 string
-	applicationID            string
-	environment              string
-	allowedUriSuffixes       map[string]platform.RawDataLogIngestorWebhookConfig
+	logContext    logrus.FieldLogger
+	repo          Repo
+	uriPrefix     string
+	configSource  ConfigSource
+	topic         string
+	tenantID      string
+	applicationID string
+	environment   string
+	stats         *webhookStatsTracker
+
+	mu              sync.RWMutex
+	config          *activeConfig
+	lastReloadError string
 }
 
-func NewService(logContext logrus.FieldLogger, uriPrefix string, pathToMicroserviceConfig string, topic string, repo Repo, tenantID string, applicationID string, environment string) *service {
+// NewService loads the microservice config from configSource and keeps it up to date whilst ctx is not done
+// A config that fails to load is logged and the last good config is kept,
+// if the first load fails no webhooks are allowed until a good config is loaded
+func NewService(ctx context.Context, logContext logrus.FieldLogger, uriPrefix string, configSource ConfigSource, topic string, repo Repo, tenantID string, applicationID string, environment string) *service {
 	s := &service{
-		logContext:               logContext,
-		uriPrefix:                uriPrefix,
-		pathToMicroserviceConfig: pathToMicroserviceConfig,
-		topic:                    topic,
-		repo:                     repo,
-		tenantID:                 tenantID,
-		applicationID:            applicationID,
-		environment:              environment,
-	}
-
-	s.loadAllowedUriSuffixes()
-	go s.watchAndLoadAllowedUriSuffixes()
+		logContext:    logContext,
+		uriPrefix:     uriPrefix,
+		configSource:  configSource,
+		topic:         topic,
+		repo:          repo,
+		tenantID:      tenantID,
+		applicationID: applicationID,
+		environment:   environment,
+		stats:         newWebhookStatsTracker(),
+		config: &activeConfig{
+			webhooks: map[string]webhook{},
+		},
+	}
+
+	s.Reload()
+	go configSource.Watch(ctx, func() {
+		s.Reload()
+	})
 	return s
 }
 
-func (s *service) loadAllowedUriSuffixes() {
-	// TODO add watch to the file
-	b, err := ioutil.ReadFile(s.pathToMicroserviceConfig)
-
-	var data platform.HttpInputRawDataLogIngestorInfo
-	if err != nil {
-		s.logContext.WithFields(logrus.Fields{
-			"error":                    err,
-			"pathToMicroserviceConfig": s.pathToMicroserviceConfig,
-		}).Fatal("loading microservice config")
+// Reload loads and validates the config, only swapping it in if it is valid
+func (s *service) Reload() error {
+	b, err := s.configSource.Load()
+	if err == nil {
+		var config *activeConfig
+		config, err = parseConfig(b, s.configSource.LoadFile)
+		if err == nil {
+			s.swapConfig(config)
+			return nil
+		}
 	}
 
-	err = json.Unmarshal(b, &data)
-	if err != nil {
-		s.logContext.WithFields(logrus.Fields{
-			"error":                    err,
-			"pathToMicroserviceConfig": s.pathToMicroserviceConfig,
-		}).Fatal("loading microservice config")
-	}
+	s.mu.Lock()
+	s.lastReloadError = err.Error()
+	hash := s.config.hash
+	s.mu.Unlock()
 
-	allowedUriSuffixes := map[string]platform.RawDataLogIngestorWebhookConfig{}
-	for _, webhook := range data.Extra.Webhooks {
-		allowedUriSuffixes[webhook.UriSuffix] = webhook
-	}
-	s.allowedUriSuffixes = allowedUriSuffixes
 	s.logContext.WithFields(logrus.Fields{
-		"webhooks": s.allowedUriSuffixes,
-	}).Info("allowedUriSuffix updated")
+		"error":      err,
+		"configHash": hash,
+	}).Error("failed to reload microservice config, keeping the last good config")
+	return err
 }
 
-func (s *service) watchAndLoadAllowedUriSuffixes() {
-
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		log.Fatal(err)
+func (s *service) swapConfig(config *activeConfig) {
+	s.mu.Lock()
+	previous := s.config
+	if previous.hash == config.hash {
+		// Watchers fire more than once for the same change
+		s.lastReloadError = ""
+		s.mu.Unlock()
+		return
 	}
-	defer watcher.Close()
 
-	err = watcher.Add(s.pathToMicroserviceConfig)
-	if err != nil {
-		s.logContext.WithFields(logrus.Fields{
-			"error": err,
-		}).Fatal("error whilst starting to watch file change")
+	config.version = previous.version + 1
+	s.config = config
+	s.lastReloadError = ""
+	s.mu.Unlock()
+
+	webhooks := make([]platform.RawDataLogIngestorWebhookConfig, 0, len(config.webhooks))
+	for _, webhook := range config.webhooks {
+		webhooks = append(webhooks, webhook.RawDataLogIngestorWebhookConfig)
 	}
 
-	for {
-		select {
-		case event, ok := <-watcher.Events:
-			if !ok {
-				s.logContext.WithFields(logrus.Fields{
-					"ok":    ok,
-					"event": event,
-				}).Info("Event")
-				return
-			}
+	s.logContext.WithFields(logrus.Fields{
+		"webhooks":      webhooks,
+		"configVersion": config.version,
+		"configHash":    config.hash,
+	}).Info("allowedUriSuffix updated")
+}
 
-			s.logContext.WithFields(logrus.Fields{
-				"event": event,
-			}).Info("Event")
-
-			// https://martensson.io/go-fsnotify-and-kubernetes-configmaps/
-			if event.Op == fsnotify.Remove {
-				watcher.Remove(event.Name)
-				watcher.Add(s.pathToMicroserviceConfig)
-				s.loadAllowedUriSuffixes()
-				continue
-			}
-
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				s.logContext.Info("file written")
-				s.loadAllowedUriSuffixes()
-			}
-		case err, ok := <-watcher.Errors:
-			if !ok {
-				return
-			}
-			s.logContext.WithFields(logrus.Fields{
-				"error": err,
-			}).Fatal("error whilst watching file change")
-		}
-	}
+func (s *service) getConfig() *activeConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
 }
 
 func (s *service) Webhook(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +126,7 @@ func (s *service) Webhook(w http.ResponseWriter, r *http.Request) {
 	pathname = strings.TrimSuffix(pathname, "/")
 
 	// TODO read from config-files
-	webhook, ok := s.allowedUriSuffixes[pathname]
+	webhook, ok := s.getConfig().webhooks[pathname]
 	if !ok {
 		s.logContext.WithFields(logrus.Fields{
 			"error":            fmt.Sprintf("uriSuffix not on the list: %s", pathname),
@@ -160,10 +146,13 @@ func (s *service) Webhook(w http.ResponseWriter, r *http.Request) {
 			"webhookUriSuffix":     webhook.UriSuffix,
 			"context":              "checking authorization",
 		}).Error("webhook")
+		s.stats.rejectedAuthorization(webhook.UriSuffix)
 		utils.RespondWithError(w, http.StatusForbidden, "Webhook not supported, failed authorization")
 		return
 	}
 
+	s.stats.received(webhook.UriSuffix)
+
 	parts := strings.Split(pathname, "/")
 
 	labels := map[string]string{
@@ -193,6 +182,7 @@ func (s *service) Webhook(w http.ResponseWriter, r *http.Request) {
 			"error":   err,
 			"context": "incoming payload",
 		}).Error("webhook")
+		s.stats.badPayload(webhook.UriSuffix, err)
 		utils.RespondWithError(w, http.StatusBadRequest, "Failed to pass payload")
 		return
 	}
@@ -204,16 +194,108 @@ func (s *service) Webhook(w http.ResponseWriter, r *http.Request) {
 		Metadata: metadata,
 	}
 
+	validationErrors, err := webhook.validate(dst)
+	if err != nil {
+		s.logContext.WithFields(logrus.Fields{
+			"error":   err,
+			"context": "validating payload",
+		}).Error("webhook")
+		s.stats.badPayload(webhook.UriSuffix, err)
+		utils.RespondWithError(w, http.StatusBadRequest, "Failed to validate payload")
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		s.logContext.WithFields(logrus.Fields{
+			"error":            errInvalidPayload,
+			"validationErrors": validationErrors,
+			"webhookUriSuffix": webhook.UriSuffix,
+			"context":          "validating payload",
+		}).Error("webhook")
+		s.stats.invalidPayload(webhook.UriSuffix, validationErrors)
+
+		if !webhook.deadLetters() {
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, HttpResponseInvalidPayload{
+				Message: errInvalidPayload.Error(),
+				Errors:  validationErrors,
+			})
+			return
+		}
+
+		moment.Metadata.Labels["validationError"] = strings.Join(validationErrors, "; ")
+		err = s.repo.Write(webhook.deadLetterTopic(topic), moment)
+		if err != nil {
+			s.logContext.WithFields(logrus.Fields{
+				"error":   err,
+				"context": "writing to dead letter log",
+			}).Error("webhook")
+			s.stats.writeFailure(webhook.UriSuffix, err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to log")
+			return
+		}
+
+		s.stats.deadLettered(webhook.UriSuffix)
+		utils.RespondNoContent(w, http.StatusAccepted)
+		return
+	}
+
 	err = s.repo.Write(topic, moment)
 	if err != nil {
 		s.logContext.WithFields(logrus.Fields{
 			"error":   err,
 			"context": "writing to log",
 		}).Error("webhook")
+		s.stats.writeFailure(webhook.UriSuffix, err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to write to log")
 		return
 	}
 
+	s.stats.accepted(webhook.UriSuffix)
+
 	utils.RespondNoContent(w, http.StatusOK)
 	return
 }
+
+// WebhookStats returns the counters for each configured webhook
+// The request must have the webhookStatsAuthorization from the microservice config as the Authorization header
+func (s *service) WebhookStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	config := s.config
+	lastReloadError := s.lastReloadError
+	s.mu.RUnlock()
+
+	authorization := config.webhookStatsAuthorization
+
+	if authorization == "" {
+		utils.RespondWithError(w, http.StatusForbidden, "Webhook stats are not enabled")
+		return
+	}
+
+	if r.Header.Get("Authorization") != authorization {
+		s.logContext.WithFields(logrus.Fields{
+			"error":   "authorization failed",
+			"context": "checking stats authorization",
+		}).Error("webhook stats")
+		utils.RespondWithError(w, http.StatusForbidden, "Webhook stats, failed authorization")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, platform.HttpResponseRawDataLogIngestorWebhookStats{
+		ApplicationID: s.applicationID,
+		Environment:   s.environment,
+		Webhooks:      s.stats.snapshot(config.uriSuffixes()),
+		Config: platform.RawDataLogIngestorConfigStatus{
+			Version:         config.version,
+			Hash:            config.hash,
+			LoadedAt:        formatTime(config.loadedAt),
+			LastReloadError: lastReloadError,
+		},
+	})
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
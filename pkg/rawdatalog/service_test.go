@@ -0,0 +1,238 @@
+package rawdatalog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	. "github.com/dolittle/platform-api/pkg/rawdatalog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+type fakeRepo struct {
+	written []RawMoment
+	topics  []string
+	err     error
+}
+
+func (r *fakeRepo) Write(topic string, moment RawMoment) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.written = append(r.written, moment)
+	r.topics = append(r.topics, topic)
+	return nil
+}
+
+var _ = Describe("Service", func() {
+	var (
+		dir        string
+		configPath string
+		repo       *fakeRepo
+		service    interface{ Reload() error }
+		handler    http.Handler
+		stats      http.HandlerFunc
+		cancel     context.CancelFunc
+	)
+
+	writeConfig := func(info platform.HttpInputRawDataLogIngestorInfo) {
+		b, _ := json.Marshal(info)
+		Expect(ioutil.WriteFile(configPath, b, 0644)).To(Succeed())
+	}
+
+	post := func(path string, authorization string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		request.Header.Set("Authorization", authorization)
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	getStats := func(authorization string) (*httptest.ResponseRecorder, platform.HttpResponseRawDataLogIngestorWebhookStats) {
+		var response platform.HttpResponseRawDataLogIngestorWebhookStats
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		request.Header.Set("Authorization", authorization)
+		stats(recorder, request)
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "rawdatalog")
+		Expect(err).ToNot(HaveOccurred())
+		configPath = filepath.Join(dir, "ms.json")
+
+		schema := `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`
+		Expect(ioutil.WriteFile(filepath.Join(dir, "item.schema.json"), []byte(schema), 0644)).To(Succeed())
+
+		writeConfig(platform.HttpInputRawDataLogIngestorInfo{
+			Extra: platform.HttpInputRawDataLogIngestorExtra{
+				Webhooks: []platform.RawDataLogIngestorWebhookConfig{
+					{Kind: "purchaseorder", UriSuffix: "m3/purchaseorder", Authorization: "Bearer webhook"},
+					{Kind: "item", UriSuffix: "m3/item", Authorization: "Bearer webhook", Schema: &platform.RawDataLogIngestorWebhookSchema{
+						ConfigFile: "item.schema.json",
+					}},
+					{Kind: "order", UriSuffix: "m3/order", Authorization: "Bearer webhook", Schema: &platform.RawDataLogIngestorWebhookSchema{
+						Inline: map[string]interface{}{
+							"type":     "object",
+							"required": []string{"orderNumber"},
+						},
+						OnInvalid: platform.RawDataLogIngestorOnInvalidDeadLetter,
+					}},
+				},
+				WebhookStatsAuthorization: "Bearer stats",
+			},
+		})
+
+		repo = &fakeRepo{}
+		logger, _ := logrusTest.NewNullLogger()
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		s := NewService(ctx, logger, "/webhook", NewFileConfigSource(configPath, logger), "topic.todo", repo, "tenant", "application", "dev")
+		service = s
+		handler = http.HandlerFunc(s.Webhook)
+		stats = s.WebhookStats
+	})
+
+	AfterEach(func() {
+		cancel()
+		os.RemoveAll(dir)
+	})
+
+	It("should count accepted, rejected and bad payloads per uri suffix", func() {
+		Expect(post("/webhook/m3/purchaseorder", "Bearer webhook", `{"id": 1}`).Code).To(Equal(http.StatusOK))
+		Expect(post("/webhook/m3/purchaseorder", "Bearer wrong", `{"id": 2}`).Code).To(Equal(http.StatusForbidden))
+		Expect(post("/webhook/m3/purchaseorder", "Bearer webhook", `{"id": `).Code).To(Equal(http.StatusBadRequest))
+
+		repo.err = errors.New("log is down")
+		Expect(post("/webhook/m3/purchaseorder", "Bearer webhook", `{"id": 3}`).Code).To(Equal(http.StatusInternalServerError))
+
+		recorder, response := getStats("Bearer stats")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(response.Webhooks).To(HaveLen(3))
+
+		item := response.Webhooks[0]
+		Expect(item.UriSuffix).To(Equal("m3/item"))
+		Expect(item.Accepted).To(BeZero())
+		Expect(item.LastReceived).To(BeEmpty())
+
+		purchaseOrder := response.Webhooks[2]
+		Expect(purchaseOrder.UriSuffix).To(Equal("m3/purchaseorder"))
+		Expect(purchaseOrder.Accepted).To(Equal(int64(1)))
+		Expect(purchaseOrder.RejectedAuthorization).To(Equal(int64(1)))
+		Expect(purchaseOrder.BadPayload).To(Equal(int64(1)))
+		Expect(purchaseOrder.WriteFailures).To(Equal(int64(1)))
+		Expect(purchaseOrder.LastError).To(Equal("log is down"))
+		Expect(purchaseOrder.LastReceived).ToNot(BeEmpty())
+	})
+
+	It("should not count a request that failed authorization as received", func() {
+		Expect(post("/webhook/m3/item", "Bearer wrong", `{"id": 1}`).Code).To(Equal(http.StatusForbidden))
+
+		_, response := getStats("Bearer stats")
+		item := response.Webhooks[0]
+		Expect(item.RejectedAuthorization).To(Equal(int64(1)))
+		Expect(item.LastReceived).To(BeEmpty())
+	})
+
+	It("should require the stats authorization", func() {
+		recorder, _ := getStats("Bearer webhook")
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+	})
+
+	Describe("when the webhook has a schema", func() {
+		It("should write valid payloads", func() {
+			Expect(post("/webhook/m3/item", "Bearer webhook", `{"id": 1}`).Code).To(Equal(http.StatusOK))
+			Expect(repo.topics).To(Equal([]string{"topic.todo"}))
+		})
+
+		It("should reject invalid payloads with the validation errors", func() {
+			recorder := post("/webhook/m3/item", "Bearer webhook", `{"id": "one"}`)
+			Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(repo.written).To(BeEmpty())
+
+			var response HttpResponseInvalidPayload
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.Errors).To(HaveLen(1))
+
+			_, stats := getStats("Bearer stats")
+			Expect(stats.Webhooks[0].UriSuffix).To(Equal("m3/item"))
+			Expect(stats.Webhooks[0].InvalidPayload).To(Equal(int64(1)))
+			Expect(stats.Webhooks[0].Accepted).To(BeZero())
+		})
+
+		It("should route invalid payloads to the dead letter topic", func() {
+			Expect(post("/webhook/m3/order", "Bearer webhook", `{"id": 1}`).Code).To(Equal(http.StatusAccepted))
+			Expect(repo.topics).To(Equal([]string{"topic.todo.dead-letter"}))
+			Expect(repo.written[0].Metadata.Labels["validationError"]).ToNot(BeEmpty())
+
+			_, stats := getStats("Bearer stats")
+			Expect(stats.Webhooks[1].UriSuffix).To(Equal("m3/order"))
+			Expect(stats.Webhooks[1].InvalidPayload).To(Equal(int64(1)))
+			Expect(stats.Webhooks[1].DeadLettered).To(Equal(int64(1)))
+		})
+	})
+
+	Describe("when reloading the config", func() {
+		It("should report the active config", func() {
+			_, response := getStats("Bearer stats")
+			Expect(response.Config.Version).To(Equal(1))
+			Expect(response.Config.Hash).To(HaveLen(64))
+			Expect(response.Config.LastReloadError).To(BeEmpty())
+		})
+
+		It("should keep the last good config when the new one is invalid", func() {
+			Expect(ioutil.WriteFile(configPath, []byte(`{"extra": {"webhooks": [`), 0644)).To(Succeed())
+			Expect(service.Reload()).ToNot(Succeed())
+
+			Expect(post("/webhook/m3/purchaseorder", "Bearer webhook", `{"id": 1}`).Code).To(Equal(http.StatusOK))
+
+			_, response := getStats("Bearer stats")
+			Expect(response.Config.Version).To(Equal(1))
+			Expect(response.Config.LastReloadError).ToNot(BeEmpty())
+		})
+
+		It("should keep the last good config when a schema is broken", func() {
+			writeConfig(platform.HttpInputRawDataLogIngestorInfo{
+				Extra: platform.HttpInputRawDataLogIngestorExtra{
+					Webhooks: []platform.RawDataLogIngestorWebhookConfig{
+						{Kind: "item", UriSuffix: "m3/item", Schema: &platform.RawDataLogIngestorWebhookSchema{
+							ConfigFile: "missing.schema.json",
+						}},
+					},
+				},
+			})
+			Expect(service.Reload()).ToNot(Succeed())
+			Expect(post("/webhook/m3/purchaseorder", "Bearer webhook", `{"id": 1}`).Code).To(Equal(http.StatusOK))
+		})
+
+		It("should swap in a valid config", func() {
+			writeConfig(platform.HttpInputRawDataLogIngestorInfo{
+				Extra: platform.HttpInputRawDataLogIngestorExtra{
+					Webhooks: []platform.RawDataLogIngestorWebhookConfig{
+						{Kind: "invoice", UriSuffix: "m3/invoice", Authorization: "Bearer webhook"},
+					},
+					WebhookStatsAuthorization: "Bearer stats",
+				},
+			})
+			Expect(service.Reload()).To(Succeed())
+
+			Expect(post("/webhook/m3/purchaseorder", "Bearer webhook", `{"id": 1}`).Code).To(Equal(http.StatusForbidden))
+			Expect(post("/webhook/m3/invoice", "Bearer webhook", `{"id": 1}`).Code).To(Equal(http.StatusOK))
+
+			_, response := getStats("Bearer stats")
+			Expect(response.Config.Version).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,86 @@
+package rawdatalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/stan.go"
+)
+
+type stanLogReader struct {
+	sc          stan.Conn
+	idleTimeout time.Duration
+}
+
+// NewStanLogReader reads a topic with a non durable subscription
+// The topic is considered fully read when no message arrives within idleTimeout
+func NewStanLogReader(sc stan.Conn, idleTimeout time.Duration) Reader {
+	return &stanLogReader{
+		sc:          sc,
+		idleTimeout: idleTimeout,
+	}
+}
+
+func (r *stanLogReader) Read(ctx context.Context, topic string, position ReadPosition, onRead func(entry LogEntry) error) error {
+	messages := make(chan *stan.Msg)
+	done := make(chan struct{})
+	defer close(done)
+
+	opts := []stan.SubscriptionOption{
+		stan.MaxInflight(1),
+	}
+
+	switch {
+	case position.Sequence != 0:
+		opts = append(opts, stan.StartAtSequence(position.Sequence))
+	case !position.Time.IsZero():
+		opts = append(opts, stan.StartAtTime(position.Time))
+	default:
+		opts = append(opts, stan.DeliverAllAvailable())
+	}
+
+	subscription, err := r.sc.Subscribe(topic, func(msg *stan.Msg) {
+		select {
+		case messages <- msg:
+		case <-done:
+		}
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	idle := time.NewTimer(r.idleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-idle.C:
+			return nil
+		case msg := <-messages:
+			var moment RawMoment
+			err := json.Unmarshal(msg.Data, &moment)
+			if err != nil {
+				return fmt.Errorf("failed to parse message at sequence %d: %w", msg.Sequence, err)
+			}
+
+			err = onRead(LogEntry{
+				Sequence:  msg.Sequence,
+				Timestamp: msg.Timestamp,
+				Moment:    moment,
+			})
+			if err != nil {
+				return err
+			}
+
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(r.idleTimeout)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package rawdatalog
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+)
+
+// WebhookStatsPath is where the webhook stats are served, the platform-api proxies to it
+const WebhookStatsPath = "/stats"
+
+type webhookStatsTracker struct {
+	mu    sync.Mutex
+	stats map[string]*platform.RawDataLogIngestorWebhookStats
+}
+
+func newWebhookStatsTracker() *webhookStatsTracker {
+	return &webhookStatsTracker{
+		stats: map[string]*platform.RawDataLogIngestorWebhookStats{},
+	}
+}
+
+// get assumes the lock is held
+func (t *webhookStatsTracker) get(uriSuffix string) *platform.RawDataLogIngestorWebhookStats {
+	stats, ok := t.stats[uriSuffix]
+	if !ok {
+		stats = &platform.RawDataLogIngestorWebhookStats{
+			UriSuffix: uriSuffix,
+		}
+		t.stats[uriSuffix] = stats
+	}
+	return stats
+}
+
+func (t *webhookStatsTracker) received(uriSuffix string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(uriSuffix).LastReceived = time.Now().UTC().Format(time.RFC3339)
+}
+
+func (t *webhookStatsTracker) accepted(uriSuffix string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(uriSuffix).Accepted++
+}
+
+func (t *webhookStatsTracker) rejectedAuthorization(uriSuffix string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.get(uriSuffix)
+	stats.RejectedAuthorization++
+	stats.LastError = "authorization failed"
+}
+
+func (t *webhookStatsTracker) badPayload(uriSuffix string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.get(uriSuffix)
+	stats.BadPayload++
+	stats.LastError = err.Error()
+}
+
+func (t *webhookStatsTracker) invalidPayload(uriSuffix string, validationErrors []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.get(uriSuffix)
+	stats.InvalidPayload++
+	stats.LastError = strings.Join(validationErrors, "; ")
+}
+
+func (t *webhookStatsTracker) deadLettered(uriSuffix string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(uriSuffix).DeadLettered++
+}
+
+func (t *webhookStatsTracker) writeFailure(uriSuffix string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.get(uriSuffix)
+	stats.WriteFailures++
+	stats.LastError = err.Error()
+}
+
+// snapshot returns a copy of the stats for the given uriSuffixes, sorted by uriSuffix
+// uriSuffixes without any traffic are included with zero values
+func (t *webhookStatsTracker) snapshot(uriSuffixes []string) []platform.RawDataLogIngestorWebhookStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := make([]platform.RawDataLogIngestorWebhookStats, 0, len(uriSuffixes))
+	for _, uriSuffix := range uriSuffixes {
+		snapshot = append(snapshot, *t.get(uriSuffix))
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].UriSuffix < snapshot[j].UriSuffix
+	})
+	return snapshot
+}
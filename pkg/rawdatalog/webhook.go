@@ -0,0 +1,89 @@
+package rawdatalog
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dolittle/platform-api/pkg/platform"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// webhook is a configured webhook with its schema compiled
+type webhook struct {
+	platform.RawDataLogIngestorWebhookConfig
+	schema *gojsonschema.Schema
+}
+
+// newWebhook compiles the schema of the webhook
+// loadFile is used when the schema points to a config file
+func newWebhook(config platform.RawDataLogIngestorWebhookConfig, loadFile func(name string) ([]byte, error)) (webhook, error) {
+	w := webhook{
+		RawDataLogIngestorWebhookConfig: config,
+	}
+
+	if config.Schema == nil {
+		return w, nil
+	}
+
+	switch config.Schema.OnInvalid {
+	case "", platform.RawDataLogIngestorOnInvalidReject, platform.RawDataLogIngestorOnInvalidDeadLetter:
+	default:
+		return w, fmt.Errorf("webhook %s: onInvalid %s not supported, pick reject or dead-letter", config.UriSuffix, config.Schema.OnInvalid)
+	}
+
+	var loader gojsonschema.JSONLoader
+	switch {
+	case config.Schema.Inline != nil:
+		loader = gojsonschema.NewGoLoader(config.Schema.Inline)
+	case config.Schema.ConfigFile != "":
+		b, err := loadFile(config.Schema.ConfigFile)
+		if err != nil {
+			return w, fmt.Errorf("webhook %s: failed to read schema: %w", config.UriSuffix, err)
+		}
+		loader = gojsonschema.NewBytesLoader(b)
+	default:
+		return w, fmt.Errorf("webhook %s: schema needs inline or configFile", config.UriSuffix)
+	}
+
+	schema, err := gojsonschema.NewSchema(loader)
+	if err != nil {
+		return w, fmt.Errorf("webhook %s: invalid schema: %w", config.UriSuffix, err)
+	}
+	w.schema = schema
+	return w, nil
+}
+
+// validate returns the validation errors of data, empty if valid or no schema is configured
+func (w webhook) validate(data interface{}) ([]string, error) {
+	if w.schema == nil {
+		return nil, nil
+	}
+
+	result, err := w.schema.Validate(gojsonschema.NewGoLoader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Valid() {
+		return nil, nil
+	}
+
+	validationErrors := make([]string, 0, len(result.Errors()))
+	for _, resultError := range result.Errors() {
+		validationErrors = append(validationErrors, resultError.String())
+	}
+	return validationErrors, nil
+}
+
+func (w webhook) deadLetters() bool {
+	return w.schema != nil && w.Schema.OnInvalid == platform.RawDataLogIngestorOnInvalidDeadLetter
+}
+
+func (w webhook) deadLetterTopic(topic string) string {
+	if w.Schema != nil && w.Schema.DeadLetterTopic != "" {
+		return w.Schema.DeadLetterTopic
+	}
+	return fmt.Sprintf("%s.dead-letter", topic)
+}
+
+var errInvalidPayload = errors.New("payload does not match the webhook schema")